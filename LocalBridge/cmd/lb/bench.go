@@ -0,0 +1,171 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/config"
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/logger"
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/mfw"
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/paths"
+	"github.com/spf13/cobra"
+)
+
+// bench 命令参数
+var (
+	benchAdbPath string
+	benchAddress string
+	benchHwnd    string
+	benchMethods string
+	benchFrames  int
+	benchSave    bool
+)
+
+var benchCmd = &cobra.Command{
+	Use:   "bench",
+	Short: "性能测试命令",
+	Long:  `对设备控制相关能力进行性能测试`,
+}
+
+var benchScreencapCmd = &cobra.Command{
+	Use:   "screencap",
+	Short: "测试各截图方法的速度",
+	Long: `依次使用每种截图方法连接设备并截取若干帧，
+统计平均耗时、P95 耗时、失败率与截图分辨率，并推荐最快的可用方法。
+
+未指定 --address 与 --hwnd 时，使用扫描到的第一个 ADB 设备。
+
+示例:
+  mpelb bench screencap
+  mpelb bench screencap --address 127.0.0.1:16384 --frames 20 --save
+  mpelb bench screencap --hwnd 0x1234 --methods FramePool,PrintWindow`,
+	Run: runBenchScreencap,
+}
+
+func init() {
+	rootCmd.AddCommand(benchCmd)
+	benchCmd.AddCommand(benchScreencapCmd)
+
+	benchCmd.PersistentFlags().StringVar(&configPath, "config", "", "配置文件路径")
+	benchCmd.PersistentFlags().BoolVar(&portableMode, "portable", false, "便携模式")
+
+	benchScreencapCmd.Flags().StringVar(&benchAdbPath, "adb-path", "", "adb 可执行文件路径（默认使用扫描结果）")
+	benchScreencapCmd.Flags().StringVar(&benchAddress, "address", "", "ADB 设备地址")
+	benchScreencapCmd.Flags().StringVar(&benchHwnd, "hwnd", "", "Win32 窗口句柄（十六进制）")
+	benchScreencapCmd.Flags().StringVar(&benchMethods, "methods", "", "逗号分隔的截图方法，默认测试全部")
+	benchScreencapCmd.Flags().IntVar(&benchFrames, "frames", 10, "每种方法截取的帧数")
+	benchScreencapCmd.Flags().BoolVar(&benchSave, "save", false, "将推荐方法保存到设备偏好配置")
+}
+
+// 截图测速
+func runBenchScreencap(cmd *cobra.Command, args []string) {
+	paths.SetPortableMode(portableMode)
+	paths.Init()
+
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "加载配置失败: %v\n", err)
+		os.Exit(1)
+	}
+	if err := logger.Init(logger.Options{
+		Level:      "WARN",
+		Dir:        cfg.Log.Dir,
+		Format:     cfg.Log.Format,
+		MaxSizeMB:  cfg.Log.MaxSizeMB,
+		MaxFiles:   cfg.Log.MaxFiles,
+		MaxAgeDays: cfg.Log.MaxAgeDays,
+	}); err != nil {
+		fmt.Fprintf(os.Stderr, "初始化日志系统失败: %v\n", err)
+		os.Exit(1)
+	}
+
+	svc := mfw.NewService()
+	if err := svc.Initialize(); err != nil {
+		fmt.Fprintf(os.Stderr, "MaaFramework 初始化失败: %v\n", err)
+		os.Exit(1)
+	}
+	defer svc.Shutdown()
+
+	req := mfw.ScreencapBenchRequest{
+		Type:    "ADB",
+		AdbPath: benchAdbPath,
+		Address: benchAddress,
+		Hwnd:    benchHwnd,
+		Frames:  benchFrames,
+	}
+	for _, method := range strings.Split(benchMethods, ",") {
+		if method = strings.TrimSpace(method); method != "" {
+			req.Methods = append(req.Methods, method)
+		}
+	}
+
+	if benchHwnd != "" {
+		req.Type = "Win32"
+	} else if benchAddress == "" || benchAdbPath == "" {
+		devices, err := svc.DeviceManager().RefreshAdbDevices()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "扫描 ADB 设备失败: %v\n", err)
+			os.Exit(1)
+		}
+		device, ok := pickBenchDevice(devices, benchAddress)
+		if !ok {
+			fmt.Fprintln(os.Stderr, "未找到可用的 ADB 设备，请使用 --address 与 --adb-path 指定")
+			os.Exit(1)
+		}
+		req.AdbPath, req.Address, req.Config = device.AdbPath, device.Address, device.Config
+	}
+
+	target := req.Address
+	if req.Type == "Win32" {
+		target = req.Hwnd
+	}
+	fmt.Printf("🚀 开始截图测速: %s %s，每种方法 %d 帧\n\n", req.Type, target, benchFrames)
+	fmt.Printf("%-24s %8s %10s %10s %8s %12s\n", "方法", "连接", "平均(ms)", "P95(ms)", "失败率", "分辨率")
+
+	report, err := mfw.BenchmarkScreencap(req, func(result mfw.ScreencapMethodResult) {
+		connected := "✅"
+		if !result.Connected {
+			connected = "❌"
+		}
+		resolution := "-"
+		if result.ImageWidth > 0 {
+			resolution = fmt.Sprintf("%dx%d", result.ImageWidth, result.ImageHeight)
+		}
+		fmt.Printf("%-24s %8s %10.2f %10.2f %7.0f%% %12s\n",
+			result.Method, connected, result.MeanMS, result.P95MS, result.FailureRate*100, resolution)
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "截图测速失败: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Println()
+	if report.Recommended == "" {
+		fmt.Println("⚠️  没有可稳定截图的方法")
+		return
+	}
+	fmt.Printf("⭐ 推荐截图方法: %s\n", report.Recommended)
+
+	if benchSave {
+		profile, err := mfw.SaveBenchRecommendation(svc.DeviceManager().Profiles(), report)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "保存推荐方法失败: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("✅ 已保存到设备偏好配置: %s\n", profile.Key)
+		if req.Type == "Win32" {
+			fmt.Println("   窗口句柄在窗口重新打开后会变化，偏好配置仅对当前窗口生效")
+		}
+	}
+}
+
+// 选择测速设备，指定地址时按地址匹配
+func pickBenchDevice(devices []mfw.AdbDeviceInfo, address string) (mfw.AdbDeviceInfo, bool) {
+	for _, device := range devices {
+		if address == "" || device.Address == address {
+			return device, true
+		}
+	}
+	return mfw.AdbDeviceInfo{}, false
+}
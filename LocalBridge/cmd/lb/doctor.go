@@ -0,0 +1,159 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/config"
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/logger"
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/mfw"
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/paths"
+	resourceService "github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/service/resource"
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/support"
+	"github.com/spf13/cobra"
+)
+
+// doctor 命令参数
+var (
+	doctorOutput     string
+	doctorReportOnly bool
+)
+
+// 自检项显示名称
+var doctorCheckTitles = map[string]string{
+	"maafw_lib": "MaaFramework 库",
+	"ocr_model": "OCR 模型",
+	"adb":       "ADB 设备",
+	"port":      "服务端口",
+}
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "环境自检并生成诊断包",
+	Long: `检查 MaaFramework 库能否加载、OCR 模型是否齐全、ADB 设备能否扫描以及服务端口是否可用，
+并将日志、maafw.log 尾部、脱敏后的配置、路径信息、设备与资源包列表打包为 zip，便于反馈问题。
+
+调试 trace 只保存在运行中的服务内，需通过前端的诊断包功能导出。
+存在失败的自检项时以非零状态码退出。
+
+示例:
+  mpelb doctor
+  mpelb doctor --output ./support.zip
+  mpelb doctor --report-only`,
+	Run: runDoctor,
+}
+
+func init() {
+	rootCmd.AddCommand(doctorCmd)
+
+	doctorCmd.Flags().StringVar(&configPath, "config", "", "配置文件路径")
+	doctorCmd.Flags().BoolVar(&portableMode, "portable", false, "便携模式")
+	doctorCmd.Flags().StringVarP(&doctorOutput, "output", "o", "", "诊断包保存路径（默认保存到数据目录的 support/ 下）")
+	doctorCmd.Flags().BoolVar(&doctorReportOnly, "report-only", false, "只输出自检报告，不生成诊断包")
+}
+
+// 环境自检
+func runDoctor(cmd *cobra.Command, args []string) {
+	paths.SetPortableMode(portableMode)
+	paths.Init()
+
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "加载配置失败: %v\n", err)
+		os.Exit(1)
+	}
+	if err := logger.Init(logger.Options{
+		Level:      "ERROR",
+		Dir:        cfg.Log.Dir,
+		Format:     cfg.Log.Format,
+		MaxSizeMB:  cfg.Log.MaxSizeMB,
+		MaxFiles:   cfg.Log.MaxFiles,
+		MaxAgeDays: cfg.Log.MaxAgeDays,
+	}); err != nil {
+		fmt.Fprintf(os.Stderr, "初始化日志系统失败: %v\n", err)
+		os.Exit(1)
+	}
+	defer logger.Close()
+
+	svc := mfw.NewService()
+	initErr := svc.Initialize()
+	if initErr == nil {
+		defer svc.Shutdown()
+	}
+
+	resources := resourceService.NewService(cfg.File.WorkspaceRoots(), nil)
+	if err := resources.Scan(); err != nil {
+		fmt.Fprintf(os.Stderr, "扫描资源包失败: %v\n", err)
+	}
+
+	bundle := support.Collect(support.Sources{
+		Version:    Version,
+		Config:     cfg,
+		MaaFW:      svc,
+		MaaFWError: initErr,
+		Resources:  resources,
+	}, 0)
+	printDoctorReport(bundle)
+
+	if !doctorReportOnly {
+		output := doctorOutput
+		if output == "" {
+			output = support.DefaultOutputPath(time.Now())
+		}
+		if err := bundle.WriteFile(output); err != nil {
+			fmt.Fprintf(os.Stderr, "生成诊断包失败: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("📦 诊断包已保存: %s\n\n", output)
+	}
+
+	if !bundle.Report.Healthy() {
+		os.Exit(1)
+	}
+}
+
+// 打印自检报告
+func printDoctorReport(bundle *support.Bundle) {
+	report := bundle.Report
+	fmt.Println()
+	fmt.Println("══════════════════════════════════════════════════")
+	fmt.Println("🩺 MPE Local Bridge 环境自检")
+	fmt.Println("══════════════════════════════════════════════════")
+	fmt.Println()
+	fmt.Printf("   版本:         %s (%s/%s)\n", report.Version, report.OS, report.Arch)
+	fmt.Printf("   运行模式:     %s\n", report.Paths.Mode)
+	fmt.Printf("   配置文件:     %s\n", report.Paths.ConfigFile)
+	fmt.Printf("   日志目录:     %s\n", report.Paths.LogDir)
+	if report.MaaFWVersion != "" {
+		fmt.Printf("   MaaFramework: %s\n", report.MaaFWVersion)
+	}
+	if bundle.Resources != nil {
+		fmt.Printf("   资源包:       %d 个\n", len(bundle.Resources.Bundles))
+	}
+	fmt.Println()
+
+	for _, check := range report.Checks {
+		icon := "✅"
+		switch check.Status {
+		case support.StatusWarn:
+			icon = "⚠️ "
+		case support.StatusFail:
+			icon = "❌"
+		case support.StatusSkip:
+			icon = "⏭️ "
+		}
+		title := doctorCheckTitles[check.Name]
+		if title == "" {
+			title = check.Name
+		}
+		fmt.Printf("%s %s: %s\n", icon, title, check.Message)
+		if check.Detail != "" {
+			fmt.Printf("   \033[90m%s\033[0m\n", check.Detail)
+		}
+	}
+	for _, device := range bundle.Devices {
+		fmt.Printf("   📱 %s (%s)\n", device.Name, device.Address)
+	}
+	fmt.Println()
+}
@@ -15,18 +15,22 @@ import (
 	"sync"
 	"time"
 
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/auth"
 	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/config"
 	debugapi "github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/debug/api"
 	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/eventbus"
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/gateway"
 	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/logger"
 	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/mfw"
 	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/paths"
 	aiProtocol "github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/protocol/ai"
 	configProtocol "github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/protocol/config"
 	fileProtocol "github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/protocol/file"
+	logProtocol "github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/protocol/log"
 	mfwProtocol "github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/protocol/mfw"
 	resourceProtocol "github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/protocol/resource"
 	utilityProtocol "github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/protocol/utility"
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/reload"
 	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/router"
 	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/server"
 	fileService "github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/service/file"
@@ -207,9 +211,23 @@ func runServer(cmd *cobra.Command, args []string) {
 
 	// 从命令行参数覆盖配置
 	cfg.OverrideFromFlags(rootDir, logDir, logLevel, port)
+	roots := cfg.File.WorkspaceRoots()
+	if err := roots.Validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "工作区根目录配置无效: %v\n", err)
+		os.Exit(1)
+	}
 
 	// 初始化日志系统
-	if err := logger.Init(cfg.Log.Level, cfg.Log.Dir, cfg.Log.PushToClient); err != nil {
+	if err := logger.Init(logger.Options{
+		Level:        cfg.Log.Level,
+		Dir:          cfg.Log.Dir,
+		PushToClient: cfg.Log.PushToClient,
+		Format:       cfg.Log.Format,
+		MaxSizeMB:    cfg.Log.MaxSizeMB,
+		MaxFiles:     cfg.Log.MaxFiles,
+		MaxAgeDays:   cfg.Log.MaxAgeDays,
+		Modules:      cfg.Log.Modules,
+	}); err != nil {
 		fmt.Fprintf(os.Stderr, "初始化日志系统失败: %v\n", err)
 		os.Exit(1)
 	}
@@ -218,6 +236,11 @@ func runServer(cmd *cobra.Command, args []string) {
 	logger.Debug("Main", "运行模式: %s", paths.GetModeName())
 	logger.Debug("Main", "数据目录: %s", paths.GetDataDir())
 	logger.Info("Main", "运行目录: %s", cfg.File.Root)
+	for i, root := range roots {
+		if i > 0 {
+			logger.Info("Main", "附加根目录: %s -> %s", root.Name, root.Path)
+		}
+	}
 	logger.Debug("Main", "监听端口: %d", cfg.Server.Port)
 	logger.Debug("Main", "扫描限制: 深度=%d, 文件数=%d", cfg.File.MaxDepth, cfg.File.MaxFiles)
 
@@ -266,15 +289,8 @@ func runServer(cmd *cobra.Command, args []string) {
 	// 创建事件总线
 	eventBus := eventbus.GetGlobalBus()
 
-	// 创建文件服务
-	fileSvc, err := fileService.NewService(
-		cfg.File.Root,
-		cfg.File.Exclude,
-		cfg.File.Extensions,
-		cfg.File.MaxDepth,
-		cfg.File.MaxFiles,
-		eventBus,
-	)
+	// 创建文件服务，每个根目录一个
+	fileSvcs, err := fileService.NewWorkspaceServices(cfg.File, eventBus)
 	if err != nil {
 		logger.Error("Main", "创建文件服务失败: %v", err)
 		os.Exit(1)
@@ -300,13 +316,15 @@ func runServer(cmd *cobra.Command, args []string) {
 	}
 
 	// 启动文件服务
-	if err := fileSvc.Start(); err != nil {
-		logger.Error("Main", "启动文件服务失败: %v", err)
-		os.Exit(1)
+	for _, fileSvc := range fileSvcs {
+		if err := fileSvc.Start(); err != nil {
+			logger.Error("Main", "启动文件服务失败 (%s): %v", fileSvc.Root().Name, err)
+			os.Exit(1)
+		}
 	}
 
 	// 创建资源扫描服务
-	resSvc := resourceService.NewService(cfg.File.Root, eventBus)
+	resSvc := resourceService.NewService(roots, eventBus)
 	if err := resSvc.Start(); err != nil {
 		logger.Warn("Main", "资源扫描服务启动失败: %v", err)
 	} else {
@@ -316,6 +334,9 @@ func runServer(cmd *cobra.Command, args []string) {
 	// 检查更新
 	checkAndPrintUpdateNotice()
 
+	// 离线更新后首次启动时读取更新报告，推送给连接的客户端
+	updateReport := takeUpdateReport()
+
 	// 创建 WebSocket 服务器
 	wsServer := server.NewWebSocketServer(
 		cfg.Server.Host,
@@ -324,6 +345,19 @@ func runServer(cmd *cobra.Command, args []string) {
 		cfg.Server.AllowedOrigins,
 	)
 
+	// 启用鉴权时生成本次启动的令牌，令牌只打印到终端，不写入日志
+	if cfg.Server.Auth.Enabled {
+		tokenStore := auth.NewStore(paths.GetTokenFile())
+		startupToken, err := tokenStore.RotateStartup()
+		if err != nil {
+			logger.Error("Main", "生成访问令牌失败: %v", err)
+			os.Exit(1)
+		}
+		wsServer.SetTokenStore(tokenStore)
+		fmt.Printf("\n  \033[33m访问令牌: %s\033[0m\n", startupToken.Value)
+		fmt.Printf("  \033[90m已保存至 %s，可使用 'mpelb token issue' 签发受限令牌\033[0m\n\n", tokenStore.Path())
+	}
+
 	// 设置日志推送函数
 	logger.SetPushFunc(func(level, module, message string) {
 		wsServer.Broadcast(models.Message{
@@ -337,7 +371,15 @@ func runServer(cmd *cobra.Command, args []string) {
 		})
 	})
 
-	// 订阅连接建立事件，推送历史日志
+	// 推送控制器健康状态
+	mfwSvc.HealthMonitor().SetNotifyFunc(func(health mfw.ControllerHealth) {
+		wsServer.Broadcast(models.Message{
+			Path: "/lte/mfw/controller_health",
+			Data: health,
+		})
+	})
+
+	// 订阅连接建立事件，推送历史日志与更新报告
 	eventBus.Subscribe(eventbus.EventConnectionEstablished, func(event eventbus.Event) {
 		conn, ok := event.Data.(*server.Connection)
 		if !ok {
@@ -356,37 +398,12 @@ func runServer(cmd *cobra.Command, args []string) {
 				},
 			})
 		}
-	})
-
-	// 订阅配置重载事件
-	eventBus.Subscribe(eventbus.EventConfigReload, func(event eventbus.Event) {
-		cfg, ok := event.Data.(*config.Config)
-		if !ok {
-			logger.Error("Main", "配置重载事件数据类型错误")
-			return
-		}
-
-		logger.Info("Main", "收到配置重载事件，开始重载各服务...")
-
-		// 重载资源扫描服务
-		if resSvc != nil {
-			if err := resSvc.Reload(cfg.File.Root); err != nil {
-				logger.Error("Main", "资源扫描服务重载失败: %v", err)
-			} else {
-				logger.Info("Main", "资源扫描服务重载完成")
-			}
-		}
-
-		// 重载MFW服务（仅当启用且配置变化时）
-		if mfwSvc != nil && cfg.MaaFW.Enabled {
-			if err := mfwSvc.Reload(); err != nil {
-				logger.Error("Main", "MFW服务重载失败: %v", err)
-			} else {
-				logger.Info("Main", "MFW服务重载完成")
-			}
+		if updateReport != nil {
+			conn.Send(models.Message{
+				Path: router.PathUpdateReport,
+				Data: updateReport,
+			})
 		}
-
-		logger.Info("Main", "所有服务重载完成")
 	})
 
 	// 创建路由分发器
@@ -407,7 +424,7 @@ func runServer(cmd *cobra.Command, args []string) {
 	})
 
 	// 注册协议处理器
-	fileHandler := fileProtocol.NewHandler(fileSvc, eventBus, wsServer, cfg.File.Root)
+	fileHandler := fileProtocol.NewHandler(fileSvcs, eventBus, wsServer)
 	rt.RegisterHandler(fileHandler)
 
 	// 注册 MFW 协议处理器
@@ -415,7 +432,7 @@ func runServer(cmd *cobra.Command, args []string) {
 	rt.RegisterHandler(mfwHandler)
 
 	// 注册 Utility 协议处理器
-	utilityHandler := utilityProtocol.NewUtilityHandler(mfwSvc, cfg.File.Root)
+	utilityHandler := utilityProtocol.NewUtilityHandler(mfwSvc, roots)
 	rt.RegisterHandler(utilityHandler)
 
 	// 注册 Config 协议处理器
@@ -424,19 +441,78 @@ func runServer(cmd *cobra.Command, args []string) {
 
 	// 注册 debug-vNext 协议处理器
 	debugHandler := debugapi.NewHandler(mfwSvc, cfg.File.Root)
+	debugHandler.SetRoots(roots)
 	rt.RegisterHandler(debugHandler)
 
+	// 诊断包附带资源包列表与最近的调试 trace
+	utilityHandler.SetSupportSources(Version, resSvc, debugHandler)
+
 	// 注册 Resource 协议处理器
 	resourceHandler := resourceProtocol.NewHandler(resSvc, eventBus, wsServer, cfg.File.Root)
 	rt.RegisterHandler(resourceHandler)
 
+	// 配置重载时协调切换根目录并重载 MaaFW，期间排空调试运行
+	reloader := reload.New(cfg.File.Root, fileHandler, debugHandler, resSvc, mfwSvc, utilityHandler, debugHandler)
+	// 控制器健康监测配置保存或重载后立即按新配置重启监测
+	configHandler.SetHealthApplier(mfwSvc.ApplyHealthConfig)
+	eventBus.Subscribe(eventbus.EventConfigReload, func(event eventbus.Event) {
+		if cfg, ok := event.Data.(*config.Config); ok {
+			mfwSvc.ApplyHealthConfig(cfg.MaaFW.ControllerHealth)
+		}
+	})
+	configHandler.SetReloader(func(cfg *config.Config) (interface{}, error) {
+		logger.Info("Main", "收到配置重载请求，开始重载各服务...")
+		result, err := reloader.Reload(cfg)
+		if err != nil {
+			return nil, err
+		}
+		for _, warning := range result.Warnings {
+			logger.Warn("Main", "%s", warning)
+		}
+		logger.Info("Main", "所有服务重载完成，根目录: %s (共 %d 个)，停止调试运行 %d 个，耗时 %s", result.Root, len(result.Roots), result.DrainedRuns, result.Duration)
+		return result, nil
+	})
+
+	// 注册日志协议处理器，配置重载时恢复配置文件中的日志级别
+	rt.RegisterHandler(logProtocol.NewHandler())
+	eventBus.Subscribe(eventbus.EventConfigReload, func(event eventbus.Event) {
+		cfg, ok := event.Data.(*config.Config)
+		if !ok {
+			return
+		}
+		if err := logger.SetLevel(cfg.Log.Level); err != nil {
+			logger.Warn("Main", "%v", err)
+		}
+		if err := logger.SetModuleLevels(cfg.Log.Modules); err != nil {
+			logger.Warn("Main", "%v", err)
+		}
+	})
+
 	// 注册 AI 代理协议处理器。业务入口可以暂时没有，但传输基础设施保持可用。
 	aiHandler := aiProtocol.NewAIHandler()
 	rt.RegisterHandler(aiHandler)
+	eventBus.Subscribe(eventbus.EventConfigReload, func(event eventbus.Event) {
+		if cfg, ok := event.Data.(*config.Config); ok {
+			aiHandler.ApplyConfig(cfg.AI)
+		}
+	})
 
 	// 设置消息处理器
 	wsServer.SetMessageHandler(rt.Route)
 
+	// 注册 HTTP 网关，与 WebSocket 共用端口
+	gateway.New(wsServer, rt.Route).Register()
+	rt.AddCapabilities("http-gateway")
+	if cfg.Server.Auth.Enabled {
+		rt.AddCapabilities("auth")
+	}
+
+	// 协议 Schema 同时通过 HTTP 提供，便于第三方工具直接获取
+	wsServer.Handle(http.MethodGet+" "+router.PathSchema, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/schema+json; charset=utf-8")
+		_ = json.NewEncoder(w).Encode(rt.Schema())
+	}))
+
 	// 启动 WebSocket 服务器
 	go func() {
 		if err := wsServer.Start(); err != nil {
@@ -457,12 +533,13 @@ func runServer(cmd *cobra.Command, args []string) {
 	logger.Info("Main", "正在关闭 Local Bridge 服务...")
 
 	wsServer.Stop()
-	fileSvc.Stop()
+	fileHandler.Stop()
 
 	// 关闭 MFW 服务
 	if err := mfwSvc.Shutdown(); err != nil {
 		logger.Error("Main", "MFW 服务关闭失败: %v", err)
 	}
+	defer logger.Close()
 
 	if protocolMismatchClientVersion != "" {
 		printProtocolMismatchUpdateNotice(protocolMismatchClientVersion)
@@ -791,6 +868,7 @@ func checkAndPrintUpdateNotice() {
 		fmt.Printf("   %s\n", releaseURL)
 		fmt.Println("   快速更新指令:")
 		utils.PrintInstallCommand()
+		fmt.Println("   离线更新: mpelb update --from <更新包.zip>")
 		fmt.Println("══════════════════════════════════════════════════")
 		fmt.Println()
 	} else {
@@ -804,7 +882,7 @@ func printProtocolMismatchUpdateNotice(clientVersion string) {
 	fmt.Println("⚠️  检测到前后端通信协议版本不一致")
 	fmt.Println("══════════════════════════════════════════════════")
 	fmt.Printf("   前端需求版本: %s\n", clientVersion)
-	fmt.Printf("   后端当前版本: %s\n", server.ProtocolVersion)
+	fmt.Printf("   后端当前版本: %s（支持前端协议 %s）\n", server.ProtocolVersion, server.SupportedProtocolRange)
 	fmt.Println()
 	fmt.Println("   请更新 MaaPipelineEditor 或 Local Bridge 后重试")
 	fmt.Println("   快速更新指令:")
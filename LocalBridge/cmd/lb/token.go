@@ -0,0 +1,134 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/auth"
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/paths"
+	"github.com/spf13/cobra"
+)
+
+// token 命令参数
+var (
+	tokenName   string
+	tokenScopes string
+)
+
+var tokenCmd = &cobra.Command{
+	Use:   "token",
+	Short: "访问令牌管理命令",
+	Long: `管理连接 LocalBridge 所需的访问令牌。
+
+需要在配置中开启 server.auth.enabled 后令牌才会生效。
+服务运行期间签发或吊销令牌会在下一次连接握手时生效。
+
+权限范围:
+  file.read   读取文件、图片资源与配置
+  file.write  保存与创建文件
+  device      连接与操作设备、运行调试任务
+  shell       执行设备 shell 命令、修改服务配置
+  ai.proxy    通过 AI 代理转发请求`,
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		paths.SetPortableMode(portableMode)
+		paths.Init()
+	},
+}
+
+var tokenIssueCmd = &cobra.Command{
+	Use:   "issue",
+	Short: "签发新令牌",
+	Long: `签发一个指定权限范围的令牌，令牌值仅在签发时显示一次。
+
+示例:
+  mpelb token issue --name viewer --scopes file.read
+  mpelb token issue --name ci --scopes file.read,file.write,device
+  mpelb token issue --name admin --scopes all`,
+	Run: runTokenIssue,
+}
+
+var tokenListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "列出已签发的令牌",
+	Run:   runTokenList,
+}
+
+var tokenRevokeCmd = &cobra.Command{
+	Use:   "revoke <id|name>",
+	Short: "吊销令牌",
+	Long:  `按令牌 ID 或名称吊销令牌，已建立的连接需重新连接后才会失效`,
+	Args:  cobra.ExactArgs(1),
+	Run:   runTokenRevoke,
+}
+
+func init() {
+	rootCmd.AddCommand(tokenCmd)
+	tokenCmd.AddCommand(tokenIssueCmd)
+	tokenCmd.AddCommand(tokenListCmd)
+	tokenCmd.AddCommand(tokenRevokeCmd)
+
+	tokenCmd.PersistentFlags().BoolVar(&portableMode, "portable", false, "便携模式")
+
+	tokenIssueCmd.Flags().StringVar(&tokenName, "name", "", "令牌名称")
+	tokenIssueCmd.Flags().StringVar(&tokenScopes, "scopes", auth.ScopeFileRead, "逗号分隔的权限范围，all 表示全部权限")
+	tokenIssueCmd.MarkFlagRequired("name")
+}
+
+// 签发令牌
+func runTokenIssue(cmd *cobra.Command, args []string) {
+	scopes, err := auth.ParseScopes(tokenScopes)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+		os.Exit(1)
+	}
+	store := auth.NewStore(paths.GetTokenFile())
+	token, err := store.Issue(tokenName, scopes)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("已签发令牌 %s (ID: %s)\n", token.Name, token.ID)
+	fmt.Printf("权限范围: %s\n", strings.Join(token.Scopes, ", "))
+	fmt.Printf("令牌: %s\n", token.Value)
+	fmt.Println("请妥善保存，连接时通过 token 查询参数或 Authorization: Bearer 请求头携带")
+}
+
+// 列出令牌，不显示令牌值
+func runTokenList(cmd *cobra.Command, args []string) {
+	store := auth.NewStore(paths.GetTokenFile())
+	tokens, err := store.List()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+		os.Exit(1)
+	}
+	if len(tokens) == 0 {
+		fmt.Println("尚未签发任何令牌")
+		return
+	}
+
+	fmt.Printf("%-10s %-16s %-20s %s\n", "ID", "名称", "创建时间", "权限范围")
+	for _, token := range tokens {
+		fmt.Printf("%-10s %-16s %-20s %s\n",
+			token.ID,
+			token.Name,
+			token.CreatedAt.Format("2006-01-02 15:04:05"),
+			strings.Join(token.Scopes, ","),
+		)
+	}
+}
+
+// 吊销令牌
+func runTokenRevoke(cmd *cobra.Command, args []string) {
+	store := auth.NewStore(paths.GetTokenFile())
+	token, err := store.Revoke(args[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("已吊销令牌 %s (ID: %s)\n", token.Name, token.ID)
+	if token.Name == auth.StartupTokenName {
+		fmt.Println("启动令牌将在下次启动服务时重新生成")
+	}
+}
@@ -0,0 +1,261 @@
+package main
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/config"
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/logger"
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/mfw"
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/paths"
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/server"
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/update"
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/pkg/models"
+	"github.com/spf13/cobra"
+)
+
+// 更新包签名公钥（base64 编码的 Ed25519 公钥，由构建时注入）
+var UpdatePublicKey = ""
+
+// 启动新版本校验的超时时间
+const updateVerifyTimeout = 15 * time.Second
+
+// update 命令参数
+var (
+	updateFrom          string
+	updatePublicKey     string
+	updateForce         bool
+	updateRollback      bool
+	updateAllowUnsigned bool
+)
+
+var updateCmd = &cobra.Command{
+	Use:   "update",
+	Short: "使用离线更新包更新",
+	Long: `在无法联网的环境中，使用离线更新包更新 Local Bridge 与附带的 MaaFramework 运行时。
+
+更新前依次校验清单签名（使用构建时注入或 --public-key 指定的公钥）、各文件的 SHA-256、
+目标平台，以及 MaaFramework 运行时版本是否在 maa-framework-go 绑定支持的范围内。
+未签名或无法校验签名的更新包默认拒绝，仅在确认来源可信时使用 --allow-unsigned 安装。
+可执行文件与 runtime/maafw 目录通过重命名整体替换，任一步失败或新版本无法启动时自动回滚，
+上一版本保留在安装目录的 .mpelb-update/backup 下，可通过 --rollback 恢复。
+
+更新完成后需重启正在运行的 Local Bridge，首次启动时会向连接的前端推送版本与协议变化。
+
+示例:
+  mpelb update --from ./mpelb-update-linux-amd64.zip
+  mpelb update --from ./update.zip --public-key <base64 公钥>
+  mpelb update --rollback`,
+	Run: runUpdate,
+}
+
+func init() {
+	rootCmd.AddCommand(updateCmd)
+
+	updateCmd.Flags().StringVar(&configPath, "config", "", "配置文件路径")
+	updateCmd.Flags().BoolVar(&portableMode, "portable", false, "便携模式")
+	updateCmd.Flags().StringVar(&updateFrom, "from", "", "离线更新包路径 (.zip)")
+	updateCmd.Flags().StringVar(&updatePublicKey, "public-key", "", "校验签名使用的 Ed25519 公钥（base64），默认使用构建时注入的公钥")
+	updateCmd.Flags().BoolVar(&updateForce, "force", false, "允许安装不高于当前版本的更新包")
+	updateCmd.Flags().BoolVar(&updateRollback, "rollback", false, "回滚到上一次更新前的版本")
+	updateCmd.Flags().BoolVar(&updateAllowUnsigned, "allow-unsigned", false, "允许安装未签名或无法校验签名的更新包（仅限可信来源）")
+}
+
+// 离线更新
+func runUpdate(cmd *cobra.Command, args []string) {
+	paths.SetPortableMode(portableMode)
+	paths.Init()
+
+	exePath, err := os.Executable()
+	if err == nil {
+		exePath, err = filepath.EvalSymlinks(exePath)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "获取可执行文件路径失败: %v\n", err)
+		os.Exit(1)
+	}
+	installDir := filepath.Dir(exePath)
+	target := update.TargetFor(exePath)
+
+	if updateRollback {
+		runUpdateRollback(installDir)
+		return
+	}
+	if updateFrom == "" {
+		fmt.Fprintln(os.Stderr, "请通过 --from 指定离线更新包")
+		os.Exit(1)
+	}
+
+	publicKey, err := parseUpdatePublicKey()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+	pkg, err := update.Open(updateFrom, publicKey, updateAllowUnsigned)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ 更新包校验失败: %v\n", err)
+		if errors.Is(err, update.ErrUnsigned) {
+			fmt.Fprintln(os.Stderr, "   如确认更新包来源可信，可使用 --allow-unsigned 跳过签名校验")
+		}
+		os.Exit(1)
+	}
+	manifest := pkg.Manifest
+
+	fmt.Println()
+	fmt.Println("══════════════════════════════════════════════════")
+	fmt.Println("📦 MPE Local Bridge 离线更新")
+	fmt.Println("══════════════════════════════════════════════════")
+	fmt.Printf("   当前版本:     %s（协议 %s）\n", Version, server.ProtocolVersion)
+	fmt.Printf("   更新包版本:   %s（协议 %s）\n", manifest.Version, manifest.ProtocolVersion)
+	if manifest.HasRuntime() {
+		fmt.Printf("   MaaFramework: %s\n", manifest.MaaFWVersion)
+	}
+	if manifest.MaaFWBinding != "" {
+		fmt.Printf("   maa-framework-go: %s -> %s\n", mfw.BindingVersion(), manifest.MaaFWBinding)
+	}
+	if pkg.Signed {
+		fmt.Println("   ✅ 签名校验通过")
+	}
+	for _, warning := range pkg.Warnings {
+		fmt.Printf("   ⚠️  %s\n", warning)
+	}
+
+	if Version != "dev" && compareVersion(manifest.Version, Version) <= 0 && !updateForce {
+		fmt.Fprintf(os.Stderr, "❌ 更新包版本 %s 不高于当前版本 %s，如需重新安装或降级请使用 --force\n", manifest.Version, Version)
+		os.Exit(1)
+	}
+
+	warning, err := update.CheckMaaFW(manifest, update.InstalledMaaFWVersion(target.Runtime), mfw.CompatibleMaaFWRange)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		os.Exit(1)
+	}
+	if warning != "" {
+		fmt.Printf("   ⚠️  %s\n", warning)
+	}
+	if manifest.HasRuntime() {
+		if cfg, err := config.Load(configPath); err == nil && cfg.MaaFW.LibDir != "" &&
+			filepath.Clean(cfg.MaaFW.LibDir) != filepath.Join(target.Runtime, "bin") {
+			fmt.Printf("   ⚠️  已配置 maafw.lib_dir (%s)，其优先于附带的运行时，更新后的运行时不会被使用\n", cfg.MaaFW.LibDir)
+		}
+	}
+
+	staged := update.StagingDir(installDir)
+	if err := os.RemoveAll(staged); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ 清理临时目录失败: %v\n", err)
+		os.Exit(1)
+	}
+	defer os.RemoveAll(staged)
+	if err := pkg.Extract(staged); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ 解压更新包失败: %v\n", err)
+		os.RemoveAll(staged)
+		os.Exit(1)
+	}
+
+	var verify func() error
+	if manifest.Binary != "" {
+		verify = func() error { return verifyUpdatedBinary(exePath, manifest.Version) }
+	}
+	if err := update.Install(pkg, staged, target, update.BackupDir(installDir), Version, verify); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ 更新失败: %v\n", err)
+		os.RemoveAll(staged)
+		os.Exit(1)
+	}
+
+	change := update.CompareProtocol(server.ProtocolVersion, manifest)
+	saveUpdateReport(Version, manifest.Version, manifest.MaaFWVersion)
+
+	fmt.Println()
+	fmt.Printf("✅ 已更新到 %s\n", manifest.Version)
+	if change.Changed {
+		fmt.Printf("   协议版本: %s -> %s\n", change.From, change.To)
+	}
+	if change.Breaking {
+		fmt.Printf("   ⚠️  新版本不再支持协议 %s，请同步更新 MaaPipelineEditor 前端\n", change.From)
+	}
+	fmt.Println("   如 Local Bridge 正在运行，请重启后生效")
+	fmt.Println("   如需恢复上一版本: mpelb update --rollback")
+	fmt.Println("══════════════════════════════════════════════════")
+	fmt.Println()
+}
+
+// 回滚到上一次更新前的版本
+func runUpdateRollback(installDir string) {
+	version, err := update.Rollback(update.BackupDir(installDir))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ 回滚失败: %v\n", err)
+		os.Exit(1)
+	}
+	saveUpdateReport(Version, version, "")
+	fmt.Printf("✅ 已回滚到 %s，如 Local Bridge 正在运行，请重启后生效\n", version)
+}
+
+// 解析签名公钥，命令行参数优先于构建时注入的公钥
+func parseUpdatePublicKey() (ed25519.PublicKey, error) {
+	encoded := strings.TrimSpace(updatePublicKey)
+	if encoded == "" {
+		encoded = strings.TrimSpace(UpdatePublicKey)
+	}
+	if encoded == "" {
+		return nil, nil
+	}
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("更新公钥无效，需为 base64 编码的 Ed25519 公钥")
+	}
+	return ed25519.PublicKey(key), nil
+}
+
+// 启动替换后的可执行文件确认其可以运行且版本正确
+func verifyUpdatedBinary(exePath string, version string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), updateVerifyTimeout)
+	defer cancel()
+	output, err := exec.CommandContext(ctx, exePath, "--version").CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("无法启动新版本: %w", err)
+	}
+	if !strings.Contains(string(output), strings.TrimPrefix(version, "v")) {
+		return fmt.Errorf("新版本输出的版本号与清单不一致: %s", strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// 保存更新报告，协议版本由更新后首次启动的服务填写
+func saveUpdateReport(fromVersion string, toVersion string, maafwVersion string) {
+	report := models.UpdateReport{
+		FromVersion:         fromVersion,
+		ToVersion:           toVersion,
+		FromProtocolVersion: server.ProtocolVersion,
+		MaaFWVersion:        maafwVersion,
+		UpdatedAt:           time.Now().Format(time.RFC3339),
+	}
+	if err := update.SaveReport(paths.GetDataDir(), report); err != nil {
+		fmt.Printf("   ⚠️  %v\n", err)
+	}
+}
+
+// 读取离线更新后待推送的更新报告，以当前运行的版本为准补全更新后的版本信息
+func takeUpdateReport() *models.UpdateReport {
+	report, err := update.TakeReport(paths.GetDataDir())
+	if err != nil {
+		logger.Warn("Update", "%v", err)
+		return nil
+	}
+	if report == nil {
+		return nil
+	}
+	report.ToVersion = Version
+	report.ToProtocolVersion = server.ProtocolVersion
+	report.SupportedProtocolRange = server.SupportedProtocolRange
+	logger.Info("Update", "已通过离线更新从 %s 更新到 %s，协议版本 %s -> %s",
+		report.FromVersion, report.ToVersion, report.FromProtocolVersion, report.ToProtocolVersion)
+	return report
+}
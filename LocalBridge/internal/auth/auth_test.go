@@ -0,0 +1,95 @@
+package auth
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestRequiredScope(t *testing.T) {
+	tests := []struct {
+		path  string
+		scope string
+	}{
+		{path: "/system/handshake", scope: ""},
+		{path: "/etl/open_file", scope: ScopeFileRead},
+		{path: "/etl/save_file", scope: ScopeFileWrite},
+		{path: "/etl/get_images", scope: ScopeFileRead},
+		{path: "/etl/config/get", scope: ScopeFileRead},
+		{path: "/etl/config/set", scope: ScopeShell},
+		{path: "/etl/log/set_level", scope: ScopeShell},
+		{path: "/etl/log/query", scope: ScopeFileRead},
+		{path: "/etl/mfw/controller_shell", scope: ScopeShell},
+		{path: "/etl/mfw/controller_click", scope: ScopeDevice},
+		{path: "/etl/utility/read_maafw_log", scope: ScopeFileRead},
+		{path: "/etl/utility/support_bundle", scope: ScopeDevice},
+		{path: "/etl/utility/convert_resolution", scope: ScopeFileWrite},
+		{path: "/etl/ai/proxy", scope: ScopeAIProxy},
+		{path: "/mpe/debug/run/start", scope: ScopeDevice},
+		{path: "/unknown", scope: ScopeFileRead},
+	}
+	for _, test := range tests {
+		if scope := RequiredScope(test.path); scope != test.scope {
+			t.Fatalf("RequiredScope(%q) = %q, want %q", test.path, scope, test.scope)
+		}
+	}
+}
+
+func TestParseScopes(t *testing.T) {
+	scopes, err := ParseScopes(" file.read, device,file.read ")
+	if err != nil || !reflect.DeepEqual(scopes, []string{ScopeFileRead, ScopeDevice}) {
+		t.Fatalf("ParseScopes() = %v, %v", scopes, err)
+	}
+	if scopes, err := ParseScopes("all"); err != nil || len(scopes) != len(AllScopes) {
+		t.Fatalf("ParseScopes(all) = %v, %v", scopes, err)
+	}
+	for _, value := range []string{"", "root", "file.read,root"} {
+		if _, err := ParseScopes(value); err == nil {
+			t.Fatalf("ParseScopes(%q) error = nil", value)
+		}
+	}
+}
+
+func TestStoreIssueLookupRevoke(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config", "tokens.json")
+	store := NewStore(path)
+
+	startup, err := store.RotateStartup()
+	if err != nil {
+		t.Fatalf("RotateStartup() error = %v", err)
+	}
+	viewer, err := store.Issue("viewer", []string{ScopeFileRead})
+	if err != nil {
+		t.Fatalf("Issue() error = %v", err)
+	}
+	if _, err := store.Issue("viewer", []string{ScopeFileRead}); err == nil {
+		t.Fatal("Issue() duplicate name error = nil")
+	}
+
+	// 另一个进程（CLI）通过文件看到同样的令牌
+	other := NewStore(path)
+	token, ok := other.Lookup(viewer.Value)
+	if !ok || token.Allows(ScopeFileWrite) || !token.Allows(ScopeFileRead) || !token.Allows("") {
+		t.Fatalf("Lookup(viewer) = %+v, %v", token, ok)
+	}
+	if _, err := other.Revoke(viewer.ID); err != nil {
+		t.Fatalf("Revoke() error = %v", err)
+	}
+	if _, ok := store.Lookup(viewer.Value); ok {
+		t.Fatal("revoked token still accepted")
+	}
+
+	rotated, err := store.RotateStartup()
+	if err != nil {
+		t.Fatalf("RotateStartup() error = %v", err)
+	}
+	if _, ok := store.Lookup(startup.Value); ok {
+		t.Fatal("previous startup token still accepted")
+	}
+	if token, ok := store.Lookup(rotated.Value); !ok || !token.Allows(ScopeShell) {
+		t.Fatalf("Lookup(startup) = %+v, %v", token, ok)
+	}
+	if _, ok := store.Lookup(""); ok {
+		t.Fatal("empty token accepted")
+	}
+}
@@ -0,0 +1,101 @@
+package auth
+
+import (
+	"fmt"
+	"strings"
+)
+
+// 令牌权限范围
+const (
+	ScopeFileRead  = "file.read"  // 读取文件、资源与配置
+	ScopeFileWrite = "file.write" // 保存与创建文件
+	ScopeDevice    = "device"     // 连接与操作设备、运行调试任务
+	ScopeShell     = "shell"      // 执行设备 shell 命令、修改服务配置
+	ScopeAIProxy   = "ai.proxy"   // 通过 AI 代理转发 HTTP 请求
+)
+
+// AllScopes 按权限从低到高排列
+var AllScopes = []string{ScopeFileRead, ScopeFileWrite, ScopeDevice, ScopeShell, ScopeAIProxy}
+
+type routeScope struct {
+	path   string
+	prefix bool
+	scope  string
+}
+
+// 路由所需权限，按顺序匹配，精确路由需排在其前缀之前
+var routeScopes = []routeScope{
+	{path: "/system/", prefix: true},
+
+	{path: "/etl/save_file", scope: ScopeFileWrite},
+	{path: "/etl/save_separated", scope: ScopeFileWrite},
+	{path: "/etl/create_file", scope: ScopeFileWrite},
+	{path: "/etl/open_file", scope: ScopeFileRead},
+	{path: "/etl/refresh_file_list", scope: ScopeFileRead},
+	{path: "/etl/get_image", prefix: true, scope: ScopeFileRead},
+	{path: "/etl/refresh_resources", scope: ScopeFileRead},
+
+	// 修改根目录或 MaaFramework 库路径等同于执行任意代码
+	{path: "/etl/config/get", scope: ScopeFileRead},
+	{path: "/etl/config/", prefix: true, scope: ScopeShell},
+	{path: "/etl/log/set_level", scope: ScopeShell},
+	{path: "/etl/log/", prefix: true, scope: ScopeFileRead},
+
+	{path: "/etl/mfw/controller_shell", scope: ScopeShell},
+	{path: "/etl/mfw/", prefix: true, scope: ScopeDevice},
+	{path: "/etl/utility/ocr_recognize", scope: ScopeDevice},
+	{path: "/etl/utility/template_match", scope: ScopeDevice},
+	{path: "/etl/utility/support_bundle", scope: ScopeDevice},
+	{path: "/etl/utility/convert_resolution", scope: ScopeFileWrite},
+	{path: "/etl/utility/", prefix: true, scope: ScopeFileRead},
+	{path: "/etl/ai/", prefix: true, scope: ScopeAIProxy},
+
+	{path: "/mpe/debug/capabilities", scope: ScopeFileRead},
+	{path: "/mpe/debug/", prefix: true, scope: ScopeDevice},
+}
+
+// RequiredScope 返回路由所需的权限；空字符串表示无需权限，未登记的路由至少需要只读权限
+func RequiredScope(path string) string {
+	for _, rule := range routeScopes {
+		if path == rule.path || (rule.prefix && strings.HasPrefix(path, rule.path)) {
+			return rule.scope
+		}
+	}
+	return ScopeFileRead
+}
+
+// ParseScopes 解析逗号分隔的权限列表，"all" 表示全部权限
+func ParseScopes(value string) ([]string, error) {
+	scopes := make([]string, 0, len(AllScopes))
+	seen := make(map[string]struct{})
+	for _, item := range strings.Split(value, ",") {
+		item = strings.ToLower(strings.TrimSpace(item))
+		if item == "" {
+			continue
+		}
+		if item == "all" {
+			return append([]string(nil), AllScopes...), nil
+		}
+		if !validScope(item) {
+			return nil, fmt.Errorf("未知的权限范围: %s（可选: %s, all）", item, strings.Join(AllScopes, ", "))
+		}
+		if _, ok := seen[item]; ok {
+			continue
+		}
+		seen[item] = struct{}{}
+		scopes = append(scopes, item)
+	}
+	if len(scopes) == 0 {
+		return nil, fmt.Errorf("至少需要指定一个权限范围")
+	}
+	return scopes, nil
+}
+
+func validScope(scope string) bool {
+	for _, candidate := range AllScopes {
+		if candidate == scope {
+			return true
+		}
+	}
+	return false
+}
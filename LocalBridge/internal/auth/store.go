@@ -0,0 +1,252 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 启动时自动生成的令牌名称，每次启动替换
+const StartupTokenName = "startup"
+
+// Token 访问令牌
+type Token struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Value     string    `json:"value"`
+	Scopes    []string  `json:"scopes"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Allows 判断令牌是否拥有指定权限，空权限总是允许
+func (t Token) Allows(scope string) bool {
+	if scope == "" {
+		return true
+	}
+	for _, candidate := range t.Scopes {
+		if candidate == scope {
+			return true
+		}
+	}
+	return false
+}
+
+type storeFile struct {
+	Tokens []Token `json:"tokens"`
+}
+
+// Store 保存在配置目录中的令牌列表；文件被 CLI 修改后，下一次校验时自动重新加载
+type Store struct {
+	path    string
+	mu      sync.Mutex
+	tokens  []Token
+	modTime time.Time
+	size    int64
+}
+
+func NewStore(path string) *Store {
+	return &Store{path: path}
+}
+
+// Path 返回令牌文件路径
+func (s *Store) Path() string {
+	return s.path
+}
+
+// List 返回全部令牌
+func (s *Store) List() ([]Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.reloadLocked(); err != nil {
+		return nil, err
+	}
+	return append([]Token(nil), s.tokens...), nil
+}
+
+// Lookup 按令牌值查找，比较时间与令牌内容无关
+func (s *Store) Lookup(value string) (Token, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return Token{}, false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// 读取失败时沿用内存中的令牌，避免文件写入过程中误拒连接
+	_ = s.reloadLocked()
+	for _, token := range s.tokens {
+		if subtle.ConstantTimeCompare([]byte(token.Value), []byte(value)) == 1 {
+			return token, true
+		}
+	}
+	return Token{}, false
+}
+
+// Issue 签发新令牌，名称不可重复
+func (s *Store) Issue(name string, scopes []string) (Token, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return Token{}, fmt.Errorf("令牌名称不能为空")
+	}
+	if len(scopes) == 0 {
+		return Token{}, fmt.Errorf("至少需要指定一个权限范围")
+	}
+	for _, scope := range scopes {
+		if !validScope(scope) {
+			return Token{}, fmt.Errorf("未知的权限范围: %s", scope)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.reloadLocked(); err != nil {
+		return Token{}, err
+	}
+	for _, token := range s.tokens {
+		if token.Name == name {
+			return Token{}, fmt.Errorf("令牌名称已存在: %s", name)
+		}
+	}
+	token, err := newToken(name, scopes)
+	if err != nil {
+		return Token{}, err
+	}
+	tokens := append(append([]Token(nil), s.tokens...), token)
+	if err := s.saveLocked(tokens); err != nil {
+		return Token{}, err
+	}
+	return token, nil
+}
+
+// Revoke 按 ID 或名称吊销令牌
+func (s *Store) Revoke(idOrName string) (Token, error) {
+	idOrName = strings.TrimSpace(idOrName)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.reloadLocked(); err != nil {
+		return Token{}, err
+	}
+	for i, token := range s.tokens {
+		if token.ID != idOrName && token.Name != idOrName {
+			continue
+		}
+		tokens := append(append([]Token(nil), s.tokens[:i]...), s.tokens[i+1:]...)
+		if err := s.saveLocked(tokens); err != nil {
+			return Token{}, err
+		}
+		return token, nil
+	}
+	return Token{}, fmt.Errorf("令牌不存在: %s", idOrName)
+}
+
+// RotateStartup 替换启动令牌，启动令牌拥有全部权限
+func (s *Store) RotateStartup() (Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.reloadLocked(); err != nil {
+		return Token{}, err
+	}
+	token, err := newToken(StartupTokenName, AllScopes)
+	if err != nil {
+		return Token{}, err
+	}
+	tokens := make([]Token, 0, len(s.tokens)+1)
+	for _, existing := range s.tokens {
+		if existing.Name != StartupTokenName {
+			tokens = append(tokens, existing)
+		}
+	}
+	tokens = append(tokens, token)
+	if err := s.saveLocked(tokens); err != nil {
+		return Token{}, err
+	}
+	return token, nil
+}
+
+func (s *Store) reloadLocked() error {
+	info, err := os.Stat(s.path)
+	if os.IsNotExist(err) {
+		s.tokens = nil
+		s.modTime = time.Time{}
+		s.size = 0
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("读取令牌文件失败: %w", err)
+	}
+	if info.ModTime().Equal(s.modTime) && info.Size() == s.size && s.tokens != nil {
+		return nil
+	}
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return fmt.Errorf("读取令牌文件失败: %w", err)
+	}
+	var file storeFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return fmt.Errorf("解析令牌文件失败: %w", err)
+	}
+	if file.Tokens == nil {
+		file.Tokens = []Token{}
+	}
+	s.tokens = file.Tokens
+	s.modTime = info.ModTime()
+	s.size = info.Size()
+	return nil
+}
+
+// 令牌文件仅允许当前用户读写
+func (s *Store) saveLocked(tokens []Token) error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+		return fmt.Errorf("创建令牌目录失败: %w", err)
+	}
+	data, err := json.MarshalIndent(storeFile{Tokens: tokens}, "", "    ")
+	if err != nil {
+		return fmt.Errorf("序列化令牌失败: %w", err)
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("写入令牌文件失败: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("写入令牌文件失败: %w", err)
+	}
+	s.tokens = tokens
+	if info, err := os.Stat(s.path); err == nil {
+		s.modTime = info.ModTime()
+		s.size = info.Size()
+	}
+	return nil
+}
+
+func newToken(name string, scopes []string) (Token, error) {
+	id, err := randomHex(4)
+	if err != nil {
+		return Token{}, err
+	}
+	secret, err := randomHex(24)
+	if err != nil {
+		return Token{}, err
+	}
+	return Token{
+		ID:        id,
+		Name:      name,
+		Value:     "mpelb_" + secret,
+		Scopes:    append([]string(nil), scopes...),
+		CreatedAt: time.Now(),
+	}, nil
+}
+
+func randomHex(size int) (string, error) {
+	buf := make([]byte, size)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("生成令牌失败: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
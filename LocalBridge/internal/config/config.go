@@ -8,37 +8,107 @@ import (
 	"strings"
 
 	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/paths"
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/workspace"
 	"github.com/spf13/viper"
 )
 
+// 连接鉴权配置
+type AuthConfig struct {
+	Enabled bool `mapstructure:"enabled" json:"enabled"` // 是否要求连接携带令牌，启动时生成拥有全部权限的令牌
+}
+
 // 服务器配置
 type ServerConfig struct {
-	Port           int      `mapstructure:"port" json:"port"`
-	Host           string   `mapstructure:"host" json:"host"`
-	AllowedOrigins []string `mapstructure:"allowed_origins" json:"allowed_origins"`
+	Port           int        `mapstructure:"port" json:"port"`
+	Host           string     `mapstructure:"host" json:"host"`
+	AllowedOrigins []string   `mapstructure:"allowed_origins" json:"allowed_origins"`
+	Auth           AuthConfig `mapstructure:"auth" json:"auth"`
+}
+
+// 命名的工作区根目录
+type RootConfig struct {
+	Name string `mapstructure:"name" json:"name"`
+	Path string `mapstructure:"path" json:"path"`
 }
 
 // 文件相关配置
 type FileConfig struct {
-	Root       string   `mapstructure:"root" json:"root"`
-	Exclude    []string `mapstructure:"exclude" json:"exclude"`
-	Extensions []string `mapstructure:"extensions" json:"extensions"`
-	MaxDepth   int      `mapstructure:"max_depth" json:"max_depth"` // 最大扫描深度，0 表示无限制
-	MaxFiles   int      `mapstructure:"max_files" json:"max_files"` // 最大文件数量，0 表示无限制
+	Root       string       `mapstructure:"root" json:"root"`
+	Roots      []RootConfig `mapstructure:"roots" json:"roots,omitempty"` // 额外的命名根目录，与 root 同时扫描
+	Exclude    []string     `mapstructure:"exclude" json:"exclude"`
+	Extensions []string     `mapstructure:"extensions" json:"extensions"`
+	MaxDepth   int          `mapstructure:"max_depth" json:"max_depth"` // 最大扫描深度，0 表示无限制
+	MaxFiles   int          `mapstructure:"max_files" json:"max_files"` // 最大文件数量，0 表示无限制
+}
+
+// WorkspaceRoots 返回全部命名根目录，root 为主根目录；
+// roots 中与 root 路径相同的条目只用于为主根目录命名
+func (c FileConfig) WorkspaceRoots() workspace.Roots {
+	roots := make(workspace.Roots, 0, len(c.Roots)+1)
+	if c.Root != "" {
+		primary := workspace.Root{Name: workspace.DefaultName, Path: c.Root}
+		for _, root := range c.Roots {
+			if filepath.Clean(root.Path) == filepath.Clean(c.Root) {
+				primary.Name = root.Name
+			}
+		}
+		roots = append(roots, primary)
+	}
+	for _, root := range c.Roots {
+		if c.Root != "" && filepath.Clean(root.Path) == filepath.Clean(c.Root) {
+			continue
+		}
+		roots = append(roots, workspace.Root{Name: root.Name, Path: root.Path})
+	}
+	return roots
 }
 
 // 日志配置
 type LogConfig struct {
-	Level        string `mapstructure:"level" json:"level"`
-	Dir          string `mapstructure:"dir" json:"dir"`
-	PushToClient bool   `mapstructure:"push_to_client" json:"push_to_client"`
+	Level        string            `mapstructure:"level" json:"level"`
+	Dir          string            `mapstructure:"dir" json:"dir"`
+	PushToClient bool              `mapstructure:"push_to_client" json:"push_to_client"`
+	Format       string            `mapstructure:"format" json:"format"`             // 文件日志格式: text 或 json
+	MaxSizeMB    int               `mapstructure:"max_size_mb" json:"max_size_mb"`   // 单个日志文件大小上限（MB），0 表示仅按日期切分
+	MaxFiles     int               `mapstructure:"max_files" json:"max_files"`       // 最多保留的日志文件数，0 表示不限制
+	MaxAgeDays   int               `mapstructure:"max_age_days" json:"max_age_days"` // 日志文件保留天数，0 表示不限制
+	Modules      map[string]string `mapstructure:"modules" json:"modules,omitempty"` // 按模块覆盖的日志级别
+}
+
+// 控制器健康监测配置
+type ControllerHealthConfig struct {
+	ProbeIntervalSeconds       int  `mapstructure:"probe_interval_seconds" json:"probe_interval_seconds"`               // 探测间隔（秒），0 表示关闭监测
+	ProbeTimeoutSeconds        int  `mapstructure:"probe_timeout_seconds" json:"probe_timeout_seconds"`                 // 单次探测超时（秒）
+	FailureThreshold           int  `mapstructure:"failure_threshold" json:"failure_threshold"`                         // 连续失败多少次判定为断开
+	AutoReconnect              bool `mapstructure:"auto_reconnect" json:"auto_reconnect"`                               // 是否自动重连 ADB / WlRoots 控制器
+	ReconnectMaxAttempts       int  `mapstructure:"reconnect_max_attempts" json:"reconnect_max_attempts"`               // 最大重连次数，0 表示不限制
+	ReconnectMaxBackoffSeconds int  `mapstructure:"reconnect_max_backoff_seconds" json:"reconnect_max_backoff_seconds"` // 重连退避上限（秒）
+	InactiveTimeoutMinutes     int  `mapstructure:"inactive_timeout_minutes" json:"inactive_timeout_minutes"`           // 无操作多久后释放控制器，0 表示不释放
 }
 
 // MaaFramework配置
 type MaaFWConfig struct {
-	Enabled     bool   `mapstructure:"enabled" json:"enabled"`
-	LibDir      string `mapstructure:"lib_dir" json:"lib_dir"`
-	ResourceDir string `mapstructure:"resource_dir" json:"resource_dir"`
+	Enabled          bool                   `mapstructure:"enabled" json:"enabled"`
+	LibDir           string                 `mapstructure:"lib_dir" json:"lib_dir"`
+	ResourceDir      string                 `mapstructure:"resource_dir" json:"resource_dir"`
+	ControllerHealth ControllerHealthConfig `mapstructure:"controller_health" json:"controller_health"`
+}
+
+// AI 代理单个主机的限流配置，0 表示不限制
+type AIHostLimit struct {
+	RequestsPerMinute int `mapstructure:"requests_per_minute" json:"requests_per_minute"`
+	MaxConcurrent     int `mapstructure:"max_concurrent" json:"max_concurrent"`
+}
+
+// AI 代理配置
+type AIConfig struct {
+	AuditEnabled       bool                   `mapstructure:"audit_enabled" json:"audit_enabled"`               // 是否记录本地审计日志
+	AuditRetentionDays int                    `mapstructure:"audit_retention_days" json:"audit_retention_days"` // 审计日志保留天数，0 表示不限制
+	CacheEnabled       bool                   `mapstructure:"cache_enabled" json:"cache_enabled"`               // 是否允许请求使用响应缓存
+	CacheTTLSeconds    int                    `mapstructure:"cache_ttl_seconds" json:"cache_ttl_seconds"`       // 缓存有效期（秒）
+	CacheMaxEntries    int                    `mapstructure:"cache_max_entries" json:"cache_max_entries"`       // 缓存最大条目数
+	DefaultLimit       AIHostLimit            `mapstructure:"default_limit" json:"default_limit"`               // 未单独配置主机时的默认限流
+	HostLimits         map[string]AIHostLimit `mapstructure:"host_limits" json:"host_limits"`                   // 按主机名配置的限流
 }
 
 // 全局配置
@@ -47,6 +117,7 @@ type Config struct {
 	File   FileConfig   `mapstructure:"file" json:"file"`
 	Log    LogConfig    `mapstructure:"log" json:"log"`
 	MaaFW  MaaFWConfig  `mapstructure:"maafw" json:"maafw"`
+	AI     AIConfig     `mapstructure:"ai" json:"ai"`
 }
 
 // 全局单例
@@ -112,6 +183,7 @@ func setDefaults(v *viper.Viper) {
 		"http://127.0.0.1",
 		"http://[::1]",
 	})
+	v.SetDefault("server.auth.enabled", false)
 
 	// 文件相关配置
 	v.SetDefault("file.exclude", []string{"node_modules", ".git", "dist", "build", ".cache", ".venv", "__pycache__", ".idea", ".vscode"})
@@ -123,11 +195,31 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("log.level", "INFO")
 	v.SetDefault("log.dir", paths.GetLogDir())
 	v.SetDefault("log.push_to_client", true)
+	v.SetDefault("log.format", "text")
+	v.SetDefault("log.max_size_mb", 10)
+	v.SetDefault("log.max_files", 10)
+	v.SetDefault("log.max_age_days", 3)
 
 	// MaaFramework 配置
 	v.SetDefault("maafw.enabled", false)
 	v.SetDefault("maafw.lib_dir", "")
 	v.SetDefault("maafw.resource_dir", "")
+	v.SetDefault("maafw.controller_health.probe_interval_seconds", 10)
+	v.SetDefault("maafw.controller_health.probe_timeout_seconds", 5)
+	v.SetDefault("maafw.controller_health.failure_threshold", 3)
+	v.SetDefault("maafw.controller_health.auto_reconnect", true)
+	v.SetDefault("maafw.controller_health.reconnect_max_attempts", 5)
+	v.SetDefault("maafw.controller_health.reconnect_max_backoff_seconds", 60)
+	v.SetDefault("maafw.controller_health.inactive_timeout_minutes", 0)
+
+	// AI 代理配置
+	v.SetDefault("ai.audit_enabled", true)
+	v.SetDefault("ai.audit_retention_days", 30)
+	v.SetDefault("ai.cache_enabled", false)
+	v.SetDefault("ai.cache_ttl_seconds", 600)
+	v.SetDefault("ai.cache_max_entries", 100)
+	v.SetDefault("ai.default_limit.requests_per_minute", 0)
+	v.SetDefault("ai.default_limit.max_concurrent", 0)
 }
 
 // 规范化配置路径
@@ -148,6 +240,25 @@ func (c *Config) normalize() error {
 		}
 	}
 
+	// 处理额外根目录路径
+	for i := range c.File.Roots {
+		root := &c.File.Roots[i]
+		if root.Path == "" {
+			return fmt.Errorf("根目录 %s 缺少路径", root.Name)
+		}
+		absPath, err := filepath.Abs(root.Path)
+		if err != nil {
+			return fmt.Errorf("解析根目录 %s 路径失败: %w", root.Name, err)
+		}
+		root.Path = absPath
+		if _, err := os.Stat(root.Path); os.IsNotExist(err) {
+			return fmt.Errorf("根目录 %s 不存在: %s", root.Name, root.Path)
+		}
+	}
+	if err := c.File.WorkspaceRoots().Validate(); err != nil {
+		return err
+	}
+
 	// 处理日志目录路径
 	if c.Log.Dir != "" && !filepath.IsAbs(c.Log.Dir) {
 		absPath, err := filepath.Abs(c.Log.Dir)
@@ -156,6 +267,9 @@ func (c *Config) normalize() error {
 		}
 		c.Log.Dir = absPath
 	}
+	if c.Log.Format != "" && c.Log.Format != "text" && c.Log.Format != "json" {
+		return fmt.Errorf("无效的日志格式: %s（可选: text, json）", c.Log.Format)
+	}
 
 	return nil
 }
@@ -0,0 +1,50 @@
+package api
+
+import (
+	"sync"
+
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/server"
+)
+
+// sessionBindings 记录每个 debug session 当前绑定的连接，
+// 运行事件在发送时按 session 查找连接，重连后重新绑定即可继续接收。
+type sessionBindings struct {
+	mu    sync.RWMutex
+	conns map[string]*server.Connection
+}
+
+func newSessionBindings() *sessionBindings {
+	return &sessionBindings{
+		conns: make(map[string]*server.Connection),
+	}
+}
+
+// 绑定原连接而非请求视图，后续运行事件属于推送，不带发起请求的关联 ID
+func (b *sessionBindings) bind(sessionID string, conn *server.Connection) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.conns[sessionID] = conn.Root()
+}
+
+func (b *sessionBindings) unbind(sessionID string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.conns, sessionID)
+}
+
+// 返回 session 当前可用的连接，连接已关闭时返回 nil
+func (b *sessionBindings) get(sessionID string) *server.Connection {
+	b.mu.RLock()
+	conn := b.conns[sessionID]
+	b.mu.RUnlock()
+
+	if conn == nil {
+		return nil
+	}
+	select {
+	case <-conn.Done():
+		return nil
+	default:
+		return conn
+	}
+}
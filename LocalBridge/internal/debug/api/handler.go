@@ -9,7 +9,12 @@ import (
 
 	maa "github.com/MaaXYZ/maa-framework-go/v4"
 	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/debug/artifact"
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/debug/compare"
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/debug/coverage"
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/debug/delivery"
 	debugdiagnostics "github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/debug/diagnostics"
+	debugevents "github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/debug/events"
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/debug/projectinterface"
 	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/debug/protocol"
 	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/debug/registry"
 	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/debug/replay"
@@ -18,6 +23,7 @@ import (
 	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/debug/screenshot"
 	debugsession "github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/debug/session"
 	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/debug/trace"
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/debug/watch"
 	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/logger"
 	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/mfw"
 	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/server"
@@ -26,7 +32,6 @@ import (
 
 type Handler struct {
 	service      *mfw.Service
-	root         string
 	sessions     *debugsession.Manager
 	traces       *trace.Store
 	artifacts    *artifact.Store
@@ -34,25 +39,45 @@ type Handler struct {
 	runner       *debugrunner.Runner
 	screenshots  *screenshot.Service
 	traceReplay  *replay.Service
+	coverage     *coverage.Service
+	traceCompare *compare.Service
+	delivery     *delivery.Manager
+	interfaces   *projectinterface.Service
 	capabilities protocol.CapabilityManifest
+	bindings     *sessionBindings
 }
 
 func NewHandler(service *mfw.Service, root string) *Handler {
 	sessions := debugsession.NewManager()
 	traces := trace.NewStore()
 	artifacts := artifact.NewStore()
-	return &Handler{
+	h := &Handler{
 		service:      service,
-		root:         root,
 		sessions:     sessions,
 		traces:       traces,
 		artifacts:    artifacts,
 		diagnostics:  debugdiagnostics.NewService(service, root),
 		runner:       debugrunner.New(service, sessions, traces, artifacts, root),
 		screenshots:  screenshot.NewService(service, artifacts),
-		traceReplay:  replay.NewService(traces),
+		traceReplay:  replay.NewService(traces, artifacts),
+		coverage:     coverage.NewService(traces, artifacts, root),
+		traceCompare: compare.NewService(traces, root),
+		interfaces:   projectinterface.NewService(root),
 		capabilities: registry.DefaultCapabilityManifest(),
+		bindings:     newSessionBindings(),
 	}
+	h.delivery = delivery.NewManager(func(sessionID string) delivery.Target {
+		if conn := h.bindings.get(sessionID); conn != nil {
+			return conn
+		}
+		return nil
+	})
+	return h
+}
+
+// RecentTraces 返回最近 limit 次调试运行的 trace，供诊断包使用
+func (h *Handler) RecentTraces(limit int) []protocol.TraceSnapshot {
+	return h.traces.RecentRuns(limit)
 }
 
 func (h *Handler) GetRoutePrefix() []string {
@@ -64,13 +89,15 @@ func (h *Handler) Handle(msg models.Message, conn *server.Connection) *models.Me
 
 	switch msg.Path {
 	case "/mpe/debug/capabilities":
-		h.send(conn, "/lte/debug/capabilities", h.capabilities)
+		h.send(conn, "/lte/debug/capabilities", h.capabilitySnapshot())
 	case "/mpe/debug/session/create":
 		h.handleCreateSession(conn)
 	case "/mpe/debug/session/destroy":
 		h.handleDestroySession(conn, msg)
 	case "/mpe/debug/session/snapshot":
 		h.handleSessionSnapshot(conn, msg)
+	case "/mpe/debug/session/attach":
+		h.handleSessionAttach(conn, msg)
 	case "/mpe/debug/run/start":
 		h.handleRunStart(conn, msg)
 	case "/mpe/debug/resource/preflight":
@@ -79,6 +106,18 @@ func (h *Handler) Handle(msg models.Message, conn *server.Connection) *models.Me
 		h.handleResourceHealth(conn, msg)
 	case "/mpe/debug/run/stop":
 		h.handleRunStop(conn, msg)
+	case "/mpe/debug/run/pause", "/mpe/debug/run/resume", "/mpe/debug/run/step":
+		h.handleRunControl(conn, msg)
+	case "/mpe/debug/run/patch":
+		h.handleRunPatch(conn, msg)
+	case "/mpe/debug/watch/add":
+		h.handleWatchAdd(conn, msg)
+	case "/mpe/debug/watch/remove":
+		h.handleWatchRemove(conn, msg)
+	case "/mpe/debug/watch/list":
+		h.handleWatchList(conn, msg)
+	case "/mpe/debug/events/subscribe":
+		h.handleEventsSubscribe(conn, msg)
 	case "/mpe/debug/artifact/get":
 		h.handleArtifactGet(conn, msg)
 	case "/mpe/debug/screenshot/capture":
@@ -93,6 +132,16 @@ func (h *Handler) Handle(msg models.Message, conn *server.Connection) *models.Me
 		h.handleTraceReplaySeek(conn, msg)
 	case "/mpe/debug/trace/replay/stop":
 		h.handleTraceReplayStop(conn, msg)
+	case "/mpe/debug/trace/replay/export":
+		h.handleTraceReplayExport(conn, msg)
+	case "/mpe/debug/trace/compare":
+		h.handleTraceCompare(conn, msg)
+	case "/mpe/debug/coverage/report":
+		h.handleCoverageReport(conn, msg)
+	case "/mpe/debug/interface/list":
+		h.send(conn, "/lte/debug/interface_list", h.interfaces.List())
+	case "/mpe/debug/interface/profile":
+		h.handleInterfaceProfile(conn, msg)
 	case "/mpe/debug/start", "/mpe/debug/stop":
 		h.sendError(conn, "debug_legacy_route_removed", "旧调试路由已移除，请使用 debug-vNext 契约", map[string]string{
 			"path": msg.Path,
@@ -112,10 +161,31 @@ func (h *Handler) handleCreateSession(conn *server.Connection) {
 		return
 	}
 
-	snapshot := h.sessions.Create(h.capabilities)
+	snapshot := h.sessions.Create(h.capabilitySnapshot())
+	h.bindings.bind(snapshot.SessionID, conn)
 	h.send(conn, "/lte/debug/session_created", snapshot)
 }
 
+// capabilitySnapshot 在静态能力清单上附加当前控制器健康状态
+func (h *Handler) capabilitySnapshot() protocol.CapabilityManifest {
+	manifest := h.capabilities
+	for _, health := range h.service.HealthMonitor().Snapshot() {
+		manifest.ControllerHealth = append(manifest.ControllerHealth, protocol.ControllerHealthState{
+			ControllerID:        health.ControllerID,
+			Type:                health.Type,
+			Status:              string(health.Status),
+			LatencyMS:           health.LatencyMS,
+			ConsecutiveFailures: health.ConsecutiveFailures,
+			LastError:           health.LastError,
+			LastProbeAt:         health.LastProbeAt,
+			AutoReconnect:       health.AutoReconnect,
+			ReconnectAttempts:   health.ReconnectAttempts,
+			NextReconnectAt:     health.NextReconnectAt,
+		})
+	}
+	return manifest
+}
+
 func (h *Handler) handleDestroySession(conn *server.Connection, msg models.Message) {
 	if !h.service.IsInitialized() {
 		h.sendError(conn, "debug_not_initialized", "MaaFramework 未初始化，请先初始化服务", nil)
@@ -128,8 +198,14 @@ func (h *Handler) handleDestroySession(conn *server.Connection, msg models.Messa
 		return
 	}
 
+	if !h.authorizeSession(conn, sessionID, getOwnerToken(msg)) {
+		return
+	}
+
 	h.traceReplay.StopSession(sessionID)
 	h.runner.DisposeSession(sessionID)
+	h.bindings.unbind(sessionID)
+	h.delivery.DeleteSession(sessionID)
 	if err := h.sessions.Destroy(sessionID); err != nil {
 		h.sendError(conn, "debug_session_not_found", err.Error(), nil)
 		return
@@ -161,6 +237,39 @@ func (h *Handler) handleSessionSnapshot(conn *server.Connection, msg models.Mess
 	h.send(conn, "/lte/debug/session_snapshot", snapshot)
 }
 
+func (h *Handler) handleSessionAttach(conn *server.Connection, msg models.Message) {
+	req, err := decodeData[protocol.SessionAttachRequest](msg)
+	if err != nil {
+		h.sendError(conn, "debug_invalid_request", err.Error(), nil)
+		return
+	}
+
+	if strings.TrimSpace(req.SessionID) == "" {
+		h.sendError(conn, "debug_invalid_request", "缺少必需参数: sessionId", nil)
+		return
+	}
+
+	snapshot, err := h.sessions.Authorize(req.SessionID, req.OwnerToken)
+	if err != nil {
+		h.sendError(conn, "debug_session_attach_failed", err.Error(), map[string]string{
+			"sessionId": req.SessionID,
+		})
+		return
+	}
+
+	// 先绑定再读取 trace，衔接处的事件可能重复，由客户端按 seq 去重
+	h.bindings.bind(req.SessionID, conn)
+	missed := h.traces.ListSince(req.SessionID, req.SinceSeq)
+	logger.Info("DebugVNext", "debug session 重新绑定连接: session=%s conn=%s missed=%d", req.SessionID, conn.ID, len(missed))
+
+	h.send(conn, "/lte/debug/session_attached", map[string]interface{}{
+		"sessionId": req.SessionID,
+		"session":   snapshot,
+		"sinceSeq":  req.SinceSeq,
+		"events":    missed,
+	})
+}
+
 func (h *Handler) handleRunStart(conn *server.Connection, msg models.Message) {
 	if !h.service.IsInitialized() {
 		h.sendError(conn, "debug_not_initialized", "MaaFramework 未初始化，请先初始化服务", nil)
@@ -177,19 +286,24 @@ func (h *Handler) handleRunStart(conn *server.Connection, msg models.Message) {
 		h.sendError(conn, "debug_invalid_request", err.Error(), nil)
 		return
 	}
+	resourcePaths, err := runutil.ResolveResourcePaths(h.runner.Roots(), req.Profile.ResourcePaths)
+	if err != nil {
+		h.sendError(conn, "debug_invalid_request", err.Error(), nil)
+		return
+	}
+	req.Profile.ResourcePaths = resourcePaths
 
+	// 只有新建的会话在这里绑定连接，已有会话需通过 attach 重新绑定
 	if req.SessionID == "" {
 		snapshot := h.sessions.Create(h.capabilities)
 		req.SessionID = snapshot.SessionID
+		h.bindings.bind(req.SessionID, conn)
 		h.send(conn, "/lte/debug/session_created", snapshot)
-	} else {
-		if _, err := h.sessions.Snapshot(req.SessionID); err != nil {
-			h.sendError(conn, "debug_session_not_found", err.Error(), nil)
-			return
-		}
+	} else if !h.authorizeSession(conn, req.SessionID, req.OwnerToken) {
+		return
 	}
 
-	result, err := h.runner.Start(req, h.eventSender(conn), h.snapshotSender(conn))
+	result, err := h.runner.Start(req, h.eventSender(req.SessionID), h.snapshotSender(req.SessionID))
 	if err != nil {
 		h.sendError(conn, "debug_run_start_failed", err.Error(), map[string]interface{}{
 			"mode":      req.Mode,
@@ -225,6 +339,19 @@ func (h *Handler) handleResourcePreflight(conn *server.Connection, msg models.Me
 		CheckedAt:     checkedAt,
 	}
 
+	resolved, err := runutil.ResolveResourcePaths(h.runner.Roots(), paths)
+	if err != nil {
+		result.Diagnostics = []protocol.Diagnostic{{
+			Severity: "error",
+			Code:     "debug.resource.outside_root",
+			Message:  err.Error(),
+		}}
+		h.send(conn, "/lte/debug/resource_preflight", result)
+		return
+	}
+	paths = resolved
+	result.ResourcePaths = paths
+
 	if len(paths) == 0 {
 		result.Diagnostics = []protocol.Diagnostic{{
 			Severity: "error",
@@ -285,6 +412,13 @@ func (h *Handler) handleResourceHealth(conn *server.Connection, msg models.Messa
 		return
 	}
 
+	resourcePaths, err := runutil.ResolveResourcePaths(h.runner.Roots(), req.ResourcePaths)
+	if err != nil {
+		h.sendError(conn, "debug_invalid_request", err.Error(), nil)
+		return
+	}
+	req.ResourcePaths = resourcePaths
+
 	result := h.diagnostics.CheckResourceHealth(req)
 	h.send(conn, "/lte/debug/resource_health", result)
 }
@@ -301,12 +435,11 @@ func (h *Handler) handleRunStop(conn *server.Connection, msg models.Message) {
 		return
 	}
 
-	if _, err := h.sessions.Snapshot(req.SessionID); err != nil {
-		h.sendError(conn, "debug_session_not_found", err.Error(), nil)
+	if !h.authorizeSession(conn, req.SessionID, req.OwnerToken) {
 		return
 	}
 
-	if err := h.runner.Stop(req.SessionID, req.RunID, req.Reason, h.eventSender(conn), h.snapshotSender(conn)); err != nil {
+	if err := h.runner.Stop(req.SessionID, req.RunID, req.Reason, h.eventSender(req.SessionID), h.snapshotSender(req.SessionID)); err != nil {
 		h.sendError(conn, "debug_run_stop_failed", err.Error(), map[string]string{
 			"sessionId": req.SessionID,
 			"runId":     req.RunID,
@@ -321,6 +454,170 @@ func (h *Handler) handleRunStop(conn *server.Connection, msg models.Message) {
 	})
 }
 
+func (h *Handler) handleRunControl(conn *server.Connection, msg models.Message) {
+	req, err := decodeData[protocol.RunControlRequest](msg)
+	if err != nil {
+		h.sendError(conn, "debug_invalid_request", err.Error(), nil)
+		return
+	}
+
+	if strings.TrimSpace(req.SessionID) == "" {
+		h.sendError(conn, "debug_invalid_request", "缺少必需参数: sessionId", nil)
+		return
+	}
+
+	if !h.authorizeSession(conn, req.SessionID, req.OwnerToken) {
+		return
+	}
+
+	action := strings.TrimPrefix(msg.Path, "/mpe/debug/run/")
+	switch action {
+	case "pause":
+		err = h.runner.Pause(req.SessionID, req.RunID)
+	case "resume":
+		err = h.runner.Resume(req.SessionID, req.RunID)
+	default:
+		err = h.runner.Step(req.SessionID, req.RunID)
+	}
+	if err != nil {
+		h.sendError(conn, "debug_run_"+action+"_failed", err.Error(), map[string]string{
+			"sessionId": req.SessionID,
+			"runId":     req.RunID,
+		})
+		return
+	}
+
+	h.send(conn, "/lte/debug/run_"+action+"_requested", map[string]string{
+		"sessionId": req.SessionID,
+		"runId":     req.RunID,
+	})
+}
+
+func (h *Handler) handleRunPatch(conn *server.Connection, msg models.Message) {
+	req, err := decodeData[protocol.RunPatchRequest](msg)
+	if err != nil {
+		h.sendError(conn, "debug_invalid_request", err.Error(), nil)
+		return
+	}
+
+	if strings.TrimSpace(req.SessionID) == "" {
+		h.sendError(conn, "debug_invalid_request", "缺少必需参数: sessionId", nil)
+		return
+	}
+
+	if !h.authorizeSession(conn, req.SessionID, req.OwnerToken) {
+		return
+	}
+
+	changes, err := h.runner.Patch(req.SessionID, req.RunID, req.Overrides)
+	if err != nil {
+		h.sendError(conn, "debug_run_patch_failed", err.Error(), map[string]string{
+			"sessionId": req.SessionID,
+			"runId":     req.RunID,
+		})
+		return
+	}
+
+	h.send(conn, "/lte/debug/run_patched", map[string]interface{}{
+		"sessionId": req.SessionID,
+		"runId":     req.RunID,
+		"changes":   changes,
+	})
+}
+
+func (h *Handler) handleWatchAdd(conn *server.Connection, msg models.Message) {
+	req, err := decodeData[protocol.WatchAddRequest](msg)
+	if err != nil {
+		h.sendError(conn, "debug_invalid_request", err.Error(), nil)
+		return
+	}
+	if !h.authorizeSession(conn, req.SessionID, req.OwnerToken) {
+		return
+	}
+
+	added, err := h.runner.Watches().Add(req.SessionID, req.Expression, req.Pause)
+	if err != nil {
+		detail := map[string]interface{}{
+			"sessionId":  req.SessionID,
+			"expression": req.Expression,
+		}
+		var parseErr *watch.ParseError
+		if errors.As(err, &parseErr) {
+			detail["column"] = parseErr.Column
+		}
+		h.sendError(conn, "debug_watch_invalid", err.Error(), detail)
+		return
+	}
+
+	h.send(conn, "/lte/debug/watch_added", map[string]interface{}{
+		"sessionId": req.SessionID,
+		"watch":     added,
+	})
+}
+
+func (h *Handler) handleWatchRemove(conn *server.Connection, msg models.Message) {
+	req, err := decodeData[protocol.WatchRemoveRequest](msg)
+	if err != nil {
+		h.sendError(conn, "debug_invalid_request", err.Error(), nil)
+		return
+	}
+	if !h.authorizeSession(conn, req.SessionID, req.OwnerToken) {
+		return
+	}
+	if err := h.runner.Watches().Remove(req.SessionID, req.WatchID); err != nil {
+		h.sendError(conn, "debug_watch_not_found", err.Error(), map[string]string{
+			"sessionId": req.SessionID,
+			"watchId":   req.WatchID,
+		})
+		return
+	}
+
+	h.send(conn, "/lte/debug/watch_removed", map[string]string{
+		"sessionId": req.SessionID,
+		"watchId":   req.WatchID,
+	})
+}
+
+func (h *Handler) handleWatchList(conn *server.Connection, msg models.Message) {
+	sessionID, err := getSessionID(msg)
+	if err != nil {
+		h.sendError(conn, "debug_invalid_request", err.Error(), nil)
+		return
+	}
+
+	h.send(conn, "/lte/debug/watch_list", map[string]interface{}{
+		"sessionId": sessionID,
+		"watches":   h.runner.Watches().List(sessionID),
+	})
+}
+
+func (h *Handler) handleEventsSubscribe(conn *server.Connection, msg models.Message) {
+	req, err := decodeData[protocol.EventSubscribeRequest](msg)
+	if err != nil {
+		h.sendError(conn, "debug_invalid_request", err.Error(), nil)
+		return
+	}
+	if strings.TrimSpace(req.SessionID) == "" {
+		h.sendError(conn, "debug_invalid_request", "缺少必需参数: sessionId", nil)
+		return
+	}
+	if !h.authorizeSession(conn, req.SessionID, req.OwnerToken) {
+		return
+	}
+
+	if err := h.delivery.Subscribe(req.SessionID, req.Subscription); err != nil {
+		h.sendError(conn, "debug_events_subscribe_failed", err.Error(), map[string]string{
+			"sessionId": req.SessionID,
+		})
+		return
+	}
+
+	h.send(conn, "/lte/debug/events_subscribed", map[string]interface{}{
+		"sessionId":    req.SessionID,
+		"subscription": req.Subscription,
+	})
+}
+
 func (h *Handler) handleArtifactGet(conn *server.Connection, msg models.Message) {
 	req, err := decodeData[protocol.ArtifactGetRequest](msg)
 	if err != nil {
@@ -369,9 +666,9 @@ func (h *Handler) handleScreenshotCapture(conn *server.Connection, msg models.Me
 	if sessionID == "" {
 		snapshot := h.sessions.Create(h.capabilities)
 		sessionID = snapshot.SessionID
+		h.bindings.bind(sessionID, conn)
 		h.send(conn, "/lte/debug/session_created", snapshot)
-	} else if _, err := h.sessions.Snapshot(sessionID); err != nil {
-		h.sendError(conn, "debug_session_not_found", err.Error(), nil)
+	} else if !h.authorizeSession(conn, sessionID, req.OwnerToken) {
 		return
 	}
 
@@ -417,7 +714,11 @@ func (h *Handler) testAgentConnection(agent protocol.AgentProfile, resourcePaths
 		CheckedAt: time.Now().UTC().Format(time.RFC3339Nano),
 	}
 	logger.Debug("DebugVNext", "开始测试 agent 连接: %s", agentProfileLogLabel(agent))
-	paths := nonEmptyStrings(resourcePaths)
+	paths, err := runutil.ResolveResourcePaths(h.runner.Roots(), resourcePaths)
+	if err != nil {
+		result.Message = err.Error()
+		return result
+	}
 	if len(paths) == 0 {
 		result.Message = "Agent 连接测试需要先配置资源路径"
 		return result
@@ -429,7 +730,6 @@ func (h *Handler) testAgentConnection(agent protocol.AgentProfile, resourcePaths
 
 	agentPool := h.runner.AgentPool()
 	var client *maa.AgentClient
-	var err error
 	if agentPool != nil {
 		client, err = agentPool.EnsureBound(agent, paths)
 	} else {
@@ -500,8 +800,7 @@ func (h *Handler) handleTraceReplayStart(conn *server.Connection, msg models.Mes
 		h.sendError(conn, "debug_invalid_request", err.Error(), nil)
 		return
 	}
-	if _, err := h.sessions.Snapshot(strings.TrimSpace(req.SessionID)); err != nil {
-		h.sendError(conn, "debug_session_not_found", err.Error(), nil)
+	if !h.authorizeSession(conn, strings.TrimSpace(req.SessionID), req.OwnerToken) {
 		return
 	}
 	status, err := h.traceReplay.Start(req)
@@ -518,8 +817,7 @@ func (h *Handler) handleTraceReplaySeek(conn *server.Connection, msg models.Mess
 		h.sendError(conn, "debug_invalid_request", err.Error(), nil)
 		return
 	}
-	if _, err := h.sessions.Snapshot(strings.TrimSpace(req.SessionID)); err != nil {
-		h.sendError(conn, "debug_session_not_found", err.Error(), nil)
+	if !h.authorizeSession(conn, strings.TrimSpace(req.SessionID), req.OwnerToken) {
 		return
 	}
 	status, err := h.traceReplay.Seek(req)
@@ -536,8 +834,7 @@ func (h *Handler) handleTraceReplayStop(conn *server.Connection, msg models.Mess
 		h.sendError(conn, "debug_invalid_request", err.Error(), nil)
 		return
 	}
-	if _, err := h.sessions.Snapshot(strings.TrimSpace(req.SessionID)); err != nil {
-		h.sendError(conn, "debug_session_not_found", err.Error(), nil)
+	if !h.authorizeSession(conn, strings.TrimSpace(req.SessionID), req.OwnerToken) {
 		return
 	}
 	status, err := h.traceReplay.Stop(req)
@@ -548,6 +845,81 @@ func (h *Handler) handleTraceReplayStop(conn *server.Connection, msg models.Mess
 	h.send(conn, "/lte/debug/trace_replay_status", status)
 }
 
+func (h *Handler) handleTraceReplayExport(conn *server.Connection, msg models.Message) {
+	req, err := decodeData[protocol.TraceReplayExportRequest](msg)
+	if err != nil {
+		h.sendError(conn, "debug_invalid_request", err.Error(), nil)
+		return
+	}
+	if _, err := h.sessions.Snapshot(strings.TrimSpace(req.SessionID)); err != nil {
+		h.sendError(conn, "debug_session_not_found", err.Error(), nil)
+		return
+	}
+	export, err := h.traceReplay.Export(req)
+	if err != nil {
+		h.sendError(conn, "debug_trace_replay_export_failed", err.Error(), nil)
+		return
+	}
+	h.send(conn, "/lte/debug/trace_replay_exported", export)
+}
+
+func (h *Handler) handleTraceCompare(conn *server.Connection, msg models.Message) {
+	req, err := decodeData[protocol.TraceCompareRequest](msg)
+	if err != nil {
+		h.sendError(conn, "debug_invalid_request", err.Error(), nil)
+		return
+	}
+	comparison, err := h.traceCompare.Compare(req)
+	if err != nil {
+		h.sendError(conn, "debug_trace_compare_failed", err.Error(), nil)
+		return
+	}
+	h.send(conn, "/lte/debug/trace_compared", comparison)
+}
+
+func (h *Handler) handleCoverageReport(conn *server.Connection, msg models.Message) {
+	req, err := decodeData[protocol.CoverageReportRequest](msg)
+	if err != nil {
+		h.sendError(conn, "debug_invalid_request", err.Error(), nil)
+		return
+	}
+	if _, err := h.sessions.Snapshot(req.SessionID); err != nil {
+		h.sendError(conn, "debug_session_not_found", err.Error(), nil)
+		return
+	}
+
+	ref, report, err := h.coverage.Store(req)
+	if err != nil {
+		h.sendError(conn, "debug_coverage_failed", err.Error(), map[string]string{
+			"sessionId": req.SessionID,
+		})
+		return
+	}
+
+	h.send(conn, "/lte/debug/coverage_report", map[string]interface{}{
+		"sessionId": req.SessionID,
+		"ref":       ref,
+		"report":    report,
+	})
+}
+
+func (h *Handler) handleInterfaceProfile(conn *server.Connection, msg models.Message) {
+	req, err := decodeData[protocol.ProjectInterfaceProfileRequest](msg)
+	if err != nil {
+		h.sendError(conn, "debug_invalid_request", err.Error(), nil)
+		return
+	}
+	profile, err := h.interfaces.Profile(req)
+	if err != nil {
+		h.sendError(conn, "debug_interface_profile_failed", err.Error(), map[string]string{
+			"interfacePath": req.InterfacePath,
+			"task":          req.Task,
+		})
+		return
+	}
+	h.send(conn, "/lte/debug/interface_profile", profile)
+}
+
 func validateRunRequest(req protocol.RunRequest) error {
 	if !protocol.IsValidRunMode(req.Mode) {
 		return fmt.Errorf("无效的 run mode: %s", req.Mode)
@@ -610,6 +982,9 @@ func validateRunRequest(req protocol.RunRequest) error {
 			return fmt.Errorf("缺少必需字段: resolverSnapshot.nodes[%d].runtimeName", i)
 		}
 	}
+	if err := debugevents.ValidateBreakpoints(req.Breakpoints); err != nil {
+		return err
+	}
 	if protocol.RunModeRequiresTarget(req.Mode) {
 		if req.Target == nil {
 			return fmt.Errorf("%s 需要 target", req.Mode)
@@ -704,6 +1079,33 @@ func isSupportedSavePolicy(savePolicy string) bool {
 	}
 }
 
+// authorizeSession 校验会话存在，且请求来自会话绑定的连接或携带正确的所有权令牌，失败时回复错误
+func (h *Handler) authorizeSession(conn *server.Connection, sessionID string, ownerToken string) bool {
+	if _, err := h.sessions.Snapshot(sessionID); err != nil {
+		h.sendError(conn, "debug_session_not_found", err.Error(), nil)
+		return false
+	}
+	if bound := h.bindings.get(sessionID); bound != nil && bound == conn.Root() {
+		return true
+	}
+	if _, err := h.sessions.Authorize(sessionID, ownerToken); err != nil {
+		h.sendError(conn, "debug_session_forbidden", err.Error(), map[string]string{
+			"sessionId": sessionID,
+		})
+		return false
+	}
+	return true
+}
+
+func getOwnerToken(msg models.Message) string {
+	dataMap, ok := msg.Data.(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	token, _ := dataMap["ownerToken"].(string)
+	return token
+}
+
 func getSessionID(msg models.Message) (string, error) {
 	dataMap, ok := msg.Data.(map[string]interface{})
 	if !ok {
@@ -762,14 +1164,18 @@ func (h *Handler) sendError(conn *server.Connection, code string, message string
 	})
 }
 
-func (h *Handler) eventSender(conn *server.Connection) debugrunner.EventSender {
+// 事件发送时按 session 查找当前绑定的连接，断线期间的事件仅写入 trace，重连后通过 attach 补发；
+// 推送前按订阅过滤与合并，连接积压时改为批量推送
+func (h *Handler) eventSender(sessionID string) debugrunner.EventSender {
 	return func(event protocol.Event) {
-		h.send(conn, "/lte/debug/event", event)
+		h.delivery.Deliver(sessionID, event)
 	}
 }
 
-func (h *Handler) snapshotSender(conn *server.Connection) debugrunner.SnapshotSender {
+func (h *Handler) snapshotSender(sessionID string) debugrunner.SnapshotSender {
 	return func(snapshot debugsession.Snapshot) {
-		h.send(conn, "/lte/debug/session_snapshot", snapshot)
+		if conn := h.bindings.get(sessionID); conn != nil {
+			h.send(conn, "/lte/debug/session_snapshot", snapshot)
+		}
 	}
 }
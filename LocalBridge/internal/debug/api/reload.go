@@ -0,0 +1,42 @@
+package api
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/workspace"
+)
+
+// Drain 停止所有运行中的 run 并等待其结束，期间拒绝新的运行，结束后需调用 Resume。
+// 返回停止的 run 数量，超时返回错误。
+func (h *Handler) Drain(reason string, timeout time.Duration) (int, error) {
+	runs := h.runner.Suspend(reason)
+	for _, run := range runs {
+		// run 可能已自行结束，停止失败不影响排空
+		_ = h.runner.Stop(run.SessionID, run.ID, reason, h.eventSender(run.SessionID), h.snapshotSender(run.SessionID))
+	}
+
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+	for _, run := range runs {
+		select {
+		case <-run.Done:
+		case <-deadline.C:
+			return len(runs), fmt.Errorf("等待调试运行结束超时: %s", run.ID)
+		}
+	}
+	return len(runs), nil
+}
+
+// Resume 恢复接受新的运行
+func (h *Handler) Resume() {
+	h.runner.Unsuspend()
+}
+
+// SetRoots 切换调试运行、覆盖率、trace 对比与 interface.json 发现使用的工作区根目录
+func (h *Handler) SetRoots(roots workspace.Roots) {
+	h.runner.SetRoots(roots)
+	h.coverage.SetRoots(roots)
+	h.traceCompare.SetRoots(roots)
+	h.interfaces.SetRoots(roots)
+}
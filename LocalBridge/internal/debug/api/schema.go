@@ -0,0 +1,146 @@
+package api
+
+import (
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/debug/protocol"
+	debugsession "github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/debug/session"
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/debug/watch"
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/schema"
+)
+
+// 声明 debug-vNext 协议的消息结构，请求结构与 decodeData 使用的类型一致
+func (h *Handler) MessageSchemas() []schema.Message {
+	sessionRequest := schema.Object(schema.RequiredField("sessionId", schema.String()))
+	sessionID := schema.RequiredField("sessionId", schema.String())
+	runID := schema.RequiredField("runId", schema.String())
+	request := func(route string, description string, payload schema.Schema) schema.Message {
+		return schema.Request("debug", "/mpe/debug/"+route, description, payload)
+	}
+	push := func(route string, description string, payload schema.Schema) schema.Message {
+		return schema.Push("debug", "/lte/debug/"+route, description, payload)
+	}
+	runControl := schema.Object(sessionID, runID)
+
+	return []schema.Message{
+		request("capabilities", "查询调试能力清单", schema.Object()),
+		push("capabilities", "调试能力清单", schema.Of(protocol.CapabilityManifest{})),
+
+		// 会话
+		request("session/create", "创建调试会话", schema.Object()),
+		request("session/destroy", "销毁调试会话", sessionRequest),
+		request("session/snapshot", "获取会话快照", sessionRequest),
+		request("session/attach", "重连后重新绑定会话并补发事件", schema.Of(protocol.SessionAttachRequest{}).Require("sessionId")),
+		push("session_created", "会话已创建", schema.Of(debugsession.Snapshot{})),
+		push("session_snapshot", "会话快照", schema.Of(debugsession.Snapshot{})),
+		push("session_destroyed", "会话已销毁", schema.Object(sessionID)),
+		push("session_attached", "会话已重新绑定", schema.Object(
+			sessionID,
+			schema.RequiredField("session", schema.Of(debugsession.Snapshot{})),
+			schema.RequiredField("sinceSeq", schema.Integer()),
+			schema.RequiredField("events", schema.Of([]protocol.Event{})),
+		)),
+
+		// 运行
+		request("run/start", "开始调试运行", schema.Of(protocol.RunRequest{}).Require("mode")),
+		request("run/stop", "停止运行", schema.Of(protocol.RunStopRequest{}).Require("sessionId")),
+		request("run/pause", "暂停运行", schema.Of(protocol.RunControlRequest{}).Require("sessionId")),
+		request("run/resume", "继续运行", schema.Of(protocol.RunControlRequest{}).Require("sessionId")),
+		request("run/step", "单步运行", schema.Of(protocol.RunControlRequest{}).Require("sessionId")),
+		request("run/patch", "运行中修改 pipeline 节点", schema.Of(protocol.RunPatchRequest{}).Require("sessionId")),
+		push("run_started", "运行已开始", schema.Object(
+			sessionID,
+			runID,
+			schema.RequiredField("mode", schema.String()),
+			schema.RequiredField("entry", schema.String()),
+			schema.RequiredField("startedAt", schema.String()),
+			schema.RequiredField("session", schema.Of(debugsession.Snapshot{})),
+		)),
+		push("run_stop_requested", "已请求停止", schema.Object(sessionID, runID, schema.Field("reason", schema.String()))),
+		push("run_pause_requested", "已请求暂停", runControl),
+		push("run_resume_requested", "已请求继续", runControl),
+		push("run_step_requested", "已请求单步", runControl),
+		push("run_patched", "修改已生效", schema.Object(
+			sessionID,
+			runID,
+			schema.RequiredField("changes", schema.Of([]protocol.PipelinePatchChange{})),
+		)),
+
+		// 资源诊断
+		request("resource/preflight", "运行前资源预检", schema.Of(protocol.ResourcePreflightRequest{})),
+		request("resource/health", "资源包健康检查", schema.Of(protocol.ResourceHealthRequest{})),
+		push("resource_preflight", "预检结果", schema.Of(protocol.ResourcePreflightResult{})),
+		push("resource_health", "健康检查结果", schema.Of(protocol.ResourceHealthResult{})),
+
+		// 监视表达式与事件
+		request("watch/add", "添加监视表达式", schema.Of(protocol.WatchAddRequest{}).Require("sessionId")),
+		request("watch/remove", "移除监视表达式", schema.Of(protocol.WatchRemoveRequest{}).Require("sessionId")),
+		request("watch/list", "列出监视表达式", sessionRequest),
+		push("watch_added", "已添加", schema.Object(sessionID, schema.RequiredField("watch", schema.Of(watch.Watch{})))),
+		push("watch_removed", "已移除", schema.Object(sessionID, schema.RequiredField("watchId", schema.String()))),
+		push("watch_list", "监视表达式列表", schema.Object(sessionID, schema.RequiredField("watches", schema.Of([]watch.Watch{})))),
+		request("events/subscribe", "设置事件订阅过滤与合并", schema.Of(protocol.EventSubscribeRequest{}).Require("sessionId")),
+		push("events_subscribed", "订阅已生效", schema.Object(
+			sessionID,
+			schema.RequiredField("subscription", schema.Of(protocol.EventSubscription{})),
+		)),
+		push("event", "调试事件", schema.Of(protocol.Event{})),
+		push("event_batch", "连接积压时批量推送的事件", schema.Object(
+			sessionID,
+			schema.RequiredField("events", schema.Of([]protocol.Event{})),
+		)),
+		push("event_gap", "因积压丢弃的事件区间，可通过 trace 补齐", schema.Object(
+			sessionID,
+			schema.RequiredField("fromSeq", schema.Integer()),
+			schema.RequiredField("toSeq", schema.Integer()),
+			schema.RequiredField("count", schema.Integer()),
+		)),
+
+		// 产物、截图与 Agent
+		request("artifact/get", "读取调试产物", schema.Of(protocol.ArtifactGetRequest{}).Require("sessionId", "artifactId")),
+		push("artifact", "调试产物", schema.Of(protocol.ArtifactPayload{})),
+		request("screenshot/capture", "截图，结果以 screenshot 事件推送", schema.Of(protocol.ScreenshotCaptureRequest{})),
+		request("agent/test", "测试 Agent 连接", schema.Of(protocol.AgentTestRequest{})),
+		push("agent_tested", "Agent 测试结果", schema.Of(protocol.AgentTestResult{})),
+
+		// Trace 回放、对比与覆盖率
+		request("trace/snapshot", "获取 trace 快照", schema.Of(protocol.TraceSnapshotRequest{}).Require("sessionId")),
+		request("trace/replay/start", "开始回放", schema.Of(protocol.TraceReplayRequest{}).Require("sessionId")),
+		request("trace/replay/seek", "跳转回放位置", schema.Of(protocol.TraceReplayRequest{}).Require("sessionId")),
+		request("trace/replay/stop", "停止回放", schema.Of(protocol.TraceReplayStopRequest{}).Require("sessionId")),
+		request("trace/replay/export", "导出回放为 GIF 或帧序列", schema.Of(protocol.TraceReplayExportRequest{}).Require("sessionId")),
+		request("trace/compare", "对比两次运行的 trace", schema.Of(protocol.TraceCompareRequest{})),
+		push("trace_snapshot", "trace 快照", schema.Of(protocol.TraceSnapshot{})),
+		push("trace_replay_status", "回放状态", schema.Of(protocol.TraceReplayStatus{})),
+		push("trace_replay_exported", "回放导出结果", schema.Of(protocol.TraceReplayExport{})),
+		push("trace_compared", "trace 对比结果", schema.Of(protocol.TraceComparison{})),
+		request("coverage/report", "生成节点覆盖率报告", schema.Of(protocol.CoverageReportRequest{}).Require("sessionId")),
+		push("coverage_report", "覆盖率报告", schema.Object(
+			sessionID,
+			schema.RequiredField("ref", schema.Of(protocol.ArtifactRef{})),
+			schema.RequiredField("report", schema.Of(protocol.CoverageReport{})),
+		)),
+
+		// 项目 interface.json
+		request("interface/list", "列出项目中的 interface.json", schema.Object()),
+		request("interface/profile", "由 interface.json 生成运行配置", schema.Of(protocol.ProjectInterfaceProfileRequest{})),
+		push("interface_list", "interface.json 列表", schema.Of(protocol.ProjectInterfaceListResult{})),
+		push("interface_profile", "运行配置", schema.Of(protocol.ProjectInterfaceProfile{})),
+
+		push("error", "调试请求失败", schema.Object(
+			schema.RequiredField("code", schema.String()),
+			schema.RequiredField("message", schema.String()),
+			schema.Field("detail", schema.Any()),
+		)),
+	}
+}
+
+// 调试协议提供的功能标识，由能力清单的 debugFeatures 加 "debug." 前缀得到
+func (h *Handler) Capabilities() []string {
+	if !h.service.IsInitialized() {
+		return nil
+	}
+	capabilities := []string{"debug"}
+	for _, feature := range h.capabilities.DebugFeatures {
+		capabilities = append(capabilities, "debug."+feature)
+	}
+	return capabilities
+}
@@ -82,6 +82,28 @@ func (s *Store) AddPNG(sessionID string, artifactType string, img image.Image) (
 	return ref, nil
 }
 
+func (s *Store) AddBinary(sessionID string, artifactType string, mime string, content []byte) (protocol.ArtifactRef, error) {
+	if sessionID == "" {
+		return protocol.ArtifactRef{}, fmt.Errorf("artifact missing sessionId")
+	}
+
+	ref := protocol.ArtifactRef{
+		ID:        uuid.NewString(),
+		SessionID: sessionID,
+		Type:      artifactType,
+		Mime:      mime,
+		Size:      int64(len(content)),
+		CreatedAt: time.Now().UTC().Format(time.RFC3339Nano),
+	}
+	payload := protocol.ArtifactPayload{
+		Ref:      ref,
+		Encoding: "base64",
+		Content:  base64.StdEncoding.EncodeToString(content),
+	}
+	s.put(payload)
+	return ref, nil
+}
+
 func (s *Store) SetEventSeq(sessionID string, artifactID string, seq int64) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -113,6 +135,26 @@ func (s *Store) Get(sessionID string, artifactID string) (protocol.ArtifactPaylo
 	return payload, nil
 }
 
+// Image 解码图片类 artifact
+func (s *Store) Image(sessionID string, artifactID string) (image.Image, error) {
+	payload, err := s.Get(sessionID, artifactID)
+	if err != nil {
+		return nil, err
+	}
+	if payload.Encoding != "base64" || payload.Ref.Mime != "image/png" {
+		return nil, fmt.Errorf("artifact is not an image: %s", artifactID)
+	}
+	content, err := base64.StdEncoding.DecodeString(payload.Content)
+	if err != nil {
+		return nil, fmt.Errorf("decode artifact content: %w", err)
+	}
+	img, err := png.Decode(bytes.NewReader(content))
+	if err != nil {
+		return nil, fmt.Errorf("decode png artifact: %w", err)
+	}
+	return img, nil
+}
+
 func (s *Store) ListRefs(sessionID string) []protocol.ArtifactRef {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
@@ -0,0 +1,428 @@
+package compare
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/debug/performance"
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/debug/protocol"
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/debug/trace"
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/workspace"
+)
+
+const (
+	DefaultDurationThresholdMs = 50
+
+	// 节点序列对齐的 LCS 表上限，超过时中间段整体视为删除 + 新增
+	maxAlignCells = 4_000_000
+)
+
+type Service struct {
+	traces *trace.Store
+	mu     sync.RWMutex
+	roots  workspace.Roots
+}
+
+func NewService(traces *trace.Store, root string) *Service {
+	return &Service{traces: traces, roots: workspace.Single(root)}
+}
+
+// SetRoots 切换读取 trace 文件的工作区根目录
+func (s *Service) SetRoots(roots workspace.Roots) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.roots = roots
+}
+
+func (s *Service) workspaceRoots() workspace.Roots {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.roots
+}
+
+func (s *Service) Compare(req protocol.TraceCompareRequest) (protocol.TraceComparison, error) {
+	baseSource, baseEvents, err := s.load("base", req.Base)
+	if err != nil {
+		return protocol.TraceComparison{}, err
+	}
+	targetSource, targetEvents, err := s.load("target", req.Target)
+	if err != nil {
+		return protocol.TraceComparison{}, err
+	}
+	return Compare(baseSource, baseEvents, targetSource, targetEvents, req.DurationThresholdMs, req.ScoreThreshold), nil
+}
+
+// 读取对比的一侧；未指定 runId 时取最后一个 run
+func (s *Service) load(label string, side protocol.TraceCompareSide) (protocol.TraceCompareSource, []protocol.Event, error) {
+	source := protocol.TraceCompareSource{
+		SessionID: strings.TrimSpace(side.SessionID),
+		RunID:     strings.TrimSpace(side.RunID),
+		TracePath: strings.TrimSpace(side.TracePath),
+	}
+
+	var events []protocol.Event
+	switch {
+	case len(side.Events) > 0:
+		source.Imported = true
+		events = side.Events
+	case source.TracePath != "":
+		loaded, err := trace.LoadFile(s.workspaceRoots(), source.TracePath)
+		if err != nil {
+			return source, nil, err
+		}
+		events = loaded
+	case source.SessionID != "":
+		events = s.traces.List(source.SessionID)
+	default:
+		return source, nil, fmt.Errorf("%s 缺少 sessionId、tracePath 或 events", label)
+	}
+
+	if source.RunID == "" {
+		source.RunID = latestRunID(events)
+	}
+	events = filterRun(events, source.RunID)
+	if len(events) == 0 {
+		return source, nil, fmt.Errorf("%s 没有可对比的事件: runId=%s", label, source.RunID)
+	}
+	if source.SessionID == "" {
+		source.SessionID = events[0].SessionID
+	}
+	return source, events, nil
+}
+
+type nodeStats struct {
+	hits      int
+	misses    int
+	bestScore float64
+	hasScore  bool
+}
+
+type diagnosticEntry struct {
+	key    string
+	change protocol.TraceDiagnosticChange
+}
+
+type traceStats struct {
+	sequence    []string
+	nodes       map[string]*nodeStats
+	diagnostics []diagnosticEntry
+	durations   map[string]float64
+	summary     protocol.PerformanceSummary
+}
+
+// Compare 对齐两次运行的节点序列，并汇总命中变化、识别分数差异、耗时回退与新增诊断
+func Compare(
+	baseSource protocol.TraceCompareSource,
+	baseEvents []protocol.Event,
+	targetSource protocol.TraceCompareSource,
+	targetEvents []protocol.Event,
+	durationThresholdMs float64,
+	scoreThreshold float64,
+) protocol.TraceComparison {
+	if durationThresholdMs <= 0 {
+		durationThresholdMs = DefaultDurationThresholdMs
+	}
+	base := collectStats(baseSource, baseEvents)
+	target := collectStats(targetSource, targetEvents)
+
+	comparison := protocol.TraceComparison{
+		Base:                describeSource(baseSource, base),
+		Target:              describeSource(targetSource, target),
+		Sequence:            alignSequences(base.sequence, target.sequence),
+		HitChanges:          make([]protocol.TraceHitChange, 0),
+		ScoreDeltas:         make([]protocol.TraceScoreDelta, 0),
+		DurationRegressions: make([]protocol.TraceDurationDelta, 0),
+		NewDiagnostics:      make([]protocol.TraceDiagnosticChange, 0),
+		DurationThresholdMs: durationThresholdMs,
+		GeneratedAt:         time.Now().UTC().Format(time.RFC3339Nano),
+	}
+	for _, entry := range comparison.Sequence {
+		if entry.Op != "same" {
+			comparison.SequenceChanged = true
+			break
+		}
+	}
+
+	for _, name := range unionKeys(base.nodes, target.nodes) {
+		baseNode, targetNode := base.node(name), target.node(name)
+		change := ""
+		if baseNode.hits > 0 && targetNode.hits == 0 {
+			change = "no-longer-hit"
+		} else if baseNode.hits == 0 && targetNode.hits > 0 {
+			change = "newly-hit"
+		}
+		if change != "" {
+			comparison.HitChanges = append(comparison.HitChanges, protocol.TraceHitChange{
+				RuntimeName:  name,
+				Change:       change,
+				BaseHits:     baseNode.hits,
+				BaseMisses:   baseNode.misses,
+				TargetHits:   targetNode.hits,
+				TargetMisses: targetNode.misses,
+			})
+		}
+
+		if baseNode.hasScore && targetNode.hasScore {
+			delta := targetNode.bestScore - baseNode.bestScore
+			if delta != 0 && math.Abs(delta) >= scoreThreshold {
+				comparison.ScoreDeltas = append(comparison.ScoreDeltas, protocol.TraceScoreDelta{
+					RuntimeName: name,
+					BaseScore:   baseNode.bestScore,
+					TargetScore: targetNode.bestScore,
+					Delta:       delta,
+				})
+			}
+		}
+	}
+	sort.SliceStable(comparison.ScoreDeltas, func(i, j int) bool {
+		return math.Abs(comparison.ScoreDeltas[i].Delta) > math.Abs(comparison.ScoreDeltas[j].Delta)
+	})
+
+	for name, baseMs := range base.durations {
+		targetMs, ok := target.durations[name]
+		if !ok || targetMs-baseMs < durationThresholdMs {
+			continue
+		}
+		comparison.DurationRegressions = append(comparison.DurationRegressions, protocol.TraceDurationDelta{
+			RuntimeName: name,
+			BaseMs:      baseMs,
+			TargetMs:    targetMs,
+			DeltaMs:     targetMs - baseMs,
+		})
+	}
+	sort.Slice(comparison.DurationRegressions, func(i, j int) bool {
+		left, right := comparison.DurationRegressions[i], comparison.DurationRegressions[j]
+		if left.DeltaMs == right.DeltaMs {
+			return left.RuntimeName < right.RuntimeName
+		}
+		return left.DeltaMs > right.DeltaMs
+	})
+
+	known := make(map[string]struct{}, len(base.diagnostics))
+	for _, entry := range base.diagnostics {
+		known[entry.key] = struct{}{}
+	}
+	for _, entry := range target.diagnostics {
+		if _, ok := known[entry.key]; ok {
+			continue
+		}
+		known[entry.key] = struct{}{}
+		comparison.NewDiagnostics = append(comparison.NewDiagnostics, entry.change)
+	}
+
+	return comparison
+}
+
+func collectStats(source protocol.TraceCompareSource, events []protocol.Event) traceStats {
+	ordered := append([]protocol.Event(nil), events...)
+	sort.SliceStable(ordered, func(i, j int) bool { return ordered[i].Seq < ordered[j].Seq })
+
+	stats := traceStats{
+		sequence:  make([]string, 0),
+		nodes:     make(map[string]*nodeStats),
+		durations: make(map[string]float64),
+		summary:   performance.BuildSummary(source.SessionID, source.RunID, ordered, nil),
+	}
+	for _, event := range ordered {
+		switch {
+		case isPipelineNodeStart(event):
+			stats.sequence = append(stats.sequence, event.Node.RuntimeName)
+		case isRecognitionResult(event):
+			node := stats.node(event.Node.RuntimeName)
+			hit := event.Phase == "succeeded"
+			if value, ok := event.Data["hit"].(bool); ok {
+				hit = value
+			}
+			if hit {
+				node.hits++
+			} else {
+				node.misses++
+			}
+			if score, ok := event.Data["score"].(float64); ok && (!node.hasScore || score > node.bestScore) {
+				node.bestScore = score
+				node.hasScore = true
+			}
+		case event.Kind == "diagnostic":
+			code, _ := event.Data["code"].(string)
+			if code == "" {
+				continue
+			}
+			change := protocol.TraceDiagnosticChange{Code: code, Seq: event.Seq}
+			change.Severity, _ = event.Data["severity"].(string)
+			change.Message, _ = event.Data["message"].(string)
+			if event.Node != nil {
+				change.RuntimeName = event.Node.RuntimeName
+			}
+			stats.diagnostics = append(stats.diagnostics, diagnosticEntry{
+				key:    code + "\x00" + change.RuntimeName,
+				change: change,
+			})
+		}
+	}
+
+	for _, node := range stats.summary.Nodes {
+		if node.RuntimeName == "" {
+			continue
+		}
+		durationMs := float64(node.DurationMs)
+		if node.Timing != nil {
+			durationMs = node.Timing.TotalMs
+		}
+		stats.durations[node.RuntimeName] += durationMs
+	}
+	return stats
+}
+
+// 缺失的节点按零值处理，便于两侧统一比较
+func (s traceStats) node(runtimeName string) *nodeStats {
+	node := s.nodes[runtimeName]
+	if node == nil {
+		node = &nodeStats{}
+		s.nodes[runtimeName] = node
+	}
+	return node
+}
+
+func describeSource(source protocol.TraceCompareSource, stats traceStats) protocol.TraceCompareSource {
+	source.EventCount = stats.summary.EventCount
+	source.NodeCount = stats.summary.NodeCount
+	source.Status = stats.summary.Status
+	source.DurationMs = float64(stats.summary.DurationMs)
+	if source.DurationMs == 0 {
+		source.DurationMs = stats.summary.Timing.TotalMs
+	}
+	return source
+}
+
+// alignSequences 先去掉公共前后缀，再用 LCS 对齐中间段
+func alignSequences(base []string, target []string) []protocol.TraceSequenceEntry {
+	result := make([]protocol.TraceSequenceEntry, 0, len(base)+len(target))
+	same := func(i, j int) {
+		result = append(result, protocol.TraceSequenceEntry{Op: "same", RuntimeName: base[i], BaseIndex: i, TargetIndex: j})
+	}
+	removed := func(i int) {
+		result = append(result, protocol.TraceSequenceEntry{Op: "removed", RuntimeName: base[i], BaseIndex: i, TargetIndex: -1})
+	}
+	added := func(j int) {
+		result = append(result, protocol.TraceSequenceEntry{Op: "added", RuntimeName: target[j], BaseIndex: -1, TargetIndex: j})
+	}
+
+	prefix := 0
+	for prefix < len(base) && prefix < len(target) && base[prefix] == target[prefix] {
+		same(prefix, prefix)
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(base)-prefix && suffix < len(target)-prefix &&
+		base[len(base)-1-suffix] == target[len(target)-1-suffix] {
+		suffix++
+	}
+
+	baseEnd, targetEnd := len(base)-suffix, len(target)-suffix
+	n, m := baseEnd-prefix, targetEnd-prefix
+	if n*m > maxAlignCells {
+		for i := prefix; i < baseEnd; i++ {
+			removed(i)
+		}
+		for j := prefix; j < targetEnd; j++ {
+			added(j)
+		}
+	} else {
+		// lcs[i][j] 为 base[prefix+i:] 与 target[prefix+j:] 的 LCS 长度
+		lcs := make([][]int32, n+1)
+		for i := range lcs {
+			lcs[i] = make([]int32, m+1)
+		}
+		for i := n - 1; i >= 0; i-- {
+			for j := m - 1; j >= 0; j-- {
+				if base[prefix+i] == target[prefix+j] {
+					lcs[i][j] = lcs[i+1][j+1] + 1
+				} else if lcs[i+1][j] >= lcs[i][j+1] {
+					lcs[i][j] = lcs[i+1][j]
+				} else {
+					lcs[i][j] = lcs[i][j+1]
+				}
+			}
+		}
+		i, j := 0, 0
+		for i < n || j < m {
+			switch {
+			case i < n && j < m && base[prefix+i] == target[prefix+j]:
+				same(prefix+i, prefix+j)
+				i++
+				j++
+			case j >= m || (i < n && lcs[i+1][j] >= lcs[i][j+1]):
+				removed(prefix + i)
+				i++
+			default:
+				added(prefix + j)
+				j++
+			}
+		}
+	}
+
+	for k := suffix; k > 0; k-- {
+		same(len(base)-k, len(target)-k)
+	}
+	return result
+}
+
+func latestRunID(events []protocol.Event) string {
+	latest := ""
+	var latestSeq int64
+	for _, event := range events {
+		if event.RunID != "" && (latest == "" || event.Seq >= latestSeq) {
+			latest = event.RunID
+			latestSeq = event.Seq
+		}
+	}
+	return latest
+}
+
+func filterRun(events []protocol.Event, runID string) []protocol.Event {
+	if runID == "" {
+		return events
+	}
+	result := make([]protocol.Event, 0, len(events))
+	for _, event := range events {
+		if event.RunID == runID {
+			result = append(result, event)
+		}
+	}
+	return result
+}
+
+func unionKeys(left map[string]*nodeStats, right map[string]*nodeStats) []string {
+	keys := make([]string, 0, len(left)+len(right))
+	for key := range left {
+		keys = append(keys, key)
+	}
+	for key := range right {
+		if _, ok := left[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func isPipelineNodeStart(event protocol.Event) bool {
+	return event.Kind == "node" &&
+		event.Phase == "starting" &&
+		strings.HasPrefix(event.MaaFWMessage, "Node.PipelineNode.") &&
+		event.Node != nil &&
+		event.Node.SyntheticKind == "" &&
+		event.Node.RuntimeName != ""
+}
+
+func isRecognitionResult(event protocol.Event) bool {
+	return event.Kind == "recognition" &&
+		(event.Phase == "succeeded" || event.Phase == "failed") &&
+		strings.HasPrefix(event.MaaFWMessage, "Node.Recognition.") &&
+		event.Node != nil &&
+		event.Node.RuntimeName != ""
+}
@@ -0,0 +1,168 @@
+package compare
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/debug/protocol"
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/debug/trace"
+)
+
+func TestAlignSequences(t *testing.T) {
+	tests := []struct {
+		name   string
+		base   []string
+		target []string
+		want   []string
+	}{
+		{name: "identical", base: []string{"A", "B"}, target: []string{"A", "B"}, want: []string{"=A", "=B"}},
+		{name: "inserted", base: []string{"A", "C"}, target: []string{"A", "B", "C"}, want: []string{"=A", "+B", "=C"}},
+		{name: "replaced", base: []string{"A", "B", "D"}, target: []string{"A", "C", "D"}, want: []string{"=A", "-B", "+C", "=D"}},
+		{name: "reordered", base: []string{"A", "B", "C"}, target: []string{"B", "A", "C"}, want: []string{"-A", "=B", "+A", "=C"}},
+		{name: "empty base", base: nil, target: []string{"A"}, want: []string{"+A"}},
+	}
+
+	ops := map[string]string{"same": "=", "removed": "-", "added": "+"}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := make([]string, 0)
+			for _, entry := range alignSequences(test.base, test.target) {
+				got = append(got, ops[entry.Op]+entry.RuntimeName)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Fatalf("alignSequences() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestCompare(t *testing.T) {
+	origin := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	base := (&runBuilder{runID: "run-1", origin: origin}).
+		node("Start", 0, 10).
+		recognition("Login", 10, 0.92, true).
+		node("Login", 10, 30).
+		recognition("Reward", 30, 0.75, true).
+		node("Reward", 30, 40).
+		events
+	target := (&runBuilder{runID: "run-2", origin: origin}).
+		node("Start", 0, 10).
+		recognition("Login", 10, 0.61, false).
+		recognition("Popup", 10, 0.95, true).
+		node("Popup", 10, 20).
+		diagnostic("debug.override.live_patch_failed", "Popup", 20).
+		recognition("Reward", 20, 0.76, true).
+		node("Reward", 20, 200).
+		events
+
+	comparison := Compare(
+		protocol.TraceCompareSource{SessionID: "session-1", RunID: "run-1"}, base,
+		protocol.TraceCompareSource{SessionID: "session-1", RunID: "run-2"}, target,
+		0, 0.05,
+	)
+
+	if !comparison.SequenceChanged || len(comparison.Sequence) != 4 {
+		t.Fatalf("Sequence = %+v", comparison.Sequence)
+	}
+	if comparison.Sequence[1].Op != "removed" || comparison.Sequence[2].Op != "added" {
+		t.Fatalf("Sequence = %+v", comparison.Sequence)
+	}
+
+	wantHits := []protocol.TraceHitChange{
+		{RuntimeName: "Login", Change: "no-longer-hit", BaseHits: 1, TargetMisses: 1},
+		{RuntimeName: "Popup", Change: "newly-hit", TargetHits: 1},
+	}
+	if !reflect.DeepEqual(comparison.HitChanges, wantHits) {
+		t.Fatalf("HitChanges = %+v", comparison.HitChanges)
+	}
+	if len(comparison.ScoreDeltas) != 1 || comparison.ScoreDeltas[0].RuntimeName != "Login" {
+		t.Fatalf("ScoreDeltas = %+v", comparison.ScoreDeltas)
+	}
+	if comparison.DurationThresholdMs != DefaultDurationThresholdMs || len(comparison.DurationRegressions) != 1 ||
+		comparison.DurationRegressions[0].RuntimeName != "Reward" || comparison.DurationRegressions[0].DeltaMs != 170 {
+		t.Fatalf("DurationRegressions = %+v", comparison.DurationRegressions)
+	}
+	if len(comparison.NewDiagnostics) != 1 || comparison.NewDiagnostics[0].RuntimeName != "Popup" {
+		t.Fatalf("NewDiagnostics = %+v", comparison.NewDiagnostics)
+	}
+}
+
+func TestServiceCompareDefaultsToLatestRun(t *testing.T) {
+	traces := trace.NewStore()
+	origin := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, event := range (&runBuilder{runID: "run-1", origin: origin}).node("A", 0, 5).events {
+		traces.Append(event)
+	}
+	for _, event := range (&runBuilder{runID: "run-2", origin: origin}).node("B", 0, 5).events {
+		traces.Append(event)
+	}
+	imported := (&runBuilder{runID: "imported", origin: origin}).node("A", 0, 5).events
+
+	service := NewService(traces, t.TempDir())
+	comparison, err := service.Compare(protocol.TraceCompareRequest{
+		Base:   protocol.TraceCompareSide{Events: imported},
+		Target: protocol.TraceCompareSide{SessionID: "session-1"},
+	})
+	if err != nil {
+		t.Fatalf("Compare() error = %v", err)
+	}
+	if !comparison.Base.Imported || comparison.Target.RunID != "run-2" {
+		t.Fatalf("sources = %+v / %+v", comparison.Base, comparison.Target)
+	}
+
+	if _, err := service.Compare(protocol.TraceCompareRequest{Target: protocol.TraceCompareSide{SessionID: "session-1"}}); err == nil {
+		t.Fatal("Compare() error = nil for missing base")
+	}
+}
+
+type runBuilder struct {
+	runID  string
+	origin time.Time
+	seq    int64
+	nodeID int
+	events []protocol.Event
+}
+
+func (b *runBuilder) add(ms int, event protocol.Event) {
+	b.seq++
+	event.SessionID = "session-1"
+	event.RunID = b.runID
+	event.Seq = b.seq
+	event.Timestamp = b.origin.Add(time.Duration(ms) * time.Millisecond).Format(time.RFC3339Nano)
+	b.events = append(b.events, event)
+}
+
+func (b *runBuilder) node(runtimeName string, start int, end int) *runBuilder {
+	b.nodeID++
+	node := &protocol.EventNode{RuntimeName: runtimeName}
+	data := map[string]interface{}{"nodeId": b.nodeID}
+	b.add(start, protocol.Event{Kind: "node", Phase: "starting", MaaFWMessage: "Node.PipelineNode.Starting", Node: node, Data: data})
+	b.add(end, protocol.Event{Kind: "node", Phase: "succeeded", MaaFWMessage: "Node.PipelineNode.Succeeded", Node: node, Data: data})
+	return b
+}
+
+func (b *runBuilder) recognition(runtimeName string, at int, score float64, hit bool) *runBuilder {
+	phase, message := "failed", "Node.Recognition.Failed"
+	if hit {
+		phase, message = "succeeded", "Node.Recognition.Succeeded"
+	}
+	b.add(at, protocol.Event{
+		Kind:         "recognition",
+		Phase:        phase,
+		MaaFWMessage: message,
+		Node:         &protocol.EventNode{RuntimeName: runtimeName},
+		Data:         map[string]interface{}{"score": score, "hit": hit},
+	})
+	return b
+}
+
+func (b *runBuilder) diagnostic(code string, runtimeName string, at int) *runBuilder {
+	b.add(at, protocol.Event{
+		Kind:  "diagnostic",
+		Phase: "failed",
+		Node:  &protocol.EventNode{RuntimeName: runtimeName},
+		Data:  map[string]interface{}{"code": code, "severity": "error"},
+	})
+	return b
+}
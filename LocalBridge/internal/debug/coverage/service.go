@@ -0,0 +1,271 @@
+package coverage
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/debug/artifact"
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/debug/protocol"
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/debug/trace"
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/workspace"
+)
+
+const edgeReasonOnError = "on_error"
+
+type Service struct {
+	traces    *trace.Store
+	artifacts *artifact.Store
+	mu        sync.RWMutex
+	roots     workspace.Roots
+}
+
+func NewService(traces *trace.Store, artifacts *artifact.Store, root string) *Service {
+	return &Service{traces: traces, artifacts: artifacts, roots: workspace.Single(root)}
+}
+
+// SetRoots 切换读取 trace 文件的工作区根目录
+func (s *Service) SetRoots(roots workspace.Roots) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.roots = roots
+}
+
+func (s *Service) workspaceRoots() workspace.Roots {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.roots
+}
+
+// 汇总会话内全部 run、指定的 trace 文件以及在工作区中查找到的 trace 文件，生成覆盖率报告
+func (s *Service) Build(req protocol.CoverageReportRequest) (protocol.CoverageReport, error) {
+	sessionID := strings.TrimSpace(req.SessionID)
+	if sessionID == "" {
+		return protocol.CoverageReport{}, fmt.Errorf("缺少必需参数: sessionId")
+	}
+	if len(req.ResolverSnapshot.Nodes) == 0 {
+		return protocol.CoverageReport{}, fmt.Errorf("resolverSnapshot.nodes 不能为空")
+	}
+
+	roots := s.workspaceRoots()
+	candidates := append([]string(nil), req.TracePaths...)
+	if req.DiscoverTraces || strings.TrimSpace(req.TraceDir) != "" {
+		discovered, err := trace.Discover(roots, req.TraceDir)
+		if err != nil {
+			return protocol.CoverageReport{}, err
+		}
+		candidates = append(candidates, discovered...)
+	}
+
+	sources := [][]protocol.Event{s.traces.List(sessionID)}
+	tracePaths := make([]string, 0, len(candidates))
+	loaded := make(map[string]struct{}, len(candidates))
+	for _, candidate := range candidates {
+		if strings.TrimSpace(candidate) == "" {
+			continue
+		}
+		// 显式列出的文件也可能被目录查找命中，按解析后的路径去重
+		if resolved, err := roots.Resolve(candidate); err == nil {
+			if _, ok := loaded[resolved]; ok {
+				continue
+			}
+			loaded[resolved] = struct{}{}
+		}
+		events, err := trace.LoadFile(roots, candidate)
+		if err != nil {
+			return protocol.CoverageReport{}, err
+		}
+		sources = append(sources, events)
+		tracePaths = append(tracePaths, candidate)
+	}
+
+	report := BuildReport(req.ResolverSnapshot, sources...)
+	report.SessionID = sessionID
+	report.TracePaths = tracePaths
+	return report, nil
+}
+
+func (s *Service) Store(req protocol.CoverageReportRequest) (protocol.ArtifactRef, protocol.CoverageReport, error) {
+	report, err := s.Build(req)
+	if err != nil {
+		return protocol.ArtifactRef{}, report, err
+	}
+	ref, err := s.artifacts.AddJSON(report.SessionID, "coverage-report", report)
+	return ref, report, err
+}
+
+type runState struct {
+	current        string
+	nextListFailed bool
+}
+
+type transition struct {
+	from    string
+	to      string
+	onError bool
+}
+
+// BuildReport 按 resolver 快照统计节点进入、边经过与识别命中情况，
+// 每个事件来源内按 run 顺序重放，进入新节点时记录一次跳转；
+// 跳转前当前节点的 next 列表识别失败则视为 on_error 分支。
+func BuildReport(resolver protocol.NodeResolverSnapshot, sources ...[]protocol.Event) protocol.CoverageReport {
+	enterCounts := make(map[string]int)
+	attempts := make(map[string]int)
+	hits := make(map[string]int)
+	transitions := make(map[transition]int)
+	runIDs := make(map[string]struct{})
+	eventCount := 0
+
+	for sourceIndex, events := range sources {
+		ordered := append([]protocol.Event(nil), events...)
+		sort.SliceStable(ordered, func(i, j int) bool { return ordered[i].Seq < ordered[j].Seq })
+
+		states := make(map[string]*runState)
+		for _, event := range ordered {
+			eventCount++
+			if event.RunID != "" {
+				runIDs[event.RunID] = struct{}{}
+			}
+			key := fmt.Sprintf("%d\x00%s", sourceIndex, event.RunID)
+			state := states[key]
+			if state == nil {
+				state = &runState{}
+				states[key] = state
+			}
+
+			switch {
+			case event.MaaFWMessage == "Tasker.Task.Starting":
+				*state = runState{}
+			case isPipelineNodeStart(event):
+				name := event.Node.RuntimeName
+				enterCounts[name]++
+				if state.current != "" {
+					transitions[transition{from: state.current, to: name, onError: state.nextListFailed}]++
+				}
+				state.current = name
+				state.nextListFailed = false
+			case event.Kind == "next-list" && event.Phase == "failed":
+				state.nextListFailed = true
+			case isRecognitionResult(event):
+				name := event.Node.RuntimeName
+				attempts[name]++
+				hit := event.Phase == "succeeded"
+				if value, ok := event.Data["hit"].(bool); ok {
+					hit = value
+				}
+				if hit {
+					hits[name]++
+				}
+			}
+		}
+	}
+
+	report := protocol.CoverageReport{
+		RunIDs:      sortedKeys(runIDs),
+		EventCount:  eventCount,
+		Nodes:       make(map[string]protocol.CoverageNode, len(resolver.Nodes)),
+		Edges:       make(map[string]protocol.CoverageEdge, len(resolver.Edges)),
+		GeneratedAt: time.Now().UTC().Format(time.RFC3339Nano),
+	}
+
+	for _, node := range resolver.Nodes {
+		covered := protocol.CoverageNode{
+			FileID:              node.FileID,
+			NodeID:              node.NodeID,
+			RuntimeName:         node.RuntimeName,
+			Label:               node.DisplayName,
+			EnterCount:          enterCounts[node.RuntimeName],
+			RecognitionAttempts: attempts[node.RuntimeName],
+			RecognitionHits:     hits[node.RuntimeName],
+		}
+		covered.Entered = covered.EnterCount > 0
+		covered.RecognitionNeverHit = covered.RecognitionAttempts > 0 && covered.RecognitionHits == 0
+		report.Nodes[node.NodeID] = covered
+
+		report.Totals.Nodes++
+		if covered.Entered {
+			report.Totals.EnteredNodes++
+		}
+		if covered.RecognitionNeverHit {
+			report.Totals.RecognitionNeverHit++
+		}
+	}
+
+	for _, edge := range resolver.Edges {
+		covered := protocol.CoverageEdge{
+			EdgeID:          edge.EdgeID,
+			FromRuntimeName: edge.FromRuntimeName,
+			ToRuntimeName:   edge.ToRuntimeName,
+			Reason:          edge.Reason,
+			TakenCount:      takenCount(resolver.Edges, edge, transitions),
+		}
+		covered.Taken = covered.TakenCount > 0
+		report.Edges[edge.EdgeID] = covered
+
+		report.Totals.Edges++
+		if covered.Taken {
+			report.Totals.TakenEdges++
+		}
+		if edge.Reason == edgeReasonOnError {
+			report.Totals.OnErrorEdges++
+			if covered.Taken {
+				report.Totals.FiredOnErrorEdges++
+			}
+		}
+	}
+
+	return report
+}
+
+// 统计跳转命中某条边的次数；两端之间若只存在一类边，则不区分 on_error
+func takenCount(edges []protocol.NodeResolverSnapshotEdge, edge protocol.NodeResolverSnapshotEdge, transitions map[transition]int) int {
+	hasOnError, hasOther := false, false
+	for _, candidate := range edges {
+		if candidate.FromRuntimeName != edge.FromRuntimeName || candidate.ToRuntimeName != edge.ToRuntimeName {
+			continue
+		}
+		if candidate.Reason == edgeReasonOnError {
+			hasOnError = true
+		} else {
+			hasOther = true
+		}
+	}
+
+	onErrorCount := transitions[transition{from: edge.FromRuntimeName, to: edge.ToRuntimeName, onError: true}]
+	otherCount := transitions[transition{from: edge.FromRuntimeName, to: edge.ToRuntimeName, onError: false}]
+	if !(hasOnError && hasOther) {
+		return onErrorCount + otherCount
+	}
+	if edge.Reason == edgeReasonOnError {
+		return onErrorCount
+	}
+	return otherCount
+}
+
+func isPipelineNodeStart(event protocol.Event) bool {
+	return event.Kind == "node" &&
+		event.Phase == "starting" &&
+		strings.HasPrefix(event.MaaFWMessage, "Node.PipelineNode.") &&
+		event.Node != nil &&
+		event.Node.SyntheticKind == "" &&
+		event.Node.RuntimeName != ""
+}
+
+func isRecognitionResult(event protocol.Event) bool {
+	return event.Kind == "recognition" &&
+		(event.Phase == "succeeded" || event.Phase == "failed") &&
+		strings.HasPrefix(event.MaaFWMessage, "Node.Recognition.") &&
+		event.Node != nil &&
+		event.Node.RuntimeName != ""
+}
+
+func sortedKeys(values map[string]struct{}) []string {
+	result := make([]string, 0, len(values))
+	for value := range values {
+		result = append(result, value)
+	}
+	sort.Strings(result)
+	return result
+}
@@ -0,0 +1,256 @@
+package coverage
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/debug/artifact"
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/debug/protocol"
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/debug/trace"
+)
+
+func TestBuildReport(t *testing.T) {
+	resolver := testResolver()
+	first := []protocol.Event{
+		taskStart("run-1", 1),
+		nodeStart("run-1", 2, "Start"),
+		recognition("run-1", 3, "A", "failed"),
+		recognition("run-1", 4, "B", "failed"),
+		nextListFailed("run-1", 5),
+		nodeStart("run-1", 6, "Recover"),
+	}
+	second := []protocol.Event{
+		taskStart("run-2", 1),
+		nodeStart("run-2", 2, "Start"),
+		recognition("run-2", 3, "A", "succeeded"),
+		nodeStart("run-2", 4, "A"),
+	}
+
+	report := BuildReport(resolver, first, second)
+
+	if !reflect.DeepEqual(report.RunIDs, []string{"run-1", "run-2"}) || report.EventCount != 10 {
+		t.Fatalf("RunIDs/EventCount = %v/%d", report.RunIDs, report.EventCount)
+	}
+	if got := report.Nodes["n-start"]; !got.Entered || got.EnterCount != 2 {
+		t.Fatalf("Start node = %+v", got)
+	}
+	if got := report.Nodes["n-b"]; got.Entered || !got.RecognitionNeverHit || got.RecognitionAttempts != 1 {
+		t.Fatalf("B node = %+v", got)
+	}
+	if got := report.Nodes["n-a"]; got.RecognitionNeverHit || got.RecognitionHits != 1 || got.RecognitionAttempts != 2 {
+		t.Fatalf("A node = %+v", got)
+	}
+	if got := report.Edges["e-start-a"]; !got.Taken || got.TakenCount != 1 {
+		t.Fatalf("Start->A edge = %+v", got)
+	}
+	if got := report.Edges["e-start-b"]; got.Taken {
+		t.Fatalf("Start->B edge = %+v, want not taken", got)
+	}
+	if got := report.Edges["e-start-recover"]; !got.Taken || got.TakenCount != 1 {
+		t.Fatalf("Start->Recover on_error edge = %+v", got)
+	}
+
+	want := protocol.CoverageTotals{
+		Nodes:               4,
+		EnteredNodes:        3,
+		Edges:               3,
+		TakenEdges:          2,
+		OnErrorEdges:        1,
+		FiredOnErrorEdges:   1,
+		RecognitionNeverHit: 1,
+	}
+	if report.Totals != want {
+		t.Fatalf("Totals = %+v, want %+v", report.Totals, want)
+	}
+}
+
+func TestBuildReportSeparatesEdgeKindsBetweenSameNodes(t *testing.T) {
+	resolver := protocol.NodeResolverSnapshot{
+		Nodes: []protocol.NodeResolverSnapshotNode{
+			{NodeID: "n-x", RuntimeName: "X"},
+			{NodeID: "n-y", RuntimeName: "Y"},
+		},
+		Edges: []protocol.NodeResolverSnapshotEdge{
+			{EdgeID: "e-next", FromRuntimeName: "X", ToRuntimeName: "Y", Reason: "next"},
+			{EdgeID: "e-error", FromRuntimeName: "X", ToRuntimeName: "Y", Reason: "on_error"},
+		},
+	}
+	events := []protocol.Event{
+		nodeStart("run-1", 1, "X"),
+		nodeStart("run-1", 2, "Y"),
+		nodeStart("run-2", 3, "X"),
+		nextListFailed("run-2", 4),
+		nodeStart("run-2", 5, "Y"),
+	}
+
+	report := BuildReport(resolver, events)
+	if report.Edges["e-next"].TakenCount != 1 || report.Edges["e-error"].TakenCount != 1 {
+		t.Fatalf("Edges = %+v", report.Edges)
+	}
+}
+
+func TestServiceBuildLoadsTraceFiles(t *testing.T) {
+	root := t.TempDir()
+	snapshot := protocol.TraceSnapshot{
+		SessionID: "old-session",
+		Events: []protocol.Event{
+			nodeStart("run-old", 1, "Start"),
+			nodeStart("run-old", 2, "A"),
+		},
+	}
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "trace.json"), data, 0o644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	traces := trace.NewStore()
+	traces.Append(nodeStart("run-1", 1, "Start"))
+	service := NewService(traces, artifact.NewStore(), root)
+
+	report, err := service.Build(protocol.CoverageReportRequest{
+		SessionID:        "session-1",
+		ResolverSnapshot: testResolver(),
+		TracePaths:       []string{"trace.json"},
+	})
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	if report.Nodes["n-start"].EnterCount != 2 || !report.Edges["e-start-a"].Taken {
+		t.Fatalf("report = %+v", report)
+	}
+	if !reflect.DeepEqual(report.TracePaths, []string{"trace.json"}) {
+		t.Fatalf("TracePaths = %v", report.TracePaths)
+	}
+
+	_, err = service.Build(protocol.CoverageReportRequest{
+		SessionID:        "session-1",
+		ResolverSnapshot: testResolver(),
+		TracePaths:       []string{"../outside.json"},
+	})
+	if err == nil {
+		t.Fatal("Build() error = nil for trace path outside root")
+	}
+}
+
+func testResolver() protocol.NodeResolverSnapshot {
+	return protocol.NodeResolverSnapshot{
+		Nodes: []protocol.NodeResolverSnapshotNode{
+			{FileID: "f", NodeID: "n-start", RuntimeName: "Start", DisplayName: "Start"},
+			{FileID: "f", NodeID: "n-a", RuntimeName: "A", DisplayName: "A"},
+			{FileID: "f", NodeID: "n-b", RuntimeName: "B", DisplayName: "B"},
+			{FileID: "f", NodeID: "n-recover", RuntimeName: "Recover", DisplayName: "Recover"},
+		},
+		Edges: []protocol.NodeResolverSnapshotEdge{
+			{EdgeID: "e-start-a", FromRuntimeName: "Start", ToRuntimeName: "A", Reason: "next"},
+			{EdgeID: "e-start-b", FromRuntimeName: "Start", ToRuntimeName: "B", Reason: "next"},
+			{EdgeID: "e-start-recover", FromRuntimeName: "Start", ToRuntimeName: "Recover", Reason: "on_error"},
+		},
+	}
+}
+
+func taskStart(runID string, seq int64) protocol.Event {
+	return protocol.Event{SessionID: "session-1", RunID: runID, Seq: seq, Kind: "task", Phase: "starting", MaaFWMessage: "Tasker.Task.Starting"}
+}
+
+func nodeStart(runID string, seq int64, runtimeName string) protocol.Event {
+	return protocol.Event{
+		SessionID:    "session-1",
+		RunID:        runID,
+		Seq:          seq,
+		Kind:         "node",
+		Phase:        "starting",
+		MaaFWMessage: "Node.PipelineNode.Starting",
+		Node:         &protocol.EventNode{RuntimeName: runtimeName},
+	}
+}
+
+func nextListFailed(runID string, seq int64) protocol.Event {
+	return protocol.Event{SessionID: "session-1", RunID: runID, Seq: seq, Kind: "next-list", Phase: "failed", MaaFWMessage: "Node.NextList.Failed"}
+}
+
+func recognition(runID string, seq int64, runtimeName string, phase string) protocol.Event {
+	suffix := map[string]string{"succeeded": "Succeeded", "failed": "Failed"}[phase]
+	return protocol.Event{
+		SessionID:    "session-1",
+		RunID:        runID,
+		Seq:          seq,
+		Kind:         "recognition",
+		Phase:        phase,
+		MaaFWMessage: "Node.Recognition." + suffix,
+		Node:         &protocol.EventNode{RuntimeName: runtimeName},
+	}
+}
+
+func TestServiceBuildDiscoversTraceFiles(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		filepath.Join("runs", "a.trace.json"):           "run-a",
+		filepath.Join("runs", "nested", "b.trace.json"): "run-b",
+		filepath.Join(".cache", "c.trace.json"):         "run-c",
+		filepath.Join("runs", "other.json"):             "run-d",
+	}
+	for name, runID := range files {
+		data, err := json.Marshal([]protocol.Event{nodeStart(runID, 1, "Start")})
+		if err != nil {
+			t.Fatalf("Marshal() error = %v", err)
+		}
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("MkdirAll() error = %v", err)
+		}
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			t.Fatalf("WriteFile() error = %v", err)
+		}
+	}
+	service := NewService(trace.NewStore(), artifact.NewStore(), root)
+
+	tests := []struct {
+		name string
+		req  protocol.CoverageReportRequest
+		want []string
+	}{
+		{
+			name: "all roots",
+			req:  protocol.CoverageReportRequest{DiscoverTraces: true},
+			want: []string{filepath.Join(root, "runs", "a.trace.json"), filepath.Join(root, "runs", "nested", "b.trace.json")},
+		},
+		{
+			name: "trace dir",
+			req:  protocol.CoverageReportRequest{TraceDir: "runs/nested"},
+			want: []string{filepath.Join(root, "runs", "nested", "b.trace.json")},
+		},
+		{
+			name: "explicit path is not loaded twice",
+			req:  protocol.CoverageReportRequest{TracePaths: []string{"runs/nested/b.trace.json"}, TraceDir: "runs/nested"},
+			want: []string{"runs/nested/b.trace.json"},
+		},
+	}
+	for _, test := range tests {
+		test.req.SessionID = "session-1"
+		test.req.ResolverSnapshot = testResolver()
+		report, err := service.Build(test.req)
+		if err != nil {
+			t.Fatalf("%s: Build() error = %v", test.name, err)
+		}
+		if !reflect.DeepEqual(report.TracePaths, test.want) {
+			t.Fatalf("%s: TracePaths = %v, want %v", test.name, report.TracePaths, test.want)
+		}
+		if report.Nodes["n-start"].EnterCount != len(test.want) {
+			t.Fatalf("%s: EnterCount = %d, want %d", test.name, report.Nodes["n-start"].EnterCount, len(test.want))
+		}
+	}
+
+	if _, err := service.Build(protocol.CoverageReportRequest{
+		SessionID:        "session-1",
+		ResolverSnapshot: testResolver(),
+		TraceDir:         "../",
+	}); err == nil {
+		t.Fatal("Build() error = nil for trace dir outside root")
+	}
+}
@@ -0,0 +1,116 @@
+package delivery
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/debug/protocol"
+)
+
+type pendingEvent struct {
+	last     protocol.Event
+	count    int
+	firstSeq int64
+}
+
+// Coalescer 合并重复的识别未命中、next 列表重试与控制器动作：
+// 同类事件首次出现时直接推送，之后的重复事件只计数，
+// 遇到其他事件或超过合并窗口时以最后一条事件加计数的形式推送汇总。
+type Coalescer struct {
+	window    time.Duration
+	seen      map[string]struct{}
+	pending   map[string]*pendingEvent
+	order     []string
+	pendingAt time.Time
+}
+
+func NewCoalescer(window time.Duration) *Coalescer {
+	return &Coalescer{
+		window:  window,
+		seen:    make(map[string]struct{}),
+		pending: make(map[string]*pendingEvent),
+	}
+}
+
+// Push 返回应立即推送的事件
+func (c *Coalescer) Push(event protocol.Event, now time.Time) []protocol.Event {
+	if !coalescable(event) {
+		out := c.Flush()
+		c.seen = make(map[string]struct{})
+		return append(out, event)
+	}
+
+	key := coalesceKey(event)
+	if _, ok := c.seen[key]; !ok {
+		c.seen[key] = struct{}{}
+		return []protocol.Event{event}
+	}
+
+	pending := c.pending[key]
+	if pending == nil {
+		pending = &pendingEvent{firstSeq: event.Seq}
+		c.pending[key] = pending
+		c.order = append(c.order, key)
+		if len(c.order) == 1 {
+			c.pendingAt = now
+		}
+	}
+	pending.last = event
+	pending.count++
+	return nil
+}
+
+func (c *Coalescer) Pending() bool {
+	return len(c.order) > 0
+}
+
+// 汇总等待时间超过窗口时需要刷新
+func (c *Coalescer) Due(now time.Time) bool {
+	return c.Pending() && now.Sub(c.pendingAt) >= c.window
+}
+
+// Flush 按首次被合并的顺序输出汇总事件
+func (c *Coalescer) Flush() []protocol.Event {
+	if len(c.order) == 0 {
+		return nil
+	}
+	out := make([]protocol.Event, 0, len(c.order))
+	for _, key := range c.order {
+		pending := c.pending[key]
+		summary := pending.last
+		data := make(map[string]interface{}, len(summary.Data)+2)
+		for dataKey, value := range summary.Data {
+			data[dataKey] = value
+		}
+		data["coalescedCount"] = pending.count
+		data["coalescedFromSeq"] = pending.firstSeq
+		summary.Data = data
+		out = append(out, summary)
+	}
+	c.pending = make(map[string]*pendingEvent)
+	c.order = nil
+	return out
+}
+
+func coalescable(event protocol.Event) bool {
+	switch event.Kind {
+	case "recognition", "next-list":
+		return event.Phase == "starting" || event.Phase == "failed"
+	case "controller":
+		return true
+	default:
+		return false
+	}
+}
+
+func coalesceKey(event protocol.Event) string {
+	runtimeName := ""
+	if event.Node != nil {
+		runtimeName = event.Node.RuntimeName
+	}
+	action := ""
+	if event.Kind == "controller" {
+		action = fmt.Sprint(event.Data["action"])
+	}
+	return event.RunID + "\x00" + event.MaaFWMessage + "\x00" + runtimeName + "\x00" + action
+}
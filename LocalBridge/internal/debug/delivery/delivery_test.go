@@ -0,0 +1,237 @@
+package delivery
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/debug/protocol"
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/pkg/models"
+)
+
+func TestFilterAllows(t *testing.T) {
+	tests := []struct {
+		name         string
+		subscription protocol.EventSubscription
+		event        protocol.Event
+		want         bool
+	}{
+		{name: "default hides controller", event: protocol.Event{Kind: "controller"}, want: false},
+		{name: "default keeps recognition", event: protocol.Event{Kind: "recognition"}, want: true},
+		{name: "verbose keeps controller", subscription: protocol.EventSubscription{Verbosity: "verbose"}, event: protocol.Event{Kind: "controller"}, want: true},
+		{name: "minimal hides recognition", subscription: protocol.EventSubscription{Verbosity: "minimal"}, event: protocol.Event{Kind: "recognition"}, want: false},
+		{name: "minimal keeps node", subscription: protocol.EventSubscription{Verbosity: "minimal"}, event: protocol.Event{Kind: "node"}, want: true},
+		{name: "kinds filter", subscription: protocol.EventSubscription{Kinds: []string{"node"}}, event: protocol.Event{Kind: "task"}, want: false},
+		{name: "session always passes", subscription: protocol.EventSubscription{Kinds: []string{"node"}, Coalesce: true}, event: protocol.Event{Kind: "session"}, want: true},
+		{
+			name:         "nodes filter by runtime name",
+			subscription: protocol.EventSubscription{Nodes: []string{"Login"}},
+			event:        protocol.Event{Kind: "node", Node: &protocol.EventNode{RuntimeName: "Login"}},
+			want:         true,
+		},
+		{
+			name:         "nodes filter by node id",
+			subscription: protocol.EventSubscription{Nodes: []string{"node-2"}},
+			event:        protocol.Event{Kind: "node", Node: &protocol.EventNode{RuntimeName: "Login", NodeID: "node-2"}},
+			want:         true,
+		},
+		{
+			name:         "nodes filter rejects other node",
+			subscription: protocol.EventSubscription{Nodes: []string{"Login"}},
+			event:        protocol.Event{Kind: "node", Node: &protocol.EventNode{RuntimeName: "Reward"}},
+			want:         false,
+		},
+		{name: "nodes filter keeps nodeless event", subscription: protocol.EventSubscription{Nodes: []string{"Login"}}, event: protocol.Event{Kind: "task"}, want: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			filter, err := NewFilter(test.subscription)
+			if err != nil {
+				t.Fatalf("NewFilter() error = %v", err)
+			}
+			if got := filter.Allows(test.event); got != test.want {
+				t.Fatalf("Allows() = %v, want %v", got, test.want)
+			}
+		})
+	}
+
+	if _, err := NewFilter(protocol.EventSubscription{Verbosity: "debug"}); err == nil {
+		t.Fatal("NewFilter() error = nil for invalid verbosity")
+	}
+}
+
+func TestDefaultFilterPassesThrough(t *testing.T) {
+	filter := DefaultFilter()
+	if filter.Coalesce() || filter.Batch() {
+		t.Fatalf("DefaultFilter() coalesce = %v, batch = %v", filter.Coalesce(), filter.Batch())
+	}
+	for _, kind := range []string{"session", "task", "node", "recognition", "controller"} {
+		if !filter.Allows(protocol.Event{Kind: kind}) {
+			t.Fatalf("DefaultFilter() rejects %s event", kind)
+		}
+	}
+}
+
+func TestCoalescerCollapsesRepeatedMisses(t *testing.T) {
+	coalescer := NewCoalescer(time.Second)
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	miss := func(seq int64) protocol.Event {
+		return protocol.Event{
+			Seq:          seq,
+			RunID:        "run-1",
+			Kind:         "recognition",
+			Phase:        "failed",
+			MaaFWMessage: "Node.Recognition.Failed",
+			Node:         &protocol.EventNode{RuntimeName: "Popup"},
+			Data:         map[string]interface{}{"hit": false},
+		}
+	}
+
+	if out := coalescer.Push(miss(1), now); len(out) != 1 {
+		t.Fatalf("first Push() = %+v", out)
+	}
+	for seq := int64(2); seq <= 5; seq++ {
+		if out := coalescer.Push(miss(seq), now); len(out) != 0 {
+			t.Fatalf("repeated Push() = %+v", out)
+		}
+	}
+	if coalescer.Due(now.Add(500*time.Millisecond)) || !coalescer.Due(now.Add(time.Second)) {
+		t.Fatal("Due() does not follow the coalesce window")
+	}
+
+	out := coalescer.Push(protocol.Event{Seq: 6, RunID: "run-1", Kind: "node", Phase: "succeeded"}, now)
+	if len(out) != 2 || out[1].Seq != 6 {
+		t.Fatalf("Push() = %+v", out)
+	}
+	summary := out[0]
+	if summary.Seq != 5 || summary.Data["coalescedCount"] != 4 || summary.Data["coalescedFromSeq"] != int64(2) {
+		t.Fatalf("summary = %+v", summary)
+	}
+	if coalescer.Pending() {
+		t.Fatal("Pending() = true after flush")
+	}
+	if out := coalescer.Push(miss(7), now); len(out) != 1 {
+		t.Fatalf("Push() after other event = %+v", out)
+	}
+}
+
+// 后台刷新协程会并发访问，字段读写需加锁
+type fakeTarget struct {
+	mu       sync.Mutex
+	backlog  int
+	messages []models.Message
+}
+
+func (f *fakeTarget) Send(msg models.Message) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.messages = append(f.messages, msg)
+	return nil
+}
+
+func (f *fakeTarget) Backlog() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.backlog
+}
+
+func (f *fakeTarget) setBacklog(backlog int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.backlog = backlog
+}
+
+func (f *fakeTarget) sent() []models.Message {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]models.Message(nil), f.messages...)
+}
+
+func TestManagerBatchesWhenBacklogged(t *testing.T) {
+	target := &fakeTarget{}
+	manager := NewManager(func(string) Target { return target })
+	if err := manager.Subscribe("session-1", protocol.EventSubscription{Batch: true}); err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+
+	manager.Deliver("session-1", protocol.Event{Seq: 1, Kind: "node"})
+	if messages := target.sent(); len(messages) != 1 || messages[0].Path != "/lte/debug/event" {
+		t.Fatalf("messages = %+v", messages)
+	}
+
+	target.setBacklog(DefaultBacklogThreshold)
+	manager.Deliver("session-1", protocol.Event{Seq: 2, Kind: "node"})
+	manager.Deliver("session-1", protocol.Event{Seq: 3, Kind: "node"})
+	if messages := target.sent(); len(messages) != 1 {
+		t.Fatalf("messages while backlogged = %+v", messages)
+	}
+
+	target.setBacklog(0)
+	flushSession(manager, "session-1")
+	messages := target.sent()
+	if len(messages) != 2 || messages[1].Path != "/lte/debug/event_batch" {
+		t.Fatalf("messages = %+v", messages)
+	}
+	batch := messages[1].Data.(map[string]interface{})
+	if events := batch["events"].([]protocol.Event); len(events) != 2 || events[0].Seq != 2 {
+		t.Fatalf("batch = %+v", batch)
+	}
+}
+
+func TestManagerReportsGapOnOverflow(t *testing.T) {
+	target := &fakeTarget{backlog: DefaultBacklogThreshold}
+	manager := NewManager(func(string) Target { return target })
+	if err := manager.Subscribe("session-1", protocol.EventSubscription{Batch: true}); err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+
+	total := maxBufferedEvents + 10
+	for seq := 1; seq <= total; seq++ {
+		manager.Deliver("session-1", protocol.Event{Seq: int64(seq), Kind: "node"})
+	}
+
+	target.setBacklog(0)
+	flushSession(manager, "session-1")
+	messages := target.sent()
+	if len(messages) != 2 || messages[0].Path != "/lte/debug/event_gap" {
+		t.Fatalf("messages = %d", len(messages))
+	}
+	gap := messages[0].Data.(map[string]interface{})
+	if gap["fromSeq"] != int64(1) || gap["toSeq"] != int64(maxBufferedEvents) || gap["count"] != maxBufferedEvents {
+		t.Fatalf("gap = %+v", gap)
+	}
+	if events := messages[1].Data.(map[string]interface{})["events"].([]protocol.Event); len(events) != 10 {
+		t.Fatalf("batch size = %d", len(events))
+	}
+}
+
+func TestManagerSendsEachEventWithoutSubscription(t *testing.T) {
+	target := &fakeTarget{backlog: DefaultBacklogThreshold}
+	manager := NewManager(func(string) Target { return target })
+
+	miss := protocol.Event{RunID: "run-1", Kind: "recognition", Phase: "failed", Node: &protocol.EventNode{RuntimeName: "Popup"}}
+	for seq := int64(1); seq <= 3; seq++ {
+		miss.Seq = seq
+		manager.Deliver("session-1", miss)
+	}
+	manager.Deliver("session-1", protocol.Event{Seq: 4, Kind: "controller"})
+
+	messages := target.sent()
+	if len(messages) != 4 {
+		t.Fatalf("messages = %+v", messages)
+	}
+	for i, message := range messages {
+		if message.Path != "/lte/debug/event" || message.Data.(protocol.Event).Seq != int64(i+1) {
+			t.Fatalf("messages[%d] = %+v", i, message)
+		}
+	}
+}
+
+// 立即执行一次刷新，不等待后台协程
+func flushSession(manager *Manager, sessionID string) {
+	manager.mu.Lock()
+	state := manager.sessions[sessionID]
+	manager.mu.Unlock()
+	manager.flush(sessionID, state, time.Now())
+}
@@ -0,0 +1,103 @@
+package delivery
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/debug/protocol"
+)
+
+// minimal 级别只推送流程级事件
+var minimalKinds = map[string]struct{}{
+	"session":    {},
+	"task":       {},
+	"node":       {},
+	"diagnostic": {},
+	"watch":      {},
+}
+
+// Filter 按事件类型、节点集合与详细程度过滤推送；session 事件始终推送以维持客户端状态
+type Filter struct {
+	kinds     map[string]struct{}
+	nodes     map[string]struct{}
+	verbosity string
+	coalesce  bool
+	batch     bool
+}
+
+func NewFilter(subscription protocol.EventSubscription) (Filter, error) {
+	verbosity := strings.TrimSpace(subscription.Verbosity)
+	switch verbosity {
+	case "":
+		verbosity = protocol.EventVerbosityNormal
+	case protocol.EventVerbosityMinimal, protocol.EventVerbosityNormal, protocol.EventVerbosityVerbose:
+	default:
+		return Filter{}, fmt.Errorf("无效的 verbosity: %s", subscription.Verbosity)
+	}
+
+	filter := Filter{
+		verbosity: verbosity,
+		coalesce:  subscription.Coalesce,
+		batch:     subscription.Batch,
+	}
+	if len(subscription.Kinds) > 0 {
+		filter.kinds = toSet(subscription.Kinds)
+	}
+	if len(subscription.Nodes) > 0 {
+		filter.nodes = toSet(subscription.Nodes)
+	}
+	return filter, nil
+}
+
+// DefaultFilter 用于未订阅的会话：推送全部事件，不合并也不批量推送，与订阅功能加入前的行为一致
+func DefaultFilter() Filter {
+	return Filter{verbosity: protocol.EventVerbosityVerbose}
+}
+
+func (f Filter) Allows(event protocol.Event) bool {
+	if event.Kind == "session" {
+		return true
+	}
+	switch f.verbosity {
+	case protocol.EventVerbosityMinimal:
+		if _, ok := minimalKinds[event.Kind]; !ok {
+			return false
+		}
+	case protocol.EventVerbosityNormal:
+		// 控制器动作量大，仅在 verbose 下推送
+		if event.Kind == "controller" {
+			return false
+		}
+	}
+	if f.kinds != nil {
+		if _, ok := f.kinds[event.Kind]; !ok {
+			return false
+		}
+	}
+	if f.nodes != nil && event.Node != nil {
+		_, byName := f.nodes[event.Node.RuntimeName]
+		_, byID := f.nodes[event.Node.NodeID]
+		if !byName && !byID {
+			return false
+		}
+	}
+	return true
+}
+
+func (f Filter) Coalesce() bool {
+	return f.coalesce
+}
+
+func (f Filter) Batch() bool {
+	return f.batch
+}
+
+func toSet(values []string) map[string]struct{} {
+	result := make(map[string]struct{}, len(values))
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			result[value] = struct{}{}
+		}
+	}
+	return result
+}
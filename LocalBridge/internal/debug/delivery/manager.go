@@ -0,0 +1,208 @@
+package delivery
+
+import (
+	"sync"
+	"time"
+
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/debug/protocol"
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/pkg/models"
+)
+
+const (
+	// 发送队列积压超过该值时改为批量推送
+	DefaultBacklogThreshold = 64
+
+	maxBufferedEvents = 2000
+	flushInterval     = 100 * time.Millisecond
+	coalesceWindow    = 500 * time.Millisecond
+)
+
+// Target 是事件推送目标，通常为 WebSocket 连接
+type Target interface {
+	Send(msg models.Message) error
+	Backlog() int
+}
+
+type TargetResolver func(sessionID string) Target
+
+// 缓冲区溢出时丢弃的事件区间，客户端可据此从 trace 补齐
+type eventGap struct {
+	fromSeq int64
+	toSeq   int64
+	count   int
+}
+
+type sessionState struct {
+	filter    Filter
+	coalescer *Coalescer
+	buffer    []protocol.Event
+	gap       *eventGap
+	flushing  bool
+}
+
+// Manager 负责会话事件推送：订阅过滤、重复事件合并，以及连接积压时的批量推送。
+// 未订阅的会话逐条推送全部事件；完整事件始终保存在 trace.Store 中，这里只决定推送什么、何时推送。
+type Manager struct {
+	resolve   TargetResolver
+	threshold int
+
+	mu       sync.Mutex
+	sessions map[string]*sessionState
+}
+
+func NewManager(resolve TargetResolver) *Manager {
+	return &Manager{
+		resolve:   resolve,
+		threshold: DefaultBacklogThreshold,
+		sessions:  make(map[string]*sessionState),
+	}
+}
+
+func (m *Manager) Subscribe(sessionID string, subscription protocol.EventSubscription) error {
+	filter, err := NewFilter(subscription)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	state := m.session(sessionID)
+	state.filter = filter
+	if !filter.Coalesce() {
+		m.enqueueLocked(state, state.coalescer.Flush())
+	}
+	m.drainLocked(sessionID, state)
+	m.scheduleLocked(sessionID, state)
+	return nil
+}
+
+func (m *Manager) Deliver(sessionID string, event protocol.Event) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	state := m.session(sessionID)
+	if !state.filter.Allows(event) {
+		return
+	}
+	out := []protocol.Event{event}
+	if state.filter.Coalesce() {
+		out = state.coalescer.Push(event, time.Now())
+	}
+	m.enqueueLocked(state, out)
+	m.drainLocked(sessionID, state)
+	m.scheduleLocked(sessionID, state)
+}
+
+func (m *Manager) DeleteSession(sessionID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.sessions, sessionID)
+}
+
+func (m *Manager) session(sessionID string) *sessionState {
+	state := m.sessions[sessionID]
+	if state == nil {
+		state = &sessionState{
+			filter:    DefaultFilter(),
+			coalescer: NewCoalescer(coalesceWindow),
+		}
+		m.sessions[sessionID] = state
+	}
+	return state
+}
+
+func (m *Manager) enqueueLocked(state *sessionState, events []protocol.Event) {
+	for _, event := range events {
+		if len(state.buffer) >= maxBufferedEvents {
+			if state.gap == nil {
+				state.gap = &eventGap{fromSeq: state.buffer[0].Seq}
+			}
+			state.gap.toSeq = state.buffer[len(state.buffer)-1].Seq
+			state.gap.count += len(state.buffer)
+			state.buffer = state.buffer[:0]
+		}
+		state.buffer = append(state.buffer, event)
+	}
+}
+
+// 推送缓冲区：未开启批量时逐条推送；开启后等待连接空闲，单条按原格式推送，多条合并为一个批次
+func (m *Manager) drainLocked(sessionID string, state *sessionState) {
+	if len(state.buffer) == 0 && state.gap == nil {
+		return
+	}
+	target := m.resolve(sessionID)
+	if target == nil {
+		// 无连接时不再缓存，重连后由 attach 从 trace 补发
+		state.buffer = state.buffer[:0]
+		state.gap = nil
+		return
+	}
+	if state.filter.Batch() && target.Backlog() >= m.threshold {
+		return
+	}
+
+	if state.gap != nil {
+		_ = target.Send(models.Message{Path: "/lte/debug/event_gap", Data: map[string]interface{}{
+			"sessionId": sessionID,
+			"fromSeq":   state.gap.fromSeq,
+			"toSeq":     state.gap.toSeq,
+			"count":     state.gap.count,
+		}})
+		state.gap = nil
+	}
+	switch {
+	case len(state.buffer) == 0:
+	case !state.filter.Batch():
+		for _, event := range state.buffer {
+			_ = target.Send(models.Message{Path: "/lte/debug/event", Data: event})
+		}
+	case len(state.buffer) == 1:
+		_ = target.Send(models.Message{Path: "/lte/debug/event", Data: state.buffer[0]})
+	default:
+		events := append([]protocol.Event(nil), state.buffer...)
+		_ = target.Send(models.Message{Path: "/lte/debug/event_batch", Data: map[string]interface{}{
+			"sessionId": sessionID,
+			"events":    events,
+		}})
+	}
+	state.buffer = state.buffer[:0]
+}
+
+// 缓冲区或合并汇总未清空时启动后台刷新
+func (m *Manager) scheduleLocked(sessionID string, state *sessionState) {
+	if state.flushing || (len(state.buffer) == 0 && state.gap == nil && !state.coalescer.Pending()) {
+		return
+	}
+	state.flushing = true
+	go m.flushLoop(sessionID, state)
+}
+
+func (m *Manager) flushLoop(sessionID string, state *sessionState) {
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if !m.flush(sessionID, state, time.Now()) {
+			return
+		}
+	}
+}
+
+// 返回是否仍需继续刷新
+func (m *Manager) flush(sessionID string, state *sessionState, now time.Time) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.sessions[sessionID] != state {
+		return false
+	}
+	if state.coalescer.Due(now) {
+		m.enqueueLocked(state, state.coalescer.Flush())
+	}
+	m.drainLocked(sessionID, state)
+	if len(state.buffer) == 0 && state.gap == nil && !state.coalescer.Pending() {
+		state.flushing = false
+		return false
+	}
+	return true
+}
@@ -0,0 +1,264 @@
+package events
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/debug/protocol"
+)
+
+const (
+	PauseReasonBreakpoint = "breakpoint"
+	PauseReasonPause      = "pause"
+	PauseReasonStep       = "step"
+	PauseReasonWatch      = "watch"
+
+	ResumeActionResume = "resume"
+	ResumeActionStep   = "step"
+)
+
+// 暂停位置信息
+type PauseInfo struct {
+	Reason     string               `json:"reason"`
+	Node       *protocol.EventNode  `json:"node,omitempty"`
+	Breakpoint *protocol.Breakpoint `json:"breakpoint,omitempty"`
+}
+
+type PauseFunc func(info PauseInfo)
+type ResumeFunc func(info PauseInfo, action string)
+
+// BreakpointGate 在 Node.PipelineNode 开始时判断是否暂停，并阻塞回调线程直到恢复。
+// recognition-miss 断点在该节点识别未命中后，于下一个节点开始时暂停。
+type BreakpointGate struct {
+	mu          sync.Mutex
+	breakpoints []protocol.Breakpoint
+	satisfied   []int
+	lastHit     map[string]bool
+	missPending map[string]bool
+
+	pauseNext   bool
+	pauseReason string
+	paused      bool
+	current     PauseInfo
+	resumeCh    chan struct{}
+	released    bool
+
+	onPause  PauseFunc
+	onResume ResumeFunc
+}
+
+func NewBreakpointGate(breakpoints []protocol.Breakpoint) *BreakpointGate {
+	normalized := make([]protocol.Breakpoint, 0, len(breakpoints))
+	for _, breakpoint := range breakpoints {
+		breakpoint.RuntimeName = strings.TrimSpace(breakpoint.RuntimeName)
+		if breakpoint.RuntimeName == "" {
+			continue
+		}
+		normalized = append(normalized, breakpoint)
+	}
+	return &BreakpointGate{
+		breakpoints: normalized,
+		satisfied:   make([]int, len(normalized)),
+		lastHit:     make(map[string]bool),
+		missPending: make(map[string]bool),
+	}
+}
+
+func ValidateBreakpoints(breakpoints []protocol.Breakpoint) error {
+	for i, breakpoint := range breakpoints {
+		if strings.TrimSpace(breakpoint.RuntimeName) == "" {
+			return fmt.Errorf("缺少必需字段: breakpoints[%d].runtimeName", i)
+		}
+		switch breakpoint.Condition {
+		case protocol.BreakpointConditionAlways,
+			protocol.BreakpointConditionRecognitionHit,
+			protocol.BreakpointConditionRecognitionMiss:
+		default:
+			return fmt.Errorf("无效的 breakpoints[%d].condition: %s", i, breakpoint.Condition)
+		}
+		if breakpoint.HitCount < 0 {
+			return fmt.Errorf("breakpoints[%d].hitCount 不能为负数", i)
+		}
+	}
+	return nil
+}
+
+func (g *BreakpointGate) SetNotify(onPause PauseFunc, onResume ResumeFunc) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.onPause = onPause
+	g.onResume = onResume
+}
+
+// 记录识别结果，供条件断点使用
+func (g *BreakpointGate) RecordRecognition(runtimeName string, hit bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.lastHit[runtimeName] = hit
+	if !hit {
+		g.missPending[runtimeName] = true
+	}
+}
+
+// 节点开始时调用，命中断点或存在暂停请求时阻塞直到恢复
+func (g *BreakpointGate) AtNode(node *protocol.EventNode) {
+	if node == nil || node.SyntheticKind != "" {
+		return
+	}
+	info, ok := g.evaluate(node.RuntimeName)
+	if !ok {
+		return
+	}
+	info.Node = node
+	g.block(info)
+}
+
+func (g *BreakpointGate) evaluate(runtimeName string) (PauseInfo, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.released {
+		return PauseInfo{}, false
+	}
+
+	var matched *protocol.Breakpoint
+	for i := range g.breakpoints {
+		breakpoint := g.breakpoints[i]
+		if !g.conditionMet(breakpoint, runtimeName) {
+			continue
+		}
+		g.satisfied[i]++
+		if breakpoint.HitCount > 0 && g.satisfied[i] != breakpoint.HitCount {
+			continue
+		}
+		if matched == nil {
+			matched = &breakpoint
+		}
+	}
+	for name := range g.missPending {
+		delete(g.missPending, name)
+	}
+
+	if matched != nil {
+		return PauseInfo{Reason: PauseReasonBreakpoint, Breakpoint: matched}, true
+	}
+	if g.pauseNext {
+		g.pauseNext = false
+		return PauseInfo{Reason: g.pauseReason}, true
+	}
+	return PauseInfo{}, false
+}
+
+func (g *BreakpointGate) conditionMet(breakpoint protocol.Breakpoint, runtimeName string) bool {
+	switch breakpoint.Condition {
+	case protocol.BreakpointConditionRecognitionHit:
+		return breakpoint.RuntimeName == runtimeName && g.lastHit[runtimeName]
+	case protocol.BreakpointConditionRecognitionMiss:
+		return g.missPending[breakpoint.RuntimeName]
+	default:
+		return breakpoint.RuntimeName == runtimeName
+	}
+}
+
+func (g *BreakpointGate) block(info PauseInfo) {
+	g.mu.Lock()
+	if g.released {
+		g.mu.Unlock()
+		return
+	}
+	resumeCh := make(chan struct{})
+	g.paused = true
+	g.current = info
+	g.resumeCh = resumeCh
+	onPause := g.onPause
+	g.mu.Unlock()
+
+	if onPause != nil {
+		onPause(info)
+	}
+	<-resumeCh
+}
+
+// 请求在下一个节点开始时暂停
+func (g *BreakpointGate) Pause() error {
+	return g.RequestPause(PauseReasonPause)
+}
+
+// 以指定原因请求在下一个节点开始时暂停
+func (g *BreakpointGate) RequestPause(reason string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.released {
+		return fmt.Errorf("run 已结束")
+	}
+	if g.paused {
+		return fmt.Errorf("run 已处于暂停状态")
+	}
+	g.pauseNext = true
+	g.pauseReason = reason
+	return nil
+}
+
+// 恢复运行；尚未暂停时取消待生效的暂停请求
+func (g *BreakpointGate) Resume() error {
+	return g.resume(ResumeActionResume)
+}
+
+// 单步执行：恢复运行并在下一个节点开始时再次暂停
+func (g *BreakpointGate) Step() error {
+	return g.resume(ResumeActionStep)
+}
+
+func (g *BreakpointGate) resume(action string) error {
+	g.mu.Lock()
+	if g.released {
+		g.mu.Unlock()
+		return fmt.Errorf("run 已结束")
+	}
+	if !g.paused {
+		if action == ResumeActionResume && g.pauseNext {
+			g.pauseNext = false
+			g.mu.Unlock()
+			return nil
+		}
+		g.mu.Unlock()
+		return fmt.Errorf("run 未处于暂停状态")
+	}
+	g.paused = false
+	g.pauseNext = action == ResumeActionStep
+	g.pauseReason = PauseReasonStep
+	info := g.current
+	resumeCh := g.resumeCh
+	g.resumeCh = nil
+	onResume := g.onResume
+	g.mu.Unlock()
+
+	// 先通知恢复再放行，避免与下一次暂停通知乱序
+	if onResume != nil {
+		onResume(info, action)
+	}
+	close(resumeCh)
+	return nil
+}
+
+func (g *BreakpointGate) Paused() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.paused
+}
+
+// 释放阻塞并停止后续暂停，用于停止或销毁 run
+func (g *BreakpointGate) Release() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.released = true
+	g.paused = false
+	g.pauseNext = false
+	if g.resumeCh != nil {
+		close(g.resumeCh)
+		g.resumeCh = nil
+	}
+}
@@ -0,0 +1,169 @@
+package events
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/debug/protocol"
+)
+
+func TestBreakpointGateEvaluate(t *testing.T) {
+	tests := []struct {
+		name        string
+		breakpoint  protocol.Breakpoint
+		recognition map[string]bool
+		visits      []string
+		wantPauses  []bool
+	}{
+		{
+			name:       "always",
+			breakpoint: protocol.Breakpoint{RuntimeName: "B"},
+			visits:     []string{"A", "B", "B"},
+			wantPauses: []bool{false, true, true},
+		},
+		{
+			name:       "hit count",
+			breakpoint: protocol.Breakpoint{RuntimeName: "B", HitCount: 2},
+			visits:     []string{"B", "B", "B"},
+			wantPauses: []bool{false, true, false},
+		},
+		{
+			name:        "recognition hit",
+			breakpoint:  protocol.Breakpoint{RuntimeName: "B", Condition: protocol.BreakpointConditionRecognitionHit},
+			recognition: map[string]bool{"B": true},
+			visits:      []string{"B"},
+			wantPauses:  []bool{true},
+		},
+		{
+			name:        "recognition miss pauses at next node",
+			breakpoint:  protocol.Breakpoint{RuntimeName: "B", Condition: protocol.BreakpointConditionRecognitionMiss},
+			recognition: map[string]bool{"B": false},
+			visits:      []string{"C", "C"},
+			wantPauses:  []bool{true, false},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			gate := NewBreakpointGate([]protocol.Breakpoint{test.breakpoint})
+			for name, hit := range test.recognition {
+				gate.RecordRecognition(name, hit)
+			}
+			for i, visit := range test.visits {
+				info, paused := gate.evaluate(visit)
+				if paused != test.wantPauses[i] {
+					t.Fatalf("visit %d (%s) paused = %v, want %v", i, visit, paused, test.wantPauses[i])
+				}
+				if paused && info.Reason != PauseReasonBreakpoint {
+					t.Fatalf("visit %d reason = %q, want %q", i, info.Reason, PauseReasonBreakpoint)
+				}
+			}
+		})
+	}
+}
+
+func TestBreakpointGatePauseStepResume(t *testing.T) {
+	gate := NewBreakpointGate(nil)
+	pauses := make(chan PauseInfo, 4)
+	resumes := make(chan string, 4)
+	gate.SetNotify(
+		func(info PauseInfo) { pauses <- info },
+		func(_ PauseInfo, action string) { resumes <- action },
+	)
+
+	if err := gate.Step(); err == nil {
+		t.Fatal("Step() error = nil while running")
+	}
+	if err := gate.Pause(); err != nil {
+		t.Fatalf("Pause() error = %v", err)
+	}
+
+	done := runNodes(gate, "A", "B", "C")
+	if info := waitPause(t, pauses); info.Reason != PauseReasonPause || info.Node.RuntimeName != "A" {
+		t.Fatalf("first pause = %+v", info)
+	}
+	if err := gate.Step(); err != nil {
+		t.Fatalf("Step() error = %v", err)
+	}
+	if info := waitPause(t, pauses); info.Reason != PauseReasonStep || info.Node.RuntimeName != "B" {
+		t.Fatalf("step pause = %+v", info)
+	}
+	if err := gate.Resume(); err != nil {
+		t.Fatalf("Resume() error = %v", err)
+	}
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("nodes did not finish after resume")
+	}
+	if len(pauses) != 0 {
+		t.Fatalf("unexpected extra pause: %+v", <-pauses)
+	}
+	if got := []string{<-resumes, <-resumes}; got[0] != ResumeActionStep || got[1] != ResumeActionResume {
+		t.Fatalf("resume actions = %v", got)
+	}
+}
+
+func TestBreakpointGateReleaseUnblocks(t *testing.T) {
+	gate := NewBreakpointGate([]protocol.Breakpoint{{RuntimeName: "A"}})
+	pauses := make(chan PauseInfo, 1)
+	gate.SetNotify(func(info PauseInfo) { pauses <- info }, nil)
+
+	done := runNodes(gate, "A", "A")
+	waitPause(t, pauses)
+	gate.Release()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Release() did not unblock paused node")
+	}
+	if gate.Paused() {
+		t.Fatal("gate still paused after Release()")
+	}
+	if err := gate.Pause(); err == nil {
+		t.Fatal("Pause() error = nil after Release()")
+	}
+}
+
+func TestValidateBreakpoints(t *testing.T) {
+	valid := []protocol.Breakpoint{
+		{RuntimeName: "A"},
+		{RuntimeName: "B", Condition: protocol.BreakpointConditionRecognitionMiss, HitCount: 3},
+	}
+	if err := ValidateBreakpoints(valid); err != nil {
+		t.Fatalf("ValidateBreakpoints() error = %v", err)
+	}
+	for _, invalid := range []protocol.Breakpoint{
+		{RuntimeName: " "},
+		{RuntimeName: "A", Condition: "sometimes"},
+		{RuntimeName: "A", HitCount: -1},
+	} {
+		if err := ValidateBreakpoints([]protocol.Breakpoint{invalid}); err == nil {
+			t.Fatalf("ValidateBreakpoints(%+v) error = nil", invalid)
+		}
+	}
+}
+
+func runNodes(gate *BreakpointGate, names ...string) <-chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for _, name := range names {
+			gate.AtNode(&protocol.EventNode{RuntimeName: name})
+		}
+	}()
+	return done
+}
+
+func waitPause(t *testing.T, pauses <-chan PauseInfo) PauseInfo {
+	t.Helper()
+	select {
+	case info := <-pauses:
+		return info
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for pause")
+		return PauseInfo{}
+	}
+}
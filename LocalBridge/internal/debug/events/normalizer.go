@@ -21,12 +21,16 @@ type Normalizer struct {
 	policy    protocol.ArtifactPolicy
 	artifacts *artifact.Store
 	emit      EmitFunc
+	gate      *BreakpointGate
 
 	mu          sync.Mutex
 	currentNode string
 
 	bootstrapPending bool
 	bootstrapActive  bool
+
+	// 待在回调线程中写入任务 Context 的热更新 override
+	pendingOverrides []map[string]interface{}
 }
 
 func NewNormalizer(
@@ -47,6 +51,17 @@ func NewNormalizer(
 	}
 }
 
+func (n *Normalizer) SetBreakpointGate(gate *BreakpointGate) {
+	n.gate = gate
+}
+
+// 排队热更新 override，在下一个节点或 next 列表开始时写入运行中任务的 Context
+func (n *Normalizer) QueuePipelineOverride(override map[string]interface{}) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.pendingOverrides = append(n.pendingOverrides, override)
+}
+
 func (n *Normalizer) OnTaskerTask(tasker *maa.Tasker, status maa.EventStatus, detail maa.TaskerTaskDetail) {
 	if status == maa.EventStatusStarting {
 		n.mu.Lock()
@@ -74,7 +89,7 @@ func (n *Normalizer) OnTaskerTask(tasker *maa.Tasker, status maa.EventStatus, de
 	n.publish(event)
 }
 
-func (n *Normalizer) OnNodePipelineNode(_ *maa.Context, status maa.EventStatus, detail maa.NodePipelineNodeDetail) {
+func (n *Normalizer) OnNodePipelineNode(ctx *maa.Context, status maa.EventStatus, detail maa.NodePipelineNodeDetail) {
 	event := n.baseEvent("node", "Node.PipelineNode", status)
 	event.TaskID = int64(detail.TaskID)
 	event.Node = n.nodeForPipelineEvent(status, detail.Name)
@@ -83,6 +98,13 @@ func (n *Normalizer) OnNodePipelineNode(_ *maa.Context, status maa.EventStatus,
 		"focus":  detail.Focus,
 	}
 	n.publish(event)
+
+	if status == maa.EventStatusStarting {
+		if n.gate != nil {
+			n.gate.AtNode(event.Node)
+		}
+		n.applyPendingOverrides(ctx)
+	}
 }
 
 func (n *Normalizer) OnNodeRecognitionNode(_ *maa.Context, status maa.EventStatus, detail maa.NodeRecognitionNodeDetail) {
@@ -107,7 +129,11 @@ func (n *Normalizer) OnNodeActionNode(_ *maa.Context, status maa.EventStatus, de
 	n.publish(event)
 }
 
-func (n *Normalizer) OnNodeNextList(_ *maa.Context, status maa.EventStatus, detail maa.NodeNextListDetail) {
+func (n *Normalizer) OnNodeNextList(ctx *maa.Context, status maa.EventStatus, detail maa.NodeNextListDetail) {
+	if status == maa.EventStatusStarting {
+		n.applyPendingOverrides(ctx)
+	}
+
 	event := n.baseEvent("next-list", "Node.NextList", status)
 	event.TaskID = int64(detail.TaskID)
 	event.Node = n.nodeForNextListEvent(detail.Name)
@@ -134,6 +160,10 @@ func (n *Normalizer) OnNodeRecognition(ctx *maa.Context, status maa.EventStatus,
 		event.Data["parentNode"] = currentNode
 	}
 
+	if (status == maa.EventStatusSucceeded || status == maa.EventStatusFailed) && n.gate != nil {
+		n.gate.RecordRecognition(detail.Name, status == maa.EventStatusSucceeded)
+	}
+
 	if status == maa.EventStatusSucceeded || status == maa.EventStatusFailed {
 		if ref := n.storeRecognitionDetail(ctx, int64(detail.RecognitionID)); ref != nil {
 			event.DetailRef = ref.ID
@@ -165,6 +195,24 @@ func (n *Normalizer) OnNodeAction(ctx *maa.Context, status maa.EventStatus, deta
 	n.publish(event)
 }
 
+// 控制器动作（截图、点击等）用于性能分析中的耗时拆分
+func (n *Normalizer) OnControllerAction(_ *maa.Controller, status maa.EventStatus, detail maa.ControllerActionDetail) {
+	event := n.baseEvent("controller", "Controller.Action", status)
+	event.Data = map[string]interface{}{
+		"ctrlId": detail.CtrlID,
+		"action": detail.Action,
+	}
+
+	n.mu.Lock()
+	currentNode := n.currentNode
+	n.mu.Unlock()
+	if currentNode != "" {
+		event.Data["parentNode"] = currentNode
+	}
+
+	n.publish(event)
+}
+
 func (n *Normalizer) baseEvent(kind string, baseMessage string, status maa.EventStatus) protocol.Event {
 	return protocol.Event{
 		SessionID:    n.sessionID,
@@ -182,6 +230,35 @@ func (n *Normalizer) publish(event protocol.Event) {
 	}
 }
 
+func (n *Normalizer) applyPendingOverrides(ctx *maa.Context) {
+	if ctx == nil {
+		return
+	}
+	n.mu.Lock()
+	pending := n.pendingOverrides
+	n.pendingOverrides = nil
+	n.mu.Unlock()
+
+	for _, override := range pending {
+		if err := ctx.OverridePipeline(override); err != nil {
+			logger.Warn("DebugVNext", "写入运行中任务 pipeline override 失败: %v", err)
+			n.publish(protocol.Event{
+				SessionID: n.sessionID,
+				RunID:     n.runID,
+				Source:    "localbridge",
+				Kind:      "diagnostic",
+				Phase:     "failed",
+				Status:    "error",
+				Data: map[string]interface{}{
+					"severity": "error",
+					"code":     "debug.override.live_patch_failed",
+					"message":  fmt.Sprintf("热更新 pipeline 写入任务失败: %v", err),
+				},
+			})
+		}
+	}
+}
+
 func (n *Normalizer) finishBootstrap() {
 	n.mu.Lock()
 	defer n.mu.Unlock()
@@ -240,7 +317,7 @@ func mergeRecognitionDetailEventData(eventData map[string]interface{}, detailDat
 	if eventData == nil || detailData == nil {
 		return
 	}
-	for _, key := range []string{"id", "name", "algorithm", "hit", "box", "rawImageRef", "drawImageRefs"} {
+	for _, key := range []string{"id", "name", "algorithm", "hit", "box", "roi", "score", "text", "rawImageRef", "drawImageRefs"} {
 		if value, ok := detailData[key]; ok {
 			eventData[key] = value
 		}
@@ -283,6 +360,12 @@ func (n *Normalizer) storeRecognitionDetail(ctx *maa.Context, recognitionID int6
 	}
 
 	data := SummarizeRecognitionDetail(detail)
+	// 识别详情不含 ROI，从节点定义补充，供回放叠加图使用
+	if nodeJSON, err := ctx.GetNodeJSON(detail.Name); err == nil {
+		if roi, ok := RecognitionROI(nodeJSON); ok {
+			data["roi"] = roi
+		}
+	}
 	if n.policy.IncludeRawImage && detail.Raw != nil {
 		if ref, err := n.artifacts.AddPNG(n.sessionID, "recognition-raw-image", detail.Raw); err == nil {
 			data["rawImageRef"] = ref.ID
@@ -456,6 +539,12 @@ func SummarizeRecognitionDetail(detail *maa.RecognitionDetail) map[string]interf
 	if strings.TrimSpace(detail.DetailJson) != "" {
 		data["detailJson"] = detail.DetailJson
 		data["detail"] = parseDetailJSON(detail.DetailJson)
+		if score, ok := recognitionScore(data["detail"]); ok {
+			data["score"] = score
+		}
+		if text, ok := recognitionText(data["detail"]); ok {
+			data["text"] = text
+		}
 	}
 	if len(detail.CombinedResult) > 0 {
 		combined := make([]map[string]interface{}, 0, len(detail.CombinedResult))
@@ -487,6 +576,85 @@ func SummarizeActionDetail(detail *maa.ActionDetail) map[string]interface{} {
 	return data
 }
 
+// 识别分数：命中时取 best，未命中时取 all 中的最高分
+func recognitionScore(detail interface{}) (float64, bool) {
+	detailMap, ok := detail.(map[string]interface{})
+	if !ok {
+		return 0, false
+	}
+	if best, ok := detailMap["best"].(map[string]interface{}); ok {
+		if score, ok := best["score"].(float64); ok {
+			return score, true
+		}
+	}
+	all, _ := detailMap["all"].([]interface{})
+	found := false
+	maxScore := 0.0
+	for _, item := range all {
+		result, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if score, ok := result["score"].(float64); ok && (!found || score > maxScore) {
+			maxScore = score
+			found = true
+		}
+	}
+	return maxScore, found
+}
+
+// OCR 文本：命中时取 best，未命中时取 all 中的第一条
+func recognitionText(detail interface{}) (string, bool) {
+	detailMap, ok := detail.(map[string]interface{})
+	if !ok {
+		return "", false
+	}
+	if best, ok := detailMap["best"].(map[string]interface{}); ok {
+		if text, ok := best["text"].(string); ok {
+			return text, true
+		}
+	}
+	all, _ := detailMap["all"].([]interface{})
+	for _, item := range all {
+		if result, ok := item.(map[string]interface{}); ok {
+			if text, ok := result["text"].(string); ok {
+				return text, true
+			}
+		}
+	}
+	return "", false
+}
+
+// RecognitionROI 读取节点定义中的固定 ROI；引用其他节点或全屏时返回 false
+func RecognitionROI(nodeJSON string) ([4]int, bool) {
+	var node map[string]interface{}
+	if err := json.Unmarshal([]byte(nodeJSON), &node); err != nil {
+		return [4]int{}, false
+	}
+	raw := node["roi"]
+	if recognition, ok := node["recognition"].(map[string]interface{}); ok {
+		if param, ok := recognition["param"].(map[string]interface{}); ok {
+			raw = param["roi"]
+		}
+	}
+	values, ok := raw.([]interface{})
+	if !ok || len(values) != 4 {
+		return [4]int{}, false
+	}
+	var roi [4]int
+	for i, value := range values {
+		number, ok := value.(float64)
+		if !ok {
+			return [4]int{}, false
+		}
+		roi[i] = int(number)
+	}
+	if roi[2] <= 0 || roi[3] <= 0 {
+		return [4]int{}, false
+	}
+	return roi, true
+}
+
 func parseDetailJSON(raw string) interface{} {
 	var parsed interface{}
 	if err := json.Unmarshal([]byte(raw), &parsed); err != nil {
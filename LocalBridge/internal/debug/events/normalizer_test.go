@@ -178,3 +178,59 @@ func newTestNormalizer() (*Normalizer, *[]protocol.Event) {
 	)
 	return normalizer, &emitted
 }
+
+func TestSummarizeRecognitionDetailScoreAndText(t *testing.T) {
+	tests := []struct {
+		name      string
+		detail    string
+		wantScore interface{}
+		wantText  interface{}
+	}{
+		{
+			name:      "best hit",
+			detail:    `{"best":{"box":[0,0,1,1],"score":0.91,"text":"开始"},"all":[]}`,
+			wantScore: 0.91,
+			wantText:  "开始",
+		},
+		{
+			name:      "miss uses highest score",
+			detail:    `{"best":null,"all":[{"score":0.42},{"score":0.67,"text":"12"}]}`,
+			wantScore: 0.67,
+			wantText:  "12",
+		},
+		{name: "no results", detail: `{"best":null,"all":[]}`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			data := SummarizeRecognitionDetail(&maa.RecognitionDetail{DetailJson: test.detail})
+			if data["score"] != test.wantScore || data["text"] != test.wantText {
+				t.Fatalf("score/text = %v/%v, want %v/%v", data["score"], data["text"], test.wantScore, test.wantText)
+			}
+		})
+	}
+}
+
+func TestRecognitionROI(t *testing.T) {
+	tests := []struct {
+		name     string
+		nodeJSON string
+		want     [4]int
+		ok       bool
+	}{
+		{name: "recognition param", nodeJSON: `{"recognition":{"type":"TemplateMatch","param":{"roi":[10,20,300,400]}}}`, want: [4]int{10, 20, 300, 400}, ok: true},
+		{name: "legacy top level", nodeJSON: `{"recognition":"OCR","roi":[1,2,3,4]}`, want: [4]int{1, 2, 3, 4}, ok: true},
+		{name: "full screen", nodeJSON: `{"recognition":{"param":{"roi":[0,0,0,0]}}}`},
+		{name: "node reference", nodeJSON: `{"recognition":{"param":{"roi":"OtherNode"}}}`},
+		{name: "invalid json", nodeJSON: `{`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, ok := RecognitionROI(test.nodeJSON)
+			if ok != test.ok || got != test.want {
+				t.Fatalf("RecognitionROI() = %v, %v, want %v, %v", got, ok, test.want, test.ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package performance
+
+import (
+	"sort"
+	"time"
+
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/debug/protocol"
+)
+
+// ChromeTrace 是 Chrome Trace Event 格式，可直接导入 chrome://tracing、Perfetto 或 speedscope
+type ChromeTrace struct {
+	TraceEvents     []ChromeTraceEvent `json:"traceEvents"`
+	DisplayTimeUnit string             `json:"displayTimeUnit"`
+	OtherData       map[string]string  `json:"otherData,omitempty"`
+}
+
+type ChromeTraceEvent struct {
+	Name      string                 `json:"name"`
+	Category  string                 `json:"cat,omitempty"`
+	Phase     string                 `json:"ph"`
+	Timestamp int64                  `json:"ts"`
+	Duration  int64                  `json:"dur"`
+	PID       int                    `json:"pid"`
+	TID       int                    `json:"tid"`
+	Args      map[string]interface{} `json:"args,omitempty"`
+}
+
+const (
+	chromeTracePID = 1
+	chromeTraceTID = 1
+)
+
+// BuildChromeTrace 将调用树导出为完整区间（ph=X）事件，时间戳为相对 run 开始的微秒数
+func BuildChromeTrace(sessionID string, runID string, spans []*Span) ChromeTrace {
+	var origin time.Time
+	for _, span := range spans {
+		if origin.IsZero() || span.Start.Before(origin) {
+			origin = span.Start
+		}
+	}
+
+	events := make([]ChromeTraceEvent, 0)
+	walkSpans(spans, nil, func(span *Span, _ *protocol.EventNode) {
+		events = append(events, ChromeTraceEvent{
+			Name:      span.Name,
+			Category:  span.Category,
+			Phase:     "X",
+			Timestamp: span.Start.Sub(origin).Microseconds(),
+			Duration:  span.Duration().Microseconds(),
+			PID:       chromeTracePID,
+			TID:       chromeTraceTID,
+			Args:      spanArgs(span),
+		})
+	})
+	// 同一时刻开始时较长的区间在前，保证父区间先于子区间
+	sort.SliceStable(events, func(i, j int) bool {
+		if events[i].Timestamp == events[j].Timestamp {
+			return events[i].Duration > events[j].Duration
+		}
+		return events[i].Timestamp < events[j].Timestamp
+	})
+
+	metadata := []ChromeTraceEvent{
+		{Name: "process_name", Phase: "M", PID: chromeTracePID, TID: chromeTraceTID, Args: map[string]interface{}{"name": "debug run " + runID}},
+		{Name: "thread_name", Phase: "M", PID: chromeTracePID, TID: chromeTraceTID, Args: map[string]interface{}{"name": "tasker"}},
+	}
+	return ChromeTrace{
+		TraceEvents:     append(metadata, events...),
+		DisplayTimeUnit: "ms",
+		OtherData: map[string]string{
+			"sessionId": sessionID,
+			"runId":     runID,
+		},
+	}
+}
+
+func spanArgs(span *Span) map[string]interface{} {
+	args := map[string]interface{}{
+		"status":   span.Status,
+		"startSeq": span.StartSeq,
+		"endSeq":   span.EndSeq,
+		"selfMs":   millis(span.SelfDuration()),
+	}
+	if span.Node != nil {
+		args["runtimeName"] = span.Node.RuntimeName
+		if span.Node.NodeID != "" {
+			args["nodeId"] = span.Node.NodeID
+		}
+		if span.Node.FileID != "" {
+			args["fileId"] = span.Node.FileID
+		}
+	}
+	for _, key := range []string{"parentNode", "algorithm", "hit", "score", "action"} {
+		if value, ok := span.Data[key]; ok {
+			args[key] = value
+		}
+	}
+	return args
+}
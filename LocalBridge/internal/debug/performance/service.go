@@ -24,7 +24,15 @@ func (s *Service) Build(sessionID string, runID string) protocol.PerformanceSumm
 }
 
 func (s *Service) Store(sessionID string, runID string) (protocol.ArtifactRef, protocol.PerformanceSummary, error) {
-	summary := s.Build(sessionID, runID)
+	events := s.traces.ListRun(sessionID, runID)
+	summary := BuildSummary(sessionID, runID, events, s.artifacts.ListRefs(sessionID))
+	if spans := BuildSpans(events); len(spans) > 0 {
+		flameRef, err := s.artifacts.AddJSON(sessionID, "performance-trace", BuildChromeTrace(sessionID, runID, spans))
+		if err != nil {
+			return protocol.ArtifactRef{}, summary, err
+		}
+		summary.FlameGraphRef = flameRef.ID
+	}
 	ref, err := s.artifacts.AddJSON(sessionID, "performance-summary", summary)
 	return ref, summary, err
 }
@@ -133,6 +141,32 @@ func BuildSummary(sessionID string, runID string, events []protocol.Event, artif
 		}
 	}
 
+	walkSpans(BuildSpans(events), nil, func(span *Span, owner *protocol.EventNode) {
+		self := span.SelfDuration()
+		addTiming(&summary.Timing, span.Category, self)
+		if owner == nil {
+			return
+		}
+		key := owner.NodeID
+		if key == "" {
+			key = owner.RuntimeName
+		}
+		node := nodeIndex[key]
+		if node == nil {
+			return
+		}
+		if node.Timing == nil {
+			node.Timing = &protocol.PerformanceTiming{}
+		}
+		addTiming(node.Timing, span.Category, self)
+		switch span.Message {
+		case "Node.Recognition":
+			node.RecognitionAttempts = append(node.RecognitionAttempts, attemptFromSpan(span))
+		case "Node.Action":
+			node.ActionAttempts = append(node.ActionAttempts, attemptFromSpan(span))
+		}
+	})
+
 	summary.ScreenshotRefCount = len(screenshotRefs)
 	nodes := make([]protocol.PerformanceNodeSummary, 0, len(nodeIndex))
 	for _, node := range nodeIndex {
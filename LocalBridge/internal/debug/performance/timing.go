@@ -0,0 +1,267 @@
+package performance
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/debug/protocol"
+)
+
+const (
+	CategoryTask         = "task"
+	CategoryPipelineNode = "pipeline-node"
+	CategoryNextList     = "next-list"
+	CategoryRecognition  = "recognition"
+	CategoryAction       = "action"
+	CategoryWaitFreezes  = "wait-freezes"
+	CategoryScreencap    = "screencap"
+	CategoryController   = "controller"
+)
+
+// Span 是由一对开始/结束事件还原出的调用区间
+type Span struct {
+	Name     string
+	Category string
+	Message  string
+	Node     *protocol.EventNode
+	Status   string
+	StartSeq int64
+	EndSeq   int64
+	Start    time.Time
+	End      time.Time
+	Data     map[string]interface{}
+	Children []*Span
+
+	key string
+}
+
+func (s *Span) Duration() time.Duration {
+	if s.End.Before(s.Start) {
+		return 0
+	}
+	return s.End.Sub(s.Start)
+}
+
+// 自身耗时：扣除与子区间重叠的部分
+func (s *Span) SelfDuration() time.Duration {
+	self := s.Duration()
+	for _, child := range s.Children {
+		start, end := child.Start, child.End
+		if start.Before(s.Start) {
+			start = s.Start
+		}
+		if end.After(s.End) {
+			end = s.End
+		}
+		if end.After(start) {
+			self -= end.Sub(start)
+		}
+	}
+	if self < 0 {
+		return 0
+	}
+	return self
+}
+
+// BuildSpans 按 seq 顺序配对 MaaFW 开始/结束事件，还原调用树。
+// 开始时挂到最内层未结束区间下；结束时只关闭匹配的区间，以容忍控制器回调与节点回调交错。
+func BuildSpans(events []protocol.Event) []*Span {
+	ordered := append([]protocol.Event(nil), events...)
+	sort.SliceStable(ordered, func(i, j int) bool { return ordered[i].Seq < ordered[j].Seq })
+
+	roots := make([]*Span, 0)
+	open := make([]*Span, 0)
+	var last time.Time
+	for _, event := range ordered {
+		base, suffix := splitMaaFWMessage(event.MaaFWMessage)
+		category := spanCategory(base, event.Data)
+		if category == "" {
+			continue
+		}
+		at, err := time.Parse(time.RFC3339Nano, event.Timestamp)
+		if err != nil {
+			continue
+		}
+		if at.After(last) {
+			last = at
+		}
+		key := spanKey(base, event)
+
+		switch suffix {
+		case "Starting":
+			span := &Span{
+				Name:     spanName(base, event),
+				Category: category,
+				Message:  base,
+				Node:     event.Node,
+				Status:   "running",
+				StartSeq: event.Seq,
+				Start:    at,
+				Data:     copyData(event.Data),
+				key:      key,
+			}
+			if len(open) > 0 {
+				parent := open[len(open)-1]
+				parent.Children = append(parent.Children, span)
+			} else {
+				roots = append(roots, span)
+			}
+			open = append(open, span)
+		case "Succeeded", "Failed":
+			for i := len(open) - 1; i >= 0; i-- {
+				span := open[i]
+				if span.key != key {
+					continue
+				}
+				span.End = at
+				span.EndSeq = event.Seq
+				span.Status = event.Phase
+				// 结束事件带有识别结果等字段，按最新值覆盖
+				span.Category = spanCategory(base, event.Data)
+				for dataKey, value := range event.Data {
+					span.Data[dataKey] = value
+				}
+				open = append(open[:i], open[i+1:]...)
+				break
+			}
+		}
+	}
+
+	for _, span := range open {
+		span.End = last
+		span.EndSeq = span.StartSeq
+		span.Status = "unfinished"
+	}
+	return roots
+}
+
+// 按 owner 节点遍历区间；区间自身未关联节点时沿用上层节点
+func walkSpans(spans []*Span, owner *protocol.EventNode, visit func(span *Span, owner *protocol.EventNode)) {
+	for _, span := range spans {
+		spanOwner := owner
+		if span.Node != nil {
+			spanOwner = span.Node
+		}
+		visit(span, spanOwner)
+		walkSpans(span.Children, spanOwner, visit)
+	}
+}
+
+// 动作节点除动作本身外的耗时来自 pre/post delay 与 wait_freezes，统一计入 waitFreezesMs
+func addTiming(timing *protocol.PerformanceTiming, category string, duration time.Duration) {
+	ms := millis(duration)
+	switch category {
+	case CategoryScreencap:
+		timing.ScreencapMs += ms
+	case CategoryRecognition:
+		timing.RecognitionMs += ms
+	case CategoryAction, CategoryController:
+		timing.ActionMs += ms
+	case CategoryWaitFreezes:
+		timing.WaitFreezesMs += ms
+	default:
+		timing.OtherMs += ms
+	}
+	timing.TotalMs += ms
+}
+
+func attemptFromSpan(span *Span) protocol.PerformanceAttempt {
+	attempt := protocol.PerformanceAttempt{
+		StartSeq:   span.StartSeq,
+		EndSeq:     span.EndSeq,
+		DurationMs: millis(span.Duration()),
+		Status:     span.Status,
+	}
+	if parentNode, ok := span.Data["parentNode"].(string); ok {
+		attempt.ParentNode = parentNode
+	}
+	return attempt
+}
+
+func millis(duration time.Duration) float64 {
+	return float64(duration.Microseconds()) / 1000
+}
+
+func splitMaaFWMessage(message string) (string, string) {
+	index := strings.LastIndex(message, ".")
+	if index <= 0 {
+		return "", ""
+	}
+	return message[:index], message[index+1:]
+}
+
+func spanCategory(base string, data map[string]interface{}) string {
+	switch base {
+	case "Tasker.Task":
+		return CategoryTask
+	case "Node.PipelineNode":
+		return CategoryPipelineNode
+	case "Node.NextList":
+		return CategoryNextList
+	case "Node.Recognition", "Node.RecognitionNode":
+		return CategoryRecognition
+	case "Node.Action":
+		return CategoryAction
+	case "Node.ActionNode":
+		return CategoryWaitFreezes
+	case "Controller.Action":
+		if action, _ := data["action"].(string); action == "screencap" {
+			return CategoryScreencap
+		}
+		return CategoryController
+	default:
+		return ""
+	}
+}
+
+func spanKey(base string, event protocol.Event) string {
+	var id interface{}
+	switch base {
+	case "Tasker.Task":
+		id = event.TaskID
+	case "Node.Recognition":
+		id = event.Data["recognitionId"]
+	case "Node.Action":
+		id = event.Data["actionId"]
+	case "Node.NextList":
+		if event.Node != nil {
+			id = event.Node.RuntimeName
+		}
+	case "Controller.Action":
+		id = event.Data["action"]
+	default:
+		id = event.Data["nodeId"]
+	}
+	return fmt.Sprintf("%s\x00%v", base, id)
+}
+
+func spanName(base string, event protocol.Event) string {
+	if base == "Controller.Action" {
+		if action, _ := event.Data["action"].(string); action != "" {
+			return action
+		}
+		return "controller"
+	}
+	label := base[strings.LastIndex(base, ".")+1:]
+	if event.Node != nil {
+		name := event.Node.Label
+		if name == "" {
+			name = event.Node.RuntimeName
+		}
+		return label + " " + name
+	}
+	if entry, _ := event.Data["entry"].(string); entry != "" {
+		return label + " " + entry
+	}
+	return label
+}
+
+func copyData(data map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{}, len(data))
+	for key, value := range data {
+		result[key] = value
+	}
+	return result
+}
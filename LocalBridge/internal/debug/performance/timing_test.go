@@ -0,0 +1,139 @@
+package performance
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/debug/protocol"
+)
+
+func TestBuildSummaryTimingBreakdown(t *testing.T) {
+	origin := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	node := &protocol.EventNode{RuntimeName: "Start", NodeID: "n-start"}
+	builder := eventBuilder{origin: origin}
+	events := []protocol.Event{
+		builder.at(0, "Tasker.Task.Starting", nil, nil),
+		builder.at(0, "Node.PipelineNode.Starting", node, map[string]interface{}{"nodeId": 1}),
+		builder.at(1, "Node.NextList.Starting", node, nil),
+		builder.at(1, "Controller.Action.Starting", nil, map[string]interface{}{"action": "screencap"}),
+		builder.at(31, "Controller.Action.Succeeded", nil, map[string]interface{}{"action": "screencap"}),
+		builder.at(31, "Node.Recognition.Starting", node, map[string]interface{}{"recognitionId": 7, "parentNode": "Start"}),
+		builder.at(51, "Node.Recognition.Succeeded", node, map[string]interface{}{"recognitionId": 7, "hit": true}),
+		builder.at(51, "Node.NextList.Succeeded", node, nil),
+		builder.at(51, "Node.ActionNode.Starting", node, map[string]interface{}{"nodeId": 2}),
+		builder.at(61, "Node.Action.Starting", node, map[string]interface{}{"actionId": 3}),
+		builder.at(66, "Controller.Action.Starting", nil, map[string]interface{}{"action": "click"}),
+		builder.at(71, "Controller.Action.Succeeded", nil, map[string]interface{}{"action": "click"}),
+		builder.at(71, "Node.Action.Succeeded", node, map[string]interface{}{"actionId": 3}),
+		builder.at(171, "Node.ActionNode.Succeeded", node, map[string]interface{}{"nodeId": 2}),
+		builder.at(171, "Node.PipelineNode.Succeeded", node, map[string]interface{}{"nodeId": 1}),
+		builder.at(172, "Tasker.Task.Succeeded", nil, nil),
+	}
+
+	summary := BuildSummary("session-1", "run-1", events, nil)
+	want := protocol.PerformanceTiming{
+		TotalMs:       172,
+		ScreencapMs:   30,
+		RecognitionMs: 20,
+		ActionMs:      10,
+		WaitFreezesMs: 110,
+		OtherMs:       2,
+	}
+	if summary.Timing != want {
+		t.Fatalf("Timing = %+v, want %+v", summary.Timing, want)
+	}
+
+	if len(summary.Nodes) != 1 {
+		t.Fatalf("Nodes len = %d, want 1", len(summary.Nodes))
+	}
+	nodeSummary := summary.Nodes[0]
+	if nodeSummary.Timing == nil || nodeSummary.Timing.TotalMs != 171 || nodeSummary.Timing.ScreencapMs != 30 {
+		t.Fatalf("node Timing = %+v", nodeSummary.Timing)
+	}
+	if len(nodeSummary.RecognitionAttempts) != 1 || nodeSummary.RecognitionAttempts[0].DurationMs != 20 ||
+		nodeSummary.RecognitionAttempts[0].ParentNode != "Start" {
+		t.Fatalf("RecognitionAttempts = %+v", nodeSummary.RecognitionAttempts)
+	}
+	if len(nodeSummary.ActionAttempts) != 1 || nodeSummary.ActionAttempts[0].DurationMs != 10 {
+		t.Fatalf("ActionAttempts = %+v", nodeSummary.ActionAttempts)
+	}
+}
+
+func TestBuildSpansToleratesInterleavingAndUnfinished(t *testing.T) {
+	origin := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	node := &protocol.EventNode{RuntimeName: "A"}
+	builder := eventBuilder{origin: origin}
+	events := []protocol.Event{
+		builder.at(0, "Node.PipelineNode.Starting", node, map[string]interface{}{"nodeId": 1}),
+		builder.at(1, "Controller.Action.Starting", nil, map[string]interface{}{"action": "screencap"}),
+		builder.at(2, "Node.NextList.Starting", node, nil),
+		builder.at(3, "Controller.Action.Succeeded", nil, map[string]interface{}{"action": "screencap"}),
+		builder.at(4, "Node.NextList.Failed", node, nil),
+		builder.at(9, "Resource.Loading.Starting", nil, nil),
+	}
+
+	roots := BuildSpans(events)
+	if len(roots) != 1 || roots[0].Status != "unfinished" || roots[0].Duration() != 4*time.Millisecond {
+		t.Fatalf("roots = %+v", roots)
+	}
+	capture := roots[0].Children[0]
+	if capture.Category != CategoryScreencap || capture.Status != "succeeded" || len(capture.Children) != 1 {
+		t.Fatalf("screencap span = %+v", capture)
+	}
+	if nextList := capture.Children[0]; nextList.Status != "failed" || nextList.Duration() != 2*time.Millisecond {
+		t.Fatalf("next-list span = %+v", nextList)
+	}
+	if self := capture.SelfDuration(); self != time.Millisecond {
+		t.Fatalf("screencap SelfDuration() = %v, want 1ms", self)
+	}
+}
+
+func TestBuildChromeTrace(t *testing.T) {
+	origin := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	node := &protocol.EventNode{RuntimeName: "A", Label: "开始"}
+	builder := eventBuilder{origin: origin}
+	events := []protocol.Event{
+		builder.at(5, "Node.PipelineNode.Starting", node, map[string]interface{}{"nodeId": 1}),
+		builder.at(5, "Node.Recognition.Starting", node, map[string]interface{}{"recognitionId": 2}),
+		builder.at(6, "Node.Recognition.Failed", node, map[string]interface{}{"recognitionId": 2, "hit": false}),
+		builder.at(8, "Node.PipelineNode.Failed", node, map[string]interface{}{"nodeId": 1}),
+	}
+
+	trace := BuildChromeTrace("session-1", "run-1", BuildSpans(events))
+	if _, err := json.Marshal(trace); err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if len(trace.TraceEvents) != 4 {
+		t.Fatalf("TraceEvents len = %d, want 4", len(trace.TraceEvents))
+	}
+	parent, child := trace.TraceEvents[2], trace.TraceEvents[3]
+	if parent.Name != "PipelineNode 开始" || parent.Timestamp != 0 || parent.Duration != 3000 {
+		t.Fatalf("parent event = %+v", parent)
+	}
+	if child.Category != CategoryRecognition || child.Duration != 1000 || child.Args["hit"] != false {
+		t.Fatalf("child event = %+v", child)
+	}
+}
+
+type eventBuilder struct {
+	origin time.Time
+	seq    int64
+}
+
+func (b *eventBuilder) at(ms int, message string, node *protocol.EventNode, data map[string]interface{}) protocol.Event {
+	b.seq++
+	phase := map[string]string{"Starting": "starting", "Succeeded": "succeeded", "Failed": "failed"}
+	_, suffix := splitMaaFWMessage(message)
+	return protocol.Event{
+		SessionID:    "session-1",
+		RunID:        "run-1",
+		Seq:          b.seq,
+		Timestamp:    b.origin.Add(time.Duration(ms) * time.Millisecond).Format(time.RFC3339Nano),
+		Source:       "maafw",
+		MaaFWMessage: message,
+		Phase:        phase[suffix],
+		Node:         node,
+		Data:         data,
+	}
+}
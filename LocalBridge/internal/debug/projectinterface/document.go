@@ -0,0 +1,252 @@
+package projectinterface
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/debug/protocol"
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/utils"
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/workspace"
+)
+
+// 以下结构对应 MaaFramework ProjectInterface 规范中的 interface.json 字段
+
+type document struct {
+	InterfaceVersion int                  `json:"interface_version"`
+	Name             string               `json:"name"`
+	Version          string               `json:"version"`
+	Controller       []controllerDef      `json:"controller"`
+	Resource         []resourceDef        `json:"resource"`
+	Agent            *agentDef            `json:"agent"`
+	Task             []taskDef            `json:"task"`
+	Option           map[string]optionDef `json:"option"`
+}
+
+type controllerDef struct {
+	Name  string                 `json:"name"`
+	Type  string                 `json:"type"`
+	Adb   map[string]interface{} `json:"adb,omitempty"`
+	Win32 map[string]interface{} `json:"win32,omitempty"`
+}
+
+type resourceDef struct {
+	Name string   `json:"name"`
+	Path []string `json:"path"`
+}
+
+type agentDef struct {
+	ChildExec  string   `json:"child_exec"`
+	ChildArgs  []string `json:"child_args"`
+	Identifier string   `json:"identifier"`
+}
+
+type taskDef struct {
+	Name             string                 `json:"name"`
+	Entry            string                 `json:"entry"`
+	DefaultCheck     bool                   `json:"default_check"`
+	Option           []string               `json:"option"`
+	Resource         []string               `json:"resource"`
+	PipelineOverride map[string]interface{} `json:"pipeline_override"`
+}
+
+type optionDef struct {
+	Type             string                 `json:"type"`
+	Cases            []caseDef              `json:"cases"`
+	DefaultCase      interface{}            `json:"default_case"`
+	Inputs           []inputDef             `json:"inputs"`
+	PipelineOverride map[string]interface{} `json:"pipeline_override"`
+}
+
+type caseDef struct {
+	Name             string                 `json:"name"`
+	Option           []string               `json:"option"`
+	PipelineOverride map[string]interface{} `json:"pipeline_override"`
+}
+
+type inputDef struct {
+	Name         string `json:"name"`
+	Default      string `json:"default"`
+	PipelineType string `json:"pipeline_type"`
+	Verify       string `json:"verify"`
+}
+
+const (
+	optionTypeSelect = "select"
+	optionTypeSwitch = "switch"
+	optionTypeInput  = "input"
+)
+
+// loaded 是解析后的 interface 文件及其所在目录
+type loaded struct {
+	path string
+	dir  string
+	doc  document
+}
+
+func parseFile(path string) (*loaded, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取 interface 文件失败: %w", err)
+	}
+	var doc document
+	if err := utils.ParseJSONC(data, &doc); err != nil {
+		return nil, fmt.Errorf("解析 interface 文件失败: %w", err)
+	}
+	return &loaded{path: path, dir: filepath.Dir(path), doc: doc}, nil
+}
+
+func optionType(option optionDef) string {
+	if strings.TrimSpace(option.Type) == "" {
+		return optionTypeSelect
+	}
+	return strings.ToLower(strings.TrimSpace(option.Type))
+}
+
+// defaultCase 兼容字符串形式与 v2 多选场景下的数组形式，数组时取第一个
+func defaultCase(option optionDef) string {
+	switch value := option.DefaultCase.(type) {
+	case string:
+		return value
+	case []interface{}:
+		if len(value) > 0 {
+			if name, ok := value[0].(string); ok {
+				return name
+			}
+		}
+	}
+	return ""
+}
+
+// resourcePaths 展开 {PROJECT_DIR} 占位符，相对路径以 interface 所在目录为基准
+func (l *loaded) resourcePaths(resource resourceDef) []string {
+	paths := make([]string, 0, len(resource.Path))
+	for _, path := range resource.Path {
+		path = strings.TrimSpace(strings.ReplaceAll(path, "{PROJECT_DIR}", l.dir))
+		if path == "" {
+			continue
+		}
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(l.dir, path)
+		}
+		paths = append(paths, filepath.Clean(path))
+	}
+	return paths
+}
+
+func (l *loaded) summary(roots workspace.Roots) protocol.ProjectInterface {
+	doc := l.doc
+	result := protocol.ProjectInterface{
+		Path:             l.path,
+		RelativePath:     relativePath(roots, l.path),
+		Name:             doc.Name,
+		Version:          doc.Version,
+		InterfaceVersion: doc.InterfaceVersion,
+		Controllers:      make([]protocol.ProjectInterfaceController, 0, len(doc.Controller)),
+		Resources:        make([]protocol.ProjectInterfaceResource, 0, len(doc.Resource)),
+		Tasks:            make([]protocol.ProjectInterfaceTask, 0, len(doc.Task)),
+		Options:          make([]protocol.ProjectInterfaceOption, 0, len(doc.Option)),
+	}
+	for _, controller := range doc.Controller {
+		result.Controllers = append(result.Controllers, protocol.ProjectInterfaceController{Name: controller.Name, Type: controller.Type})
+	}
+	for _, resource := range doc.Resource {
+		result.Resources = append(result.Resources, protocol.ProjectInterfaceResource{Name: resource.Name, Paths: l.resourcePaths(resource)})
+	}
+	for _, task := range doc.Task {
+		result.Tasks = append(result.Tasks, protocol.ProjectInterfaceTask{
+			Name:         task.Name,
+			Entry:        task.Entry,
+			DefaultCheck: task.DefaultCheck,
+			Options:      task.Option,
+			Resources:    task.Resource,
+		})
+	}
+	for _, name := range sortedOptionNames(doc.Option) {
+		option := doc.Option[name]
+		summary := protocol.ProjectInterfaceOption{
+			Name:        name,
+			Type:        optionType(option),
+			DefaultCase: defaultCase(option),
+		}
+		for _, item := range option.Cases {
+			summary.Cases = append(summary.Cases, item.Name)
+		}
+		for _, input := range option.Inputs {
+			summary.Inputs = append(summary.Inputs, protocol.ProjectInterfaceOptionInput{
+				Name:         input.Name,
+				Default:      input.Default,
+				PipelineType: input.PipelineType,
+				Verify:       input.Verify,
+			})
+		}
+		result.Options = append(result.Options, summary)
+	}
+	if doc.Agent != nil {
+		result.Agent = &protocol.ProjectInterfaceAgent{
+			ChildExec:  doc.Agent.ChildExec,
+			ChildArgs:  doc.Agent.ChildArgs,
+			Identifier: doc.Agent.Identifier,
+		}
+	}
+	return result
+}
+
+// loadNodeNames 收集资源路径下 pipeline 目录中定义的全部节点名；语法错误由资源体检负责报告
+func loadNodeNames(paths []string) map[string]struct{} {
+	names := make(map[string]struct{})
+	for _, path := range paths {
+		_ = filepath.WalkDir(filepath.Join(path, "pipeline"), func(file string, entry os.DirEntry, err error) error {
+			if err != nil || entry.IsDir() {
+				return nil
+			}
+			lower := strings.ToLower(entry.Name())
+			if !strings.HasSuffix(lower, ".json") && !strings.HasSuffix(lower, ".jsonc") {
+				return nil
+			}
+			data, err := os.ReadFile(file)
+			if err != nil {
+				return nil
+			}
+			var nodes map[string]json.RawMessage
+			if err := utils.ParseJSONC(data, &nodes); err != nil {
+				return nil
+			}
+			for name := range nodes {
+				if !strings.HasPrefix(name, "$") {
+					names[name] = struct{}{}
+				}
+			}
+			return nil
+		})
+	}
+	return names
+}
+
+func sortedOptionNames(options map[string]optionDef) []string {
+	names := make([]string, 0, len(options))
+	for name := range options {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// relativePath 返回相对所在根目录的路径，非主根目录下的路径带 "名称:" 前缀
+func relativePath(roots workspace.Roots, path string) string {
+	root, ok := roots.Locate(path)
+	if !ok {
+		return filepath.ToSlash(path)
+	}
+	rel, err := filepath.Rel(root.Path, path)
+	if err != nil {
+		return filepath.ToSlash(path)
+	}
+	if root.Name != roots.Primary().Name {
+		return root.Name + ":" + filepath.ToSlash(rel)
+	}
+	return filepath.ToSlash(rel)
+}
@@ -0,0 +1,336 @@
+package projectinterface
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/debug/protocol"
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/workspace"
+)
+
+// interface agent 生成的 AgentProfile ID
+const interfaceAgentID = "interface-agent"
+
+// buildProfile 按任务、资源、控制器与选项生成 RunProfile 及合并后的 pipeline 覆盖
+func (l *loaded) buildProfile(roots workspace.Roots, req protocol.ProjectInterfaceProfileRequest) (protocol.ProjectInterfaceProfile, error) {
+	doc := l.doc
+	task := findTask(doc, strings.TrimSpace(req.Task))
+	if task == nil {
+		return protocol.ProjectInterfaceProfile{}, fmt.Errorf("interface 中不存在任务: %s", req.Task)
+	}
+	if strings.TrimSpace(task.Entry) == "" {
+		return protocol.ProjectInterfaceProfile{}, fmt.Errorf("任务 %s 未配置 entry", task.Name)
+	}
+
+	resource, err := selectResource(doc, *task, strings.TrimSpace(req.Resource))
+	if err != nil {
+		return protocol.ProjectInterfaceProfile{}, err
+	}
+
+	result := protocol.ProjectInterfaceProfile{
+		InterfacePath: relativePath(roots, l.path),
+		Task:          task.Name,
+		Resource:      resource.Name,
+		Diagnostics:   make([]protocol.Diagnostic, 0),
+	}
+	controller, err := l.controllerProfile(strings.TrimSpace(req.Controller), strings.TrimSpace(req.ControllerID), &result)
+	if err != nil {
+		return protocol.ProjectInterfaceProfile{}, err
+	}
+
+	merged := make(map[string]interface{})
+	order := make([]string, 0)
+	apply := func(override map[string]interface{}) {
+		for name, value := range override {
+			if _, ok := merged[name]; !ok {
+				order = append(order, name)
+			}
+			merged[name] = mergeValue(merged[name], value)
+		}
+	}
+	apply(task.PipelineOverride)
+	visited := make(map[string]struct{})
+	for _, optionName := range task.Option {
+		if err := l.applyOption(optionName, req, apply, visited); err != nil {
+			return protocol.ProjectInterfaceProfile{}, err
+		}
+	}
+	result.Overrides = make([]protocol.PipelineOverride, 0, len(order))
+	for _, name := range order {
+		pipeline, ok := merged[name].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		result.Overrides = append(result.Overrides, protocol.PipelineOverride{RuntimeName: name, Pipeline: pipeline})
+	}
+
+	result.Profile = protocol.RunProfile{
+		ID:            fmt.Sprintf("interface:%s#%s", result.InterfacePath, task.Name),
+		Name:          profileName(doc.Name, task.Name),
+		ResourcePaths: l.resourcePaths(*resource),
+		Controller:    controller,
+		Agents:        l.agentProfiles(&result),
+		Entry:         protocol.NodeTarget{RuntimeName: task.Entry},
+		SavePolicy:    "sandbox",
+		MaaOptions:    map[string]interface{}{},
+	}
+	return result, nil
+}
+
+// applyOption 应用选项选中的 case 或输入值，case 的子选项递归应用
+func (l *loaded) applyOption(
+	name string,
+	req protocol.ProjectInterfaceProfileRequest,
+	apply func(map[string]interface{}),
+	visited map[string]struct{},
+) error {
+	if _, ok := visited[name]; ok {
+		return nil
+	}
+	visited[name] = struct{}{}
+
+	option, ok := l.doc.Option[name]
+	if !ok {
+		return fmt.Errorf("interface 中不存在选项: %s", name)
+	}
+	switch optionType(option) {
+	case optionTypeInput:
+		values := make(map[string]interface{}, len(option.Inputs))
+		for _, input := range option.Inputs {
+			value, ok := req.Inputs[name][input.Name]
+			if !ok {
+				value = input.Default
+			}
+			if err := checkInputValue(input, value); err != nil {
+				return fmt.Errorf("选项 %s 的输入 %s 无效: %w", name, input.Name, err)
+			}
+			typed, _ := typedInputValue(input, value)
+			values[input.Name] = typed
+		}
+		if override, ok := substituteInputs(option.PipelineOverride, values).(map[string]interface{}); ok {
+			apply(override)
+		}
+		return nil
+	case optionTypeSelect, optionTypeSwitch:
+		caseName, ok := req.Options[name]
+		if !ok || caseName == "" {
+			caseName = defaultCase(option)
+		}
+		if caseName == "" && len(option.Cases) > 0 {
+			caseName = option.Cases[0].Name
+		}
+		selected := findCase(option, caseName)
+		if selected == nil {
+			return fmt.Errorf("选项 %s 不存在 case: %s", name, caseName)
+		}
+		apply(selected.PipelineOverride)
+		for _, child := range selected.Option {
+			if err := l.applyOption(child, req, apply, visited); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("选项 %s 的类型 %s 暂不支持", name, option.Type)
+	}
+}
+
+func (l *loaded) controllerProfile(name string, controllerID string, result *protocol.ProjectInterfaceProfile) (protocol.ControllerProfile, error) {
+	options := map[string]interface{}{}
+	if controllerID != "" {
+		options["controllerId"] = controllerID
+	}
+	if len(l.doc.Controller) == 0 {
+		if name != "" {
+			return protocol.ControllerProfile{}, fmt.Errorf("interface 中不存在控制器: %s", name)
+		}
+		return protocol.ControllerProfile{Type: "adb", Options: options}, nil
+	}
+
+	controller := l.doc.Controller[0]
+	if name != "" {
+		found := false
+		for _, candidate := range l.doc.Controller {
+			if candidate.Name == name {
+				controller, found = candidate, true
+				break
+			}
+		}
+		if !found {
+			return protocol.ControllerProfile{}, fmt.Errorf("interface 中不存在控制器: %s", name)
+		}
+	}
+	controllerType, ok := controllerTypes[strings.ToLower(controller.Type)]
+	if !ok {
+		return protocol.ControllerProfile{}, fmt.Errorf("控制器 %s 的类型 %s 暂不支持调试运行", controller.Name, controller.Type)
+	}
+	result.Controller = controller.Name
+	// interface 中的截图、输入方式等配置供前端连接控制器时参考
+	if controller.Adb != nil {
+		options["interface"] = controller.Adb
+	}
+	if controller.Win32 != nil {
+		options["interface"] = controller.Win32
+	}
+	if controllerID == "" {
+		result.Diagnostics = append(result.Diagnostics, protocol.Diagnostic{
+			Severity:   "warning",
+			Code:       "debug.interface.controller_not_connected",
+			Message:    fmt.Sprintf("尚未指定已连接的控制器，运行前请先连接 %s 控制器。", controller.Name),
+			SourcePath: l.path,
+		})
+	}
+	return protocol.ControllerProfile{Type: controllerType, Options: options}, nil
+}
+
+func (l *loaded) agentProfiles(result *protocol.ProjectInterfaceProfile) []protocol.AgentProfile {
+	agent := l.doc.Agent
+	if agent == nil {
+		return []protocol.AgentProfile{}
+	}
+	if strings.TrimSpace(agent.Identifier) == "" {
+		result.Diagnostics = append(result.Diagnostics, protocol.Diagnostic{
+			Severity:   "warning",
+			Code:       "debug.interface.agent_manual_start",
+			Message:    "interface 的 agent 未配置 identifier，生成的配置不包含 agent，请手动启动后在调试配置中补充。",
+			SourcePath: l.path,
+		})
+		return []protocol.AgentProfile{}
+	}
+	return []protocol.AgentProfile{{
+		ID:         interfaceAgentID,
+		Enabled:    true,
+		Transport:  "identifier",
+		Identifier: strings.TrimSpace(agent.Identifier),
+	}}
+}
+
+func findTask(doc document, name string) *taskDef {
+	for i := range doc.Task {
+		if doc.Task[i].Name == name {
+			return &doc.Task[i]
+		}
+	}
+	return nil
+}
+
+// 未指定资源时取任务适用的第一个资源
+func selectResource(doc document, task taskDef, name string) (*resourceDef, error) {
+	allowed := taskResources(doc, task)
+	if name == "" {
+		if len(allowed) == 0 {
+			return nil, fmt.Errorf("interface 未定义任何资源")
+		}
+		name = allowed[0]
+	}
+	permitted := false
+	for _, candidate := range allowed {
+		if candidate == name {
+			permitted = true
+			break
+		}
+	}
+	if !permitted {
+		return nil, fmt.Errorf("任务 %s 不支持资源: %s", task.Name, name)
+	}
+	for i := range doc.Resource {
+		if doc.Resource[i].Name == name {
+			return &doc.Resource[i], nil
+		}
+	}
+	return nil, fmt.Errorf("interface 中不存在资源: %s", name)
+}
+
+func profileName(interfaceName string, taskName string) string {
+	if strings.TrimSpace(interfaceName) == "" {
+		return taskName
+	}
+	return fmt.Sprintf("%s - %s", interfaceName, taskName)
+}
+
+// mergeValue 深度合并节点覆盖，后应用的字段优先
+func mergeValue(base interface{}, override interface{}) interface{} {
+	baseMap, baseOK := base.(map[string]interface{})
+	overrideMap, overrideOK := override.(map[string]interface{})
+	if !baseOK || !overrideOK {
+		return cloneValue(override)
+	}
+	merged := make(map[string]interface{}, len(baseMap)+len(overrideMap))
+	for key, value := range baseMap {
+		merged[key] = value
+	}
+	for key, value := range overrideMap {
+		merged[key] = mergeValue(merged[key], value)
+	}
+	return merged
+}
+
+func cloneValue(value interface{}) interface{} {
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		cloned := make(map[string]interface{}, len(typed))
+		for key, item := range typed {
+			cloned[key] = cloneValue(item)
+		}
+		return cloned
+	case []interface{}:
+		cloned := make([]interface{}, len(typed))
+		for i, item := range typed {
+			cloned[i] = cloneValue(item)
+		}
+		return cloned
+	default:
+		return value
+	}
+}
+
+// substituteInputs 替换覆盖中的 {输入名} 占位符；整个字符串只有占位符时保留输入的类型
+func substituteInputs(value interface{}, inputs map[string]interface{}) interface{} {
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(typed))
+		for key, item := range typed {
+			result[key] = substituteInputs(item, inputs)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(typed))
+		for i, item := range typed {
+			result[i] = substituteInputs(item, inputs)
+		}
+		return result
+	case string:
+		for name, input := range inputs {
+			placeholder := "{" + name + "}"
+			if typed == placeholder {
+				return input
+			}
+			typed = strings.ReplaceAll(typed, placeholder, fmt.Sprint(input))
+		}
+		return typed
+	default:
+		return value
+	}
+}
+
+func typedInputValue(input inputDef, value string) (interface{}, error) {
+	switch strings.ToLower(strings.TrimSpace(input.PipelineType)) {
+	case "", "string":
+		return value, nil
+	case "int":
+		number, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("%q 不是整数", value)
+		}
+		return number, nil
+	case "bool":
+		flag, err := strconv.ParseBool(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("%q 不是布尔值", value)
+		}
+		return flag, nil
+	default:
+		return nil, fmt.Errorf("不支持的 pipeline_type: %s", input.PipelineType)
+	}
+}
@@ -0,0 +1,178 @@
+package projectinterface
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/debug/diagnostics"
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/debug/protocol"
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/workspace"
+)
+
+// 在工作区中查找 interface.json 的最大目录深度
+const maxDiscoveryDepth = 3
+
+var interfaceFileNames = map[string]struct{}{
+	"interface.json":  {},
+	"interface.jsonc": {},
+}
+
+var skippedDirs = map[string]struct{}{
+	"node_modules": {},
+	"__pycache__":  {},
+	"venv":         {},
+	"debug":        {},
+	"logs":         {},
+	"cache":        {},
+	"install":      {},
+}
+
+// Service 发现并校验工作区内的 MaaFramework ProjectInterface，并据此生成调试配置
+type Service struct {
+	mu    sync.RWMutex
+	roots workspace.Roots
+}
+
+func NewService(root string) *Service {
+	return &Service{roots: workspace.Single(root)}
+}
+
+// SetRoots 切换工作区根目录
+func (s *Service) SetRoots(roots workspace.Roots) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.roots = roots
+}
+
+func (s *Service) workspaceRoots() workspace.Roots {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.roots
+}
+
+// List 依次在各根目录中查找 interface.json
+func (s *Service) List() protocol.ProjectInterfaceListResult {
+	roots := s.workspaceRoots()
+	result := protocol.ProjectInterfaceListResult{
+		Root:       roots.Primary().Path,
+		Interfaces: make([]protocol.ProjectInterface, 0),
+	}
+	for _, root := range roots {
+		for _, path := range discover(root.Path) {
+			result.Interfaces = append(result.Interfaces, describe(roots, path))
+		}
+	}
+	return result
+}
+
+func (s *Service) Get(candidate string) (protocol.ProjectInterface, error) {
+	roots := s.workspaceRoots()
+	path, err := resolve(roots, candidate)
+	if err != nil {
+		return protocol.ProjectInterface{}, err
+	}
+	return describe(roots, path), nil
+}
+
+func (s *Service) Profile(req protocol.ProjectInterfaceProfileRequest) (protocol.ProjectInterfaceProfile, error) {
+	roots := s.workspaceRoots()
+	path, err := resolve(roots, req.InterfacePath)
+	if err != nil {
+		return protocol.ProjectInterfaceProfile{}, err
+	}
+	file, err := parseFile(path)
+	if err != nil {
+		return protocol.ProjectInterfaceProfile{}, err
+	}
+	return file.buildProfile(roots, req)
+}
+
+// describe 解析失败时仍返回条目，以便前端展示错误
+func describe(roots workspace.Roots, path string) protocol.ProjectInterface {
+	file, err := parseFile(path)
+	if err != nil {
+		return protocol.ProjectInterface{
+			Path:         path,
+			RelativePath: relativePath(roots, path),
+			Status:       "invalid",
+			Controllers:  []protocol.ProjectInterfaceController{},
+			Resources:    []protocol.ProjectInterfaceResource{},
+			Tasks:        []protocol.ProjectInterfaceTask{},
+			Options:      []protocol.ProjectInterfaceOption{},
+			Diagnostics: []protocol.Diagnostic{{
+				Severity:   "error",
+				Code:       "debug.interface.parse_failed",
+				Message:    err.Error(),
+				SourcePath: path,
+			}},
+		}
+	}
+	summary := file.summary(roots)
+	summary.Diagnostics = file.validate()
+	summary.Status = "ready"
+	if diagnostics.HasBlockingDiagnostic(summary.Diagnostics) {
+		summary.Status = "invalid"
+	}
+	return summary
+}
+
+// resolve 将请求中的路径限制在工作区内；传入目录时查找其中的 interface.json
+func resolve(roots workspace.Roots, candidate string) (string, error) {
+	candidate = strings.TrimSpace(candidate)
+	if candidate == "" {
+		return "", fmt.Errorf("缺少 interfacePath")
+	}
+	resolved, err := roots.Resolve(candidate)
+	if err != nil {
+		return "", fmt.Errorf("interface 文件不在工作区内: %s", candidate)
+	}
+	info, err := os.Stat(resolved)
+	if err != nil {
+		return "", fmt.Errorf("interface 文件不存在: %s", candidate)
+	}
+	if info.IsDir() {
+		for name := range interfaceFileNames {
+			path := filepath.Join(resolved, name)
+			if fileInfo, err := os.Stat(path); err == nil && !fileInfo.IsDir() {
+				return path, nil
+			}
+		}
+		return "", fmt.Errorf("目录中未找到 interface.json: %s", candidate)
+	}
+	return resolved, nil
+}
+
+func discover(root string) []string {
+	paths := make([]string, 0)
+	if root == "" {
+		return paths
+	}
+	var walk func(dir string, depth int)
+	walk = func(dir string, depth int) {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return
+		}
+		for _, entry := range entries {
+			name := entry.Name()
+			if !entry.IsDir() {
+				if _, ok := interfaceFileNames[strings.ToLower(name)]; ok {
+					paths = append(paths, filepath.Join(dir, name))
+				}
+				continue
+			}
+			if depth >= maxDiscoveryDepth || strings.HasPrefix(name, ".") {
+				continue
+			}
+			if _, skip := skippedDirs[name]; skip {
+				continue
+			}
+			walk(filepath.Join(dir, name), depth+1)
+		}
+	}
+	walk(root, 0)
+	return paths
+}
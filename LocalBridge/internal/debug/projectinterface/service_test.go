@@ -0,0 +1,180 @@
+package projectinterface
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/debug/protocol"
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/workspace"
+)
+
+const fixtureInterface = `{
+	// interface 允许 JSONC
+	"interface_version": 2,
+	"name": "Demo",
+	"controller": [
+		{"name": "安卓端", "type": "Adb", "adb": {"screencap": 2}},
+		{"name": "Gamepad", "type": "Gamepad"}
+	],
+	"resource": [
+		{"name": "官服", "path": ["{PROJECT_DIR}/resource"]},
+		{"name": "缺失", "path": ["./missing"]}
+	],
+	"agent": {"child_exec": "python", "child_args": ["agent/main.py"], "identifier": "demo-agent"},
+	"task": [
+		{
+			"name": "日常",
+			"entry": "Daily",
+			"resource": ["官服"],
+			"option": ["关卡", "次数"],
+			"pipeline_override": {"Daily": {"timeout": 1000}}
+		},
+		{"name": "坏任务", "entry": "Nowhere", "option": ["未定义"]}
+	],
+	"option": {
+		"关卡": {
+			"cases": [
+				{"name": "1-7", "pipeline_override": {"Stage": {"text": ["1-7"]}}},
+				{"name": "活动", "option": ["活动难度"], "pipeline_override": {"Stage": {"text": ["EV"]}, "Typo": {}}}
+			],
+			"default_case": "1-7"
+		},
+		"活动难度": {
+			"type": "switch",
+			"cases": [{"name": "Yes", "pipeline_override": {"Stage": {"enabled": true}}}, {"name": "No"}],
+			"default_case": "Hard"
+		},
+		"次数": {
+			"type": "input",
+			"inputs": [{"name": "count", "default": "3", "pipeline_type": "int", "verify": "^\\d+$"}],
+			"pipeline_override": {"Daily": {"max_hit": "{count}", "focus": "run {count} times"}}
+		}
+	}
+}`
+
+func writeFixture(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	project := filepath.Join(root, "project")
+	files := map[string]string{
+		filepath.Join(project, "interface.json"):                     fixtureInterface,
+		filepath.Join(project, "resource", "pipeline", "main.json"):  `{"Daily": {}, "Stage": {}, "$schema": "x"}`,
+		filepath.Join(root, "node_modules", "pkg", "interface.json"): `{}`,
+	}
+	for path, content := range files {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("MkdirAll() error = %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("WriteFile() error = %v", err)
+		}
+	}
+	return root
+}
+
+func TestServiceListValidates(t *testing.T) {
+	root := writeFixture(t)
+	result := NewService(root).List()
+	if len(result.Interfaces) != 1 {
+		t.Fatalf("Interfaces = %+v", result.Interfaces)
+	}
+	item := result.Interfaces[0]
+	if item.RelativePath != "project/interface.json" || item.Status != "invalid" || item.Name != "Demo" {
+		t.Fatalf("interface = %+v", item)
+	}
+	if len(item.Tasks) != 2 || len(item.Options) != 3 || item.Options[0].Name != "关卡" {
+		t.Fatalf("tasks = %+v, options = %+v", item.Tasks, item.Options)
+	}
+
+	codes := make([]string, 0, len(item.Diagnostics))
+	for _, diagnostic := range item.Diagnostics {
+		codes = append(codes, diagnostic.Severity+" "+diagnostic.Code)
+	}
+	sort.Strings(codes)
+	want := []string{
+		"error debug.interface.option_default_invalid",
+		"error debug.interface.option_missing",
+		"error debug.interface.resource_path_missing",
+		"error debug.interface.task_entry_missing",
+		"error debug.interface.task_entry_missing",
+		"warning debug.interface.controller_unsupported",
+		"warning debug.interface.override_node_missing",
+	}
+	if !reflect.DeepEqual(codes, want) {
+		t.Fatalf("diagnostics = %v, want %v", codes, want)
+	}
+}
+
+func TestServiceListAcrossRoots(t *testing.T) {
+	primary := t.TempDir()
+	common := writeFixture(t)
+	service := NewService(primary)
+	service.SetRoots(workspace.Roots{{Name: workspace.DefaultName, Path: primary}, {Name: "common", Path: common}})
+
+	result := service.List()
+	if result.Root != primary || len(result.Interfaces) != 1 || result.Interfaces[0].RelativePath != "common:project/interface.json" {
+		t.Fatalf("result = %+v", result)
+	}
+	if _, err := service.Get(result.Interfaces[0].RelativePath); err != nil {
+		t.Fatalf("Get(%q) error = %v", result.Interfaces[0].RelativePath, err)
+	}
+}
+
+func TestServiceProfile(t *testing.T) {
+	root := writeFixture(t)
+	service := NewService(root)
+
+	profile, err := service.Profile(protocol.ProjectInterfaceProfileRequest{
+		InterfacePath: "project",
+		Task:          "日常",
+		ControllerID:  "ctrl-1",
+		Options:       map[string]string{"关卡": "活动", "活动难度": "Yes"},
+		Inputs:        map[string]map[string]string{"次数": {"count": "5"}},
+	})
+	if err != nil {
+		t.Fatalf("Profile() error = %v", err)
+	}
+	if profile.Resource != "官服" || profile.Controller != "安卓端" || profile.Profile.Entry.RuntimeName != "Daily" {
+		t.Fatalf("profile = %+v", profile)
+	}
+	wantPaths := []string{filepath.Join(root, "project", "resource")}
+	if !reflect.DeepEqual(profile.Profile.ResourcePaths, wantPaths) {
+		t.Fatalf("ResourcePaths = %v, want %v", profile.Profile.ResourcePaths, wantPaths)
+	}
+	if profile.Profile.Controller.Type != "adb" || profile.Profile.Controller.Options["controllerId"] != "ctrl-1" {
+		t.Fatalf("Controller = %+v", profile.Profile.Controller)
+	}
+	if len(profile.Profile.Agents) != 1 || profile.Profile.Agents[0].Identifier != "demo-agent" {
+		t.Fatalf("Agents = %+v", profile.Profile.Agents)
+	}
+
+	overrides := make(map[string]map[string]interface{}, len(profile.Overrides))
+	for _, override := range profile.Overrides {
+		overrides[override.RuntimeName] = override.Pipeline
+	}
+	wantDaily := map[string]interface{}{"timeout": float64(1000), "max_hit": 5, "focus": "run 5 times"}
+	if !reflect.DeepEqual(overrides["Daily"], wantDaily) {
+		t.Fatalf("Daily override = %#v", overrides["Daily"])
+	}
+	wantStage := map[string]interface{}{"text": []interface{}{"EV"}, "enabled": true}
+	if !reflect.DeepEqual(overrides["Stage"], wantStage) {
+		t.Fatalf("Stage override = %#v", overrides["Stage"])
+	}
+
+	invalid := []protocol.ProjectInterfaceProfileRequest{
+		{InterfacePath: "project", Task: "不存在"},
+		{InterfacePath: "project", Task: "日常", Resource: "缺失"},
+		{InterfacePath: "project", Task: "日常", Options: map[string]string{"关卡": "未知"}},
+		{InterfacePath: "project", Task: "日常", Inputs: map[string]map[string]string{"次数": {"count": "many"}}},
+		{InterfacePath: "project", Task: "日常", Controller: "Gamepad"},
+		{InterfacePath: "../outside", Task: "日常"},
+	}
+	for _, req := range invalid {
+		if _, err := service.Profile(req); err == nil {
+			t.Fatalf("Profile(%+v) error = nil", req)
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package projectinterface
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/debug/protocol"
+)
+
+// 调试运行目前支持的控制器类型，key 为 interface.json 中的写法
+var controllerTypes = map[string]string{
+	"adb":   "adb",
+	"win32": "win32",
+}
+
+// validate 校验资源路径、任务入口、选项引用与覆盖节点
+func (l *loaded) validate() []protocol.Diagnostic {
+	doc := l.doc
+	diagnostics := make([]protocol.Diagnostic, 0)
+	add := func(severity string, code string, fieldPath string, message string, data map[string]interface{}) {
+		diagnostics = append(diagnostics, protocol.Diagnostic{
+			Severity:   severity,
+			Code:       code,
+			Message:    message,
+			FieldPath:  fieldPath,
+			SourcePath: l.path,
+			Data:       data,
+		})
+	}
+
+	if len(doc.Resource) == 0 {
+		add("error", "debug.interface.resource_missing", "resource", "interface 未定义任何资源。", nil)
+	}
+	if len(doc.Task) == 0 {
+		add("warning", "debug.interface.task_missing", "task", "interface 未定义任何任务。", nil)
+	}
+	for i, controller := range doc.Controller {
+		if _, ok := controllerTypes[strings.ToLower(controller.Type)]; !ok {
+			add("warning", "debug.interface.controller_unsupported", fmt.Sprintf("controller[%d].type", i),
+				fmt.Sprintf("控制器 %s 的类型 %s 暂不支持调试运行。", controller.Name, controller.Type),
+				map[string]interface{}{"controller": controller.Name, "type": controller.Type})
+		}
+	}
+
+	// 每个资源集的节点名，任务入口需在其适用的每个资源集中存在
+	resourceNodes := make(map[string]map[string]struct{}, len(doc.Resource))
+	allNodes := make(map[string]struct{})
+	for i, resource := range doc.Resource {
+		paths := l.resourcePaths(resource)
+		if len(paths) == 0 {
+			add("error", "debug.interface.resource_path_missing", fmt.Sprintf("resource[%d].path", i),
+				fmt.Sprintf("资源 %s 未配置路径。", resource.Name), map[string]interface{}{"resource": resource.Name})
+		}
+		for j, path := range paths {
+			if info, err := os.Stat(path); err != nil || !info.IsDir() {
+				add("error", "debug.interface.resource_path_missing", fmt.Sprintf("resource[%d].path[%d]", i, j),
+					fmt.Sprintf("资源 %s 的路径不存在：%s", resource.Name, path),
+					map[string]interface{}{"resource": resource.Name, "path": path})
+			}
+		}
+		nodes := loadNodeNames(paths)
+		resourceNodes[resource.Name] = nodes
+		for name := range nodes {
+			allNodes[name] = struct{}{}
+		}
+	}
+
+	for i, task := range doc.Task {
+		field := fmt.Sprintf("task[%d]", i)
+		if strings.TrimSpace(task.Entry) == "" {
+			add("error", "debug.interface.task_entry_missing", field+".entry",
+				fmt.Sprintf("任务 %s 未配置 entry。", task.Name), map[string]interface{}{"task": task.Name})
+		} else {
+			for _, resourceName := range taskResources(doc, task) {
+				nodes, ok := resourceNodes[resourceName]
+				if !ok {
+					add("error", "debug.interface.task_resource_missing", field+".resource",
+						fmt.Sprintf("任务 %s 引用了不存在的资源 %s。", task.Name, resourceName),
+						map[string]interface{}{"task": task.Name, "resource": resourceName})
+					continue
+				}
+				if _, ok := nodes[task.Entry]; !ok {
+					add("error", "debug.interface.task_entry_missing", field+".entry",
+						fmt.Sprintf("任务 %s 的入口节点 %s 不在资源 %s 的 pipeline 中。", task.Name, task.Entry, resourceName),
+						map[string]interface{}{"task": task.Name, "entry": task.Entry, "resource": resourceName})
+				}
+			}
+		}
+		for j, optionName := range task.Option {
+			if _, ok := doc.Option[optionName]; !ok {
+				add("error", "debug.interface.option_missing", fmt.Sprintf("%s.option[%d]", field, j),
+					fmt.Sprintf("任务 %s 引用了未定义的选项 %s。", task.Name, optionName),
+					map[string]interface{}{"task": task.Name, "option": optionName})
+			}
+		}
+		checkOverrideNodes(task.PipelineOverride, allNodes, field+".pipeline_override", add)
+	}
+
+	for _, name := range sortedOptionNames(doc.Option) {
+		option := doc.Option[name]
+		field := "option." + name
+		switch optionType(option) {
+		case optionTypeSelect, optionTypeSwitch:
+			if len(option.Cases) == 0 {
+				add("error", "debug.interface.option_case_missing", field+".cases",
+					fmt.Sprintf("选项 %s 未定义任何 case。", name), map[string]interface{}{"option": name})
+			}
+			if defaultName := defaultCase(option); defaultName != "" && findCase(option, defaultName) == nil {
+				add("error", "debug.interface.option_default_invalid", field+".default_case",
+					fmt.Sprintf("选项 %s 的默认值 %s 不在 cases 中。", name, defaultName),
+					map[string]interface{}{"option": name, "case": defaultName})
+			}
+			for i, item := range option.Cases {
+				caseField := fmt.Sprintf("%s.cases[%d]", field, i)
+				for _, child := range item.Option {
+					if _, ok := doc.Option[child]; !ok {
+						add("error", "debug.interface.option_missing", caseField+".option",
+							fmt.Sprintf("选项 %s 的 case %s 引用了未定义的子选项 %s。", name, item.Name, child),
+							map[string]interface{}{"option": name, "case": item.Name, "child": child})
+					}
+				}
+				checkOverrideNodes(item.PipelineOverride, allNodes, caseField+".pipeline_override", add)
+			}
+		case optionTypeInput:
+			for i, input := range option.Inputs {
+				inputField := fmt.Sprintf("%s.inputs[%d]", field, i)
+				if err := checkInputValue(input, input.Default); err != nil && input.Default != "" {
+					add("warning", "debug.interface.option_input_default_invalid", inputField+".default",
+						fmt.Sprintf("选项 %s 的输入 %s 默认值无效：%v", name, input.Name, err),
+						map[string]interface{}{"option": name, "input": input.Name})
+				}
+			}
+			checkOverrideNodes(option.PipelineOverride, allNodes, field+".pipeline_override", add)
+		default:
+			add("warning", "debug.interface.option_type_unsupported", field+".type",
+				fmt.Sprintf("选项 %s 的类型 %s 暂不支持。", name, option.Type),
+				map[string]interface{}{"option": name, "type": option.Type})
+		}
+	}
+
+	if doc.Agent != nil && strings.TrimSpace(doc.Agent.Identifier) == "" && strings.TrimSpace(doc.Agent.ChildExec) != "" {
+		add("info", "debug.interface.agent_manual_start", "agent",
+			"interface 通过 child_exec 启动 agent，LocalBridge 不会自动拉起子进程，请手动启动并在调试配置中填写 identifier。",
+			map[string]interface{}{"childExec": doc.Agent.ChildExec})
+	}
+	return diagnostics
+}
+
+// 覆盖中引用了 pipeline 未定义的节点时，MaaFW 会静默新建节点，通常是拼写错误
+func checkOverrideNodes(
+	override map[string]interface{},
+	nodes map[string]struct{},
+	fieldPath string,
+	add func(string, string, string, string, map[string]interface{}),
+) {
+	names := make([]string, 0, len(override))
+	for name := range override {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if _, ok := nodes[name]; !ok {
+			add("warning", "debug.interface.override_node_missing", fieldPath,
+				fmt.Sprintf("pipeline_override 引用的节点 %s 不在任何资源的 pipeline 中。", name),
+				map[string]interface{}{"node": name})
+		}
+	}
+}
+
+// 任务未限定资源时适用于全部资源
+func taskResources(doc document, task taskDef) []string {
+	if len(task.Resource) > 0 {
+		return task.Resource
+	}
+	names := make([]string, 0, len(doc.Resource))
+	for _, resource := range doc.Resource {
+		names = append(names, resource.Name)
+	}
+	return names
+}
+
+func findCase(option optionDef, name string) *caseDef {
+	for i := range option.Cases {
+		if option.Cases[i].Name == name {
+			return &option.Cases[i]
+		}
+	}
+	return nil
+}
+
+func checkInputValue(input inputDef, value string) error {
+	if input.Verify != "" {
+		pattern, err := regexp.Compile(input.Verify)
+		if err != nil {
+			return fmt.Errorf("verify 正则无效: %w", err)
+		}
+		if !pattern.MatchString(value) {
+			return fmt.Errorf("%q 不匹配 %s", value, input.Verify)
+		}
+	}
+	if _, err := typedInputValue(input, value); err != nil {
+		return err
+	}
+	return nil
+}
@@ -19,14 +19,28 @@ const (
 )
 
 type CapabilityManifest struct {
-	Generation        string   `json:"generation"`
-	RunModes          []string `json:"runModes"`
-	Diagnostics       []string `json:"diagnostics"`
-	Artifacts         []string `json:"artifacts"`
-	ScreenshotSources []string `json:"screenshotSources"`
-	ProfileFeatures   []string `json:"profileFeatures"`
-	DebugFeatures     []string `json:"debugFeatures,omitempty"`
-	Maa               MaaInfo  `json:"maa"`
+	Generation        string                  `json:"generation"`
+	RunModes          []string                `json:"runModes"`
+	Diagnostics       []string                `json:"diagnostics"`
+	Artifacts         []string                `json:"artifacts"`
+	ScreenshotSources []string                `json:"screenshotSources"`
+	ProfileFeatures   []string                `json:"profileFeatures"`
+	DebugFeatures     []string                `json:"debugFeatures,omitempty"`
+	Maa               MaaInfo                 `json:"maa"`
+	ControllerHealth  []ControllerHealthState `json:"controllerHealth,omitempty"`
+}
+
+type ControllerHealthState struct {
+	ControllerID        string `json:"controllerId"`
+	Type                string `json:"type"`
+	Status              string `json:"status"`
+	LatencyMS           int64  `json:"latencyMs"`
+	ConsecutiveFailures int    `json:"consecutiveFailures"`
+	LastError           string `json:"lastError,omitempty"`
+	LastProbeAt         string `json:"lastProbeAt,omitempty"`
+	AutoReconnect       bool   `json:"autoReconnect"`
+	ReconnectAttempts   int    `json:"reconnectAttempts"`
+	NextReconnectAt     string `json:"nextReconnectAt,omitempty"`
 }
 
 type MaaInfo struct {
@@ -135,6 +149,7 @@ type RunInput struct {
 
 type RunRequest struct {
 	SessionID        string               `json:"sessionId,omitempty"`
+	OwnerToken       string               `json:"ownerToken,omitempty"`
 	ProfileID        string               `json:"profileId,omitempty"`
 	Profile          RunProfile           `json:"profile"`
 	Mode             RunMode              `json:"mode"`
@@ -144,6 +159,22 @@ type RunRequest struct {
 	Overrides        []PipelineOverride   `json:"overrides,omitempty"`
 	ArtifactPolicy   *ArtifactPolicy      `json:"artifactPolicy,omitempty"`
 	Input            *RunInput            `json:"input,omitempty"`
+	Breakpoints      []Breakpoint         `json:"breakpoints,omitempty"`
+}
+
+type BreakpointCondition string
+
+const (
+	BreakpointConditionAlways          BreakpointCondition = ""
+	BreakpointConditionRecognitionHit  BreakpointCondition = "recognition-hit"
+	BreakpointConditionRecognitionMiss BreakpointCondition = "recognition-miss"
+)
+
+// 节点断点，HitCount > 0 时仅在第 HitCount 次进入节点时暂停
+type Breakpoint struct {
+	RuntimeName string              `json:"runtimeName"`
+	Condition   BreakpointCondition `json:"condition,omitempty"`
+	HitCount    int                 `json:"hitCount,omitempty"`
 }
 
 type ResourcePreflightRequest struct {
@@ -180,9 +211,49 @@ type ResourceHealthResult struct {
 }
 
 type RunStopRequest struct {
-	SessionID string `json:"sessionId"`
-	RunID     string `json:"runId,omitempty"`
-	Reason    string `json:"reason,omitempty"`
+	SessionID  string `json:"sessionId"`
+	OwnerToken string `json:"ownerToken,omitempty"`
+	RunID      string `json:"runId,omitempty"`
+	Reason     string `json:"reason,omitempty"`
+}
+
+type SessionAttachRequest struct {
+	SessionID  string `json:"sessionId"`
+	OwnerToken string `json:"ownerToken"`
+	SinceSeq   int64  `json:"sinceSeq,omitempty"`
+}
+
+type WatchAddRequest struct {
+	SessionID  string `json:"sessionId"`
+	OwnerToken string `json:"ownerToken,omitempty"`
+	Expression string `json:"expression"`
+	Pause      bool   `json:"pause,omitempty"`
+}
+
+type WatchRemoveRequest struct {
+	SessionID  string `json:"sessionId"`
+	OwnerToken string `json:"ownerToken,omitempty"`
+	WatchID    string `json:"watchId"`
+}
+
+type RunControlRequest struct {
+	SessionID  string `json:"sessionId"`
+	OwnerToken string `json:"ownerToken,omitempty"`
+	RunID      string `json:"runId,omitempty"`
+}
+
+type RunPatchRequest struct {
+	SessionID  string             `json:"sessionId"`
+	OwnerToken string             `json:"ownerToken,omitempty"`
+	RunID      string             `json:"runId,omitempty"`
+	Overrides  []PipelineOverride `json:"overrides"`
+}
+
+type PipelinePatchChange struct {
+	RuntimeName string      `json:"runtimeName"`
+	Field       string      `json:"field"`
+	Before      interface{} `json:"before,omitempty"`
+	After       interface{} `json:"after"`
 }
 
 type ArtifactGetRequest struct {
@@ -202,16 +273,21 @@ type TraceSnapshot struct {
 }
 
 type TraceReplayRequest struct {
-	SessionID string `json:"sessionId"`
-	RunID     string `json:"runId,omitempty"`
-	CursorSeq int64  `json:"cursorSeq,omitempty"`
-	NodeID    string `json:"nodeId,omitempty"`
-	Speed     int    `json:"speed,omitempty"`
+	SessionID  string `json:"sessionId"`
+	OwnerToken string `json:"ownerToken,omitempty"`
+	RunID      string `json:"runId,omitempty"`
+	CursorSeq  int64  `json:"cursorSeq,omitempty"`
+	NodeID     string `json:"nodeId,omitempty"`
+	Speed      int    `json:"speed,omitempty"`
+	// 为 true 时随游标推送识别叠加图
+	Overlay bool `json:"overlay,omitempty"`
 }
 
 type TraceReplayStopRequest struct {
-	SessionID string `json:"sessionId"`
-	Reason    string `json:"reason,omitempty"`
+	SessionID  string `json:"sessionId"`
+	OwnerToken string `json:"ownerToken,omitempty"`
+	RunID      string `json:"runId,omitempty"`
+	Reason     string `json:"reason,omitempty"`
 }
 
 type TraceReplayStatus struct {
@@ -228,6 +304,73 @@ type TraceReplayStatus struct {
 	UpdatedAt string `json:"updatedAt,omitempty"`
 	StoppedAt string `json:"stoppedAt,omitempty"`
 	Reason    string `json:"reason,omitempty"`
+	Overlay   bool   `json:"overlay,omitempty"`
+	// 游标所在识别步骤的叠加帧，仅 overlay 开启时返回
+	Frame *TraceReplayFrame `json:"frame,omitempty"`
+}
+
+const (
+	OverlayShapeROI       = "roi"
+	OverlayShapeCandidate = "candidate"
+	OverlayShapeHit       = "hit"
+	OverlayShapeMiss      = "miss"
+	OverlayShapeAction    = "action"
+)
+
+type OverlayShape struct {
+	Kind  string  `json:"kind"`
+	Box   [4]int  `json:"box"`
+	Label string  `json:"label,omitempty"`
+	Score float64 `json:"score,omitempty"`
+}
+
+// TraceReplayFrame 描述某一识别步骤的画面：底图、识别区域、命中框与动作目标
+type TraceReplayFrame struct {
+	RecognitionSeq int64          `json:"recognitionSeq"`
+	ActionSeq      int64          `json:"actionSeq,omitempty"`
+	RuntimeName    string         `json:"runtimeName,omitempty"`
+	Timestamp      string         `json:"timestamp,omitempty"`
+	Hit            bool           `json:"hit"`
+	ScreenshotRef  string         `json:"screenshotRef,omitempty"`
+	OverlayRef     string         `json:"overlayRef,omitempty"`
+	Width          int            `json:"width,omitempty"`
+	Height         int            `json:"height,omitempty"`
+	Shapes         []OverlayShape `json:"shapes"`
+}
+
+const (
+	TraceReplayExportGIF    = "gif"
+	TraceReplayExportFrames = "frames"
+)
+
+type TraceReplayExportRequest struct {
+	SessionID string `json:"sessionId"`
+	RunID     string `json:"runId,omitempty"`
+	NodeID    string `json:"nodeId,omitempty"`
+	Format    string `json:"format,omitempty"`
+	FromSeq   int64  `json:"fromSeq,omitempty"`
+	ToSeq     int64  `json:"toSeq,omitempty"`
+	MaxFrames int    `json:"maxFrames,omitempty"`
+}
+
+type TraceReplayExportFrame struct {
+	RecognitionSeq int64  `json:"recognitionSeq"`
+	ActionSeq      int64  `json:"actionSeq,omitempty"`
+	RuntimeName    string `json:"runtimeName,omitempty"`
+	Timestamp      string `json:"timestamp,omitempty"`
+	OverlayRef     string `json:"overlayRef,omitempty"`
+	DelayMs        int64  `json:"delayMs"`
+}
+
+type TraceReplayExport struct {
+	SessionID     string                   `json:"sessionId"`
+	RunID         string                   `json:"runId,omitempty"`
+	Format        string                   `json:"format"`
+	FrameCount    int                      `json:"frameCount"`
+	SkippedFrames int                      `json:"skippedFrames,omitempty"`
+	Truncated     bool                     `json:"truncated,omitempty"`
+	ArtifactRef   ArtifactRef              `json:"artifactRef"`
+	Frames        []TraceReplayExportFrame `json:"frames,omitempty"`
 }
 
 type PerformanceNodeSummary struct {
@@ -247,6 +390,28 @@ type PerformanceNodeSummary struct {
 	WaitFreezesCount   int    `json:"waitFreezesCount"`
 	DetailRefCount     int    `json:"detailRefCount"`
 	ScreenshotRefCount int    `json:"screenshotRefCount"`
+
+	Timing              *PerformanceTiming   `json:"timing,omitempty"`
+	RecognitionAttempts []PerformanceAttempt `json:"recognitionAttempts,omitempty"`
+	ActionAttempts      []PerformanceAttempt `json:"actionAttempts,omitempty"`
+}
+
+// 按自身耗时（扣除子调用）拆分的时间分布，单位毫秒
+type PerformanceTiming struct {
+	TotalMs       float64 `json:"totalMs"`
+	ScreencapMs   float64 `json:"screencapMs"`
+	RecognitionMs float64 `json:"recognitionMs"`
+	ActionMs      float64 `json:"actionMs"`
+	WaitFreezesMs float64 `json:"waitFreezesMs"`
+	OtherMs       float64 `json:"otherMs"`
+}
+
+type PerformanceAttempt struct {
+	StartSeq   int64   `json:"startSeq"`
+	EndSeq     int64   `json:"endSeq"`
+	DurationMs float64 `json:"durationMs"`
+	Status     string  `json:"status"`
+	ParentNode string  `json:"parentNode,omitempty"`
 }
 
 type PerformanceSummary struct {
@@ -265,12 +430,249 @@ type PerformanceSummary struct {
 	StartedAt          string                   `json:"startedAt,omitempty"`
 	CompletedAt        string                   `json:"completedAt,omitempty"`
 	DurationMs         int64                    `json:"durationMs,omitempty"`
+	Timing             PerformanceTiming        `json:"timing"`
+	FlameGraphRef      string                   `json:"flameGraphRef,omitempty"`
 	Nodes              []PerformanceNodeSummary `json:"nodes"`
 	GeneratedAt        string                   `json:"generatedAt"`
 }
 
+// 对比的一侧：会话内 run、导出的 trace 文件或前端导入的事件三选一
+type TraceCompareSide struct {
+	SessionID string  `json:"sessionId,omitempty"`
+	RunID     string  `json:"runId,omitempty"`
+	TracePath string  `json:"tracePath,omitempty"`
+	Events    []Event `json:"events,omitempty"`
+}
+
+type TraceCompareRequest struct {
+	Base                TraceCompareSide `json:"base"`
+	Target              TraceCompareSide `json:"target"`
+	DurationThresholdMs float64          `json:"durationThresholdMs,omitempty"`
+	ScoreThreshold      float64          `json:"scoreThreshold,omitempty"`
+}
+
+type TraceCompareSource struct {
+	SessionID  string  `json:"sessionId,omitempty"`
+	RunID      string  `json:"runId,omitempty"`
+	TracePath  string  `json:"tracePath,omitempty"`
+	Imported   bool    `json:"imported,omitempty"`
+	EventCount int     `json:"eventCount"`
+	NodeCount  int     `json:"nodeCount"`
+	Status     string  `json:"status,omitempty"`
+	DurationMs float64 `json:"durationMs"`
+}
+
+// 节点序列对齐结果，op 为 same / removed / added
+type TraceSequenceEntry struct {
+	Op          string `json:"op"`
+	RuntimeName string `json:"runtimeName"`
+	BaseIndex   int    `json:"baseIndex"`
+	TargetIndex int    `json:"targetIndex"`
+}
+
+type TraceHitChange struct {
+	RuntimeName  string `json:"runtimeName"`
+	Change       string `json:"change"`
+	BaseHits     int    `json:"baseHits"`
+	BaseMisses   int    `json:"baseMisses"`
+	TargetHits   int    `json:"targetHits"`
+	TargetMisses int    `json:"targetMisses"`
+}
+
+type TraceScoreDelta struct {
+	RuntimeName string  `json:"runtimeName"`
+	BaseScore   float64 `json:"baseScore"`
+	TargetScore float64 `json:"targetScore"`
+	Delta       float64 `json:"delta"`
+}
+
+type TraceDurationDelta struct {
+	RuntimeName string  `json:"runtimeName"`
+	BaseMs      float64 `json:"baseMs"`
+	TargetMs    float64 `json:"targetMs"`
+	DeltaMs     float64 `json:"deltaMs"`
+}
+
+type TraceDiagnosticChange struct {
+	Code        string `json:"code"`
+	Severity    string `json:"severity,omitempty"`
+	Message     string `json:"message,omitempty"`
+	RuntimeName string `json:"runtimeName,omitempty"`
+	Seq         int64  `json:"seq"`
+}
+
+type TraceComparison struct {
+	Base                TraceCompareSource      `json:"base"`
+	Target              TraceCompareSource      `json:"target"`
+	SequenceChanged     bool                    `json:"sequenceChanged"`
+	Sequence            []TraceSequenceEntry    `json:"sequence"`
+	HitChanges          []TraceHitChange        `json:"hitChanges"`
+	ScoreDeltas         []TraceScoreDelta       `json:"scoreDeltas"`
+	DurationRegressions []TraceDurationDelta    `json:"durationRegressions"`
+	NewDiagnostics      []TraceDiagnosticChange `json:"newDiagnostics"`
+	DurationThresholdMs float64                 `json:"durationThresholdMs"`
+	GeneratedAt         string                  `json:"generatedAt"`
+}
+
+const (
+	EventVerbosityMinimal = "minimal"
+	EventVerbosityNormal  = "normal"
+	EventVerbosityVerbose = "verbose"
+)
+
+// 会话事件订阅过滤，仅影响推送，trace 中仍保留完整事件；
+// 合并重复事件与积压时批量推送需显式开启
+type EventSubscription struct {
+	Kinds     []string `json:"kinds,omitempty"`
+	Nodes     []string `json:"nodes,omitempty"`
+	Verbosity string   `json:"verbosity,omitempty"`
+	Coalesce  bool     `json:"coalesce,omitempty"`
+	Batch     bool     `json:"batch,omitempty"`
+}
+
+type EventSubscribeRequest struct {
+	SessionID    string            `json:"sessionId"`
+	OwnerToken   string            `json:"ownerToken,omitempty"`
+	Subscription EventSubscription `json:"subscription"`
+}
+
+// ProjectInterface 是工作区内 MaaFramework interface.json 的摘要与校验结果
+type ProjectInterface struct {
+	Path             string                       `json:"path"`
+	RelativePath     string                       `json:"relativePath"`
+	Name             string                       `json:"name,omitempty"`
+	Version          string                       `json:"version,omitempty"`
+	InterfaceVersion int                          `json:"interfaceVersion,omitempty"`
+	Status           string                       `json:"status"`
+	Controllers      []ProjectInterfaceController `json:"controllers"`
+	Resources        []ProjectInterfaceResource   `json:"resources"`
+	Tasks            []ProjectInterfaceTask       `json:"tasks"`
+	Options          []ProjectInterfaceOption     `json:"options"`
+	Agent            *ProjectInterfaceAgent       `json:"agent,omitempty"`
+	Diagnostics      []Diagnostic                 `json:"diagnostics,omitempty"`
+}
+
+type ProjectInterfaceController struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+type ProjectInterfaceResource struct {
+	Name  string   `json:"name"`
+	Paths []string `json:"paths"`
+}
+
+type ProjectInterfaceTask struct {
+	Name         string   `json:"name"`
+	Entry        string   `json:"entry"`
+	DefaultCheck bool     `json:"defaultCheck,omitempty"`
+	Options      []string `json:"options,omitempty"`
+	Resources    []string `json:"resources,omitempty"`
+}
+
+type ProjectInterfaceOption struct {
+	Name        string                        `json:"name"`
+	Type        string                        `json:"type"`
+	Cases       []string                      `json:"cases,omitempty"`
+	DefaultCase string                        `json:"defaultCase,omitempty"`
+	Inputs      []ProjectInterfaceOptionInput `json:"inputs,omitempty"`
+}
+
+type ProjectInterfaceOptionInput struct {
+	Name         string `json:"name"`
+	Default      string `json:"default,omitempty"`
+	PipelineType string `json:"pipelineType,omitempty"`
+	Verify       string `json:"verify,omitempty"`
+}
+
+type ProjectInterfaceAgent struct {
+	ChildExec  string   `json:"childExec,omitempty"`
+	ChildArgs  []string `json:"childArgs,omitempty"`
+	Identifier string   `json:"identifier,omitempty"`
+}
+
+type ProjectInterfaceListResult struct {
+	Root       string             `json:"root"`
+	Interfaces []ProjectInterface `json:"interfaces"`
+}
+
+// ProjectInterfaceProfileRequest 按 interface 任务与选项生成调试 RunProfile；
+// Options 为选项名到 case 名，Inputs 为 input 类型选项的输入值
+type ProjectInterfaceProfileRequest struct {
+	InterfacePath string                       `json:"interfacePath"`
+	Task          string                       `json:"task"`
+	Resource      string                       `json:"resource,omitempty"`
+	Controller    string                       `json:"controller,omitempty"`
+	ControllerID  string                       `json:"controllerId,omitempty"`
+	Options       map[string]string            `json:"options,omitempty"`
+	Inputs        map[string]map[string]string `json:"inputs,omitempty"`
+}
+
+type ProjectInterfaceProfile struct {
+	InterfacePath string             `json:"interfacePath"`
+	Task          string             `json:"task"`
+	Resource      string             `json:"resource"`
+	Controller    string             `json:"controller,omitempty"`
+	Profile       RunProfile         `json:"profile"`
+	Overrides     []PipelineOverride `json:"overrides"`
+	Diagnostics   []Diagnostic       `json:"diagnostics,omitempty"`
+}
+
+type CoverageReportRequest struct {
+	SessionID        string               `json:"sessionId,omitempty"`
+	ResolverSnapshot NodeResolverSnapshot `json:"resolverSnapshot"`
+	TracePaths       []string             `json:"tracePaths,omitempty"`
+	// 在该目录下查找 *.trace.json 一并统计
+	TraceDir string `json:"traceDir,omitempty"`
+	// 未指定 traceDir 时，查找全部工作区根目录下的 *.trace.json
+	DiscoverTraces bool `json:"discoverTraces,omitempty"`
+}
+
+type CoverageNode struct {
+	FileID              string `json:"fileId"`
+	NodeID              string `json:"nodeId"`
+	RuntimeName         string `json:"runtimeName"`
+	Label               string `json:"label,omitempty"`
+	Entered             bool   `json:"entered"`
+	EnterCount          int    `json:"enterCount"`
+	RecognitionAttempts int    `json:"recognitionAttempts"`
+	RecognitionHits     int    `json:"recognitionHits"`
+	RecognitionNeverHit bool   `json:"recognitionNeverHit"`
+}
+
+type CoverageEdge struct {
+	EdgeID          string `json:"edgeId"`
+	FromRuntimeName string `json:"fromRuntimeName"`
+	ToRuntimeName   string `json:"toRuntimeName"`
+	Reason          string `json:"reason"`
+	Taken           bool   `json:"taken"`
+	TakenCount      int    `json:"takenCount"`
+}
+
+type CoverageTotals struct {
+	Nodes               int `json:"nodes"`
+	EnteredNodes        int `json:"enteredNodes"`
+	Edges               int `json:"edges"`
+	TakenEdges          int `json:"takenEdges"`
+	OnErrorEdges        int `json:"onErrorEdges"`
+	FiredOnErrorEdges   int `json:"firedOnErrorEdges"`
+	RecognitionNeverHit int `json:"recognitionNeverHit"`
+}
+
+type CoverageReport struct {
+	SessionID   string                  `json:"sessionId,omitempty"`
+	RunIDs      []string                `json:"runIds"`
+	TracePaths  []string                `json:"tracePaths,omitempty"`
+	EventCount  int                     `json:"eventCount"`
+	Nodes       map[string]CoverageNode `json:"nodes"`
+	Edges       map[string]CoverageEdge `json:"edges"`
+	Totals      CoverageTotals          `json:"totals"`
+	GeneratedAt string                  `json:"generatedAt"`
+}
+
 type ScreenshotCaptureRequest struct {
 	SessionID    string `json:"sessionId,omitempty"`
+	OwnerToken   string `json:"ownerToken,omitempty"`
 	ControllerID string `json:"controllerId,omitempty"`
 	Force        bool   `json:"force,omitempty"`
 }
@@ -27,6 +27,11 @@ func DefaultCapabilityManifest() protocol.CapabilityManifest {
 			"action-detail",
 			"screenshot",
 			"performance-summary",
+			"performance-trace",
+			"coverage-report",
+			"replay-overlay",
+			"replay-gif",
+			"replay-frames",
 		},
 		ScreenshotSources: []string{
 			"manual",
@@ -36,11 +41,23 @@ func DefaultCapabilityManifest() protocol.CapabilityManifest {
 			"multi-resource",
 			"multi-agent",
 			"agent-run-profile",
+			"project-interface",
 		},
 		DebugFeatures: []string{
 			"trace-replay",
 			"performance-summary",
 			"agent-run-profile",
+			"controller-health",
+			"breakpoints",
+			"live-patch",
+			"session-attach",
+			"watch-expressions",
+			"coverage-report",
+			"flame-graph",
+			"trace-compare",
+			"concurrent-runs",
+			"event-subscription",
+			"replay-overlay",
 		},
 		Maa: protocol.MaaInfo{
 			MFWVersion: "unknown",
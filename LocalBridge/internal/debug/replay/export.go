@@ -0,0 +1,174 @@
+package replay
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color/palette"
+	"image/draw"
+	"image/gif"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/debug/protocol"
+	xdraw "golang.org/x/image/draw"
+)
+
+const (
+	defaultExportFrames = 300
+	maxExportFrames     = 1000
+	// GIF 帧长边上限，控制导出体积
+	gifLongSide = 720
+
+	minFrameDelay  = 100 * time.Millisecond
+	maxFrameDelay  = 3 * time.Second
+	lastFrameDelay = time.Second
+)
+
+// Export 将回放中的识别步骤导出为 GIF 或逐帧 PNG 序列，便于附在问题反馈中
+func (s *Service) Export(req protocol.TraceReplayExportRequest) (protocol.TraceReplayExport, error) {
+	sessionID := strings.TrimSpace(req.SessionID)
+	if sessionID == "" {
+		return protocol.TraceReplayExport{}, fmt.Errorf("缺少 sessionId")
+	}
+	if s.artifacts == nil {
+		return protocol.TraceReplayExport{}, fmt.Errorf("artifact store 不可用")
+	}
+	format := strings.TrimSpace(req.Format)
+	if format == "" {
+		format = protocol.TraceReplayExportGIF
+	}
+	if format != protocol.TraceReplayExportGIF && format != protocol.TraceReplayExportFrames {
+		return protocol.TraceReplayExport{}, fmt.Errorf("不支持的导出格式: %s", req.Format)
+	}
+
+	runID := strings.TrimSpace(req.RunID)
+	events := filterNodeEvents(s.traces.ListRun(sessionID, runID), strings.TrimSpace(req.NodeID))
+	sources := make([]frameSource, 0)
+	for _, source := range collectFrames(events) {
+		seq := source.recognition.Seq
+		if (req.FromSeq > 0 && seq < req.FromSeq) || (req.ToSeq > 0 && seq > req.ToSeq) {
+			continue
+		}
+		sources = append(sources, source)
+	}
+
+	limit := req.MaxFrames
+	if limit <= 0 {
+		limit = defaultExportFrames
+	}
+	if limit > maxExportFrames {
+		limit = maxExportFrames
+	}
+	result := protocol.TraceReplayExport{
+		SessionID: sessionID,
+		RunID:     runID,
+		Format:    format,
+		Frames:    make([]protocol.TraceReplayExportFrame, 0),
+	}
+	if len(sources) > limit {
+		sources = sources[:limit]
+		result.Truncated = true
+	}
+
+	images := make([]image.Image, 0, len(sources))
+	for _, source := range sources {
+		frame := buildFrame(s.artifacts, source, true)
+		if frame.ScreenshotRef == "" {
+			result.SkippedFrames++
+			continue
+		}
+		exported := protocol.TraceReplayExportFrame{
+			RecognitionSeq: frame.RecognitionSeq,
+			ActionSeq:      frame.ActionSeq,
+			RuntimeName:    frame.RuntimeName,
+			Timestamp:      frame.Timestamp,
+		}
+		if format == protocol.TraceReplayExportFrames {
+			exported.OverlayRef, _, _ = s.overlayRef(sessionID, source.key(true), frame)
+			if exported.OverlayRef == "" {
+				result.SkippedFrames++
+				continue
+			}
+		} else {
+			base, err := s.artifacts.Image(sessionID, frame.ScreenshotRef)
+			if err != nil {
+				result.SkippedFrames++
+				continue
+			}
+			images = append(images, renderOverlay(base, frame))
+		}
+		result.Frames = append(result.Frames, exported)
+	}
+	if len(result.Frames) == 0 {
+		return protocol.TraceReplayExport{}, fmt.Errorf("没有可导出的识别画面，请确认运行时开启了原图 artifact")
+	}
+	assignDelays(result.Frames)
+	result.FrameCount = len(result.Frames)
+
+	if format == protocol.TraceReplayExportFrames {
+		ref, err := s.artifacts.AddJSON(sessionID, "replay-frames", result)
+		if err != nil {
+			return protocol.TraceReplayExport{}, err
+		}
+		result.ArtifactRef = ref
+		return result, nil
+	}
+
+	content, err := encodeGIF(images, result.Frames)
+	if err != nil {
+		return protocol.TraceReplayExport{}, err
+	}
+	ref, err := s.artifacts.AddBinary(sessionID, "replay-gif", "image/gif", content)
+	if err != nil {
+		return protocol.TraceReplayExport{}, err
+	}
+	result.ArtifactRef = ref
+	return result, nil
+}
+
+// 帧间隔取相邻识别的真实时间差，并限制在可观看的范围内
+func assignDelays(frames []protocol.TraceReplayExportFrame) {
+	for i := range frames {
+		delay := lastFrameDelay
+		if i+1 < len(frames) {
+			current, currentErr := time.Parse(time.RFC3339Nano, frames[i].Timestamp)
+			next, nextErr := time.Parse(time.RFC3339Nano, frames[i+1].Timestamp)
+			if currentErr == nil && nextErr == nil {
+				delay = min(max(next.Sub(current), minFrameDelay), maxFrameDelay)
+			} else {
+				delay = minFrameDelay
+			}
+		}
+		frames[i].DelayMs = delay.Milliseconds()
+	}
+}
+
+func encodeGIF(images []image.Image, frames []protocol.TraceReplayExportFrame) ([]byte, error) {
+	first := images[0].Bounds()
+	scale := math.Min(1, float64(gifLongSide)/float64(max(first.Dx(), first.Dy())))
+	width := max(1, int(math.Round(float64(first.Dx())*scale)))
+	height := max(1, int(math.Round(float64(first.Dy())*scale)))
+	canvas := image.Rect(0, 0, width, height)
+
+	animation := &gif.GIF{
+		Image: make([]*image.Paletted, 0, len(images)),
+		Delay: make([]int, 0, len(images)),
+	}
+	for i, img := range images {
+		scaled := image.NewRGBA(canvas)
+		xdraw.ApproxBiLinear.Scale(scaled, canvas, img, img.Bounds(), draw.Src, nil)
+		paletted := image.NewPaletted(canvas, palette.Plan9)
+		draw.FloydSteinberg.Draw(paletted, canvas, scaled, image.Point{})
+		animation.Image = append(animation.Image, paletted)
+		// GIF 延迟单位为 1/100 秒
+		animation.Delay = append(animation.Delay, int(frames[i].DelayMs/10))
+	}
+
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, animation); err != nil {
+		return nil, fmt.Errorf("编码 GIF 失败: %w", err)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,288 @@
+package replay
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"reflect"
+	"sort"
+
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/debug/artifact"
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/debug/protocol"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+// 单帧最多绘制的候选框数量，避免 OCR 等结果过多时遮挡画面
+const maxCandidateShapes = 20
+
+var shapeColors = map[string]color.RGBA{
+	protocol.OverlayShapeROI:       {R: 0x3b, G: 0x82, B: 0xf6, A: 0xff},
+	protocol.OverlayShapeCandidate: {R: 0xf5, G: 0x9e, B: 0x0b, A: 0xff},
+	protocol.OverlayShapeHit:       {R: 0x22, G: 0xc5, B: 0x5e, A: 0xff},
+	protocol.OverlayShapeMiss:      {R: 0xef, G: 0x44, B: 0x44, A: 0xff},
+	protocol.OverlayShapeAction:    {R: 0xd9, G: 0x46, B: 0xef, A: 0xff},
+}
+
+// 按绘制顺序排列，后绘制的位于上层
+var shapeOrder = map[string]int{
+	protocol.OverlayShapeROI:       0,
+	protocol.OverlayShapeCandidate: 1,
+	protocol.OverlayShapeMiss:      2,
+	protocol.OverlayShapeHit:       3,
+	protocol.OverlayShapeAction:    4,
+}
+
+// frameSource 是一次识别及其命中后执行的动作
+type frameSource struct {
+	recognition protocol.Event
+	action      *protocol.Event
+}
+
+func (f frameSource) key(withAction bool) string {
+	if withAction && f.action != nil {
+		return fmt.Sprintf("%d:%d", f.recognition.Seq, f.action.Seq)
+	}
+	return fmt.Sprintf("%d", f.recognition.Seq)
+}
+
+// collectFrames 以识别结束事件为帧，动作事件归入同节点最近一次命中的识别
+func collectFrames(events []protocol.Event) []frameSource {
+	frames := make([]frameSource, 0)
+	lastHit := -1
+	for _, event := range events {
+		if event.Phase != "succeeded" && event.Phase != "failed" {
+			continue
+		}
+		switch event.Kind {
+		case "recognition":
+			frames = append(frames, frameSource{recognition: event})
+			if event.Phase == "succeeded" {
+				lastHit = len(frames) - 1
+			}
+		case "action":
+			if lastHit < 0 || frames[lastHit].action != nil || runtimeName(frames[lastHit].recognition) != runtimeName(event) {
+				continue
+			}
+			action := event
+			frames[lastHit].action = &action
+		}
+	}
+	return frames
+}
+
+// frameAt 返回游标处可见的帧，游标尚未到达的动作不计入
+func frameAt(frames []frameSource, cursorSeq int64) (frameSource, bool, bool) {
+	index := sort.Search(len(frames), func(i int) bool {
+		return frames[i].recognition.Seq > cursorSeq
+	}) - 1
+	if index < 0 {
+		return frameSource{}, false, false
+	}
+	frame := frames[index]
+	withAction := frame.action != nil && frame.action.Seq <= cursorSeq
+	return frame, withAction, true
+}
+
+// buildFrame 从识别与动作详情中提取叠加图形；artifact 缺失时退回事件自带的数据
+func buildFrame(artifacts *artifact.Store, frame frameSource, withAction bool) protocol.TraceReplayFrame {
+	recognition := frame.recognition
+	data := detailData(artifacts, recognition.SessionID, recognition.DetailRef, recognition.Data)
+	hit := recognition.Phase == "succeeded"
+	if value, ok := data["hit"].(bool); ok {
+		hit = value
+	}
+
+	result := protocol.TraceReplayFrame{
+		RecognitionSeq: recognition.Seq,
+		RuntimeName:    runtimeName(recognition),
+		Timestamp:      recognition.Timestamp,
+		Hit:            hit,
+		ScreenshotRef:  recognition.ScreenshotRef,
+		Shapes:         make([]protocol.OverlayShape, 0),
+	}
+	if rawRef, ok := data["rawImageRef"].(string); ok && rawRef != "" {
+		result.ScreenshotRef = rawRef
+	}
+
+	if roi, ok := parseBox(data["roi"]); ok {
+		result.Shapes = append(result.Shapes, protocol.OverlayShape{Kind: protocol.OverlayShapeROI, Box: roi, Label: "ROI"})
+	}
+	hitBox, hasHitBox := parseBox(data["box"])
+	candidates := 0
+	for _, candidate := range candidateResults(data["detail"]) {
+		box, ok := parseBox(candidate["box"])
+		if !ok || (hasHitBox && box == hitBox) {
+			continue
+		}
+		if candidates >= maxCandidateShapes {
+			break
+		}
+		score, _ := candidate["score"].(float64)
+		result.Shapes = append(result.Shapes, protocol.OverlayShape{Kind: protocol.OverlayShapeCandidate, Box: box, Score: score})
+		candidates++
+	}
+	if hasHitBox {
+		kind := protocol.OverlayShapeHit
+		if !hit {
+			kind = protocol.OverlayShapeMiss
+		}
+		score, _ := data["score"].(float64)
+		result.Shapes = append(result.Shapes, protocol.OverlayShape{Kind: kind, Box: hitBox, Label: result.RuntimeName, Score: score})
+	}
+
+	if withAction && frame.action != nil {
+		action := frame.action
+		result.ActionSeq = action.Seq
+		actionData := detailData(artifacts, action.SessionID, action.DetailRef, action.Data)
+		if box, ok := parseBox(actionData["box"]); ok {
+			label, _ := actionData["action"].(string)
+			result.Shapes = append(result.Shapes, protocol.OverlayShape{Kind: protocol.OverlayShapeAction, Box: box, Label: label})
+		}
+	}
+
+	sort.SliceStable(result.Shapes, func(i, j int) bool {
+		return shapeOrder[result.Shapes[i].Kind] < shapeOrder[result.Shapes[j].Kind]
+	})
+	return result
+}
+
+func detailData(artifacts *artifact.Store, sessionID string, detailRef string, fallback map[string]interface{}) map[string]interface{} {
+	if artifacts != nil && detailRef != "" {
+		if payload, err := artifacts.Get(sessionID, detailRef); err == nil {
+			if data, ok := payload.Data.(map[string]interface{}); ok {
+				return data
+			}
+		}
+	}
+	if fallback == nil {
+		return map[string]interface{}{}
+	}
+	return fallback
+}
+
+// 识别详情中的候选结果，优先使用过滤后的结果
+func candidateResults(detail interface{}) []map[string]interface{} {
+	detailMap, ok := detail.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	list, ok := detailMap["filtered"].([]interface{})
+	if !ok || len(list) == 0 {
+		list, _ = detailMap["all"].([]interface{})
+	}
+	results := make([]map[string]interface{}, 0, len(list))
+	for _, item := range list {
+		if result, ok := item.(map[string]interface{}); ok {
+			results = append(results, result)
+		}
+	}
+	return results
+}
+
+// parseBox 兼容 MaaFW Rect、[]int 以及 JSON 反序列化得到的 []interface{}
+func parseBox(value interface{}) ([4]int, bool) {
+	if value == nil {
+		return [4]int{}, false
+	}
+	v := reflect.ValueOf(value)
+	if (v.Kind() != reflect.Array && v.Kind() != reflect.Slice) || v.Len() != 4 {
+		return [4]int{}, false
+	}
+	var box [4]int
+	for i := 0; i < 4; i++ {
+		item := v.Index(i)
+		if item.Kind() == reflect.Interface {
+			item = item.Elem()
+		}
+		switch item.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			box[i] = int(item.Int())
+		case reflect.Float32, reflect.Float64:
+			box[i] = int(item.Float())
+		default:
+			return [4]int{}, false
+		}
+	}
+	if box[2] <= 0 || box[3] <= 0 {
+		return [4]int{}, false
+	}
+	return box, true
+}
+
+func runtimeName(event protocol.Event) string {
+	if event.Node == nil {
+		return ""
+	}
+	return event.Node.RuntimeName
+}
+
+// renderOverlay 在截图上绘制识别区域、候选框、命中框与动作目标
+func renderOverlay(base image.Image, frame protocol.TraceReplayFrame) *image.RGBA {
+	bounds := base.Bounds()
+	canvas := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(canvas, canvas.Bounds(), base, bounds.Min, draw.Src)
+
+	thickness := max(2, bounds.Dx()/480)
+	for _, shape := range frame.Shapes {
+		rect := image.Rect(shape.Box[0], shape.Box[1], shape.Box[0]+shape.Box[2], shape.Box[1]+shape.Box[3]).Intersect(canvas.Bounds())
+		if rect.Empty() {
+			continue
+		}
+		shapeColor := shapeColors[shape.Kind]
+		strokeRect(canvas, rect, thickness, shapeColor)
+		if label := shapeLabel(shape); label != "" {
+			drawLabel(canvas, rect.Min, label, shapeColor)
+		}
+	}
+	return canvas
+}
+
+func shapeLabel(shape protocol.OverlayShape) string {
+	switch {
+	case shape.Label != "" && shape.Score != 0:
+		return fmt.Sprintf("%s %.3f", shape.Label, shape.Score)
+	case shape.Label != "":
+		return shape.Label
+	case shape.Score != 0:
+		return fmt.Sprintf("%.3f", shape.Score)
+	default:
+		return ""
+	}
+}
+
+func strokeRect(canvas *image.RGBA, rect image.Rectangle, thickness int, c color.RGBA) {
+	src := image.NewUniform(c)
+	edges := []image.Rectangle{
+		image.Rect(rect.Min.X, rect.Min.Y, rect.Max.X, rect.Min.Y+thickness),
+		image.Rect(rect.Min.X, rect.Max.Y-thickness, rect.Max.X, rect.Max.Y),
+		image.Rect(rect.Min.X, rect.Min.Y, rect.Min.X+thickness, rect.Max.Y),
+		image.Rect(rect.Max.X-thickness, rect.Min.Y, rect.Max.X, rect.Max.Y),
+	}
+	for _, edge := range edges {
+		draw.Draw(canvas, edge.Intersect(rect), src, image.Point{}, draw.Src)
+	}
+}
+
+// 标签绘制在框的左上角外侧，空间不足时移入框内
+func drawLabel(canvas *image.RGBA, at image.Point, text string, background color.RGBA) {
+	face := basicfont.Face7x13
+	width := font.MeasureString(face, text).Ceil() + 4
+	height := face.Metrics().Height.Ceil() + 2
+	top := at.Y - height
+	if top < canvas.Bounds().Min.Y {
+		top = at.Y
+	}
+	box := image.Rect(at.X, top, at.X+width, top+height).Intersect(canvas.Bounds())
+	draw.Draw(canvas, box, image.NewUniform(background), image.Point{}, draw.Src)
+
+	drawer := font.Drawer{
+		Dst:  canvas,
+		Src:  image.White,
+		Face: face,
+		Dot:  fixed.P(box.Min.X+2, box.Min.Y+face.Metrics().Ascent.Ceil()+1),
+	}
+	drawer.DrawString(text)
+}
@@ -0,0 +1,185 @@
+package replay
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	"image/color"
+	"image/gif"
+	"testing"
+	"time"
+
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/debug/artifact"
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/debug/protocol"
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/debug/trace"
+)
+
+type rect [4]int
+
+func TestParseBox(t *testing.T) {
+	tests := []struct {
+		name  string
+		value interface{}
+		want  [4]int
+		ok    bool
+	}{
+		{name: "named array", value: rect{1, 2, 3, 4}, want: [4]int{1, 2, 3, 4}, ok: true},
+		{name: "int slice", value: []int{5, 6, 7, 8}, want: [4]int{5, 6, 7, 8}, ok: true},
+		{name: "json slice", value: []interface{}{1.0, 2.0, 30.0, 40.0}, want: [4]int{1, 2, 30, 40}, ok: true},
+		{name: "empty box", value: []int{0, 0, 0, 0}},
+		{name: "wrong length", value: []int{1, 2, 3}},
+		{name: "string", value: "Login"},
+		{name: "nil"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, ok := parseBox(test.value)
+			if ok != test.ok || got != test.want {
+				t.Fatalf("parseBox() = %v, %v, want %v, %v", got, ok, test.want, test.ok)
+			}
+		})
+	}
+}
+
+func TestServiceSeekRendersOverlay(t *testing.T) {
+	traces, artifacts := replayFixture(t)
+	service := NewService(traces, artifacts)
+
+	status, err := service.Seek(protocol.TraceReplayRequest{SessionID: "session-1", RunID: "run-1", CursorSeq: 3, Overlay: true})
+	if err != nil {
+		t.Fatalf("Seek() error = %v", err)
+	}
+	frame := status.Frame
+	if frame == nil || frame.RecognitionSeq != 2 || !frame.Hit || frame.ActionSeq != 0 {
+		t.Fatalf("Frame = %+v", frame)
+	}
+	kinds := make([]string, 0, len(frame.Shapes))
+	for _, shape := range frame.Shapes {
+		kinds = append(kinds, shape.Kind)
+	}
+	if len(kinds) != 3 || kinds[0] != "roi" || kinds[1] != "candidate" || kinds[2] != "hit" {
+		t.Fatalf("Shapes = %+v", frame.Shapes)
+	}
+	if frame.OverlayRef == "" || frame.Width != 320 || frame.Height != 180 {
+		t.Fatalf("Frame = %+v", frame)
+	}
+
+	overlay, err := artifacts.Image("session-1", frame.OverlayRef)
+	if err != nil {
+		t.Fatalf("Image() error = %v", err)
+	}
+	if got := color.RGBAModel.Convert(overlay.At(100, 50)).(color.RGBA); got != shapeColors["hit"] {
+		t.Fatalf("hit box edge color = %v", got)
+	}
+
+	status, err = service.Seek(protocol.TraceReplayRequest{SessionID: "session-1", RunID: "run-1", CursorSeq: 4, Overlay: true})
+	if err != nil {
+		t.Fatalf("Seek() error = %v", err)
+	}
+	if status.Frame.ActionSeq != 4 || status.Frame.Shapes[len(status.Frame.Shapes)-1].Kind != "action" {
+		t.Fatalf("Frame with action = %+v", status.Frame)
+	}
+	if status.Frame.OverlayRef == frame.OverlayRef {
+		t.Fatal("overlay with action should be rendered separately")
+	}
+
+	again, _ := service.Seek(protocol.TraceReplayRequest{SessionID: "session-1", RunID: "run-1", CursorSeq: 3, Overlay: true})
+	if again.Frame.OverlayRef != frame.OverlayRef {
+		t.Fatalf("cached overlay = %s, want %s", again.Frame.OverlayRef, frame.OverlayRef)
+	}
+}
+
+func TestServiceExport(t *testing.T) {
+	traces, artifacts := replayFixture(t)
+	service := NewService(traces, artifacts)
+
+	exported, err := service.Export(protocol.TraceReplayExportRequest{SessionID: "session-1", RunID: "run-1"})
+	if err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+	if exported.FrameCount != 2 || exported.ArtifactRef.Mime != "image/gif" {
+		t.Fatalf("Export() = %+v", exported)
+	}
+	if exported.Frames[0].DelayMs != 1500 || exported.Frames[1].DelayMs != lastFrameDelay.Milliseconds() {
+		t.Fatalf("delays = %+v", exported.Frames)
+	}
+	payload, err := artifacts.Get("session-1", exported.ArtifactRef.ID)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	content, _ := base64.StdEncoding.DecodeString(payload.Content)
+	animation, err := gif.DecodeAll(bytes.NewReader(content))
+	if err != nil {
+		t.Fatalf("decode gif: %v", err)
+	}
+	if len(animation.Image) != 2 || animation.Delay[0] != 150 {
+		t.Fatalf("gif frames = %d, delays = %v", len(animation.Image), animation.Delay)
+	}
+
+	frames, err := service.Export(protocol.TraceReplayExportRequest{SessionID: "session-1", RunID: "run-1", Format: "frames", MaxFrames: 1})
+	if err != nil {
+		t.Fatalf("Export(frames) error = %v", err)
+	}
+	if frames.FrameCount != 1 || !frames.Truncated || frames.Frames[0].OverlayRef == "" || frames.ArtifactRef.Type != "replay-frames" {
+		t.Fatalf("Export(frames) = %+v", frames)
+	}
+
+	if _, err := service.Export(protocol.TraceReplayExportRequest{SessionID: "session-1", RunID: "run-1", Format: "mp4"}); err == nil {
+		t.Fatal("Export() error = nil for unsupported format")
+	}
+}
+
+// 两次识别：第一次命中并执行点击，第二次未命中
+func replayFixture(t *testing.T) (*trace.Store, *artifact.Store) {
+	t.Helper()
+	traces := trace.NewStore()
+	artifacts := artifact.NewStore()
+
+	screen := image.NewRGBA(image.Rect(0, 0, 320, 180))
+	screenshot, err := artifacts.AddPNG("session-1", "recognition-raw-image", screen)
+	if err != nil {
+		t.Fatalf("AddPNG() error = %v", err)
+	}
+	hitDetail, _ := artifacts.AddJSON("session-1", "recognition-detail", map[string]interface{}{
+		"hit":   true,
+		"box":   rect{100, 40, 60, 30},
+		"roi":   [4]int{80, 20, 200, 100},
+		"score": 0.93,
+		"detail": map[string]interface{}{
+			"filtered": []interface{}{
+				map[string]interface{}{"box": []interface{}{100.0, 40.0, 60.0, 30.0}, "score": 0.93},
+				map[string]interface{}{"box": []interface{}{10.0, 120.0, 60.0, 30.0}, "score": 0.81},
+			},
+		},
+		"rawImageRef": screenshot.ID,
+	})
+	missDetail, _ := artifacts.AddJSON("session-1", "recognition-detail", map[string]interface{}{
+		"hit":         false,
+		"rawImageRef": screenshot.ID,
+	})
+	actionDetail, _ := artifacts.AddJSON("session-1", "action-detail", map[string]interface{}{
+		"action": "Click",
+		"box":    rect{110, 45, 40, 20},
+	})
+
+	origin := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	node := &protocol.EventNode{RuntimeName: "Login"}
+	events := []protocol.Event{
+		{Kind: "recognition", Phase: "starting", Node: node},
+		{Kind: "recognition", Phase: "succeeded", Node: node, DetailRef: hitDetail.ID, ScreenshotRef: screenshot.ID},
+		{Kind: "action", Phase: "starting", Node: node},
+		{Kind: "action", Phase: "succeeded", Node: node, DetailRef: actionDetail.ID},
+		{Kind: "recognition", Phase: "failed", Node: &protocol.EventNode{RuntimeName: "Reward"}, DetailRef: missDetail.ID, ScreenshotRef: screenshot.ID},
+	}
+	offsets := []int{0, 100, 200, 300, 1600}
+	for i, event := range events {
+		event.SessionID = "session-1"
+		event.RunID = "run-1"
+		event.Timestamp = origin.Add(time.Duration(offsets[i]) * time.Millisecond).Format(time.RFC3339Nano)
+		if _, err := traces.Append(event); err != nil {
+			t.Fatalf("Append() error = %v", err)
+		}
+	}
+	return traces, artifacts
+}
@@ -6,21 +6,34 @@ import (
 	"sync"
 	"time"
 
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/debug/artifact"
 	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/debug/protocol"
 	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/debug/trace"
 )
 
 type Service struct {
-	traces *trace.Store
+	traces    *trace.Store
+	artifacts *artifact.Store
 
-	mu       sync.Mutex
-	statuses map[string]protocol.TraceReplayStatus
+	mu sync.Mutex
+	// sessionID -> runID -> 回放状态，同一会话内不同 run 可各自回放
+	statuses map[string]map[string]protocol.TraceReplayStatus
+	// sessionID -> 帧 key -> 叠加图 artifact，反复 seek 时不重复绘制
+	overlays map[string]map[string]overlayEntry
 }
 
-func NewService(traces *trace.Store) *Service {
+type overlayEntry struct {
+	ref    string
+	width  int
+	height int
+}
+
+func NewService(traces *trace.Store, artifacts *artifact.Store) *Service {
 	return &Service{
-		traces:   traces,
-		statuses: make(map[string]protocol.TraceReplayStatus),
+		traces:    traces,
+		artifacts: artifacts,
+		statuses:  make(map[string]map[string]protocol.TraceReplayStatus),
+		overlays:  make(map[string]map[string]overlayEntry),
 	}
 }
 
@@ -64,9 +77,13 @@ func (s *Service) Start(req protocol.TraceReplayRequest) (protocol.TraceReplaySt
 		Speed:     normalizeSpeed(req.Speed),
 		StartedAt: now,
 		UpdatedAt: now,
+		Overlay:   req.Overlay,
+	}
+	if req.Overlay {
+		status.Frame = s.frame(sessionID, events, cursor)
 	}
 	s.mu.Lock()
-	s.statuses[sessionID] = status
+	s.setStatusLocked(status)
 	s.mu.Unlock()
 	return status, nil
 }
@@ -86,13 +103,14 @@ func (s *Service) Seek(req protocol.TraceReplayRequest) (protocol.TraceReplaySta
 		cursor = maxSeq
 	}
 
+	runID := strings.TrimSpace(req.RunID)
 	s.mu.Lock()
-	status := s.statuses[sessionID]
+	status := s.statuses[sessionID][runID]
 	if status.SessionID == "" {
 		status.SessionID = sessionID
 		status.StartedAt = time.Now().UTC().Format(time.RFC3339Nano)
 	}
-	status.RunID = strings.TrimSpace(req.RunID)
+	status.RunID = runID
 	status.Active = true
 	status.Playing = false
 	status.CursorSeq = cursor
@@ -101,8 +119,14 @@ func (s *Service) Seek(req protocol.TraceReplayRequest) (protocol.TraceReplaySta
 	status.NodeID = strings.TrimSpace(req.NodeID)
 	status.Speed = normalizeSpeed(req.Speed)
 	status.UpdatedAt = time.Now().UTC().Format(time.RFC3339Nano)
-	s.statuses[sessionID] = status
+	status.Overlay = req.Overlay
+	status.Frame = nil
+	s.setStatusLocked(status)
 	s.mu.Unlock()
+
+	if req.Overlay {
+		status.Frame = s.frame(sessionID, events, cursor)
+	}
 	return status, nil
 }
 
@@ -111,19 +135,36 @@ func (s *Service) Stop(req protocol.TraceReplayStopRequest) (protocol.TraceRepla
 	if sessionID == "" {
 		return protocol.TraceReplayStatus{}, fmt.Errorf("缺少 sessionId")
 	}
+	// 未指定 runId 时停止会话内全部回放
+	runID := strings.TrimSpace(req.RunID)
 	s.mu.Lock()
-	status := s.statuses[sessionID]
+	runs := s.statuses[sessionID]
+	status, ok := runs[runID]
+	if !ok && runID == "" && len(runs) == 1 {
+		for _, only := range runs {
+			status = only
+		}
+	}
 	if status.SessionID == "" {
 		status.SessionID = sessionID
+		status.RunID = runID
 	}
 	status.Active = false
 	status.Playing = false
+	status.Frame = nil
 	status.StoppedAt = time.Now().UTC().Format(time.RFC3339Nano)
 	status.Reason = strings.TrimSpace(req.Reason)
 	if status.Reason == "" {
 		status.Reason = "user_stop"
 	}
-	delete(s.statuses, sessionID)
+	if runID == "" {
+		delete(s.statuses, sessionID)
+	} else if runs != nil {
+		delete(runs, runID)
+		if len(runs) == 0 {
+			delete(s.statuses, sessionID)
+		}
+	}
 	s.mu.Unlock()
 	return status, nil
 }
@@ -132,6 +173,59 @@ func (s *Service) StopSession(sessionID string) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	delete(s.statuses, sessionID)
+	delete(s.overlays, sessionID)
+}
+
+// frame 生成游标处的叠加帧；有截图时绘制叠加图并缓存为 artifact
+func (s *Service) frame(sessionID string, events []protocol.Event, cursor int64) *protocol.TraceReplayFrame {
+	source, withAction, ok := frameAt(collectFrames(events), cursor)
+	if !ok {
+		return nil
+	}
+	frame := buildFrame(s.artifacts, source, withAction)
+	frame.OverlayRef, frame.Width, frame.Height = s.overlayRef(sessionID, source.key(withAction), frame)
+	return &frame
+}
+
+func (s *Service) overlayRef(sessionID string, key string, frame protocol.TraceReplayFrame) (string, int, int) {
+	if s.artifacts == nil || frame.ScreenshotRef == "" {
+		return "", 0, 0
+	}
+	s.mu.Lock()
+	cached, ok := s.overlays[sessionID][key]
+	s.mu.Unlock()
+	if ok {
+		return cached.ref, cached.width, cached.height
+	}
+
+	base, err := s.artifacts.Image(sessionID, frame.ScreenshotRef)
+	if err != nil {
+		return "", 0, 0
+	}
+	canvas := renderOverlay(base, frame)
+	ref, err := s.artifacts.AddPNG(sessionID, "replay-overlay", canvas)
+	if err != nil {
+		return "", 0, 0
+	}
+	s.artifacts.SetEventSeq(sessionID, ref.ID, frame.RecognitionSeq)
+
+	entry := overlayEntry{ref: ref.ID, width: canvas.Bounds().Dx(), height: canvas.Bounds().Dy()}
+	s.mu.Lock()
+	if s.overlays[sessionID] == nil {
+		s.overlays[sessionID] = make(map[string]overlayEntry)
+	}
+	s.overlays[sessionID][key] = entry
+	s.mu.Unlock()
+	return entry.ref, entry.width, entry.height
+}
+
+func (s *Service) setStatusLocked(status protocol.TraceReplayStatus) {
+	runs := s.statuses[status.SessionID]
+	if runs == nil {
+		runs = make(map[string]protocol.TraceReplayStatus)
+		s.statuses[status.SessionID] = runs
+	}
+	runs[status.RunID] = status
 }
 
 func filterNodeEvents(events []protocol.Event, nodeID string) []protocol.Event {
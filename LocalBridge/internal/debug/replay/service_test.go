@@ -0,0 +1,49 @@
+package replay
+
+import (
+	"testing"
+
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/debug/artifact"
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/debug/protocol"
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/debug/trace"
+)
+
+func TestServiceReplaysRunsIndependently(t *testing.T) {
+	traces := trace.NewStore()
+	for _, runID := range []string{"run-1", "run-2", "run-1", "run-2"} {
+		if _, err := traces.Append(protocol.Event{SessionID: "session-1", RunID: runID, Kind: "node"}); err != nil {
+			t.Fatalf("Append() error = %v", err)
+		}
+	}
+	service := NewService(traces, artifact.NewStore())
+
+	first, err := service.Start(protocol.TraceReplayRequest{SessionID: "session-1", RunID: "run-1"})
+	if err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	if first.MinSeq != 1 || first.MaxSeq != 3 {
+		t.Fatalf("run-1 bounds = %d..%d, want 1..3", first.MinSeq, first.MaxSeq)
+	}
+	if _, err := service.Seek(protocol.TraceReplayRequest{SessionID: "session-1", RunID: "run-2", CursorSeq: 4}); err != nil {
+		t.Fatalf("Seek() error = %v", err)
+	}
+
+	stopped, err := service.Stop(protocol.TraceReplayStopRequest{SessionID: "session-1", RunID: "run-2"})
+	if err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+	if stopped.RunID != "run-2" || stopped.CursorSeq != 4 || stopped.Active {
+		t.Fatalf("Stop() = %+v", stopped)
+	}
+	if remaining := service.statuses["session-1"]["run-1"]; !remaining.Active || !remaining.Playing {
+		t.Fatalf("run-1 replay = %+v, want still playing", remaining)
+	}
+
+	stopped, err = service.Stop(protocol.TraceReplayStopRequest{SessionID: "session-1"})
+	if err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+	if stopped.RunID != "run-1" || len(service.statuses) != 0 {
+		t.Fatalf("Stop() without runId = %+v, statuses = %+v", stopped, service.statuses)
+	}
+}
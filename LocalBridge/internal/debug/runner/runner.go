@@ -8,30 +8,41 @@ import (
 	"github.com/google/uuid"
 	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/debug/artifact"
 	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/debug/diagnostics"
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/debug/events"
 	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/debug/performance"
 	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/debug/protocol"
 	debugruntime "github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/debug/runtime"
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/debug/runutil"
 	debugsession "github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/debug/session"
 	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/debug/trace"
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/debug/watch"
 	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/logger"
 	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/mfw"
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/workspace"
 )
 
+// 单个会话允许同时运行的 run 数量上限
+const maxActiveRunsPerSession = 8
+
 type EventSender func(protocol.Event)
 type SnapshotSender func(debugsession.Snapshot)
 
 type Runner struct {
 	service     *mfw.Service
-	root        string
+	roots       workspace.Roots
 	sessions    *debugsession.Manager
 	traces      *trace.Store
 	artifacts   *artifact.Store
 	diagnostics *diagnostics.Service
 	performance *performance.Service
 	agentPool   *debugruntime.AgentPool
+	watches     *watch.Registry
 
-	mu     sync.Mutex
-	active map[string]*Run
+	mu sync.Mutex
+	// sessionID -> runID -> run
+	active map[string]map[string]*Run
+	// 非空时拒绝新的 run，用于重载工作区前排空
+	suspended string
 }
 
 type Run struct {
@@ -40,8 +51,13 @@ type Run struct {
 	Mode      protocol.RunMode
 	Entry     string
 	StartedAt time.Time
-	Runtime   *debugruntime.Runtime
-	Done      chan struct{}
+	// 实时控制器 ID，同一会话内的并发 run 不能共用控制器
+	ControllerID string
+	Runtime      *debugruntime.Runtime
+	Done         chan struct{}
+
+	// 解除控制器独占（停止实时推流并阻止新推流）
+	releaseController func()
 
 	mu            sync.RWMutex
 	stopRequested bool
@@ -67,14 +83,15 @@ func New(
 ) *Runner {
 	return &Runner{
 		service:     service,
-		root:        root,
+		roots:       workspace.Single(root),
 		sessions:    sessions,
 		traces:      traces,
 		artifacts:   artifacts,
 		diagnostics: diagnostics.NewService(service, root),
 		performance: performance.NewService(traces, artifacts),
 		agentPool:   debugruntime.NewAgentPool(),
-		active:      make(map[string]*Run),
+		watches:     watch.NewRegistry(),
+		active:      make(map[string]map[string]*Run),
 	}
 }
 
@@ -87,12 +104,17 @@ func (r *Runner) Start(
 		return StartResult{}, fmt.Errorf("缺少必需参数: sessionId")
 	}
 
-	r.mu.Lock()
-	if existing := r.active[req.SessionID]; existing != nil {
-		r.mu.Unlock()
-		return StartResult{}, fmt.Errorf("debug session 已有运行中的 run: %s", existing.ID)
+	controllerID := ""
+	if runutil.UsesLiveController(req.Mode) {
+		controllerID = runutil.ControllerIDFromOptions(req.Profile.Controller.Options)
 	}
+	r.mu.Lock()
+	err := r.checkStartLocked(req.SessionID, controllerID)
+	roots := r.roots
 	r.mu.Unlock()
+	if err != nil {
+		return StartResult{}, err
+	}
 
 	runID := uuid.NewString()
 	entry, err := debugruntime.EntryForRequest(req)
@@ -126,29 +148,47 @@ func (r *Runner) Start(
 		return StartResult{}, err
 	}
 
-	runtime, err := debugruntime.New(r.service, r.root, req.SessionID, runID, req, r.artifacts, r.agentPool, r.emitFunc(eventSender))
+	runtime, err := debugruntime.New(r.service, roots, req.SessionID, runID, req, r.artifacts, r.agentPool, r.emitFunc(eventSender))
 	if err != nil {
 		r.failStart(req.SessionID, runID, err, eventSender, snapshotSender)
 		return StartResult{}, err
 	}
 
 	run := &Run{
-		ID:        runID,
-		SessionID: req.SessionID,
-		Mode:      req.Mode,
-		Entry:     entry,
-		StartedAt: time.Now().UTC(),
-		Runtime:   runtime,
-		Done:      make(chan struct{}),
-	}
+		ID:           runID,
+		SessionID:    req.SessionID,
+		Mode:         req.Mode,
+		Entry:        entry,
+		StartedAt:    time.Now().UTC(),
+		ControllerID: controllerID,
+		Runtime:      runtime,
+		Done:         make(chan struct{}),
+	}
+	runtime.Breakpoints().SetNotify(
+		func(info events.PauseInfo) {
+			r.onRunPaused(run, info, eventSender, snapshotSender)
+		},
+		func(info events.PauseInfo, action string) {
+			r.onRunResumed(run, info, action, eventSender, snapshotSender)
+		},
+	)
 
+	// 登记前设置释放函数，并发的停止与清理总能释放控制器占用
+	if controllerID != "" {
+		run.releaseController = r.service.StreamManager().Reserve(controllerID, "debug run "+runID)
+	}
 	r.mu.Lock()
-	if existing := r.active[req.SessionID]; existing != nil {
+	if err := r.checkStartLocked(req.SessionID, controllerID); err != nil {
 		r.mu.Unlock()
+		run.release()
 		runtime.Destroy()
-		return StartResult{}, fmt.Errorf("debug session 已有运行中的 run: %s", existing.ID)
+		r.failStart(req.SessionID, runID, err, eventSender, snapshotSender)
+		return StartResult{}, err
+	}
+	if r.active[req.SessionID] == nil {
+		r.active[req.SessionID] = make(map[string]*Run)
 	}
-	r.active[req.SessionID] = run
+	r.active[req.SessionID][runID] = run
 	r.mu.Unlock()
 
 	if err := runtime.Start(); err != nil {
@@ -166,6 +206,13 @@ func (r *Runner) Start(
 		return StartResult{}, err
 	}
 	sendSnapshot(snapshotSender, runningSnapshot)
+	if runtime.Breakpoints().Paused() {
+		// 首个节点即命中断点时，暂停通知可能早于 running 状态写入
+		if pausedSnapshot, err := r.sessions.SetPaused(req.SessionID, runID); err == nil {
+			runningSnapshot = pausedSnapshot
+			sendSnapshot(snapshotSender, pausedSnapshot)
+		}
+	}
 	r.emit(eventSender, protocol.Event{
 		SessionID: req.SessionID,
 		RunID:     runID,
@@ -198,18 +245,15 @@ func (r *Runner) Stop(
 	eventSender EventSender,
 	snapshotSender SnapshotSender,
 ) error {
-	run := r.activeRun(sessionID)
-	if run == nil {
-		return fmt.Errorf("debug session 没有运行中的 run: %s", sessionID)
-	}
-	if runID != "" && run.ID != runID {
-		return fmt.Errorf("runId 不匹配: active=%s request=%s", run.ID, runID)
+	run, err := r.controlledRun(sessionID, runID)
+	if err != nil {
+		return err
 	}
 
 	if !run.markStopRequested(reason) {
 		return nil
 	}
-	snapshot, err := r.sessions.SetStopping(sessionID)
+	snapshot, err := r.sessions.SetStopping(sessionID, run.ID)
 	if err != nil {
 		return err
 	}
@@ -229,16 +273,155 @@ func (r *Runner) Stop(
 	return run.Runtime.Stop()
 }
 
+// 请求在下一个节点开始时暂停
+func (r *Runner) Pause(sessionID string, runID string) error {
+	run, err := r.controlledRun(sessionID, runID)
+	if err != nil {
+		return err
+	}
+	return run.Runtime.Breakpoints().Pause()
+}
+
+func (r *Runner) Resume(sessionID string, runID string) error {
+	run, err := r.controlledRun(sessionID, runID)
+	if err != nil {
+		return err
+	}
+	return run.Runtime.Breakpoints().Resume()
+}
+
+// 单步执行到下一个节点
+func (r *Runner) Step(sessionID string, runID string) error {
+	run, err := r.controlledRun(sessionID, runID)
+	if err != nil {
+		return err
+	}
+	return run.Runtime.Breakpoints().Step()
+}
+
+// 热更新运行中的 pipeline
+func (r *Runner) Patch(sessionID string, runID string, overrides []protocol.PipelineOverride) ([]protocol.PipelinePatchChange, error) {
+	run, err := r.controlledRun(sessionID, runID)
+	if err != nil {
+		return nil, err
+	}
+	return run.Runtime.Patch(overrides)
+}
+
+// 定位控制目标；会话内有多个运行中的 run 时必须指定 runId
+func (r *Runner) controlledRun(sessionID string, runID string) (*Run, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	runs := r.active[sessionID]
+	if len(runs) == 0 {
+		return nil, fmt.Errorf("debug session 没有运行中的 run: %s", sessionID)
+	}
+	if runID != "" {
+		run := runs[runID]
+		if run == nil {
+			return nil, fmt.Errorf("debug session 中没有运行中的 run: %s", runID)
+		}
+		return run, nil
+	}
+	if len(runs) > 1 {
+		return nil, fmt.Errorf("debug session 有 %d 个运行中的 run，需指定 runId", len(runs))
+	}
+	for _, run := range runs {
+		return run, nil
+	}
+	return nil, nil
+}
+
+// 校验会话内并发 run 数量以及控制器占用，调用方需持有 r.mu；
+// 同一控制器同时只允许一个 run 使用，无论其属于哪个会话
+func (r *Runner) checkStartLocked(sessionID string, controllerID string) error {
+	if r.suspended != "" {
+		return fmt.Errorf("暂不接受新的运行: %s", r.suspended)
+	}
+	if len(r.active[sessionID]) >= maxActiveRunsPerSession {
+		return fmt.Errorf("debug session 运行中的 run 已达上限: %d", maxActiveRunsPerSession)
+	}
+	if controllerID == "" {
+		return nil
+	}
+	for _, runs := range r.active {
+		for _, existing := range runs {
+			if existing.ControllerID == controllerID {
+				return fmt.Errorf("控制器 %s 已被运行中的 run 占用: %s", controllerID, existing.ID)
+			}
+		}
+	}
+	return nil
+}
+
+func (r *Runner) onRunPaused(run *Run, info events.PauseInfo, eventSender EventSender, snapshotSender SnapshotSender) {
+	if run.wasStopRequested() {
+		return
+	}
+	snapshot, err := r.sessions.SetPaused(run.SessionID, run.ID)
+	if err == nil {
+		sendSnapshot(snapshotSender, snapshot)
+	}
+	data := map[string]interface{}{
+		"reason": info.Reason,
+	}
+	if info.Breakpoint != nil {
+		data["breakpoint"] = info.Breakpoint
+	}
+	r.emit(eventSender, protocol.Event{
+		SessionID: run.SessionID,
+		RunID:     run.ID,
+		Source:    "localbridge",
+		Kind:      "session",
+		Phase:     "paused",
+		Status:    "paused",
+		Node:      info.Node,
+		Data:      data,
+	})
+}
+
+func (r *Runner) onRunResumed(run *Run, info events.PauseInfo, action string, eventSender EventSender, snapshotSender SnapshotSender) {
+	if run.wasStopRequested() {
+		return
+	}
+	snapshot, err := r.sessions.SetRunning(run.SessionID, run.ID)
+	if err == nil {
+		sendSnapshot(snapshotSender, snapshot)
+	}
+	r.emit(eventSender, protocol.Event{
+		SessionID: run.SessionID,
+		RunID:     run.ID,
+		Source:    "localbridge",
+		Kind:      "session",
+		Phase:     "resumed",
+		Status:    "running",
+		Node:      info.Node,
+		Data: map[string]interface{}{
+			"action": action,
+		},
+	})
+}
+
 func (r *Runner) DisposeSession(sessionID string) {
-	run := r.activeRun(sessionID)
-	if run != nil {
+	r.mu.Lock()
+	runs := make([]*Run, 0, len(r.active[sessionID]))
+	for _, run := range r.active[sessionID] {
+		runs = append(runs, run)
+	}
+	r.mu.Unlock()
+
+	for _, run := range runs {
 		run.markDisposed()
 		if err := run.Runtime.Stop(); err != nil {
 			logger.Warn("DebugVNext", "销毁 session 时停止 run 失败: %v", err)
 		}
+	}
+	deadline := time.After(5 * time.Second)
+	for _, run := range runs {
 		select {
 		case <-run.Done:
-		case <-time.After(5 * time.Second):
+		case <-deadline:
 			logger.Warn("DebugVNext", "等待 debug run 停止超时，强制释放 runtime: %s", run.ID)
 			run.Runtime.Destroy()
 		}
@@ -246,6 +429,7 @@ func (r *Runner) DisposeSession(sessionID string) {
 	}
 	r.traces.DeleteSession(sessionID)
 	r.artifacts.DeleteSession(sessionID)
+	r.watches.DeleteSession(sessionID)
 }
 
 func (r *Runner) ArtifactStore() *artifact.Store {
@@ -256,6 +440,10 @@ func (r *Runner) AgentPool() *debugruntime.AgentPool {
 	return r.agentPool
 }
 
+func (r *Runner) Watches() *watch.Registry {
+	return r.watches
+}
+
 func (r *Runner) failStart(
 	sessionID string,
 	runID string,
@@ -263,7 +451,7 @@ func (r *Runner) failStart(
 	eventSender EventSender,
 	snapshotSender SnapshotSender,
 ) {
-	snapshot, snapshotErr := r.sessions.SetFailed(sessionID)
+	snapshot, snapshotErr := r.sessions.SetFailed(sessionID, runID)
 	if snapshotErr == nil {
 		sendSnapshot(snapshotSender, snapshot)
 	}
@@ -302,7 +490,7 @@ func (r *Runner) wait(run *Run, eventSender EventSender, snapshotSender Snapshot
 				"reason": run.stopReasonOrDefault(),
 			},
 		})
-		snapshot, err := r.sessions.SetCompleted(run.SessionID)
+		snapshot, err := r.sessions.SetCompleted(run.SessionID, run.ID)
 		if err == nil {
 			sendSnapshot(snapshotSender, snapshot)
 		}
@@ -324,7 +512,7 @@ func (r *Runner) wait(run *Run, eventSender EventSender, snapshotSender Snapshot
 				"status": result.Status,
 			},
 		})
-		snapshot, err := r.sessions.SetCompleted(run.SessionID)
+		snapshot, err := r.sessions.SetCompleted(run.SessionID, run.ID)
 		if err == nil {
 			sendSnapshot(snapshotSender, snapshot)
 		}
@@ -349,7 +537,7 @@ func (r *Runner) wait(run *Run, eventSender EventSender, snapshotSender Snapshot
 		Status:    "failed",
 		Data:      data,
 	})
-	snapshot, err := r.sessions.SetFailed(run.SessionID)
+	snapshot, err := r.sessions.SetFailed(run.SessionID, run.ID)
 	if err == nil {
 		sendSnapshot(snapshotSender, snapshot)
 	}
@@ -412,6 +600,40 @@ func (r *Runner) emit(sender EventSender, event protocol.Event) {
 	if sender != nil {
 		sender(appended)
 	}
+	if appended.Kind != "watch" {
+		r.evaluateWatches(sender, appended)
+	}
+}
+
+// 用识别事件求值会话上的监视表达式，成立时发出 watch 事件并按需暂停
+func (r *Runner) evaluateWatches(sender EventSender, event protocol.Event) {
+	for _, trigger := range r.watches.Observe(event) {
+		r.emit(sender, protocol.Event{
+			SessionID: event.SessionID,
+			RunID:     event.RunID,
+			Source:    "localbridge",
+			Kind:      "watch",
+			Phase:     "completed",
+			Status:    "triggered",
+			Node:      event.Node,
+			Data: map[string]interface{}{
+				"watchId":    trigger.Watch.ID,
+				"expression": trigger.Watch.Expression,
+				"hitCount":   trigger.Watch.HitCount,
+				"pause":      trigger.Watch.Pause,
+				"triggerSeq": event.Seq,
+				"values":     trigger.Values,
+			},
+		})
+		if !trigger.Watch.Pause {
+			continue
+		}
+		if run := r.activeRun(event.SessionID, event.RunID); run != nil {
+			if err := run.Runtime.Breakpoints().RequestPause(events.PauseReasonWatch); err != nil {
+				logger.Debug("DebugVNext", "watch 触发暂停被忽略: %v", err)
+			}
+		}
+	}
 }
 
 func sendAppendedEvent(sender EventSender, event protocol.Event) {
@@ -451,23 +673,70 @@ func (r *Runner) emitDiagnostics(sessionID string, runID string, values []protoc
 	}
 }
 
-func (r *Runner) activeRun(sessionID string) *Run {
+// Suspend 拒绝新的 run 直到 Unsuspend，返回当前运行中的全部 run
+func (r *Runner) Suspend(reason string) []*Run {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.suspended = reason
+	runs := make([]*Run, 0)
+	for _, sessionRuns := range r.active {
+		for _, run := range sessionRuns {
+			runs = append(runs, run)
+		}
+	}
+	return runs
+}
+
+func (r *Runner) Unsuspend() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.suspended = ""
+}
+
+// SetRoots 切换新 run 使用的工作区根目录，已开始的 run 不受影响
+func (r *Runner) SetRoots(roots workspace.Roots) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.roots = roots
+}
+
+// Roots 返回新 run 使用的工作区根目录
+func (r *Runner) Roots() workspace.Roots {
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	return r.active[sessionID]
+	return r.roots
+}
+
+func (r *Runner) activeRun(sessionID string, runID string) *Run {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.active[sessionID][runID]
 }
 
 func (r *Runner) unregister(run *Run) bool {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	if r.active[run.SessionID] != run {
+	runs := r.active[run.SessionID]
+	if runs[run.ID] != run {
 		return false
 	}
-	delete(r.active, run.SessionID)
+	delete(runs, run.ID)
+	if len(runs) == 0 {
+		delete(r.active, run.SessionID)
+	}
+	run.release()
+	r.watches.DeleteRun(run.SessionID, run.ID)
 	return true
 }
 
+func (r *Run) release() {
+	if r.releaseController != nil {
+		r.releaseController()
+	}
+}
+
 func (r *Run) markStopRequested(reason string) bool {
 	r.mu.Lock()
 	defer r.mu.Unlock()
@@ -0,0 +1,26 @@
+package runner
+
+import "testing"
+
+func TestCheckStartLockedControllerConflict(t *testing.T) {
+	r := &Runner{active: map[string]map[string]*Run{
+		"session-a": {"run-a": {ID: "run-a", SessionID: "session-a", ControllerID: "ctrl"}},
+	}}
+
+	tests := []struct {
+		name         string
+		sessionID    string
+		controllerID string
+		wantErr      bool
+	}{
+		{name: "same session same controller", sessionID: "session-a", controllerID: "ctrl", wantErr: true},
+		{name: "other session same controller", sessionID: "session-b", controllerID: "ctrl", wantErr: true},
+		{name: "other controller", sessionID: "session-b", controllerID: "other"},
+		{name: "no controller", sessionID: "session-b"},
+	}
+	for _, test := range tests {
+		if err := r.checkStartLocked(test.sessionID, test.controllerID); (err != nil) != test.wantErr {
+			t.Fatalf("%s: checkStartLocked() error = %v, wantErr = %v", test.name, err, test.wantErr)
+		}
+	}
+}
@@ -4,7 +4,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
-	"path/filepath"
+	"reflect"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -17,6 +18,7 @@ import (
 	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/logger"
 	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/mfw"
 	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/utils"
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/workspace"
 )
 
 type Runtime struct {
@@ -35,8 +37,11 @@ type Runtime struct {
 	taskJob       *maa.TaskJob
 	contextSinkID int64
 	taskerSinkID  int64
+	ctrlSinkID    int64
 	agentClients  []*maa.AgentClient
 	agentPool     *AgentPool
+	breakpoints   *events.BreakpointGate
+	normalizer    *events.Normalizer
 
 	emit events.EmitFunc
 
@@ -51,7 +56,7 @@ type Result struct {
 
 func New(
 	service *mfw.Service,
-	root string,
+	roots workspace.Roots,
 	sessionID string,
 	runID string,
 	req protocol.RunRequest,
@@ -64,7 +69,10 @@ func New(
 		return nil, err
 	}
 
-	resourcePaths := normalizeResourcePaths(req.Profile.ResourcePaths)
+	resourcePaths, err := runutil.ResolveResourcePaths(roots, req.Profile.ResourcePaths)
+	if err != nil {
+		return nil, err
+	}
 	if len(resourcePaths) == 0 {
 		return nil, fmt.Errorf("profile.resourcePaths 不能为空")
 	}
@@ -170,7 +178,7 @@ func New(
 		}
 	}
 
-	overrideBundle, err := buildPipelineOverrideBundle(root, req)
+	overrideBundle, err := buildPipelineOverrideBundle(roots, req)
 	if err != nil {
 		adapter.Destroy()
 		return nil, err
@@ -193,9 +201,12 @@ func New(
 	if req.ArtifactPolicy != nil {
 		policy = *req.ArtifactPolicy
 	}
+	breakpoints := events.NewBreakpointGate(req.Breakpoints)
 	normalizer := events.NewNormalizer(sessionID, runID, req.ResolverSnapshot, policy, artifacts, emit)
+	normalizer.SetBreakpointGate(breakpoints)
 	contextSinkID := adapter.AddContextSink(normalizer)
 	taskerSinkID := adapter.AddTaskerSink(normalizer)
+	ctrlSinkID := adapter.AddControllerSink(normalizer)
 
 	r := &Runtime{
 		sessionID:     sessionID,
@@ -211,7 +222,10 @@ func New(
 		adapter:       adapter,
 		contextSinkID: contextSinkID,
 		taskerSinkID:  taskerSinkID,
+		ctrlSinkID:    ctrlSinkID,
 		agentPool:     agentPool,
+		breakpoints:   breakpoints,
+		normalizer:    normalizer,
 		emit:          emit,
 	}
 
@@ -267,8 +281,8 @@ func ControllerID(req protocol.RunRequest) (string, error) {
 	return "", fmt.Errorf("缺少必需字段: profile.controller.options.controllerId")
 }
 
-func PipelineOverride(root string, req protocol.RunRequest) (map[string]interface{}, error) {
-	overrideBundle, err := buildPipelineOverrideBundle(root, req)
+func PipelineOverride(roots workspace.Roots, req protocol.RunRequest) (map[string]interface{}, error) {
+	overrideBundle, err := buildPipelineOverrideBundle(roots, req)
 	if err != nil {
 		return nil, err
 	}
@@ -282,7 +296,7 @@ type pipelineOverrideBundle struct {
 	Merged          map[string]interface{}
 }
 
-func buildPipelineOverrideBundle(root string, req protocol.RunRequest) (pipelineOverrideBundle, error) {
+func buildPipelineOverrideBundle(roots workspace.Roots, req protocol.RunRequest) (pipelineOverrideBundle, error) {
 	selectedFileID, selectedSourcePath := resolveRunSource(req)
 	entry, err := EntryForRequest(req)
 	if err != nil {
@@ -299,17 +313,17 @@ func buildPipelineOverrideBundle(root string, req protocol.RunRequest) (pipeline
 		if selectedSourcePath == "" {
 			return pipelineOverrideBundle{}, fmt.Errorf("sandbox 模式未找到目标文件快照: %s", selectedFileID)
 		}
-		override, err := loadPipelineOverrideFromDisk(root, selectedSourcePath)
+		override, err := loadPipelineOverrideFromDisk(roots, selectedSourcePath)
 		if err != nil {
 			return pipelineOverrideBundle{}, err
 		}
 		baseOverride = override
 	case "save-open-files", "use-disk":
-		sourcePath, err := resolveRunSourcePath(root, req, selectedFileID, selectedSourcePath)
+		sourcePath, err := resolveRunSourcePath(roots, req, selectedFileID, selectedSourcePath)
 		if err != nil {
 			return pipelineOverrideBundle{}, err
 		}
-		override, err := loadPipelineOverrideFromDisk(root, sourcePath)
+		override, err := loadPipelineOverrideFromDisk(roots, sourcePath)
 		if err != nil {
 			return pipelineOverrideBundle{}, err
 		}
@@ -386,48 +400,37 @@ func findGraphFileSnapshot(
 }
 
 func resolveRunSourcePath(
-	root string,
+	roots workspace.Roots,
 	req protocol.RunRequest,
 	fileID string,
 	sourcePath string,
 ) (string, error) {
 	if strings.TrimSpace(sourcePath) != "" {
-		return normalizeRunSourcePath(root, sourcePath)
+		return normalizeRunSourcePath(roots, sourcePath)
 	}
 	if file := findGraphFileSnapshot(req, fileID, ""); file != nil && strings.TrimSpace(file.Path) != "" {
-		return normalizeRunSourcePath(root, file.Path)
+		return normalizeRunSourcePath(roots, file.Path)
 	}
 	if looksLikeJSONPath(fileID) {
-		return normalizeRunSourcePath(root, fileID)
+		return normalizeRunSourcePath(roots, fileID)
 	}
 	return "", fmt.Errorf("%s 模式要求目标文件已保存到磁盘", req.Profile.SavePolicy)
 }
 
-func normalizeRunSourcePath(root string, candidate string) (string, error) {
-	trimmed := strings.TrimSpace(candidate)
-	if trimmed == "" {
+func normalizeRunSourcePath(roots workspace.Roots, candidate string) (string, error) {
+	if strings.TrimSpace(candidate) == "" {
 		return "", fmt.Errorf("目标文件路径为空")
 	}
 
-	resolved := filepath.Clean(trimmed)
-	if root != "" && !filepath.IsAbs(resolved) {
-		resolved = filepath.Join(root, resolved)
-	}
-	if root != "" {
-		cleanRoot := filepath.Clean(root)
-		rel, err := filepath.Rel(cleanRoot, resolved)
-		if err != nil {
-			return "", fmt.Errorf("无法校验目标文件路径: %w", err)
-		}
-		if rel == ".." || strings.HasPrefix(rel, fmt.Sprintf("..%c", filepath.Separator)) {
-			return "", fmt.Errorf("目标文件不在工作区内: %s", resolved)
-		}
+	resolved, err := roots.Resolve(candidate)
+	if err != nil {
+		return "", fmt.Errorf("目标文件不在工作区内: %s", candidate)
 	}
 	return resolved, nil
 }
 
-func loadPipelineOverrideFromDisk(root string, candidate string) (map[string]interface{}, error) {
-	resolved, err := normalizeRunSourcePath(root, candidate)
+func loadPipelineOverrideFromDisk(roots workspace.Roots, candidate string) (map[string]interface{}, error) {
+	resolved, err := normalizeRunSourcePath(roots, candidate)
 	if err != nil {
 		return nil, err
 	}
@@ -493,6 +496,8 @@ func (r *Runtime) Stop() error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
+	// 先放行断点处阻塞的回调，否则任务无法响应停止
+	r.breakpoints.Release()
 	if r.adapter == nil {
 		return nil
 	}
@@ -524,6 +529,7 @@ func (r *Runtime) Destroy() {
 	// 不 Disconnect agent —— Pool 拥有连接生命周期，agent server 进程需要保持运行。
 	// adapter 使用的是 Pool 的 Resource（borrowed），Destroy 时不会释放它。
 	r.agentClients = nil
+	r.breakpoints.Release()
 
 	if r.adapter == nil {
 		return
@@ -534,6 +540,9 @@ func (r *Runtime) Destroy() {
 	if r.taskerSinkID > 0 {
 		r.adapter.RemoveTaskerSink(r.taskerSinkID)
 	}
+	if r.ctrlSinkID > 0 {
+		r.adapter.RemoveControllerSink(r.ctrlSinkID)
+	}
 	r.adapter.Destroy()
 	r.adapter = nil
 }
@@ -542,6 +551,82 @@ func (r *Runtime) Entry() string {
 	return r.entry
 }
 
+func (r *Runtime) Breakpoints() *events.BreakpointGate {
+	return r.breakpoints
+}
+
+// 热更新运行中的 pipeline：写入资源，并排队写入任务 Context（任务级 override 优先于资源）
+func (r *Runtime) Patch(overrides []protocol.PipelineOverride) ([]protocol.PipelinePatchChange, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.adapter == nil {
+		return nil, fmt.Errorf("runtime 已释放")
+	}
+	if err := validatePatchOverrides(r.resolver, overrides); err != nil {
+		return nil, err
+	}
+	patch, err := mergeRequestOverrides(nil, overrides)
+	if err != nil {
+		return nil, err
+	}
+	changes := diffPipelinePatch(r.override, patch)
+
+	if err := r.adapter.OverridePipeline(patch); err != nil {
+		return nil, fmt.Errorf("应用 pipeline override 失败: %w", err)
+	}
+	r.normalizer.QueuePipelineOverride(patch)
+
+	merged, err := cloneOverride(r.override)
+	if err != nil {
+		return nil, err
+	}
+	if merged, err = mergeRequestOverrides(merged, overrides); err != nil {
+		return nil, err
+	}
+	r.override = merged
+
+	logger.Info("DebugVNext", "热更新 pipeline: session=%s run=%s changes=%d", r.sessionID, r.runID, len(changes))
+	r.emitPatchDiagnostic(changes)
+	return changes, nil
+}
+
+func (r *Runtime) emitPatchDiagnostic(changes []protocol.PipelinePatchChange) {
+	if r.emit == nil {
+		return
+	}
+	runtimeNames := make([]string, 0)
+	seen := make(map[string]bool)
+	lines := make([]string, 0, len(changes))
+	for _, change := range changes {
+		if !seen[change.RuntimeName] {
+			seen[change.RuntimeName] = true
+			runtimeNames = append(runtimeNames, change.RuntimeName)
+		}
+		lines = append(lines, fmt.Sprintf("%s.%s: %s -> %s",
+			change.RuntimeName,
+			change.Field,
+			formatOverrideNodeMapForDiagnostic(change.Before),
+			formatOverrideNodeMapForDiagnostic(change.After),
+		))
+	}
+	r.emit(protocol.Event{
+		SessionID: r.sessionID,
+		RunID:     r.runID,
+		Source:    "localbridge",
+		Kind:      "diagnostic",
+		Phase:     "completed",
+		Status:    "info",
+		Data: map[string]interface{}{
+			"severity":     "info",
+			"code":         "debug.override.live_patch",
+			"message":      "热更新 pipeline:\n" + strings.Join(lines, "\n"),
+			"runtimeNames": runtimeNames,
+			"changes":      changes,
+		},
+	})
+}
+
 func (r *Runtime) startTask(override map[string]interface{}) error {
 	if !r.entryExists(override) {
 		return fmt.Errorf("入口节点不存在: %s", r.entry)
@@ -749,10 +834,6 @@ func (r *Runtime) entryExists(override map[string]interface{}) bool {
 	return ok
 }
 
-func normalizeResourcePaths(paths []string) []string {
-	return runutil.NonEmptyResourcePaths(paths)
-}
-
 func isDirectMode(mode protocol.RunMode) bool {
 	return mode == protocol.RunModeRecognitionOnly ||
 		mode == protocol.RunModeActionOnly
@@ -930,6 +1011,70 @@ func formatOverrideNodeMapForDiagnostic(value interface{}) string {
 	return string(data)
 }
 
+// 热更新只允许修改 resolver 快照中已知的节点
+func validatePatchOverrides(resolver protocol.NodeResolverSnapshot, overrides []protocol.PipelineOverride) error {
+	if len(overrides) == 0 {
+		return fmt.Errorf("overrides 不能为空")
+	}
+	known := make(map[string]bool, len(resolver.Nodes))
+	for _, node := range resolver.Nodes {
+		known[node.RuntimeName] = true
+	}
+	for i, override := range overrides {
+		runtimeName := strings.TrimSpace(override.RuntimeName)
+		if runtimeName == "" {
+			return fmt.Errorf("缺少必需字段: overrides[%d].runtimeName", i)
+		}
+		if !known[runtimeName] {
+			return fmt.Errorf("节点不在 resolver 快照中: %s", runtimeName)
+		}
+		if len(override.Pipeline) == 0 {
+			return fmt.Errorf("overrides[%d].pipeline 不能为空", i)
+		}
+	}
+	return nil
+}
+
+// 按节点顶层字段比较热更新前后的取值
+func diffPipelinePatch(current map[string]interface{}, patch map[string]interface{}) []protocol.PipelinePatchChange {
+	runtimeNames := make([]string, 0, len(patch))
+	for runtimeName := range patch {
+		runtimeNames = append(runtimeNames, runtimeName)
+	}
+	sort.Strings(runtimeNames)
+
+	changes := make([]protocol.PipelinePatchChange, 0)
+	for _, runtimeName := range runtimeNames {
+		after, _ := patch[runtimeName].(map[string]interface{})
+		before, _ := current[runtimeName].(map[string]interface{})
+		fields := make([]string, 0, len(after))
+		for field := range after {
+			fields = append(fields, field)
+		}
+		sort.Strings(fields)
+		for _, field := range fields {
+			value := after[field]
+			if previous, ok := before[field]; ok {
+				if nested, isMap := value.(map[string]interface{}); isMap {
+					if previousMap, ok := previous.(map[string]interface{}); ok {
+						value = deepMergeOverrideMap(previousMap, nested)
+					}
+				}
+				if reflect.DeepEqual(previous, value) {
+					continue
+				}
+			}
+			changes = append(changes, protocol.PipelinePatchChange{
+				RuntimeName: runtimeName,
+				Field:       field,
+				Before:      before[field],
+				After:       value,
+			})
+		}
+	}
+	return changes
+}
+
 func createAgentClient(agent protocol.AgentProfile) (*maa.AgentClient, error) {
 	switch agent.Transport {
 	case "tcp":
@@ -0,0 +1,59 @@
+package runtime
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/debug/protocol"
+)
+
+func TestValidatePatchOverrides(t *testing.T) {
+	resolver := protocol.NodeResolverSnapshot{
+		Nodes: []protocol.NodeResolverSnapshotNode{{FileID: "main.json", NodeID: "node-a", RuntimeName: "A"}},
+	}
+	tests := []struct {
+		name      string
+		overrides []protocol.PipelineOverride
+		wantErr   bool
+	}{
+		{name: "known node", overrides: []protocol.PipelineOverride{{RuntimeName: "A", Pipeline: map[string]interface{}{"threshold": 0.6}}}},
+		{name: "empty", wantErr: true},
+		{name: "unknown node", overrides: []protocol.PipelineOverride{{RuntimeName: "B", Pipeline: map[string]interface{}{"threshold": 0.6}}}, wantErr: true},
+		{name: "missing pipeline", overrides: []protocol.PipelineOverride{{RuntimeName: "A"}}, wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := validatePatchOverrides(resolver, test.overrides)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("validatePatchOverrides() error = %v, wantErr = %v", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestDiffPipelinePatch(t *testing.T) {
+	current := map[string]interface{}{
+		"A": map[string]interface{}{
+			"threshold": 0.8,
+			"roi":       []interface{}{0, 0, 100, 100},
+			"action":    map[string]interface{}{"type": "Click", "param": map[string]interface{}{}},
+		},
+	}
+	patch := map[string]interface{}{
+		"A": map[string]interface{}{
+			"threshold": 0.6,
+			"roi":       []interface{}{0, 0, 100, 100},
+			"action":    map[string]interface{}{"type": "Click"},
+		},
+		"B": map[string]interface{}{"timeout": 500},
+	}
+
+	want := []protocol.PipelinePatchChange{
+		{RuntimeName: "A", Field: "threshold", Before: 0.8, After: 0.6},
+		{RuntimeName: "B", Field: "timeout", After: 500},
+	}
+	if got := diffPipelinePatch(current, patch); !reflect.DeepEqual(got, want) {
+		t.Fatalf("diffPipelinePatch() = %#v, want %#v", got, want)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/debug/protocol"
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/workspace"
 )
 
 func TestPipelineOverrideSandboxUsesTargetSnapshotOnly(t *testing.T) {
@@ -43,7 +44,7 @@ func TestPipelineOverrideSandboxUsesTargetSnapshotOnly(t *testing.T) {
 		},
 	}
 
-	override, err := PipelineOverride("", req)
+	override, err := PipelineOverride(nil, req)
 	if err != nil {
 		t.Fatalf("PipelineOverride returned error: %v", err)
 	}
@@ -100,7 +101,7 @@ func TestPipelineOverrideUseDiskLoadsCurrentDiskFile(t *testing.T) {
 		},
 	}
 
-	override, err := PipelineOverride(root, req)
+	override, err := PipelineOverride(workspace.Single(root), req)
 	if err != nil {
 		t.Fatalf("PipelineOverride returned error: %v", err)
 	}
@@ -184,7 +185,7 @@ func TestPipelineOverrideMergesRequestOverridesOntoBase(t *testing.T) {
 		},
 	}
 
-	override, err := PipelineOverride("", req)
+	override, err := PipelineOverride(nil, req)
 	if err != nil {
 		t.Fatalf("PipelineOverride returned error: %v", err)
 	}
@@ -268,7 +269,7 @@ func TestPipelineOverrideAllowsRequestOverridesWithoutBasePipeline(t *testing.T)
 		},
 	}
 
-	override, err := PipelineOverride("", req)
+	override, err := PipelineOverride(nil, req)
 	if err != nil {
 		t.Fatalf("PipelineOverride returned error: %v", err)
 	}
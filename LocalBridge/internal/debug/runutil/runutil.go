@@ -1,9 +1,11 @@
 package runutil
 
 import (
+	"fmt"
 	"strings"
 
 	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/debug/protocol"
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/workspace"
 )
 
 func UsesLiveController(mode protocol.RunMode) bool {
@@ -27,3 +29,26 @@ func NonEmptyResourcePaths(paths []string) []string {
 	}
 	return result
 }
+
+// ResolveResourcePaths 展开 "根目录名称:相对路径" 形式的资源路径并校验其不越出对应根目录，
+// 其余路径原样保留
+func ResolveResourcePaths(roots workspace.Roots, paths []string) ([]string, error) {
+	result := NonEmptyResourcePaths(paths)
+	for i, path := range result {
+		resolved, err := roots.ResolveResource(path)
+		if err != nil {
+			return nil, fmt.Errorf("resourcePaths[%d]: %w", i, err)
+		}
+		result[i] = resolved
+	}
+	return result, nil
+}
+
+func ControllerIDFromOptions(options map[string]interface{}) string {
+	for _, key := range []string{"controllerId", "controller_id"} {
+		if value, ok := options[key].(string); ok && strings.TrimSpace(value) != "" {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
@@ -1,6 +1,7 @@
 package session
 
 import (
+	"crypto/subtle"
 	"fmt"
 	"sync"
 	"time"
@@ -15,12 +16,31 @@ const (
 	StatusIdle      Status = "idle"
 	StatusPreparing Status = "preparing"
 	StatusRunning   Status = "running"
+	StatusPaused    Status = "paused"
 	StatusStopping  Status = "stopping"
 	StatusCompleted Status = "completed"
 	StatusFailed    Status = "failed"
 	StatusDisposed  Status = "disposed"
 )
 
+// 会话内保留的已结束 run 状态数量上限
+const maxFinishedRuns = 16
+
+// 会话内单个 run 的状态
+type RunState struct {
+	RunID     string
+	Status    Status
+	StartedAt time.Time
+	UpdatedAt time.Time
+}
+
+type RunSnapshot struct {
+	RunID     string `json:"runId"`
+	Status    Status `json:"status"`
+	StartedAt string `json:"startedAt"`
+	UpdatedAt string `json:"updatedAt"`
+}
+
 type Session struct {
 	ID           string
 	Status       Status
@@ -28,11 +48,18 @@ type Session struct {
 	UpdatedAt    time.Time
 	Capabilities protocol.CapabilityManifest
 	CurrentRunID string
+	// 按启动顺序记录的 run，会话状态由其中未结束的 run 汇总得出
+	Runs []*RunState
+	// 会话所有权令牌，仅在创建时返回给客户端，用于重连后重新绑定
+	OwnerToken string
 }
 
 type Snapshot struct {
 	SessionID    string                      `json:"sessionId"`
 	Status       Status                      `json:"status"`
+	RunID        string                      `json:"runId,omitempty"`
+	Runs         []RunSnapshot               `json:"runs,omitempty"`
+	OwnerToken   string                      `json:"ownerToken,omitempty"`
 	CreatedAt    string                      `json:"createdAt"`
 	UpdatedAt    string                      `json:"updatedAt"`
 	Capabilities protocol.CapabilityManifest `json:"capabilities"`
@@ -60,9 +87,27 @@ func (m *Manager) Create(capabilities protocol.CapabilityManifest) Snapshot {
 		CreatedAt:    now,
 		UpdatedAt:    now,
 		Capabilities: capabilities,
+		OwnerToken:   uuid.NewString(),
 	}
 	m.sessions[session.ID] = session
-	return session.Snapshot()
+	snapshot := session.Snapshot()
+	snapshot.OwnerToken = session.OwnerToken
+	return snapshot
+}
+
+// 校验会话所有权令牌
+func (m *Manager) Authorize(sessionID string, token string) (Snapshot, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	session, ok := m.sessions[sessionID]
+	if !ok {
+		return Snapshot{}, fmt.Errorf("debug session not found: %s", sessionID)
+	}
+	if token == "" || subtle.ConstantTimeCompare([]byte(token), []byte(session.OwnerToken)) != 1 {
+		return Snapshot{}, fmt.Errorf("debug session 所有权校验失败: %s", sessionID)
+	}
+	return session.Snapshot(), nil
 }
 
 func (m *Manager) Destroy(sessionID string) error {
@@ -87,16 +132,20 @@ func (m *Manager) SetRunning(sessionID string, runID string) (Snapshot, error) {
 	return m.setStatus(sessionID, StatusRunning, runID)
 }
 
-func (m *Manager) SetStopping(sessionID string) (Snapshot, error) {
-	return m.setStatus(sessionID, StatusStopping, "")
+func (m *Manager) SetPaused(sessionID string, runID string) (Snapshot, error) {
+	return m.setStatus(sessionID, StatusPaused, runID)
+}
+
+func (m *Manager) SetStopping(sessionID string, runID string) (Snapshot, error) {
+	return m.setStatus(sessionID, StatusStopping, runID)
 }
 
-func (m *Manager) SetCompleted(sessionID string) (Snapshot, error) {
-	return m.setStatus(sessionID, StatusCompleted, "")
+func (m *Manager) SetCompleted(sessionID string, runID string) (Snapshot, error) {
+	return m.setStatus(sessionID, StatusCompleted, runID)
 }
 
-func (m *Manager) SetFailed(sessionID string) (Snapshot, error) {
-	return m.setStatus(sessionID, StatusFailed, "")
+func (m *Manager) SetFailed(sessionID string, runID string) (Snapshot, error) {
+	return m.setStatus(sessionID, StatusFailed, runID)
 }
 
 func (m *Manager) SetIdle(sessionID string) (Snapshot, error) {
@@ -115,13 +164,23 @@ func (m *Manager) Snapshot(sessionID string) (Snapshot, error) {
 }
 
 func (s *Session) Snapshot() Snapshot {
-	return Snapshot{
+	snapshot := Snapshot{
 		SessionID:    s.ID,
 		Status:       s.Status,
+		RunID:        s.CurrentRunID,
 		CreatedAt:    s.CreatedAt.Format(time.RFC3339Nano),
 		UpdatedAt:    s.UpdatedAt.Format(time.RFC3339Nano),
 		Capabilities: s.Capabilities,
 	}
+	for _, run := range s.Runs {
+		snapshot.Runs = append(snapshot.Runs, RunSnapshot{
+			RunID:     run.RunID,
+			Status:    run.Status,
+			StartedAt: run.StartedAt.Format(time.RFC3339Nano),
+			UpdatedAt: run.UpdatedAt.Format(time.RFC3339Nano),
+		})
+	}
+	return snapshot
 }
 
 func (m *Manager) setStatus(sessionID string, status Status, runID string) (Snapshot, error) {
@@ -133,13 +192,79 @@ func (m *Manager) setStatus(sessionID string, status Status, runID string) (Snap
 		return Snapshot{}, fmt.Errorf("debug session not found: %s", sessionID)
 	}
 
-	session.Status = status
-	if runID != "" {
-		session.CurrentRunID = runID
+	now := time.Now().UTC()
+	session.UpdatedAt = now
+	if runID == "" {
+		session.Status = status
+		if isTerminal(status) {
+			session.CurrentRunID = ""
+		}
+		return session.Snapshot(), nil
 	}
-	if status == StatusIdle || status == StatusCompleted || status == StatusFailed || status == StatusDisposed {
-		session.CurrentRunID = ""
+
+	run := session.run(runID)
+	if run == nil {
+		run = &RunState{RunID: runID, StartedAt: now}
+		session.Runs = append(session.Runs, run)
 	}
-	session.UpdatedAt = time.Now().UTC()
+	run.Status = status
+	run.UpdatedAt = now
+	session.trimFinishedRuns()
+	session.aggregate(status)
 	return session.Snapshot(), nil
 }
+
+func (s *Session) run(runID string) *RunState {
+	for _, run := range s.Runs {
+		if run.RunID == runID {
+			return run
+		}
+	}
+	return nil
+}
+
+// 汇总会话状态：存在未结束的 run 时取优先级最高的状态，否则沿用最近结束的 run 状态
+func (s *Session) aggregate(latest Status) {
+	priority := map[Status]int{StatusRunning: 4, StatusPreparing: 3, StatusPaused: 2, StatusStopping: 1}
+	best := Status("")
+	currentRunID := ""
+	for _, run := range s.Runs {
+		if isTerminal(run.Status) {
+			continue
+		}
+		if priority[run.Status] > priority[best] {
+			best = run.Status
+		}
+		currentRunID = run.RunID
+	}
+	s.CurrentRunID = currentRunID
+	if best == "" {
+		best = latest
+	}
+	s.Status = best
+}
+
+func (s *Session) trimFinishedRuns() {
+	finished := 0
+	for _, run := range s.Runs {
+		if isTerminal(run.Status) {
+			finished++
+		}
+	}
+	if finished <= maxFinishedRuns {
+		return
+	}
+	kept := s.Runs[:0]
+	for _, run := range s.Runs {
+		if finished > maxFinishedRuns && isTerminal(run.Status) {
+			finished--
+			continue
+		}
+		kept = append(kept, run)
+	}
+	s.Runs = kept
+}
+
+func isTerminal(status Status) bool {
+	return status == StatusIdle || status == StatusCompleted || status == StatusFailed || status == StatusDisposed
+}
@@ -0,0 +1,128 @@
+package session
+
+import (
+	"testing"
+
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/debug/protocol"
+)
+
+func TestManagerAuthorize(t *testing.T) {
+	manager := NewManager()
+	created := manager.Create(protocol.CapabilityManifest{})
+	if created.OwnerToken == "" {
+		t.Fatal("Create() did not return owner token")
+	}
+
+	if _, err := manager.SetRunning(created.SessionID, "run-1"); err != nil {
+		t.Fatalf("SetRunning() error = %v", err)
+	}
+	snapshot, err := manager.Authorize(created.SessionID, created.OwnerToken)
+	if err != nil {
+		t.Fatalf("Authorize() error = %v", err)
+	}
+	if snapshot.OwnerToken != "" {
+		t.Fatal("Authorize() snapshot must not expose owner token")
+	}
+	if snapshot.Status != StatusRunning || snapshot.RunID != "run-1" {
+		t.Fatalf("Authorize() snapshot = %+v", snapshot)
+	}
+
+	for _, token := range []string{"", "wrong"} {
+		if _, err := manager.Authorize(created.SessionID, token); err == nil {
+			t.Fatalf("Authorize(%q) error = nil", token)
+		}
+	}
+	if _, err := manager.Authorize("missing", created.OwnerToken); err == nil {
+		t.Fatal("Authorize() unknown session error = nil")
+	}
+}
+
+func TestManagerTracksConcurrentRuns(t *testing.T) {
+	manager := NewManager()
+	created := manager.Create(protocol.CapabilityManifest{})
+	sessionID := created.SessionID
+
+	steps := []struct {
+		name       string
+		apply      func() (Snapshot, error)
+		wantStatus Status
+		wantRunID  string
+		wantRuns   map[string]Status
+	}{
+		{
+			name:       "first run",
+			apply:      func() (Snapshot, error) { return manager.SetRunning(sessionID, "run-1") },
+			wantStatus: StatusRunning,
+			wantRunID:  "run-1",
+			wantRuns:   map[string]Status{"run-1": StatusRunning},
+		},
+		{
+			name:       "second run preparing",
+			apply:      func() (Snapshot, error) { return manager.SetPreparing(sessionID, "run-2") },
+			wantStatus: StatusRunning,
+			wantRunID:  "run-2",
+			wantRuns:   map[string]Status{"run-1": StatusRunning, "run-2": StatusPreparing},
+		},
+		{
+			name:       "first run paused",
+			apply:      func() (Snapshot, error) { return manager.SetPaused(sessionID, "run-1") },
+			wantStatus: StatusPreparing,
+			wantRunID:  "run-2",
+			wantRuns:   map[string]Status{"run-1": StatusPaused, "run-2": StatusPreparing},
+		},
+		{
+			name:       "second run failed",
+			apply:      func() (Snapshot, error) { return manager.SetFailed(sessionID, "run-2") },
+			wantStatus: StatusPaused,
+			wantRunID:  "run-1",
+			wantRuns:   map[string]Status{"run-1": StatusPaused, "run-2": StatusFailed},
+		},
+		{
+			name:       "first run completed",
+			apply:      func() (Snapshot, error) { return manager.SetCompleted(sessionID, "run-1") },
+			wantStatus: StatusCompleted,
+			wantRunID:  "",
+			wantRuns:   map[string]Status{"run-1": StatusCompleted, "run-2": StatusFailed},
+		},
+	}
+
+	for _, step := range steps {
+		snapshot, err := step.apply()
+		if err != nil {
+			t.Fatalf("%s: error = %v", step.name, err)
+		}
+		if snapshot.Status != step.wantStatus || snapshot.RunID != step.wantRunID {
+			t.Fatalf("%s: status/runId = %s/%s, want %s/%s", step.name, snapshot.Status, snapshot.RunID, step.wantStatus, step.wantRunID)
+		}
+		got := make(map[string]Status, len(snapshot.Runs))
+		for _, run := range snapshot.Runs {
+			got[run.RunID] = run.Status
+		}
+		if len(got) != len(step.wantRuns) {
+			t.Fatalf("%s: runs = %+v", step.name, snapshot.Runs)
+		}
+		for runID, status := range step.wantRuns {
+			if got[runID] != status {
+				t.Fatalf("%s: run %s status = %s, want %s", step.name, runID, got[runID], status)
+			}
+		}
+	}
+}
+
+func TestManagerTrimsFinishedRuns(t *testing.T) {
+	manager := NewManager()
+	sessionID := manager.Create(protocol.CapabilityManifest{}).SessionID
+	for i := 0; i < maxFinishedRuns+4; i++ {
+		runID := "run-" + string(rune('a'+i))
+		if _, err := manager.SetCompleted(sessionID, runID); err != nil {
+			t.Fatalf("SetCompleted() error = %v", err)
+		}
+	}
+	snapshot, err := manager.Snapshot(sessionID)
+	if err != nil {
+		t.Fatalf("Snapshot() error = %v", err)
+	}
+	if len(snapshot.Runs) != maxFinishedRuns || snapshot.Runs[0].RunID != "run-e" {
+		t.Fatalf("Runs len/first = %d/%s", len(snapshot.Runs), snapshot.Runs[0].RunID)
+	}
+}
@@ -0,0 +1,98 @@
+package trace
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/debug/protocol"
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/workspace"
+)
+
+// LoadFile 读取导出的 trace 文件，支持 TraceSnapshot 对象或事件数组；roots 非空时限制在工作区内
+func LoadFile(roots workspace.Roots, candidate string) ([]protocol.Event, error) {
+	resolved, err := roots.Resolve(candidate)
+	if err != nil {
+		return nil, fmt.Errorf("trace 文件不在工作区内: %s", candidate)
+	}
+
+	data, err := os.ReadFile(resolved)
+	if err != nil {
+		return nil, fmt.Errorf("读取 trace 文件失败: %w", err)
+	}
+	var snapshot protocol.TraceSnapshot
+	if err := json.Unmarshal(data, &snapshot); err == nil && snapshot.Events != nil {
+		return snapshot.Events, nil
+	}
+	var events []protocol.Event
+	if err := json.Unmarshal(data, &events); err != nil {
+		return nil, fmt.Errorf("解析 trace 文件失败: %s: %w", candidate, err)
+	}
+	return events, nil
+}
+
+// FileSuffix 持久化 trace 文件的后缀，Discover 按此查找
+const FileSuffix = ".trace.json"
+
+// 单次查找的 trace 文件数量上限
+const maxDiscoveredFiles = 500
+
+// 查找 trace 文件时跳过的目录
+var discoverySkippedDirs = map[string]struct{}{
+	"node_modules": {},
+	"__pycache__":  {},
+	"venv":         {},
+}
+
+// Discover 在 dir 下递归查找 *.trace.json，dir 为空时查找全部工作区根目录；返回按路径排序的绝对路径
+func Discover(roots workspace.Roots, dir string) ([]string, error) {
+	var dirs []string
+	if strings.TrimSpace(dir) != "" {
+		resolved, err := roots.Resolve(dir)
+		if err != nil {
+			return nil, fmt.Errorf("trace 目录不在工作区内: %s", dir)
+		}
+		dirs = []string{resolved}
+	} else {
+		dirs = roots.Paths()
+	}
+	if len(dirs) == 0 {
+		return nil, fmt.Errorf("未配置工作区根目录，无法查找 trace 文件")
+	}
+
+	var files []string
+	for _, base := range dirs {
+		err := filepath.WalkDir(base, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				if path == base {
+					return err
+				}
+				return nil
+			}
+			name := entry.Name()
+			if entry.IsDir() {
+				if _, skip := discoverySkippedDirs[name]; path != base && (skip || strings.HasPrefix(name, ".")) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !strings.HasSuffix(strings.ToLower(name), FileSuffix) {
+				return nil
+			}
+			if len(files) >= maxDiscoveredFiles {
+				return fmt.Errorf("trace 文件超过 %d 个，请指定更小的目录", maxDiscoveredFiles)
+			}
+			files = append(files, path)
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("查找 trace 文件失败: %w", err)
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
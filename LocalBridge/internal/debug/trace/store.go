@@ -2,6 +2,7 @@ package trace
 
 import (
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 
@@ -103,6 +104,70 @@ func (s *Store) ListRun(sessionID string, runID string) []protocol.Event {
 	return result
 }
 
+// 返回 seq 大于 afterSeq 的事件，用于重连后补发
+func (s *Store) ListSince(sessionID string, afterSeq int64) []protocol.Event {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	events := s.events[sessionID]
+	result := make([]protocol.Event, 0)
+	for _, event := range events {
+		if event.Seq > afterSeq {
+			result = append(result, cloneEvent(event))
+		}
+	}
+	return result
+}
+
+// 按最后一条事件的时间返回最近的 limit 次运行，最新的在前；不属于任何运行的会话事件不计入
+func (s *Store) RecentRuns(limit int) []protocol.TraceSnapshot {
+	if limit <= 0 {
+		return []protocol.TraceSnapshot{}
+	}
+
+	type run struct {
+		snapshot protocol.TraceSnapshot
+		lastAt   time.Time
+	}
+
+	s.mu.RLock()
+	runs := make([]*run, 0)
+	for sessionID, events := range s.events {
+		bySession := make(map[string]*run)
+		for _, event := range events {
+			if event.RunID == "" {
+				continue
+			}
+			current, ok := bySession[event.RunID]
+			if !ok {
+				current = &run{snapshot: protocol.TraceSnapshot{SessionID: sessionID, RunID: event.RunID}}
+				bySession[event.RunID] = current
+				runs = append(runs, current)
+			}
+			current.snapshot.Events = append(current.snapshot.Events, cloneEvent(event))
+			if at, err := time.Parse(time.RFC3339Nano, event.Timestamp); err == nil && at.After(current.lastAt) {
+				current.lastAt = at
+			}
+		}
+	}
+	s.mu.RUnlock()
+
+	sort.SliceStable(runs, func(i, j int) bool {
+		if runs[i].lastAt.Equal(runs[j].lastAt) {
+			return runs[i].snapshot.RunID > runs[j].snapshot.RunID
+		}
+		return runs[i].lastAt.After(runs[j].lastAt)
+	})
+	if len(runs) > limit {
+		runs = runs[:limit]
+	}
+	result := make([]protocol.TraceSnapshot, 0, len(runs))
+	for _, current := range runs {
+		result = append(result, current.snapshot)
+	}
+	return result
+}
+
 func (s *Store) DeleteSession(sessionID string) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
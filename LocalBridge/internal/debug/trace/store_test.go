@@ -86,3 +86,50 @@ func TestStoreAttachDetailRef(t *testing.T) {
 		t.Fatalf("stored Data[performanceSummaryRef] = %v, want perf-1", got)
 	}
 }
+
+func TestStoreListSince(t *testing.T) {
+	store := NewStore()
+	for i := 0; i < 4; i++ {
+		if _, err := store.Append(protocol.Event{SessionID: "session-1", RunID: "run-1", Kind: "node"}); err != nil {
+			t.Fatalf("Append() error = %v", err)
+		}
+	}
+
+	events := store.ListSince("session-1", 2)
+	if len(events) != 2 || events[0].Seq != 3 || events[1].Seq != 4 {
+		t.Fatalf("ListSince() = %+v, want seq 3 and 4", events)
+	}
+	if got := store.ListSince("session-1", 4); len(got) != 0 {
+		t.Fatalf("ListSince() after last seq = %+v, want empty", got)
+	}
+	if got := store.ListSince("missing", 0); len(got) != 0 {
+		t.Fatalf("ListSince() unknown session = %+v, want empty", got)
+	}
+}
+
+func TestStoreRecentRuns(t *testing.T) {
+	store := NewStore()
+	events := []protocol.Event{
+		{SessionID: "session-1", RunID: "run-1", Timestamp: "2026-10-19T10:00:00Z", Kind: "node"},
+		{SessionID: "session-1", Timestamp: "2026-10-19T10:00:05Z", Kind: "session"},
+		{SessionID: "session-2", RunID: "run-2", Timestamp: "2026-10-19T10:00:02Z", Kind: "node"},
+		{SessionID: "session-1", RunID: "run-3", Timestamp: "2026-10-19T10:00:03Z", Kind: "node"},
+		{SessionID: "session-1", RunID: "run-1", Timestamp: "2026-10-19T10:00:04Z", Kind: "node"},
+	}
+	for _, event := range events {
+		if _, err := store.Append(event); err != nil {
+			t.Fatalf("Append() error = %v", err)
+		}
+	}
+
+	runs := store.RecentRuns(2)
+	if len(runs) != 2 || runs[0].RunID != "run-1" || runs[1].RunID != "run-3" {
+		t.Fatalf("RecentRuns(2) = %+v, want run-1 then run-3", runs)
+	}
+	if len(runs[0].Events) != 2 || runs[0].SessionID != "session-1" {
+		t.Fatalf("RecentRuns(2)[0] = %+v, want both run-1 events", runs[0])
+	}
+	if got := store.RecentRuns(0); len(got) != 0 {
+		t.Fatalf("RecentRuns(0) = %+v, want empty", got)
+	}
+}
@@ -0,0 +1,610 @@
+package watch
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// 监视表达式语法：
+//
+//	expr       := and { ("||" | "or") and }
+//	and        := unary { ("&&" | "and") unary }
+//	unary      := ("!" | "not") unary | "(" expr ")" | comparison
+//	comparison := value [ op value ]
+//	value      := "node" NAME FIELD | NUMBER | STRING | /REGEX/ | true | false
+//	op         := < <= > >= == != matches =~ contains
+//
+// 例如: node X score < 0.8 、 node "开始游戏" text matches /\d+/
+
+type valueType int
+
+const (
+	typeBool valueType = iota
+	typeNumber
+	typeString
+	typeRegex
+)
+
+func (t valueType) String() string {
+	switch t {
+	case typeBool:
+		return "布尔"
+	case typeNumber:
+		return "数值"
+	case typeString:
+		return "字符串"
+	default:
+		return "正则"
+	}
+}
+
+// 节点可监视字段及其类型
+var fieldTypes = map[string]valueType{
+	"hit":       typeBool,
+	"score":     typeNumber,
+	"text":      typeString,
+	"algorithm": typeString,
+	"hits":      typeNumber,
+	"misses":    typeNumber,
+}
+
+// Env 提供节点字段的当前取值
+type Env interface {
+	Lookup(runtimeName string, field string) (interface{}, bool)
+}
+
+// ParseError 描述表达式语法错误及其位置（从 1 开始的字符列）
+type ParseError struct {
+	Column  int
+	Message string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("表达式第 %d 列: %s", e.Column, e.Message)
+}
+
+// Expr 是解析后的监视表达式
+type Expr struct {
+	source string
+	root   exprNode
+	nodes  []string
+}
+
+func (e *Expr) String() string {
+	return e.source
+}
+
+// 表达式引用的节点名
+func (e *Expr) Nodes() []string {
+	return append([]string(nil), e.nodes...)
+}
+
+// References 判断表达式是否引用了指定节点
+func (e *Expr) References(runtimeName string) bool {
+	for _, name := range e.nodes {
+		if name == runtimeName {
+			return true
+		}
+	}
+	return false
+}
+
+// Eval 求值，引用的字段尚无取值时结果为 false
+func (e *Expr) Eval(env Env) bool {
+	value, ok := e.root.eval(env)
+	if !ok {
+		return false
+	}
+	result, _ := value.(bool)
+	return result
+}
+
+func Parse(source string) (*Expr, error) {
+	tokens, err := lex(source)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokenEOF {
+		return nil, p.errorAt(tok, "多余的内容 %q", tok.text)
+	}
+	if root.valueType() != typeBool {
+		return nil, &ParseError{Column: 1, Message: fmt.Sprintf("表达式结果应为布尔值，实际为%s", root.valueType())}
+	}
+	return &Expr{source: strings.TrimSpace(source), root: root, nodes: p.nodes}, nil
+}
+
+// ---- 词法分析 ----
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenString
+	tokenNumber
+	tokenRegex
+	tokenOp
+	tokenLParen
+	tokenRParen
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+func lex(source string) ([]token, error) {
+	tokens := make([]token, 0)
+	column := 0
+	for i := 0; i < len(source); {
+		r, size := utf8.DecodeRuneInString(source[i:])
+		start := column
+		switch {
+		case unicode.IsSpace(r):
+			i += size
+			column++
+			continue
+		case r == '(' || r == ')':
+			kind := tokenLParen
+			if r == ')' {
+				kind = tokenRParen
+			}
+			tokens = append(tokens, token{kind: kind, text: string(r), pos: start})
+			i += size
+			column++
+			continue
+		case r == '"':
+			end := i + 1
+			for end < len(source) && source[end] != '"' {
+				if source[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(source) {
+				return nil, &ParseError{Column: start + 1, Message: "字符串缺少结束引号"}
+			}
+			text, err := strconv.Unquote(source[i : end+1])
+			if err != nil {
+				return nil, &ParseError{Column: start + 1, Message: "无效的字符串: " + err.Error()}
+			}
+			tokens = append(tokens, token{kind: tokenString, text: text, pos: start})
+			column += utf8.RuneCountInString(source[i : end+1])
+			i = end + 1
+			continue
+		case r == '/':
+			end := i + 1
+			for end < len(source) && source[end] != '/' {
+				if source[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(source) {
+				return nil, &ParseError{Column: start + 1, Message: "正则表达式缺少结束的 /"}
+			}
+			tokens = append(tokens, token{kind: tokenRegex, text: strings.ReplaceAll(source[i+1:end], `\/`, "/"), pos: start})
+			column += utf8.RuneCountInString(source[i : end+1])
+			i = end + 1
+			continue
+		}
+
+		if op := matchOperator(source[i:]); op != "" {
+			tokens = append(tokens, token{kind: tokenOp, text: op, pos: start})
+			i += len(op)
+			column += len(op)
+			continue
+		}
+
+		if r == '-' || unicode.IsDigit(r) {
+			end := i + size
+			for end < len(source) && (source[end] == '.' || (source[end] >= '0' && source[end] <= '9')) {
+				end++
+			}
+			if _, err := strconv.ParseFloat(source[i:end], 64); err == nil && (end == len(source) || !isIdentRune(rune(source[end]))) {
+				tokens = append(tokens, token{kind: tokenNumber, text: source[i:end], pos: start})
+				column += end - i
+				i = end
+				continue
+			}
+		}
+
+		if !isIdentRune(r) {
+			return nil, &ParseError{Column: start + 1, Message: fmt.Sprintf("无法识别的字符 %q", r)}
+		}
+		end := i
+		for end < len(source) {
+			next, nextSize := utf8.DecodeRuneInString(source[end:])
+			if !isIdentRune(next) {
+				break
+			}
+			end += nextSize
+		}
+		tokens = append(tokens, token{kind: tokenIdent, text: source[i:end], pos: start})
+		column += utf8.RuneCountInString(source[i:end])
+		i = end
+	}
+	tokens = append(tokens, token{kind: tokenEOF, pos: column})
+	return tokens, nil
+}
+
+func matchOperator(rest string) string {
+	for _, op := range []string{"<=", ">=", "==", "!=", "=~", "&&", "||", "<", ">", "!"} {
+		if strings.HasPrefix(rest, op) {
+			return op
+		}
+	}
+	return ""
+}
+
+func isIdentRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '.' || r == '-'
+}
+
+// ---- 语法分析 ----
+
+type parser struct {
+	tokens []token
+	index  int
+	nodes  []string
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.index]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.index]
+	if tok.kind != tokenEOF {
+		p.index++
+	}
+	return tok
+}
+
+func (p *parser) errorAt(tok token, format string, args ...interface{}) error {
+	return &ParseError{Column: tok.pos + 1, Message: fmt.Sprintf(format, args...)}
+}
+
+func (p *parser) acceptKeyword(symbol string, keyword string) bool {
+	tok := p.peek()
+	if (tok.kind == tokenOp && tok.text == symbol) || (tok.kind == tokenIdent && strings.EqualFold(tok.text, keyword)) {
+		p.next()
+		return true
+	}
+	return false
+}
+
+func (p *parser) parseOr() (exprNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		tok := p.peek()
+		if !p.acceptKeyword("||", "or") {
+			return left, nil
+		}
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.requireBool(tok, left, right); err != nil {
+			return nil, err
+		}
+		left = &logicalNode{or: true, left: left, right: right}
+	}
+}
+
+func (p *parser) parseAnd() (exprNode, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		tok := p.peek()
+		if !p.acceptKeyword("&&", "and") {
+			return left, nil
+		}
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.requireBool(tok, left, right); err != nil {
+			return nil, err
+		}
+		left = &logicalNode{left: left, right: right}
+	}
+}
+
+func (p *parser) requireBool(tok token, operands ...exprNode) error {
+	for _, operand := range operands {
+		if operand.valueType() != typeBool {
+			return p.errorAt(tok, "%q 两侧需要布尔值，实际为%s", tok.text, operand.valueType())
+		}
+	}
+	return nil
+}
+
+func (p *parser) parseUnary() (exprNode, error) {
+	tok := p.peek()
+	if p.acceptKeyword("!", "not") {
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.requireBool(tok, operand); err != nil {
+			return nil, err
+		}
+		return &notNode{operand: operand}, nil
+	}
+	if tok.kind == tokenLParen {
+		p.next()
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokenRParen {
+			return nil, p.errorAt(closing, "缺少右括号")
+		}
+		return inner, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *parser) parseComparison() (exprNode, error) {
+	left, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+
+	tok := p.peek()
+	op := ""
+	switch {
+	case tok.kind == tokenOp && tok.text != "&&" && tok.text != "||" && tok.text != "!":
+		op = tok.text
+	case tok.kind == tokenIdent && (strings.EqualFold(tok.text, "matches") || strings.EqualFold(tok.text, "contains")):
+		op = strings.ToLower(tok.text)
+	}
+	if op == "" {
+		return left, nil
+	}
+	p.next()
+	if op == "=~" {
+		op = "matches"
+	}
+
+	right, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+	if err := checkComparison(op, left.valueType(), right.valueType()); err != nil {
+		return nil, p.errorAt(tok, "%s", err.Error())
+	}
+	return &compareNode{op: op, left: left, right: right}, nil
+}
+
+func checkComparison(op string, left valueType, right valueType) error {
+	switch op {
+	case "matches":
+		if left != typeString || right != typeRegex {
+			return fmt.Errorf("matches 需要 字符串 matches /正则/，实际为 %s matches %s", left, right)
+		}
+	case "contains":
+		if left != typeString || right != typeString {
+			return fmt.Errorf("contains 需要两个字符串，实际为 %s contains %s", left, right)
+		}
+	case "<", "<=", ">", ">=":
+		if left != typeNumber || right != typeNumber {
+			return fmt.Errorf("%s 需要两个数值，实际为 %s %s %s", op, left, op, right)
+		}
+	default:
+		if left != right || left == typeRegex {
+			return fmt.Errorf("无法比较 %s %s %s", left, op, right)
+		}
+	}
+	return nil
+}
+
+func (p *parser) parseValue() (exprNode, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokenNumber:
+		value, _ := strconv.ParseFloat(tok.text, 64)
+		return &literalNode{value: value, typ: typeNumber}, nil
+	case tokenString:
+		return &literalNode{value: tok.text, typ: typeString}, nil
+	case tokenRegex:
+		pattern, err := regexp.Compile(tok.text)
+		if err != nil {
+			return nil, p.errorAt(tok, "无效的正则表达式: %v", err)
+		}
+		return &literalNode{value: pattern, typ: typeRegex}, nil
+	case tokenIdent:
+		switch strings.ToLower(tok.text) {
+		case "true", "false":
+			return &literalNode{value: strings.EqualFold(tok.text, "true"), typ: typeBool}, nil
+		case "node":
+			return p.parseNodeField()
+		}
+		return nil, p.errorAt(tok, "未知的标识符 %q，节点字段请写作 node <节点名> <字段>", tok.text)
+	case tokenEOF:
+		return nil, p.errorAt(tok, "表达式不完整")
+	default:
+		return nil, p.errorAt(tok, "意外的 %q", tok.text)
+	}
+}
+
+func (p *parser) parseNodeField() (exprNode, error) {
+	nameTok := p.next()
+	if nameTok.kind != tokenIdent && nameTok.kind != tokenString {
+		return nil, p.errorAt(nameTok, "node 后需要节点名")
+	}
+	fieldTok := p.next()
+	if fieldTok.kind != tokenIdent {
+		return nil, p.errorAt(fieldTok, "节点 %q 后需要字段名", nameTok.text)
+	}
+	field := strings.ToLower(fieldTok.text)
+	typ, ok := fieldTypes[field]
+	if !ok {
+		return nil, p.errorAt(fieldTok, "未知字段 %q，可用字段: hit, score, text, algorithm, hits, misses", fieldTok.text)
+	}
+	p.addNode(nameTok.text)
+	return &fieldNode{runtimeName: nameTok.text, field: field, typ: typ}, nil
+}
+
+func (p *parser) addNode(name string) {
+	for _, existing := range p.nodes {
+		if existing == name {
+			return
+		}
+	}
+	p.nodes = append(p.nodes, name)
+}
+
+// ---- 语法树与求值 ----
+
+type exprNode interface {
+	valueType() valueType
+	eval(env Env) (interface{}, bool)
+}
+
+type literalNode struct {
+	value interface{}
+	typ   valueType
+}
+
+func (n *literalNode) valueType() valueType { return n.typ }
+
+func (n *literalNode) eval(Env) (interface{}, bool) { return n.value, true }
+
+type fieldNode struct {
+	runtimeName string
+	field       string
+	typ         valueType
+}
+
+func (n *fieldNode) valueType() valueType { return n.typ }
+
+func (n *fieldNode) eval(env Env) (interface{}, bool) {
+	if env == nil {
+		return nil, false
+	}
+	value, ok := env.Lookup(n.runtimeName, n.field)
+	if !ok {
+		return nil, false
+	}
+	switch n.typ {
+	case typeNumber:
+		number, ok := toFloat(value)
+		return number, ok
+	case typeBool:
+		flag, ok := value.(bool)
+		return flag, ok
+	default:
+		text, ok := value.(string)
+		return text, ok
+	}
+}
+
+type notNode struct {
+	operand exprNode
+}
+
+func (n *notNode) valueType() valueType { return typeBool }
+
+func (n *notNode) eval(env Env) (interface{}, bool) {
+	value, ok := n.operand.eval(env)
+	if !ok {
+		return nil, false
+	}
+	return !value.(bool), true
+}
+
+type logicalNode struct {
+	or    bool
+	left  exprNode
+	right exprNode
+}
+
+func (n *logicalNode) valueType() valueType { return typeBool }
+
+func (n *logicalNode) eval(env Env) (interface{}, bool) {
+	left, leftOK := n.left.eval(env)
+	leftValue := leftOK && left.(bool)
+	if n.or && leftValue {
+		return true, true
+	}
+	if !n.or && leftOK && !leftValue {
+		return false, true
+	}
+	right, rightOK := n.right.eval(env)
+	rightValue := rightOK && right.(bool)
+	if n.or {
+		return rightValue, leftOK || rightOK
+	}
+	return rightValue, leftOK && rightOK
+}
+
+type compareNode struct {
+	op    string
+	left  exprNode
+	right exprNode
+}
+
+func (n *compareNode) valueType() valueType { return typeBool }
+
+func (n *compareNode) eval(env Env) (interface{}, bool) {
+	left, ok := n.left.eval(env)
+	if !ok {
+		return nil, false
+	}
+	right, ok := n.right.eval(env)
+	if !ok {
+		return nil, false
+	}
+
+	switch n.op {
+	case "matches":
+		return right.(*regexp.Regexp).MatchString(left.(string)), true
+	case "contains":
+		return strings.Contains(left.(string), right.(string)), true
+	case "<":
+		return left.(float64) < right.(float64), true
+	case "<=":
+		return left.(float64) <= right.(float64), true
+	case ">":
+		return left.(float64) > right.(float64), true
+	case ">=":
+		return left.(float64) >= right.(float64), true
+	case "==":
+		return left == right, true
+	case "!=":
+		return left != right, true
+	}
+	return nil, false
+}
+
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,93 @@
+package watch
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type mapEnv map[string]map[string]interface{}
+
+func (e mapEnv) Lookup(runtimeName string, field string) (interface{}, bool) {
+	value, ok := e[runtimeName][field]
+	return value, ok
+}
+
+func TestParseAndEval(t *testing.T) {
+	env := mapEnv{
+		"X":    {"score": 0.72, "hit": false, "misses": 3.0},
+		"开始游戏": {"text": "剩余 12 次", "hit": true, "algorithm": "OCR"},
+	}
+	tests := []struct {
+		expression string
+		want       bool
+	}{
+		{expression: "node X score < 0.8", want: true},
+		{expression: "node X score >= 0.8", want: false},
+		{expression: `node "开始游戏" text matches /\d+/`, want: true},
+		{expression: `node 开始游戏 text =~ /^\d+$/`, want: false},
+		{expression: `node 开始游戏 text contains "剩余"`, want: true},
+		{expression: "node X hit", want: false},
+		{expression: "not node X hit and node X misses > 2", want: true},
+		{expression: `node X hit || node 开始游戏 algorithm == "OCR"`, want: true},
+		{expression: "(node X score < 0.5 || node X misses == 3) && !node X hit", want: true},
+		{expression: "node Missing score < 1", want: false},
+		{expression: "node Missing hit || node 开始游戏 hit", want: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.expression, func(t *testing.T) {
+			expr, err := Parse(test.expression)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if got := expr.Eval(env); got != test.want {
+				t.Fatalf("Eval() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		expression string
+		column     int
+	}{
+		{expression: "node X score <", column: 15},
+		{expression: "node X confidence < 0.8", column: 8},
+		{expression: `node X score < "high"`, column: 14},
+		{expression: "node X text matches /[/", column: 21},
+		{expression: "node X score", column: 1},
+		{expression: "(node X hit", column: 12},
+		{expression: "node X hit and 1", column: 12},
+		{expression: "score < 0.8", column: 1},
+		{expression: `node "X hit`, column: 6},
+		{expression: "node X hit $", column: 12},
+	}
+
+	for _, test := range tests {
+		t.Run(test.expression, func(t *testing.T) {
+			_, err := Parse(test.expression)
+			var parseErr *ParseError
+			if !errors.As(err, &parseErr) {
+				t.Fatalf("Parse() error = %v, want ParseError", err)
+			}
+			if parseErr.Column != test.column {
+				t.Fatalf("Parse() column = %d, want %d (%v)", parseErr.Column, test.column, err)
+			}
+		})
+	}
+}
+
+func TestExprNodes(t *testing.T) {
+	expr, err := Parse(`node A hit && (node "B C" score > 0.5 || node A misses > 1)`)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if got := expr.Nodes(); !reflect.DeepEqual(got, []string{"A", "B C"}) {
+		t.Fatalf("Nodes() = %v", got)
+	}
+	if !expr.References("B C") || expr.References("B") {
+		t.Fatal("References() mismatch")
+	}
+}
@@ -0,0 +1,233 @@
+package watch
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/debug/protocol"
+)
+
+// Watch 是会话上的一条监视表达式
+type Watch struct {
+	ID         string `json:"id"`
+	Expression string `json:"expression"`
+	Pause      bool   `json:"pause,omitempty"`
+	CreatedAt  string `json:"createdAt"`
+	HitCount   int    `json:"hitCount"`
+
+	expr *Expr
+}
+
+// Trigger 描述一次监视表达式成立
+type Trigger struct {
+	Watch       Watch
+	RuntimeName string
+	Values      map[string]interface{}
+}
+
+type nodeValues map[string]interface{}
+
+// 单个 run 内各节点最近一次识别结果，作为表达式求值环境
+type runValues map[string]nodeValues
+
+type sessionWatches struct {
+	watches []*Watch
+	values  map[string]runValues
+}
+
+// Registry 按会话保存监视表达式，按 run 保存节点最近一次识别结果
+type Registry struct {
+	mu       sync.Mutex
+	sessions map[string]*sessionWatches
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		sessions: make(map[string]*sessionWatches),
+	}
+}
+
+func (r *Registry) Add(sessionID string, expression string, pause bool) (Watch, error) {
+	expr, err := Parse(expression)
+	if err != nil {
+		return Watch{}, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	watch := &Watch{
+		ID:         uuid.NewString(),
+		Expression: expr.String(),
+		Pause:      pause,
+		CreatedAt:  time.Now().UTC().Format(time.RFC3339Nano),
+		expr:       expr,
+	}
+	session := r.session(sessionID)
+	session.watches = append(session.watches, watch)
+	return *watch, nil
+}
+
+func (r *Registry) Remove(sessionID string, watchID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	session := r.sessions[sessionID]
+	if session != nil {
+		for i, watch := range session.watches {
+			if watch.ID == watchID {
+				session.watches = append(session.watches[:i], session.watches[i+1:]...)
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("watch not found: %s", watchID)
+}
+
+func (r *Registry) List(sessionID string) []Watch {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	result := make([]Watch, 0)
+	if session := r.sessions[sessionID]; session != nil {
+		for _, watch := range session.watches {
+			result = append(result, *watch)
+		}
+	}
+	return result
+}
+
+// 清空会话内全部 run 的节点取值，保留表达式
+func (r *Registry) ResetValues(sessionID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if session := r.sessions[sessionID]; session != nil {
+		session.values = make(map[string]runValues)
+	}
+}
+
+// run 结束后释放其节点取值
+func (r *Registry) DeleteRun(sessionID string, runID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if session := r.sessions[sessionID]; session != nil {
+		delete(session.values, runID)
+	}
+}
+
+func (r *Registry) DeleteSession(sessionID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.sessions, sessionID)
+}
+
+// Observe 用识别事件更新节点取值，并返回因此成立的监视表达式
+func (r *Registry) Observe(event protocol.Event) []Trigger {
+	runtimeName, values, ok := recognitionValues(event)
+	if !ok {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	session := r.sessions[event.SessionID]
+	if session == nil || len(session.watches) == 0 {
+		return nil
+	}
+
+	env := session.values[event.RunID]
+	if env == nil {
+		env = make(runValues)
+		session.values[event.RunID] = env
+	}
+	current := env[runtimeName]
+	if current == nil {
+		current = nodeValues{"hits": 0.0, "misses": 0.0}
+		env[runtimeName] = current
+	}
+	delete(current, "score")
+	delete(current, "text")
+	for key, value := range values {
+		current[key] = value
+	}
+	if hit, _ := values["hit"].(bool); hit {
+		current["hits"] = current["hits"].(float64) + 1
+	} else {
+		current["misses"] = current["misses"].(float64) + 1
+	}
+
+	triggers := make([]Trigger, 0)
+	for _, watch := range session.watches {
+		if !watch.expr.References(runtimeName) || !watch.expr.Eval(env) {
+			continue
+		}
+		watch.HitCount++
+		triggers = append(triggers, Trigger{
+			Watch:       *watch,
+			RuntimeName: runtimeName,
+			Values:      env.snapshot(watch.expr.Nodes()),
+		})
+	}
+	return triggers
+}
+
+func (r *Registry) session(sessionID string) *sessionWatches {
+	session := r.sessions[sessionID]
+	if session == nil {
+		session = &sessionWatches{values: make(map[string]runValues)}
+		r.sessions[sessionID] = session
+	}
+	return session
+}
+
+func (v runValues) Lookup(runtimeName string, field string) (interface{}, bool) {
+	values := v[runtimeName]
+	if values == nil {
+		return nil, false
+	}
+	value, ok := values[field]
+	return value, ok
+}
+
+func (v runValues) snapshot(nodes []string) map[string]interface{} {
+	sort.Strings(nodes)
+	result := make(map[string]interface{}, len(nodes))
+	for _, name := range nodes {
+		values := make(map[string]interface{}, len(v[name]))
+		for key, value := range v[name] {
+			values[key] = value
+		}
+		result[name] = values
+	}
+	return result
+}
+
+// 仅 Node.Recognition 的结束事件携带识别结果
+func recognitionValues(event protocol.Event) (string, map[string]interface{}, bool) {
+	if event.Kind != "recognition" || event.Node == nil || event.Node.RuntimeName == "" {
+		return "", nil, false
+	}
+	if !strings.HasPrefix(event.MaaFWMessage, "Node.Recognition.") || (event.Phase != "succeeded" && event.Phase != "failed") {
+		return "", nil, false
+	}
+
+	hit := event.Phase == "succeeded"
+	if value, ok := event.Data["hit"].(bool); ok {
+		hit = value
+	}
+	values := map[string]interface{}{"hit": hit}
+	if score, ok := toFloat(event.Data["score"]); ok {
+		values["score"] = score
+	}
+	for _, key := range []string{"text", "algorithm"} {
+		if value, ok := event.Data[key].(string); ok {
+			values[key] = value
+		}
+	}
+	return event.Node.RuntimeName, values, true
+}
@@ -0,0 +1,99 @@
+package watch
+
+import (
+	"testing"
+
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/debug/protocol"
+)
+
+func TestRegistryObserve(t *testing.T) {
+	registry := NewRegistry()
+	low, err := registry.Add("session-1", "node X score < 0.8", true)
+	if err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if _, err := registry.Add("session-1", "node X misses >= 2", false); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+
+	triggers := registry.Observe(recognitionEvent("X", "failed", 0.7))
+	if len(triggers) != 1 || triggers[0].Watch.ID != low.ID || triggers[0].Watch.HitCount != 1 {
+		t.Fatalf("first Observe() = %+v", triggers)
+	}
+	values := triggers[0].Values["X"].(map[string]interface{})
+	if values["score"] != 0.7 || values["misses"] != 1.0 {
+		t.Fatalf("trigger values = %+v", values)
+	}
+
+	triggers = registry.Observe(recognitionEvent("X", "failed", 0.9))
+	if len(triggers) != 1 || triggers[0].Watch.Expression != "node X misses >= 2" {
+		t.Fatalf("second Observe() = %+v", triggers)
+	}
+
+	ignored := recognitionEvent("X", "starting", 0.1)
+	if got := registry.Observe(ignored); len(got) != 0 {
+		t.Fatalf("Observe() starting event = %+v, want none", got)
+	}
+	if got := registry.Observe(recognitionEvent("Y", "failed", 0.1)); len(got) != 0 {
+		t.Fatalf("Observe() unrelated node = %+v, want none", got)
+	}
+
+	if err := registry.Remove("session-1", low.ID); err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+	if got := registry.List("session-1"); len(got) != 1 {
+		t.Fatalf("List() len = %d, want 1", len(got))
+	}
+	registry.ResetValues("session-1")
+	if got := registry.Observe(recognitionEvent("X", "failed", 0.1)); len(got) != 0 {
+		t.Fatalf("Observe() after reset = %+v, want none", got)
+	}
+}
+
+func TestRegistryAddRejectsInvalidExpression(t *testing.T) {
+	registry := NewRegistry()
+	if _, err := registry.Add("session-1", "node X score <", false); err == nil {
+		t.Fatal("Add() error = nil for incomplete expression")
+	}
+	if got := registry.List("session-1"); len(got) != 0 {
+		t.Fatalf("List() = %+v, want empty", got)
+	}
+}
+
+func recognitionEvent(runtimeName string, phase string, score float64) protocol.Event {
+	suffix := map[string]string{"starting": "Starting", "succeeded": "Succeeded", "failed": "Failed"}[phase]
+	return protocol.Event{
+		SessionID:    "session-1",
+		RunID:        "run-1",
+		Kind:         "recognition",
+		MaaFWMessage: "Node.Recognition." + suffix,
+		Phase:        phase,
+		Node:         &protocol.EventNode{RuntimeName: runtimeName},
+		Data:         map[string]interface{}{"score": score},
+	}
+}
+
+func TestRegistryKeepsValuesPerRun(t *testing.T) {
+	registry := NewRegistry()
+	if _, err := registry.Add("session-1", "node X misses >= 2", false); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+
+	first := recognitionEvent("X", "failed", 0.1)
+	second := recognitionEvent("X", "failed", 0.1)
+	second.RunID = "run-2"
+	if got := registry.Observe(first); len(got) != 0 {
+		t.Fatalf("Observe() run-1 = %+v, want none", got)
+	}
+	if got := registry.Observe(second); len(got) != 0 {
+		t.Fatalf("Observe() run-2 must not see run-1 misses: %+v", got)
+	}
+	if got := registry.Observe(first); len(got) != 1 {
+		t.Fatalf("Observe() run-1 second miss = %+v, want trigger", got)
+	}
+
+	registry.DeleteRun("session-1", "run-2")
+	if got := registry.Observe(second); len(got) != 0 {
+		t.Fatalf("Observe() after DeleteRun = %+v, want none", got)
+	}
+}
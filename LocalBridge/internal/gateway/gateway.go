@@ -0,0 +1,174 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/errors"
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/server"
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/pkg/models"
+)
+
+// 关联 ID 请求头，未提供时使用网关生成的连接 ID，响应原样带回
+const HeaderRequestID = "X-Request-ID"
+
+// 请求体大小上限，与保存较大的 pipeline 文件相匹配
+const maxBodyBytes = 64 << 20
+
+// 网关自身的错误码
+const (
+	ErrGatewayTimeout = "GATEWAY_TIMEOUT"
+	ErrClientClosed   = "CLIENT_CLOSED"
+)
+
+// Dispatcher 将消息分发给协议处理器，与 WebSocket 消息处理函数一致
+type Dispatcher func(msg models.Message, conn *server.Connection)
+
+// Gateway 在 WebSocket 服务的端口上提供 HTTP/JSON 接口，
+// 每个请求通过合成连接交给现有处理器，并返回第一个匹配的响应消息
+type Gateway struct {
+	server   *server.WebSocketServer
+	dispatch Dispatcher
+	seq      atomic.Uint64
+}
+
+func New(wsServer *server.WebSocketServer, dispatch Dispatcher) *Gateway {
+	return &Gateway{server: wsServer, dispatch: dispatch}
+}
+
+// Register 将路由表与 OpenAPI 文档注册到 WebSocket 服务，需在服务启动前调用
+func (g *Gateway) Register() {
+	for _, route := range Routes {
+		g.server.Handle(route.Method+" "+route.Path, g.handler(route))
+	}
+	g.server.Handle(http.MethodGet+" "+PathOpenAPI, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, Document())
+	}))
+}
+
+func (g *Gateway) handler(route Route) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, err := requestData(w, r)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, models.ErrorData{Code: errors.ErrInvalidRequest, Message: err.Error()})
+			return
+		}
+		if field := route.missing(data); field != "" {
+			writeJSON(w, http.StatusBadRequest, models.ErrorData{Code: errors.ErrInvalidRequest, Message: "缺少必需参数: " + field})
+			return
+		}
+
+		id := fmt.Sprintf("http-%d@%s", g.seq.Add(1), r.RemoteAddr)
+		conn := g.server.NewSyntheticConnection(id, server.TokenFromContext(r.Context()))
+		defer g.server.CloseSyntheticConnection(conn)
+		requestID := strings.TrimSpace(r.Header.Get(HeaderRequestID))
+		if requestID == "" {
+			requestID = id
+		}
+		w.Header().Set(HeaderRequestID, requestID)
+
+		// 服务端默认写超时较短，按路由的等待时间放宽
+		timeout := route.timeout()
+		_ = http.NewResponseController(w).SetWriteDeadline(time.Now().Add(timeout + 5*time.Second))
+
+		go g.dispatch(models.Message{Path: route.Target, Data: data, CorrelationID: requestID}, conn)
+		status, body := collect(r.Context(), conn, route, requestID, timeout)
+		writeJSON(w, status, body)
+	})
+}
+
+// collect 等待第一个响应或错误消息，忽略日志等广播消息及带有其他关联 ID 的消息
+func collect(ctx context.Context, conn *server.Connection, route Route, requestID string, timeout time.Duration) (int, interface{}) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for {
+		select {
+		case raw, ok := <-conn.Receive():
+			if !ok {
+				return http.StatusServiceUnavailable, models.ErrorData{Code: errors.ErrConnectionFailed, Message: "服务正在关闭"}
+			}
+			var msg struct {
+				Path          string          `json:"path"`
+				Data          json.RawMessage `json:"data"`
+				CorrelationID string          `json:"correlation_id"`
+			}
+			if err := json.Unmarshal(raw, &msg); err != nil {
+				continue
+			}
+			if msg.CorrelationID != "" && msg.CorrelationID != requestID {
+				continue
+			}
+			if route.isReply(msg.Path) {
+				return http.StatusOK, msg.Data
+			}
+			if msg.Path == pathError || msg.Path == pathDebugError {
+				var data models.ErrorData
+				_ = json.Unmarshal(msg.Data, &data)
+				return errorStatus(data.Code), msg.Data
+			}
+		case <-timer.C:
+			return http.StatusGatewayTimeout, models.ErrorData{
+				Code:    ErrGatewayTimeout,
+				Message: fmt.Sprintf("等待 %s 响应超时", route.Target),
+			}
+		case <-ctx.Done():
+			return http.StatusServiceUnavailable, models.ErrorData{Code: ErrClientClosed, Message: "请求已取消"}
+		}
+	}
+}
+
+// GET 的查询参数与 POST 的 JSON 对象都转为处理器收到的 map 数据，鉴权用的 token 参数不转发
+func requestData(w http.ResponseWriter, r *http.Request) (map[string]interface{}, error) {
+	data := make(map[string]interface{})
+	if r.Method == http.MethodGet {
+		for key, values := range r.URL.Query() {
+			if key != "token" && len(values) > 0 {
+				data[key] = values[0]
+			}
+		}
+		return data, nil
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+	if err != nil {
+		return nil, fmt.Errorf("读取请求体失败: %w", err)
+	}
+	if strings.TrimSpace(string(body)) == "" {
+		return data, nil
+	}
+	if err := json.Unmarshal(body, &data); err != nil {
+		return nil, fmt.Errorf("请求体必须是 JSON 对象: %w", err)
+	}
+	return data, nil
+}
+
+func errorStatus(code string) int {
+	switch code {
+	case errors.ErrInvalidRequest, errors.ErrInvalidJSON, "debug_invalid_request":
+		return http.StatusBadRequest
+	case errors.ErrPermissionDenied, "debug_session_forbidden":
+		return http.StatusForbidden
+	case errors.ErrFileNotFound:
+		return http.StatusNotFound
+	case errors.ErrFileNameConflict:
+		return http.StatusConflict
+	case "debug_not_initialized":
+		return http.StatusServiceUnavailable
+	}
+	if strings.HasSuffix(code, "_not_found") {
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,142 @@
+package gateway
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/errors"
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/eventbus"
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/server"
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/pkg/models"
+)
+
+func findRoute(t *testing.T, path string) Route {
+	t.Helper()
+	for _, route := range Routes {
+		if route.Path == path {
+			return route
+		}
+	}
+	t.Fatalf("route %s not found", path)
+	return Route{}
+}
+
+func TestGatewayCollectsReply(t *testing.T) {
+	wsServer := server.NewWebSocketServer("localhost", 9066, eventbus.New(), nil)
+	gateway := New(wsServer, func(msg models.Message, conn *server.Connection) {
+		data, _ := msg.Data.(map[string]interface{})
+		switch msg.Path {
+		case "/etl/open_file":
+			// 广播的日志等无关消息会被忽略
+			conn.Send(models.Message{Path: "/lte/logger", Data: "ignored"})
+			conn.Send(models.Message{Path: "/lte/file_content", Data: data})
+		case "/etl/save_file":
+			conn.Send(models.Message{Path: "/error", Data: errors.NewFileNotFoundError("a.json").ToErrorData()})
+		case "/etl/refresh_file_list":
+			wsServer.Broadcast(models.Message{Path: "/lte/file_list", Data: data})
+		case "/mpe/debug/trace/snapshot":
+			conn.Send(models.Message{Path: "/lte/debug/error", Data: map[string]interface{}{"code": "debug_invalid_request"}})
+		}
+	})
+
+	tests := []struct {
+		name   string
+		route  string
+		url    string
+		body   string
+		status int
+		want   string
+	}{
+		{name: "reply", route: "/api/files/open", body: `{"file_path": "a.json"}`, status: http.StatusOK, want: `{"file_path":"a.json"}`},
+		{name: "broadcast reply", route: "/api/files", url: "/api/files?root=x&token=secret", status: http.StatusOK, want: `{"root":"x"}`},
+		{name: "protocol error", route: "/api/files/save", body: `{}`, status: http.StatusNotFound, want: `"FILE_NOT_FOUND"`},
+		{name: "debug error", route: "/api/debug/trace/snapshot", status: http.StatusBadRequest, want: `"debug_invalid_request"`},
+		{name: "invalid body", route: "/api/files/open", body: `[1]`, status: http.StatusBadRequest, want: `"INVALID_REQUEST"`},
+		{name: "missing session", route: "/api/debug/screenshot/capture", body: `{"controllerId": "c1"}`, status: http.StatusBadRequest, want: `sessionId`},
+		{name: "missing owner token", route: "/api/debug/screenshot/capture", body: `{"sessionId": "s1"}`, status: http.StatusBadRequest, want: `ownerToken`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			route := findRoute(t, test.route)
+			url := test.url
+			if url == "" {
+				url = route.Path
+			}
+			request := httptest.NewRequest(route.Method, url, strings.NewReader(test.body))
+			recorder := httptest.NewRecorder()
+			gateway.handler(route).ServeHTTP(recorder, request)
+			if recorder.Code != test.status || !strings.Contains(recorder.Body.String(), test.want) {
+				t.Fatalf("response = %d %s, want %d containing %s", recorder.Code, recorder.Body.String(), test.status, test.want)
+			}
+		})
+	}
+}
+
+func TestGatewayRequestID(t *testing.T) {
+	wsServer := server.NewWebSocketServer("localhost", 9066, eventbus.New(), nil)
+	gateway := New(wsServer, func(msg models.Message, conn *server.Connection) {
+		// 其他请求的响应被忽略
+		conn.Send(models.Message{Path: "/lte/file_content", Data: "other", CorrelationID: "other"})
+		conn.Send(models.Message{Path: "/lte/file_content", Data: msg.CorrelationID, CorrelationID: msg.CorrelationID})
+	})
+	route := findRoute(t, "/api/files/open")
+
+	request := httptest.NewRequest(route.Method, route.Path, strings.NewReader(`{}`))
+	request.Header.Set(HeaderRequestID, "req-1")
+	recorder := httptest.NewRecorder()
+	gateway.handler(route).ServeHTTP(recorder, request)
+	if got := recorder.Header().Get(HeaderRequestID); got != "req-1" {
+		t.Fatalf("%s = %q, want req-1", HeaderRequestID, got)
+	}
+	if body := strings.TrimSpace(recorder.Body.String()); body != `"req-1"` {
+		t.Fatalf("body = %s, want \"req-1\"", body)
+	}
+
+	recorder = httptest.NewRecorder()
+	gateway.handler(route).ServeHTTP(recorder, httptest.NewRequest(route.Method, route.Path, strings.NewReader(`{}`)))
+	if got := recorder.Header().Get(HeaderRequestID); !strings.HasPrefix(got, "http-") {
+		t.Fatalf("generated %s = %q, want http- prefix", HeaderRequestID, got)
+	}
+}
+
+func TestGatewayTimeout(t *testing.T) {
+	wsServer := server.NewWebSocketServer("localhost", 9066, eventbus.New(), nil)
+	gateway := New(wsServer, func(models.Message, *server.Connection) {})
+	route := findRoute(t, "/api/debug/artifact/get")
+	route.Timeout = 10 * time.Millisecond
+
+	recorder := httptest.NewRecorder()
+	gateway.handler(route).ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, route.Path, nil))
+	if recorder.Code != http.StatusGatewayTimeout {
+		t.Fatalf("status = %d, want %d", recorder.Code, http.StatusGatewayTimeout)
+	}
+}
+
+func TestDocumentCoversRoutes(t *testing.T) {
+	data, err := json.Marshal(Document())
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	var doc struct {
+		Paths map[string]map[string]struct {
+			OperationID   string `json:"operationId"`
+			RequiredScope string `json:"x-required-scope"`
+		} `json:"paths"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	for _, route := range Routes {
+		op, ok := doc.Paths[route.Path][strings.ToLower(route.Method)]
+		if !ok || op.OperationID == "" || op.RequiredScope == "" {
+			t.Fatalf("operation for %s %s = %+v", route.Method, route.Path, op)
+		}
+	}
+	if op := doc.Paths["/api/files/save"]["post"]; op.RequiredScope != "file.write" {
+		t.Fatalf("save scope = %q", op.RequiredScope)
+	}
+}
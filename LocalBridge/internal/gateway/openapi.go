@@ -0,0 +1,106 @@
+package gateway
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/auth"
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/server"
+)
+
+// Document 由路由表生成 OpenAPI 3 文档；请求与响应数据与对应的 WebSocket 消息 data 一致
+func Document() map[string]interface{} {
+	paths := make(map[string]interface{}, len(Routes))
+	for _, route := range Routes {
+		item, _ := paths[route.Path].(map[string]interface{})
+		if item == nil {
+			item = make(map[string]interface{})
+			paths[route.Path] = item
+		}
+		item[strings.ToLower(route.Method)] = operation(route)
+	}
+
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":       "MPE Local Bridge HTTP Gateway",
+			"version":     server.ProtocolVersion,
+			"description": "HTTP/JSON 接口，转发至对应的 WebSocket 路由。开启 server.auth.enabled 后需携带令牌。",
+		},
+		"paths": paths,
+		"components": map[string]interface{}{
+			"schemas": map[string]interface{}{
+				"Error": map[string]interface{}{
+					"type":     "object",
+					"required": []string{"code", "message"},
+					"properties": map[string]interface{}{
+						"code":    map[string]interface{}{"type": "string"},
+						"message": map[string]interface{}{"type": "string"},
+						"detail":  map[string]interface{}{},
+					},
+				},
+			},
+			"securitySchemes": map[string]interface{}{
+				"bearerToken": map[string]interface{}{"type": "http", "scheme": "bearer"},
+				"queryToken":  map[string]interface{}{"type": "apiKey", "in": "query", "name": "token"},
+			},
+		},
+		// 空对象表示未启用鉴权时无需令牌
+		"security": []map[string][]string{{"bearerToken": {}}, {"queryToken": {}}, {}},
+	}
+}
+
+func operation(route Route) map[string]interface{} {
+	description := "转发至 WebSocket 路由 `" + route.Target + "`，返回 `" + strings.Join(route.Replies, "` / `") + "` 消息的 data。"
+	if route.Method == http.MethodGet {
+		description += "查询参数作为请求数据。"
+	}
+	op := map[string]interface{}{
+		"summary":          route.Summary,
+		"description":      description,
+		"operationId":      operationID(route.Target),
+		"tags":             []string{route.Tag},
+		"x-websocket-path": route.Target,
+		"x-required-scope": auth.RequiredScope(route.Target),
+		"responses": map[string]interface{}{
+			"200": jsonResponse("成功响应", map[string]interface{}{"type": "object"}),
+			"400": errorResponse("请求参数无效"),
+			"401": errorResponse("缺少或无效的令牌"),
+			"403": errorResponse("令牌缺少权限或路径非法"),
+			"404": errorResponse("目标不存在"),
+			"500": errorResponse("处理失败"),
+			"504": errorResponse("等待响应超时"),
+		},
+	}
+	if route.Method != http.MethodGet {
+		schema := map[string]interface{}{"type": "object", "additionalProperties": true}
+		if len(route.Require) > 0 {
+			schema["required"] = route.Require
+		}
+		op["requestBody"] = map[string]interface{}{
+			"required": len(route.Require) > 0,
+			"content": map[string]interface{}{
+				"application/json": map[string]interface{}{"schema": schema},
+			},
+		}
+	}
+	return op
+}
+
+func jsonResponse(description string, schema interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"description": description,
+		"content": map[string]interface{}{
+			"application/json": map[string]interface{}{"schema": schema},
+		},
+	}
+}
+
+func errorResponse(description string) map[string]interface{} {
+	return jsonResponse(description, map[string]interface{}{"$ref": "#/components/schemas/Error"})
+}
+
+// /mpe/debug/trace/snapshot -> mpe_debug_trace_snapshot
+func operationID(target string) string {
+	return strings.ReplaceAll(strings.Trim(target, "/"), "/", "_")
+}
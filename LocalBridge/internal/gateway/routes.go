@@ -0,0 +1,140 @@
+package gateway
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+// 网关的 HTTP 前缀
+const PathPrefix = "/api/"
+
+// OpenAPI 文档路径
+const PathOpenAPI = "/api/openapi.json"
+
+// 默认等待响应的时间
+const defaultTimeout = 30 * time.Second
+
+// Route 将一个 HTTP 接口映射到请求/响应式的 WebSocket 路由
+type Route struct {
+	Method  string        // HTTP 方法，GET 时查询参数作为请求数据，POST 时请求体为 JSON 数据
+	Path    string        // HTTP 路径
+	Target  string        // 转发的 WebSocket 路由
+	Replies []string      // 视为响应的消息路由，取第一个到达的
+	Require []string      // 必需的请求字段，缺失时直接返回 400 而不转发
+	Summary string        // OpenAPI 摘要
+	Tag     string        // OpenAPI 分组
+	Timeout time.Duration // 等待响应的时间，0 表示使用默认值
+}
+
+// Routes 网关路由表，OpenAPI 文档由此生成
+var Routes = []Route{
+	{
+		Method:  http.MethodGet,
+		Path:    "/api/files",
+		Target:  "/etl/refresh_file_list",
+		Replies: []string{"/lte/file_list"},
+		Summary: "重新扫描并返回文件列表",
+		Tag:     "file",
+	},
+	{
+		Method:  http.MethodPost,
+		Path:    "/api/files/open",
+		Target:  "/etl/open_file",
+		Replies: []string{"/lte/file_content"},
+		Summary: "读取 pipeline 文件",
+		Tag:     "file",
+	},
+	{
+		Method:  http.MethodPost,
+		Path:    "/api/files/save",
+		Target:  "/etl/save_file",
+		Replies: []string{"/ack/save_file"},
+		Summary: "保存 pipeline 文件",
+		Tag:     "file",
+	},
+	{
+		Method:  http.MethodPost,
+		Path:    "/api/images/get",
+		Target:  "/etl/get_image",
+		Replies: []string{"/lte/image"},
+		Summary: "按相对路径读取资源图片",
+		Tag:     "resource",
+	},
+	{
+		Method:  http.MethodPost,
+		Path:    "/api/debug/resource/health",
+		Target:  "/mpe/debug/resource/health",
+		Replies: []string{"/lte/debug/resource_health"},
+		Summary: "检查资源包健康状况",
+		Tag:     "debug",
+		Timeout: 2 * time.Minute,
+	},
+	{
+		Method:  http.MethodPost,
+		Path:    "/api/debug/resource/preflight",
+		Target:  "/mpe/debug/resource/preflight",
+		Replies: []string{"/lte/debug/resource_preflight"},
+		Summary: "运行前资源预检",
+		Tag:     "debug",
+		Timeout: 2 * time.Minute,
+	},
+	{
+		Method:  http.MethodPost,
+		Path:    "/api/debug/screenshot/capture",
+		Target:  "/mpe/debug/screenshot/capture",
+		Replies: []string{"/lte/debug/event"},
+		// 合成连接在请求结束后关闭，不允许隐式创建无法再访问的调试会话
+		Require: []string{"sessionId", "ownerToken"},
+		Summary: "通过已连接的控制器截图，返回 screenshot 事件",
+		Tag:     "debug",
+	},
+	{
+		Method:  http.MethodPost,
+		Path:    "/api/debug/trace/snapshot",
+		Target:  "/mpe/debug/trace/snapshot",
+		Replies: []string{"/lte/debug/trace_snapshot"},
+		Summary: "获取调试会话的 trace 快照",
+		Tag:     "debug",
+	},
+	{
+		Method:  http.MethodPost,
+		Path:    "/api/debug/artifact/get",
+		Target:  "/mpe/debug/artifact/get",
+		Replies: []string{"/lte/debug/artifact"},
+		Summary: "读取调试产物",
+		Tag:     "debug",
+	},
+}
+
+// 处理器返回的错误消息路由
+const (
+	pathError      = "/error"
+	pathDebugError = "/lte/debug/error"
+)
+
+func (r Route) timeout() time.Duration {
+	if r.Timeout > 0 {
+		return r.Timeout
+	}
+	return defaultTimeout
+}
+
+// 返回第一个缺失或为空的必需字段
+func (r Route) missing(data map[string]interface{}) string {
+	for _, field := range r.Require {
+		if value, ok := data[field].(string); !ok || strings.TrimSpace(value) == "" {
+			return field
+		}
+	}
+	return ""
+}
+
+func (r Route) isReply(path string) bool {
+	for _, reply := range r.Replies {
+		if reply == path {
+			return true
+		}
+	}
+	return false
+}
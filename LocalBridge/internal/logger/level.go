@@ -0,0 +1,114 @@
+package logger
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// 控制台与客户端推送的日志级别，可按模块覆盖；文件日志始终记录全部级别。
+// 模块名称不区分大小写（配置文件中的键会被转为小写）
+type levelState struct {
+	mu      sync.RWMutex
+	base    logrus.Level
+	modules map[string]logrus.Level
+}
+
+var levels = &levelState{
+	base:    logrus.InfoLevel,
+	modules: make(map[string]logrus.Level),
+}
+
+// ParseLevel 解析日志级别名称，大小写不敏感，warning 与 warn 等价
+func ParseLevel(level string) (logrus.Level, error) {
+	parsed, err := logrus.ParseLevel(strings.TrimSpace(level))
+	if err != nil {
+		return logrus.InfoLevel, fmt.Errorf("无效的日志级别: %q（可选: trace, debug, info, warn, error）", level)
+	}
+	return parsed, nil
+}
+
+// SetLevel 设置全局日志级别
+func SetLevel(level string) error {
+	parsed, err := ParseLevel(level)
+	if err != nil {
+		return err
+	}
+	levels.mu.Lock()
+	defer levels.mu.Unlock()
+	levels.base = parsed
+	return nil
+}
+
+// SetModuleLevel 设置单个模块的日志级别，level 为空时移除该模块的覆盖
+func SetModuleLevel(module string, level string) error {
+	module = moduleKey(module)
+	if module == "" {
+		return fmt.Errorf("模块名称为空")
+	}
+	if strings.TrimSpace(level) == "" {
+		levels.mu.Lock()
+		defer levels.mu.Unlock()
+		delete(levels.modules, module)
+		return nil
+	}
+	parsed, err := ParseLevel(level)
+	if err != nil {
+		return err
+	}
+	levels.mu.Lock()
+	defer levels.mu.Unlock()
+	levels.modules[module] = parsed
+	return nil
+}
+
+// SetModuleLevels 替换全部模块级别覆盖，任一级别无效时不做修改
+func SetModuleLevels(modules map[string]string) error {
+	parsed, err := parseModuleLevels(modules)
+	if err != nil {
+		return err
+	}
+	levels.mu.Lock()
+	defer levels.mu.Unlock()
+	levels.modules = parsed
+	return nil
+}
+
+// Levels 返回当前全局级别与模块覆盖
+func Levels() (string, map[string]string) {
+	levels.mu.RLock()
+	defer levels.mu.RUnlock()
+	modules := make(map[string]string, len(levels.modules))
+	for module, level := range levels.modules {
+		modules[module] = level.String()
+	}
+	return levels.base.String(), modules
+}
+
+func parseModuleLevels(modules map[string]string) (map[string]logrus.Level, error) {
+	parsed := make(map[string]logrus.Level, len(modules))
+	for module, level := range modules {
+		value, err := ParseLevel(level)
+		if err != nil {
+			return nil, fmt.Errorf("模块 %s: %w", module, err)
+		}
+		parsed[moduleKey(module)] = value
+	}
+	return parsed, nil
+}
+
+// enabled 判断模块的该级别日志是否输出到控制台
+func (s *levelState) enabled(module string, level logrus.Level) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if override, ok := s.modules[moduleKey(module)]; ok {
+		return level <= override
+	}
+	return level <= s.base
+}
+
+func moduleKey(module string) string {
+	return strings.ToLower(strings.TrimSpace(module))
+}
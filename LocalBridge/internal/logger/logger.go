@@ -3,7 +3,7 @@ package logger
 import (
 	"fmt"
 	"os"
-	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 
@@ -16,6 +16,9 @@ var consoleLogger *logrus.Logger
 // 文件日志实例
 var fileLogger *logrus.Logger
 
+// 文件日志输出
+var fileOutput *rotatingFile
+
 // 对外暴露的日志实例
 var Logger *logrus.Logger
 
@@ -39,17 +42,43 @@ var (
 	maxBufferSize = 100
 )
 
+// 文件日志格式
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// Options 日志系统配置
+type Options struct {
+	Level        string
+	Dir          string
+	PushToClient bool
+	Format       string            // 文件日志格式: text 或 json（每行一个 JSON 对象）
+	MaxSizeMB    int               // 单个日志文件大小上限，0 表示仅按日期切分
+	MaxFiles     int               // 最多保留的日志文件数（含当前文件），0 表示不限制
+	MaxAgeDays   int               // 日志文件保留天数，0 表示不限制
+	Modules      map[string]string // 按模块覆盖的日志级别
+}
+
 // 初始化日志系统
-func Init(logLevel string, logDir string, pushToClient bool) error {
-	// 解析控制台日志级别
-	consoleLevel, err := logrus.ParseLevel(logLevel)
+func Init(opts Options) error {
+	// 解析控制台日志级别，无效时使用 INFO
+	consoleLevel, err := ParseLevel(opts.Level)
 	if err != nil {
 		consoleLevel = logrus.InfoLevel
 	}
+	modules, err := parseModuleLevels(opts.Modules)
+	if err != nil {
+		return err
+	}
+	levels.mu.Lock()
+	levels.base = consoleLevel
+	levels.modules = modules
+	levels.mu.Unlock()
 
-	// 创建控制台日志器
+	// 创建控制台日志器，级别由 levels 按模块过滤
 	consoleLogger = logrus.New()
-	consoleLogger.SetLevel(consoleLevel)
+	consoleLogger.SetLevel(logrus.TraceLevel)
 	consoleLogger.SetOutput(os.Stdout)
 	consoleLogger.SetFormatter(&logrus.TextFormatter{
 		FullTimestamp:   true,
@@ -58,39 +87,48 @@ func Init(logLevel string, logDir string, pushToClient bool) error {
 	})
 
 	// 添加推送到客户端的钩子
-	if pushToClient {
+	if opts.PushToClient {
 		consoleLogger.AddHook(&PushHook{})
 	}
 
 	// 创建文件日志器
-	if logDir != "" {
-		if err := os.MkdirAll(logDir, 0755); err != nil {
+	if fileOutput != nil {
+		fileOutput.Close()
+		fileOutput = nil
+	}
+	fileLogger = nil
+	if opts.Dir != "" {
+		if err := os.MkdirAll(opts.Dir, 0755); err != nil {
 			return fmt.Errorf("创建日志目录失败: %w", err)
 		}
 
-		// 清理旧日志
-		if err := cleanOldLogs(logDir, 3); err != nil {
-			fmt.Printf("清理旧日志失败: %v\n", err)
-		}
-
-		// 创建日志文件
-		logFileName := fmt.Sprintf("lb-%s.log", time.Now().Format("2006-01-02"))
-		logFilePath := filepath.Join(logDir, logFileName)
-
-		logFile, err := os.OpenFile(logFilePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+		// 创建日志文件，同时清理过期日志
+		output, err := newRotatingFile(opts.Dir, opts.MaxSizeMB, opts.MaxFiles, opts.MaxAgeDays)
 		if err != nil {
-			return fmt.Errorf("创建日志文件失败: %w", err)
+			return err
 		}
 
 		// 文件日志记录全级别
 		fileLogger = logrus.New()
 		fileLogger.SetLevel(logrus.TraceLevel)
-		fileLogger.SetOutput(logFile)
-		fileLogger.SetFormatter(&logrus.TextFormatter{
-			FullTimestamp:   true,
-			TimestampFormat: "2006-01-02 15:04:05",
-			DisableColors:   true,
-		})
+		fileLogger.SetOutput(output)
+		switch strings.ToLower(opts.Format) {
+		case "", FormatText:
+			fileLogger.SetFormatter(&logrus.TextFormatter{
+				FullTimestamp:   true,
+				TimestampFormat: textTimestampFormat,
+				DisableColors:   true,
+			})
+		case FormatJSON:
+			fileLogger.SetFormatter(&logrus.JSONFormatter{
+				TimestampFormat: time.RFC3339Nano,
+			})
+		default:
+			output.Close()
+			fileLogger = nil
+			return fmt.Errorf("无效的日志格式: %s（可选: text, json）", opts.Format)
+		}
+		fileOutput = output
 	}
 
 	// 设置对外接口使用控制台日志器
@@ -99,6 +137,22 @@ func Init(logLevel string, logDir string, pushToClient bool) error {
 	return nil
 }
 
+// Close 关闭日志文件
+func Close() error {
+	if fileOutput == nil {
+		return nil
+	}
+	return fileOutput.Close()
+}
+
+// fileDir 返回文件日志目录，未启用文件日志时为空
+func fileDir() string {
+	if fileOutput == nil {
+		return ""
+	}
+	return fileOutput.dir
+}
+
 // 设置日志推送函数
 func SetPushFunc(fn LogPushFunc) {
 	pushFunc = fn
@@ -163,34 +217,28 @@ func (hook *PushHook) Fire(entry *logrus.Entry) error {
 
 // 便捷日志方法
 func Info(module, message string, args ...interface{}) {
-	entry := consoleLogger.WithField("module", module)
-	entry.Infof(message, args...)
-	if fileLogger != nil {
-		fileLogger.WithField("module", module).Infof(message, args...)
-	}
+	logf(logrus.InfoLevel, module, message, args...)
 }
 
 func Warn(module, message string, args ...interface{}) {
-	entry := consoleLogger.WithField("module", module)
-	entry.Warnf(message, args...)
-	if fileLogger != nil {
-		fileLogger.WithField("module", module).Warnf(message, args...)
-	}
+	logf(logrus.WarnLevel, module, message, args...)
 }
 
 func Error(module, message string, args ...interface{}) {
-	entry := consoleLogger.WithField("module", module)
-	entry.Errorf(message, args...)
-	if fileLogger != nil {
-		fileLogger.WithField("module", module).Errorf(message, args...)
-	}
+	logf(logrus.ErrorLevel, module, message, args...)
 }
 
 func Debug(module, message string, args ...interface{}) {
-	entry := consoleLogger.WithField("module", module)
-	entry.Debugf(message, args...)
+	logf(logrus.DebugLevel, module, message, args...)
+}
+
+// logf 按模块级别输出到控制台，文件日志始终记录
+func logf(level logrus.Level, module, message string, args ...interface{}) {
+	if levels.enabled(module, level) {
+		consoleLogger.WithField("module", module).Logf(level, message, args...)
+	}
 	if fileLogger != nil {
-		fileLogger.WithField("module", module).Debugf(message, args...)
+		fileLogger.WithField("module", module).Logf(level, message, args...)
 	}
 }
 
@@ -204,47 +252,3 @@ func Trace(module, message string, args ...interface{}) {
 func WithModule(module string) *logrus.Entry {
 	return consoleLogger.WithField("module", module)
 }
-
-// 清理旧日志文件
-func cleanOldLogs(logDir string, keepDays int) error {
-	// 计算截止时间
-	cutoffTime := time.Now().AddDate(0, 0, -keepDays)
-
-	// 读取日志目录
-	entries, err := os.ReadDir(logDir)
-	if err != nil {
-		return fmt.Errorf("读取日志目录失败: %w", err)
-	}
-
-	// 遍历并删除过期日志
-	for _, entry := range entries {
-		if entry.IsDir() {
-			continue
-		}
-
-		// 只处理 lb- 开头的 .log 文件
-		fileName := entry.Name()
-		matched, err := filepath.Match("lb-*.log", fileName)
-		if err != nil || !matched {
-			continue
-		}
-
-		// 获取文件信息
-		filePath := filepath.Join(logDir, fileName)
-		fileInfo, err := os.Stat(filePath)
-		if err != nil {
-			continue
-		}
-
-		// 检查修改时间,删除过期文件
-		if fileInfo.ModTime().Before(cutoffTime) {
-			if err := os.Remove(filePath); err != nil {
-				fmt.Printf("删除旧日志失败 %s: %v\n", fileName, err)
-			} else {
-				fmt.Printf("已删除旧日志: %s\n", fileName)
-			}
-		}
-	}
-
-	return nil
-}
@@ -0,0 +1,171 @@
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func logNames(t *testing.T, dir string) []string {
+	t.Helper()
+	files, err := listLogFiles(dir)
+	if err != nil {
+		t.Fatalf("listLogFiles() error = %v", err)
+	}
+	names := make([]string, 0, len(files))
+	for _, file := range files {
+		names = append(names, filepath.Base(file.path))
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestRotatingFileRotatesBySizeAndDay(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2026, 10, 19, 10, 0, 0, 0, time.Local)
+	w := &rotatingFile{dir: dir, maxSize: 10, now: func() time.Time { return now }}
+	if err := w.open(); err != nil {
+		t.Fatalf("open() error = %v", err)
+	}
+	defer w.Close()
+
+	for _, line := range []string{"aaaaaaaa\n", "bbbbbbbb\n", "cccccccc\n"} {
+		if _, err := w.Write([]byte(line)); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+	}
+	now = now.Add(24 * time.Hour)
+	if _, err := w.Write([]byte("dddd\n")); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	want := []string{"lb-2026-10-19.1.log", "lb-2026-10-19.2.log", "lb-2026-10-19.log", "lb-2026-10-20.log"}
+	if got := logNames(t, dir); !reflect.DeepEqual(got, want) {
+		t.Fatalf("files = %v, want %v", got, want)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "lb-2026-10-19.log")); string(data) != "cccccccc\n" {
+		t.Fatalf("current file = %q", data)
+	}
+}
+
+func TestRotatingFileRetention(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2026, 10, 19, 10, 0, 0, 0, time.Local)
+	for i, name := range []string{"lb-2026-10-10.log", "lb-2026-10-17.log", "lb-2026-10-18.log", "lb-2026-10-18.1.log", "other.log"} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte("x\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		modTime := now.Add(-time.Duration(5-i) * 24 * time.Hour)
+		if name == "lb-2026-10-10.log" {
+			modTime = now.AddDate(0, 0, -9)
+		}
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	w := &rotatingFile{dir: dir, maxFiles: 3, maxAge: 3 * 24 * time.Hour, now: func() time.Time { return now }}
+	if err := w.open(); err != nil {
+		t.Fatalf("open() error = %v", err)
+	}
+	defer w.Close()
+	w.cleanup()
+
+	want := []string{"lb-2026-10-18.1.log", "lb-2026-10-18.log", "lb-2026-10-19.log"}
+	if got := logNames(t, dir); !reflect.DeepEqual(got, want) {
+		t.Fatalf("files = %v, want %v", got, want)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "other.log")); err != nil {
+		t.Fatalf("unrelated file removed: %v", err)
+	}
+}
+
+func TestQueryReadsTextAndJSONFiles(t *testing.T) {
+	dir := t.TempDir()
+	at := func(second int) string {
+		return time.Date(2026, 10, 19, 10, 0, second, 0, time.Local).Format(time.RFC3339)
+	}
+	files := map[string]string{
+		"lb-2026-10-19.1.log": strings.Join([]string{
+			`time="2026-10-19 10:00:00" level=info msg="启动完成" module=Main`,
+			`time="2026-10-19 10:00:01" level=debug msg="连接 \"emulator\"" module=MFW`,
+			`not a log line`,
+		}, "\n"),
+		"lb-2026-10-19.log": strings.Join([]string{
+			`{"level":"warning","module":"MFW","msg":"截图超时","time":"` + at(2) + `"}`,
+			`{"level":"error","module":"File","msg":"保存失败","time":"` + at(3) + `"}`,
+		}, "\n"),
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name      string
+		opts      QueryOptions
+		messages  []string
+		truncated bool
+	}{
+		{name: "all", opts: QueryOptions{}, messages: []string{"启动完成", `连接 "emulator"`, "截图超时", "保存失败"}},
+		{name: "level", opts: QueryOptions{Level: "WARN"}, messages: []string{"截图超时", "保存失败"}},
+		{name: "module", opts: QueryOptions{Module: "mfw"}, messages: []string{`连接 "emulator"`, "截图超时"}},
+		{name: "keyword", opts: QueryOptions{Keyword: "失败"}, messages: []string{"保存失败"}},
+		{name: "limit keeps newest", opts: QueryOptions{Limit: 1}, messages: []string{"保存失败"}, truncated: true},
+		{
+			name:     "time range",
+			opts:     QueryOptions{Until: time.Date(2026, 10, 19, 10, 0, 0, 0, time.Local)},
+			messages: []string{"启动完成"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result, err := queryDir(dir, test.opts)
+			if err != nil {
+				t.Fatalf("queryDir() error = %v", err)
+			}
+			messages := make([]string, 0, len(result.Entries))
+			for _, entry := range result.Entries {
+				messages = append(messages, entry.Message)
+			}
+			if !reflect.DeepEqual(messages, test.messages) || result.Truncated != test.truncated {
+				t.Fatalf("messages = %v truncated = %v, want %v %v", messages, result.Truncated, test.messages, test.truncated)
+			}
+		})
+	}
+}
+
+func TestLevelsModuleOverride(t *testing.T) {
+	modules, err := parseModuleLevels(map[string]string{"MFW": "debug", "file": "ERROR"})
+	if err != nil {
+		t.Fatalf("parseModuleLevels() error = %v", err)
+	}
+	state := &levelState{base: logrus.InfoLevel, modules: modules}
+
+	tests := []struct {
+		module string
+		level  logrus.Level
+		want   bool
+	}{
+		{module: "Main", level: logrus.InfoLevel, want: true},
+		{module: "Main", level: logrus.DebugLevel, want: false},
+		{module: "MFW", level: logrus.DebugLevel, want: true},
+		{module: "File", level: logrus.WarnLevel, want: false},
+	}
+	for _, test := range tests {
+		if got := state.enabled(test.module, test.level); got != test.want {
+			t.Fatalf("enabled(%s, %s) = %v, want %v", test.module, test.level, got, test.want)
+		}
+	}
+	if _, err := parseModuleLevels(map[string]string{"MFW": "verbose"}); err == nil {
+		t.Fatal("parseModuleLevels() with invalid level should fail")
+	}
+}
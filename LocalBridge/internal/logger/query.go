@@ -0,0 +1,226 @@
+package logger
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// 查询返回的默认与最大条数
+const (
+	DefaultQueryLimit = 200
+	MaxQueryLimit     = 2000
+)
+
+// 文本格式日志中的时间格式
+const textTimestampFormat = "2006-01-02 15:04:05"
+
+// QueryOptions 日志查询条件，零值字段不参与过滤
+type QueryOptions struct {
+	Since   time.Time
+	Until   time.Time
+	Level   string // 最低级别，如 warn 返回 warn 与 error
+	Module  string
+	Keyword string
+	Limit   int
+}
+
+// QueryResult 日志查询结果，条目按时间先后排列，超出条数时保留最新的部分
+type QueryResult struct {
+	Entries   []LogEntry
+	Truncated bool
+	Files     []string
+}
+
+// Query 在当前及已切分的日志文件中按时间范围、级别与模块查找日志，兼容文本与 JSON 两种格式
+func Query(opts QueryOptions) (QueryResult, error) {
+	dir := fileDir()
+	if dir == "" {
+		return QueryResult{}, fmt.Errorf("未启用文件日志")
+	}
+	return queryDir(dir, opts)
+}
+
+func queryDir(dir string, opts QueryOptions) (QueryResult, error) {
+	minLevel := logrus.TraceLevel
+	if strings.TrimSpace(opts.Level) != "" {
+		parsed, err := ParseLevel(opts.Level)
+		if err != nil {
+			return QueryResult{}, err
+		}
+		minLevel = parsed
+	}
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = DefaultQueryLimit
+	}
+	if limit > MaxQueryLimit {
+		limit = MaxQueryLimit
+	}
+
+	files, err := listLogFiles(dir)
+	if err != nil {
+		return QueryResult{}, err
+	}
+
+	result := QueryResult{Entries: make([]LogEntry, 0), Files: make([]string, 0, len(files))}
+	for _, file := range files {
+		// 最后写入早于起始时间的文件不会包含匹配条目
+		if !opts.Since.IsZero() && file.modTime.Before(opts.Since) {
+			continue
+		}
+		result.Files = append(result.Files, file.path)
+		if err := scanLogFile(file.path, func(entry LogEntry, level logrus.Level) {
+			if level > minLevel || !matchEntry(entry, opts) {
+				return
+			}
+			result.Entries = append(result.Entries, entry)
+		}); err != nil {
+			return QueryResult{}, err
+		}
+	}
+
+	sort.SliceStable(result.Entries, func(i, j int) bool {
+		return result.Entries[i].Timestamp.Before(result.Entries[j].Timestamp)
+	})
+	if len(result.Entries) > limit {
+		result.Entries = result.Entries[len(result.Entries)-limit:]
+		result.Truncated = true
+	}
+	return result, nil
+}
+
+func matchEntry(entry LogEntry, opts QueryOptions) bool {
+	if !opts.Since.IsZero() && entry.Timestamp.Before(opts.Since) {
+		return false
+	}
+	if !opts.Until.IsZero() && entry.Timestamp.After(opts.Until) {
+		return false
+	}
+	if opts.Module != "" && !strings.EqualFold(entry.Module, opts.Module) {
+		return false
+	}
+	if opts.Keyword != "" && !strings.Contains(entry.Message, opts.Keyword) {
+		return false
+	}
+	return true
+}
+
+// scanLogFile 逐行解析日志文件，无法解析的行跳过
+func scanLogFile(path string, visit func(entry LogEntry, level logrus.Level)) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("打开日志文件失败: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		entry, level, ok := parseLine(scanner.Text())
+		if ok {
+			visit(entry, level)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("读取日志文件失败: %s: %w", path, err)
+	}
+	return nil
+}
+
+// parseLine 解析一行 JSON 或 logfmt 格式的日志
+func parseLine(line string) (LogEntry, logrus.Level, bool) {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return LogEntry{}, 0, false
+	}
+
+	fields := make(map[string]string)
+	if strings.HasPrefix(line, "{") {
+		var raw map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &raw); err != nil {
+			return LogEntry{}, 0, false
+		}
+		for key, value := range raw {
+			if text, ok := value.(string); ok {
+				fields[key] = text
+			}
+		}
+	} else if !parseLogfmt(line, fields) {
+		return LogEntry{}, 0, false
+	}
+
+	level, err := logrus.ParseLevel(fields["level"])
+	if err != nil {
+		return LogEntry{}, 0, false
+	}
+	timestamp, ok := parseTimestamp(fields["time"])
+	if !ok {
+		return LogEntry{}, 0, false
+	}
+	module := fields["module"]
+	if module == "" {
+		module = "System"
+	}
+	return LogEntry{
+		Level:     level.String(),
+		Module:    module,
+		Message:   fields["msg"],
+		Timestamp: timestamp,
+	}, level, true
+}
+
+func parseTimestamp(value string) (time.Time, bool) {
+	if value == "" {
+		return time.Time{}, false
+	}
+	if timestamp, err := time.Parse(time.RFC3339Nano, value); err == nil {
+		return timestamp, true
+	}
+	if timestamp, err := time.ParseInLocation(textTimestampFormat, value, time.Local); err == nil {
+		return timestamp, true
+	}
+	return time.Time{}, false
+}
+
+// parseLogfmt 解析 logrus 文本格式输出的 key=value 序列，值可能为 Go 语法的带引号字符串
+func parseLogfmt(line string, fields map[string]string) bool {
+	for line != "" {
+		line = strings.TrimLeft(line, " ")
+		eq := strings.IndexByte(line, '=')
+		if eq <= 0 {
+			return len(fields) > 0
+		}
+		key := line[:eq]
+		line = line[eq+1:]
+
+		var value string
+		if strings.HasPrefix(line, `"`) {
+			quoted, err := strconv.QuotedPrefix(line)
+			if err != nil {
+				return false
+			}
+			value, err = strconv.Unquote(quoted)
+			if err != nil {
+				return false
+			}
+			line = line[len(quoted):]
+		} else {
+			end := strings.IndexByte(line, ' ')
+			if end < 0 {
+				end = len(line)
+			}
+			value = line[:end]
+			line = line[end:]
+		}
+		fields[key] = value
+	}
+	return len(fields) > 0
+}
@@ -0,0 +1,179 @@
+package logger
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// 日志文件按日期命名，超过大小上限时切出 lb-日期.序号.log
+const (
+	logFilePattern = "lb-*.log"
+	dayLayout      = "2006-01-02"
+)
+
+// rotatingFile 按日期与大小切分日志文件，并按数量与天数清理旧文件
+type rotatingFile struct {
+	mu       sync.Mutex
+	dir      string
+	maxSize  int64
+	maxFiles int
+	maxAge   time.Duration
+	now      func() time.Time
+
+	file *os.File
+	day  string
+	size int64
+}
+
+func newRotatingFile(dir string, maxSizeMB int, maxFiles int, maxAgeDays int) (*rotatingFile, error) {
+	w := &rotatingFile{
+		dir:      dir,
+		maxSize:  int64(maxSizeMB) * 1024 * 1024,
+		maxFiles: maxFiles,
+		maxAge:   time.Duration(maxAgeDays) * 24 * time.Hour,
+		now:      time.Now,
+	}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	w.cleanup()
+	return w, nil
+}
+
+func (w *rotatingFile) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil || w.now().Format(dayLayout) != w.day {
+		if err := w.open(); err != nil {
+			return 0, err
+		}
+		w.cleanup()
+	} else if w.maxSize > 0 && w.size > 0 && w.size+int64(len(p)) > w.maxSize {
+		if err := w.rotate(); err != nil {
+			return 0, err
+		}
+		w.cleanup()
+	}
+
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+// Close 关闭当前日志文件，之后的写入会重新打开
+func (w *rotatingFile) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file == nil {
+		return nil
+	}
+	err := w.file.Close()
+	w.file = nil
+	return err
+}
+
+func (w *rotatingFile) currentPath() string {
+	return filepath.Join(w.dir, "lb-"+w.day+".log")
+}
+
+// open 打开当天的日志文件，已存在时追加
+func (w *rotatingFile) open() error {
+	if w.file != nil {
+		w.file.Close()
+		w.file = nil
+	}
+	w.day = w.now().Format(dayLayout)
+	file, err := os.OpenFile(w.currentPath(), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+	if err != nil {
+		return fmt.Errorf("创建日志文件失败: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("读取日志文件信息失败: %w", err)
+	}
+	w.file = file
+	w.size = info.Size()
+	return nil
+}
+
+// rotate 将当前文件改名为下一个可用序号后重新打开，改名前先关闭以兼容 Windows
+func (w *rotatingFile) rotate() error {
+	current := w.currentPath()
+	w.file.Close()
+	w.file = nil
+	for index := 1; ; index++ {
+		target := filepath.Join(w.dir, fmt.Sprintf("lb-%s.%d.log", w.day, index))
+		if _, err := os.Stat(target); os.IsNotExist(err) {
+			if err := os.Rename(current, target); err != nil {
+				// 改名失败时继续写入原文件，避免丢失日志
+				fmt.Fprintf(os.Stderr, "切分日志文件失败: %v\n", err)
+			}
+			break
+		}
+	}
+	return w.open()
+}
+
+// cleanup 删除超过保留天数或超出保留数量的旧文件，当前文件始终保留
+func (w *rotatingFile) cleanup() {
+	files, err := listLogFiles(w.dir)
+	if err != nil {
+		return
+	}
+	current := w.currentPath()
+	cutoff := w.now().Add(-w.maxAge)
+	kept := 1
+	for i := len(files) - 1; i >= 0; i-- {
+		file := files[i]
+		if file.path == current {
+			continue
+		}
+		expired := w.maxAge > 0 && file.modTime.Before(cutoff)
+		overflow := w.maxFiles > 0 && kept >= w.maxFiles
+		if expired || overflow {
+			os.Remove(file.path)
+			continue
+		}
+		kept++
+	}
+}
+
+type logFile struct {
+	path    string
+	modTime time.Time
+}
+
+// listLogFiles 列出日志目录下的日志文件，按修改时间从旧到新排列
+func listLogFiles(dir string) ([]logFile, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("读取日志目录失败: %w", err)
+	}
+	files := make([]logFile, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		if matched, _ := filepath.Match(logFilePattern, entry.Name()); !matched {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, logFile{path: filepath.Join(dir, entry.Name()), modTime: info.ModTime()})
+	}
+	sort.SliceStable(files, func(i, j int) bool {
+		if files[i].modTime.Equal(files[j].modTime) {
+			return files[i].path < files[j].path
+		}
+		return files[i].modTime.Before(files[j].modTime)
+	})
+	return files, nil
+}
@@ -811,6 +811,27 @@ func (a *MaaFWAdapter) RemoveTaskerSink(sinkID int64) {
 	}
 }
 
+// AddControllerSink 添加控制器事件监听器
+func (a *MaaFWAdapter) AddControllerSink(sink maa.ControllerEventSink) int64 {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.controller == nil {
+		return 0
+	}
+	return a.controller.AddSink(sink)
+}
+
+// RemoveControllerSink 移除控制器事件监听器
+func (a *MaaFWAdapter) RemoveControllerSink(sinkID int64) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.controller != nil && sinkID > 0 {
+		a.controller.RemoveSink(sinkID)
+	}
+}
+
 // ============================================================================
 // 截图功能
 // ============================================================================
@@ -927,6 +948,13 @@ func (s *Screenshotter) SetCacheTTL(ttl time.Duration) {
 	s.cacheTTL = ttl
 }
 
+// CacheTTL 获取缓存有效期
+func (s *Screenshotter) CacheTTL() time.Duration {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.cacheTTL
+}
+
 // Capture 截图并返回 image.Image
 func (s *Screenshotter) Capture() (image.Image, error) {
 	s.mu.Lock()
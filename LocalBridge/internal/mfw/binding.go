@@ -0,0 +1,28 @@
+package mfw
+
+import "runtime/debug"
+
+// maa-framework-go 模块路径
+const bindingModule = "github.com/MaaXYZ/maa-framework-go/v4"
+
+// CompatibleMaaFWRange 当前 maa-framework-go 绑定（v4.0.0-beta.16，基于 MaaFramework v5.10.3）
+// 可加载的 MaaFramework 版本范围，升级绑定时需同步修改
+const CompatibleMaaFWRange = ">=5.10.0 <6.0.0"
+
+// BindingVersion 返回编译时使用的 maa-framework-go 版本，无法读取构建信息时返回 unknown
+func BindingVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+	for _, dep := range info.Deps {
+		if dep.Path != bindingModule {
+			continue
+		}
+		if dep.Replace != nil {
+			return dep.Replace.Version
+		}
+		return dep.Version
+	}
+	return "unknown"
+}
@@ -0,0 +1,530 @@
+package mfw
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	maa "github.com/MaaXYZ/maa-framework-go/v4"
+	mpeconfig "github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/config"
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/logger"
+)
+
+// 控制器健康状态
+type ControllerHealthStatus string
+
+const (
+	HealthStatusHealthy      ControllerHealthStatus = "healthy"      // 最近一次探测成功
+	HealthStatusDegraded     ControllerHealthStatus = "degraded"     // 探测失败但未达到阈值
+	HealthStatusDisconnected ControllerHealthStatus = "disconnected" // 连续失败达到阈值
+	HealthStatusReconnecting ControllerHealthStatus = "reconnecting" // 正在尝试自动重连
+	HealthStatusReleased     ControllerHealthStatus = "released"     // 控制器已被移除
+)
+
+const reconnectBaseBackoff = 2 * time.Second
+
+// 探测时截图锁被占用，说明控制器正在使用中
+var errProbeBusy = errors.New("controller busy")
+
+// 控制器健康信息
+type ControllerHealth struct {
+	ControllerID        string                 `json:"controller_id"`
+	Type                string                 `json:"type"`
+	Status              ControllerHealthStatus `json:"status"`
+	LatencyMS           int64                  `json:"latency_ms"`
+	AvgLatencyMS        int64                  `json:"avg_latency_ms"`
+	ConsecutiveFailures int                    `json:"consecutive_failures"`
+	TotalProbes         int                    `json:"total_probes"`
+	TotalFailures       int                    `json:"total_failures"`
+	LastError           string                 `json:"last_error,omitempty"`
+	LastProbeAt         string                 `json:"last_probe_at,omitempty"`
+	AutoReconnect       bool                   `json:"auto_reconnect"`
+	ReconnectAttempts   int                    `json:"reconnect_attempts"`
+	NextReconnectAt     string                 `json:"next_reconnect_at,omitempty"`
+}
+
+// 健康监测策略
+type healthPolicy struct {
+	interval        time.Duration
+	timeout         time.Duration
+	threshold       int
+	autoReconnect   bool
+	maxAttempts     int
+	maxBackoff      time.Duration
+	inactiveTimeout time.Duration
+}
+
+func newHealthPolicy(cfg mpeconfig.ControllerHealthConfig) healthPolicy {
+	policy := healthPolicy{
+		interval:        time.Duration(cfg.ProbeIntervalSeconds) * time.Second,
+		timeout:         time.Duration(cfg.ProbeTimeoutSeconds) * time.Second,
+		threshold:       cfg.FailureThreshold,
+		autoReconnect:   cfg.AutoReconnect,
+		maxAttempts:     cfg.ReconnectMaxAttempts,
+		maxBackoff:      time.Duration(cfg.ReconnectMaxBackoffSeconds) * time.Second,
+		inactiveTimeout: time.Duration(cfg.InactiveTimeoutMinutes) * time.Minute,
+	}
+	if policy.timeout <= 0 {
+		policy.timeout = 5 * time.Second
+	}
+	if policy.threshold <= 0 {
+		policy.threshold = 1
+	}
+	if policy.maxBackoff < reconnectBaseBackoff {
+		policy.maxBackoff = reconnectBaseBackoff
+	}
+	return policy
+}
+
+// 第 attempt 次重连前的等待时间，指数增长并受上限约束
+func (p healthPolicy) backoff(attempt int) time.Duration {
+	delay := reconnectBaseBackoff
+	for i := 0; i < attempt && delay < p.maxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > p.maxBackoff {
+		delay = p.maxBackoff
+	}
+	return delay
+}
+
+// 仅 ADB 与 WlRoots 控制器可复用实例重连
+func supportsAutoReconnect(controllerType string) bool {
+	return controllerType == "ADB" || controllerType == "WlRoots"
+}
+
+// 单个控制器的健康追踪
+type healthTracker struct {
+	health        ControllerHealth
+	latencyTotal  time.Duration
+	successes     int
+	nextReconnect time.Time
+}
+
+func newHealthTracker(controllerID, controllerType string, policy healthPolicy) *healthTracker {
+	return &healthTracker{
+		health: ControllerHealth{
+			ControllerID:  controllerID,
+			Type:          controllerType,
+			Status:        HealthStatusHealthy,
+			AutoReconnect: policy.autoReconnect && supportsAutoReconnect(controllerType),
+		},
+	}
+}
+
+func (t *healthTracker) recordSuccess(now time.Time, latency time.Duration) {
+	t.successes++
+	t.latencyTotal += latency
+	t.health.Status = HealthStatusHealthy
+	t.health.LatencyMS = latency.Milliseconds()
+	t.health.AvgLatencyMS = (t.latencyTotal / time.Duration(t.successes)).Milliseconds()
+	t.health.ConsecutiveFailures = 0
+	t.health.TotalProbes++
+	t.health.LastError = ""
+	t.health.LastProbeAt = now.Format(time.RFC3339)
+	t.health.ReconnectAttempts = 0
+	t.health.NextReconnectAt = ""
+	t.nextReconnect = time.Time{}
+}
+
+// 记录探测失败，返回本次是否刚刚判定为断开
+func (t *healthTracker) recordFailure(now time.Time, err error, policy healthPolicy) bool {
+	wasDisconnected := t.health.Status == HealthStatusDisconnected
+	t.health.ConsecutiveFailures++
+	t.health.TotalProbes++
+	t.health.TotalFailures++
+	t.health.LastError = err.Error()
+	t.health.LastProbeAt = now.Format(time.RFC3339)
+
+	if t.health.ConsecutiveFailures < policy.threshold {
+		t.health.Status = HealthStatusDegraded
+		return false
+	}
+	t.health.Status = HealthStatusDisconnected
+	t.scheduleReconnect(now, policy)
+	return !wasDisconnected
+}
+
+// 记录一次重连结果
+func (t *healthTracker) recordReconnect(now time.Time, err error, policy healthPolicy) {
+	if err == nil {
+		t.recordSuccess(now, 0)
+		return
+	}
+	t.health.ReconnectAttempts++
+	t.health.LastError = err.Error()
+	t.health.Status = HealthStatusDisconnected
+	t.scheduleReconnect(now, policy)
+}
+
+func (t *healthTracker) scheduleReconnect(now time.Time, policy healthPolicy) {
+	t.nextReconnect = time.Time{}
+	t.health.NextReconnectAt = ""
+	if !t.health.AutoReconnect {
+		return
+	}
+	if policy.maxAttempts > 0 && t.health.ReconnectAttempts >= policy.maxAttempts {
+		return
+	}
+	t.nextReconnect = now.Add(policy.backoff(t.health.ReconnectAttempts))
+	t.health.NextReconnectAt = t.nextReconnect.Format(time.RFC3339)
+}
+
+func (t *healthTracker) reconnectDue(now time.Time) bool {
+	return !t.nextReconnect.IsZero() && !now.Before(t.nextReconnect)
+}
+
+// 控制器健康监测器
+type HealthMonitor struct {
+	manager  *ControllerManager
+	policy   healthPolicy
+	trackers map[string]*healthTracker
+	notify   func(ControllerHealth)
+	now      func() time.Time
+	stopCh   chan struct{}
+	doneCh   chan struct{}
+	mu       sync.Mutex
+}
+
+// 创建健康监测器
+func NewHealthMonitor(manager *ControllerManager) *HealthMonitor {
+	return &HealthMonitor{
+		manager:  manager,
+		trackers: make(map[string]*healthTracker),
+		now:      time.Now,
+	}
+}
+
+// 设置健康状态变化推送函数
+func (m *HealthMonitor) SetNotifyFunc(fn func(ControllerHealth)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.notify = fn
+}
+
+// 启动监测，已在运行时先停止
+func (m *HealthMonitor) Start(cfg mpeconfig.ControllerHealthConfig) {
+	m.Stop()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.setPolicyLocked(newHealthPolicy(cfg))
+	if m.policy.interval <= 0 {
+		logger.Info("MFW", "控制器健康监测已关闭")
+		return
+	}
+	m.stopCh = make(chan struct{})
+	m.doneCh = make(chan struct{})
+	go m.run(m.policy.interval, m.stopCh, m.doneCh)
+	logger.Debug("MFW", "控制器健康监测已启动，间隔: %v", m.policy.interval)
+}
+
+// 更新策略，已追踪的控制器按新策略更新自动重连设置
+func (m *HealthMonitor) setPolicyLocked(policy healthPolicy) {
+	m.policy = policy
+	for _, tracker := range m.trackers {
+		tracker.health.AutoReconnect = policy.autoReconnect && supportsAutoReconnect(tracker.health.Type)
+		if !tracker.health.AutoReconnect {
+			tracker.nextReconnect = time.Time{}
+			tracker.health.NextReconnectAt = ""
+		}
+	}
+}
+
+// 停止监测并等待当前一轮探测结束
+func (m *HealthMonitor) Stop() {
+	m.mu.Lock()
+	stopCh, doneCh := m.stopCh, m.doneCh
+	m.stopCh, m.doneCh = nil, nil
+	m.mu.Unlock()
+
+	if stopCh == nil {
+		return
+	}
+	close(stopCh)
+	<-doneCh
+}
+
+// 获取所有被监测控制器的健康快照
+func (m *HealthMonitor) Snapshot() []ControllerHealth {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	result := make([]ControllerHealth, 0, len(m.trackers))
+	for _, tracker := range m.trackers {
+		result = append(result, tracker.health)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ControllerID < result[j].ControllerID
+	})
+	return result
+}
+
+func (m *HealthMonitor) run(interval time.Duration, stopCh, doneCh chan struct{}) {
+	defer close(doneCh)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stopCh:
+			return
+		case <-ticker.C:
+			m.checkAll()
+		}
+	}
+}
+
+// 执行一轮探测、重连与非活跃清理
+func (m *HealthMonitor) checkAll() {
+	m.mu.Lock()
+	policy := m.policy
+	m.mu.Unlock()
+
+	if policy.inactiveTimeout > 0 {
+		for _, id := range m.manager.CleanupInactive(policy.inactiveTimeout) {
+			logger.Info("MFW", "控制器长时间无操作，已释放: %s", id)
+		}
+	}
+
+	present := make(map[string]bool)
+	for _, info := range m.manager.ListControllers() {
+		present[info.ControllerID] = true
+		m.checkController(info, policy)
+	}
+
+	// 已移除的控制器推送 released 后停止追踪
+	m.mu.Lock()
+	var released []ControllerHealth
+	for id, tracker := range m.trackers {
+		if present[id] {
+			continue
+		}
+		health := tracker.health
+		health.Status = HealthStatusReleased
+		health.NextReconnectAt = ""
+		released = append(released, health)
+		delete(m.trackers, id)
+	}
+	m.mu.Unlock()
+	for _, health := range released {
+		m.emit(health)
+	}
+}
+
+func (m *HealthMonitor) checkController(info *ControllerInfo, policy healthPolicy) {
+	id := info.ControllerID
+
+	m.mu.Lock()
+	tracker, tracked := m.trackers[id]
+	if !tracked {
+		// 仅追踪已成功连接过的控制器
+		if !info.Connected {
+			m.mu.Unlock()
+			return
+		}
+		tracker = newHealthTracker(id, info.Type, policy)
+		m.trackers[id] = tracker
+	}
+	m.mu.Unlock()
+
+	if !info.Connected {
+		m.mu.Lock()
+		due := tracker.reconnectDue(m.now())
+		if due {
+			tracker.health.Status = HealthStatusReconnecting
+		}
+		health := tracker.health
+		m.mu.Unlock()
+		if !due {
+			return
+		}
+		m.emit(health)
+
+		logger.Info("MFW", "尝试重连控制器: %s (第 %d 次)", id, health.ReconnectAttempts+1)
+		err := m.manager.ReconnectController(id, policy.timeout)
+		if err != nil {
+			logger.Warn("MFW", "控制器重连失败: %s, %v", id, err)
+		} else {
+			logger.Info("MFW", "控制器已重连: %s", id)
+		}
+
+		m.mu.Lock()
+		tracker.recordReconnect(m.now(), err, policy)
+		health = tracker.health
+		m.mu.Unlock()
+		m.emit(health)
+		return
+	}
+
+	latency, err := m.manager.ProbeController(id, policy.timeout)
+	if errors.Is(err, errProbeBusy) {
+		return
+	}
+
+	m.mu.Lock()
+	justDisconnected := false
+	if err == nil {
+		tracker.recordSuccess(m.now(), latency)
+	} else {
+		justDisconnected = tracker.recordFailure(m.now(), err, policy)
+	}
+	health := tracker.health
+	m.mu.Unlock()
+
+	if justDisconnected {
+		logger.Warn("MFW", "控制器连续 %d 次探测失败，判定为断开: %s", health.ConsecutiveFailures, id)
+		m.manager.markDisconnected(id)
+	}
+	m.emit(health)
+}
+
+func (m *HealthMonitor) emit(health ControllerHealth) {
+	m.mu.Lock()
+	notify := m.notify
+	m.mu.Unlock()
+	if notify != nil {
+		notify(health)
+	}
+}
+
+// 在后台等待任务结束，返回任务结束时关闭的通道
+func watchJob(job *maa.Job) <-chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		job.Wait()
+		close(done)
+	}()
+	return done
+}
+
+// 等待通道关闭，超时返回 false
+func waitDone(done <-chan struct{}, timeout time.Duration) bool {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-done:
+		return true
+	case <-timer.C:
+		return false
+	}
+}
+
+// 投递健康监测任务并记录，上一个任务超时后仍未结束时不再投递，
+// 避免设备卡死时每轮探测都新增一个等待协程
+func (cm *ControllerManager) postHealthJob(info *ControllerInfo, post func() *maa.Job) (<-chan struct{}, bool) {
+	cm.mu.RLock()
+	pending := info.healthJob
+	cm.mu.RUnlock()
+	if pending != nil {
+		select {
+		case <-pending:
+		default:
+			return nil, false
+		}
+	}
+
+	job := post()
+	if job == nil {
+		return nil, true
+	}
+	done := watchJob(job)
+	cm.mu.Lock()
+	info.healthJob = done
+	cm.mu.Unlock()
+	return done, true
+}
+
+// 以一次截图探测控制器是否可用，返回耗时
+func (cm *ControllerManager) ProbeController(controllerID string, timeout time.Duration) (time.Duration, error) {
+	cm.mu.RLock()
+	info, exists := cm.controllers[controllerID]
+	cm.mu.RUnlock()
+
+	if !exists {
+		return 0, ErrControllerNotFound
+	}
+	ctrl, ok := info.Controller.(*maa.Controller)
+	if !ok || ctrl == nil {
+		return 0, ErrNotConnected
+	}
+
+	// 截图正在进行时跳过本次探测，避免与用户操作争抢
+	if !info.screenshotMu.TryLock() {
+		return 0, errProbeBusy
+	}
+	defer info.screenshotMu.Unlock()
+
+	start := time.Now()
+	var job *maa.Job
+	done, posted := cm.postHealthJob(info, func() *maa.Job {
+		job = ctrl.PostScreencap()
+		return job
+	})
+	if !posted {
+		return 0, NewMFWError(ErrCodeScreencapFailed, "previous screencap probe still pending", nil)
+	}
+	if job == nil {
+		return 0, NewMFWError(ErrCodeScreencapFailed, "failed to post screencap", nil)
+	}
+	if !waitDone(done, timeout) {
+		return 0, NewMFWError(ErrCodeScreencapFailed, "screencap probe timeout", nil)
+	}
+	if !job.Success() {
+		return 0, NewMFWError(ErrCodeScreencapFailed, "screencap probe failed", nil)
+	}
+	return time.Since(start), nil
+}
+
+// 复用原有控制器实例重新连接，保持任务器上的绑定不变
+func (cm *ControllerManager) ReconnectController(controllerID string, timeout time.Duration) error {
+	cm.mu.RLock()
+	info, exists := cm.controllers[controllerID]
+	cm.mu.RUnlock()
+
+	if !exists {
+		return ErrControllerNotFound
+	}
+	ctrl, ok := info.Controller.(*maa.Controller)
+	if !ok || ctrl == nil {
+		return NewMFWError(ErrCodeControllerNotConnected, "controller instance not available", nil)
+	}
+
+	var job *maa.Job
+	done, posted := cm.postHealthJob(info, func() *maa.Job {
+		job = ctrl.PostConnect()
+		return job
+	})
+	if !posted {
+		return NewMFWError(ErrCodeControllerConnectFail, "previous controller job still pending", nil)
+	}
+	if job == nil {
+		return NewMFWError(ErrCodeControllerConnectFail, "failed to post connect", nil)
+	}
+	if !waitDone(done, timeout) {
+		return NewMFWError(ErrCodeControllerConnectFail, "controller reconnect timeout", nil)
+	}
+	if !ctrl.Connected() {
+		return NewMFWError(ErrCodeControllerConnectFail, "controller connection failed", nil)
+	}
+
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	if uuidStr, err := ctrl.GetUUID(); err == nil {
+		info.UUID = uuidStr
+	}
+	info.Connected = true
+	return nil
+}
+
+// 标记控制器为断开状态，后续操作将直接返回未连接
+func (cm *ControllerManager) markDisconnected(controllerID string) {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	if info, exists := cm.controllers[controllerID]; exists {
+		info.Connected = false
+	}
+}
@@ -0,0 +1,142 @@
+package mfw
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	maa "github.com/MaaXYZ/maa-framework-go/v4"
+	mpeconfig "github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/config"
+)
+
+func TestHealthPolicyBackoff(t *testing.T) {
+	policy := newHealthPolicy(mpeconfig.ControllerHealthConfig{ReconnectMaxBackoffSeconds: 10})
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{attempt: 0, want: 2 * time.Second},
+		{attempt: 1, want: 4 * time.Second},
+		{attempt: 2, want: 8 * time.Second},
+		{attempt: 3, want: 10 * time.Second},
+		{attempt: 30, want: 10 * time.Second},
+	}
+
+	for _, test := range tests {
+		if got := policy.backoff(test.attempt); got != test.want {
+			t.Fatalf("backoff(%d) = %v, want %v", test.attempt, got, test.want)
+		}
+	}
+}
+
+func TestHealthTrackerFailureThreshold(t *testing.T) {
+	policy := newHealthPolicy(mpeconfig.ControllerHealthConfig{
+		FailureThreshold:           2,
+		AutoReconnect:              true,
+		ReconnectMaxAttempts:       2,
+		ReconnectMaxBackoffSeconds: 60,
+	})
+	tracker := newHealthTracker("ctrl", "ADB", policy)
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	probeErr := errors.New("screencap probe failed")
+
+	if tracker.recordFailure(now, probeErr, policy) {
+		t.Fatal("recordFailure() reported disconnect before threshold")
+	}
+	if tracker.health.Status != HealthStatusDegraded {
+		t.Fatalf("status = %s, want %s", tracker.health.Status, HealthStatusDegraded)
+	}
+
+	if !tracker.recordFailure(now, probeErr, policy) {
+		t.Fatal("recordFailure() did not report disconnect at threshold")
+	}
+	if tracker.health.Status != HealthStatusDisconnected {
+		t.Fatalf("status = %s, want %s", tracker.health.Status, HealthStatusDisconnected)
+	}
+	if tracker.reconnectDue(now.Add(time.Second)) || !tracker.reconnectDue(now.Add(2*time.Second)) {
+		t.Fatalf("next reconnect = %v, want %v", tracker.nextReconnect, now.Add(2*time.Second))
+	}
+	if tracker.recordFailure(now, probeErr, policy) {
+		t.Fatal("recordFailure() reported disconnect twice")
+	}
+
+	reconnectErr := errors.New("controller connection failed")
+	tracker.recordReconnect(now, reconnectErr, policy)
+	if tracker.health.ReconnectAttempts != 1 || !tracker.reconnectDue(now.Add(4*time.Second)) {
+		t.Fatalf("after first reconnect failure = %+v", tracker.health)
+	}
+	tracker.recordReconnect(now, reconnectErr, policy)
+	if tracker.health.NextReconnectAt != "" || tracker.reconnectDue(now.Add(time.Hour)) {
+		t.Fatalf("reconnect scheduled after max attempts: %+v", tracker.health)
+	}
+
+	tracker.recordReconnect(now, nil, policy)
+	if tracker.health.Status != HealthStatusHealthy || tracker.health.ConsecutiveFailures != 0 || tracker.health.ReconnectAttempts != 0 {
+		t.Fatalf("after reconnect success = %+v", tracker.health)
+	}
+	if tracker.health.TotalFailures != 3 {
+		t.Fatalf("total failures = %d, want 3", tracker.health.TotalFailures)
+	}
+}
+
+func TestHealthTrackerWithoutAutoReconnect(t *testing.T) {
+	policy := newHealthPolicy(mpeconfig.ControllerHealthConfig{FailureThreshold: 1, AutoReconnect: true})
+	tracker := newHealthTracker("ctrl", "Win32", policy)
+	now := time.Now()
+
+	if tracker.health.AutoReconnect {
+		t.Fatal("Win32 controller must not auto reconnect")
+	}
+	tracker.recordFailure(now, errors.New("screencap probe failed"), policy)
+	if tracker.health.Status != HealthStatusDisconnected || tracker.reconnectDue(now.Add(time.Hour)) {
+		t.Fatalf("health = %+v, want disconnected without reconnect", tracker.health)
+	}
+}
+
+func TestHealthTrackerLatency(t *testing.T) {
+	tracker := newHealthTracker("ctrl", "ADB", newHealthPolicy(mpeconfig.ControllerHealthConfig{}))
+	now := time.Now()
+	tracker.recordSuccess(now, 100*time.Millisecond)
+	tracker.recordSuccess(now, 300*time.Millisecond)
+
+	if tracker.health.LatencyMS != 300 || tracker.health.AvgLatencyMS != 200 || tracker.health.TotalProbes != 2 {
+		t.Fatalf("health = %+v", tracker.health)
+	}
+}
+
+func TestHealthMonitorSetPolicyUpdatesTrackers(t *testing.T) {
+	monitor := NewHealthMonitor(nil)
+	monitor.setPolicyLocked(newHealthPolicy(mpeconfig.ControllerHealthConfig{AutoReconnect: true}))
+
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	tracker := newHealthTracker("ctrl", "ADB", monitor.policy)
+	tracker.recordFailure(now, errors.New("screencap probe failed"), monitor.policy)
+	monitor.trackers["ctrl"] = tracker
+	if !tracker.health.AutoReconnect || tracker.health.NextReconnectAt == "" {
+		t.Fatalf("before restart = %+v", tracker.health)
+	}
+
+	monitor.setPolicyLocked(newHealthPolicy(mpeconfig.ControllerHealthConfig{AutoReconnect: false}))
+	if tracker.health.AutoReconnect || tracker.health.NextReconnectAt != "" || tracker.reconnectDue(now.Add(time.Hour)) {
+		t.Fatalf("after restart = %+v", tracker.health)
+	}
+}
+
+func TestPostHealthJobSkipsWhilePending(t *testing.T) {
+	cm := &ControllerManager{}
+	pending := make(chan struct{})
+	info := &ControllerInfo{ControllerID: "ctrl", healthJob: pending}
+	calls := 0
+	post := func() *maa.Job {
+		calls++
+		return nil
+	}
+
+	if _, posted := cm.postHealthJob(info, post); posted || calls != 0 {
+		t.Fatalf("postHealthJob() while pending: posted = %v, calls = %d", posted, calls)
+	}
+	close(pending)
+	if _, posted := cm.postHealthJob(info, post); !posted || calls != 1 {
+		t.Fatalf("postHealthJob() after completion: posted = %v, calls = %d", posted, calls)
+	}
+}
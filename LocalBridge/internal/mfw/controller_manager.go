@@ -145,18 +145,7 @@ func (cm *ControllerManager) CreateWin32Controller(hwnd, screencapMethod, inputM
 	controllerID := uuid.New().String()
 
 	// 解析窗口句柄
-	var hwndPtr unsafe.Pointer
-	if hwnd != "" {
-		// 去掉 "0x" 或 "0X" 前缀
-		hexStr := strings.TrimPrefix(hwnd, "0x")
-		hexStr = strings.TrimPrefix(hexStr, "0X")
-		if val, err := strconv.ParseUint(hexStr, 16, 64); err == nil {
-			hwndPtr = unsafe.Pointer(uintptr(val))
-			logger.Debug("MFW", "解析窗口句柄: %s -> %v", hwnd, hwndPtr)
-		} else {
-			logger.Error("MFW", "解析窗口句柄失败: %s, %v", hwnd, err)
-		}
-	}
+	hwndPtr := parseWin32Hwnd(hwnd)
 
 	// 解析截图方法，默认使用 FramePool
 	scMethod, err := parseWin32ScreencapMethod(screencapMethod)
@@ -195,6 +184,24 @@ func (cm *ControllerManager) CreateWin32Controller(hwnd, screencapMethod, inputM
 	return controllerID, nil
 }
 
+// 解析十六进制窗口句柄，解析失败时返回 nil
+func parseWin32Hwnd(hwnd string) unsafe.Pointer {
+	if hwnd == "" {
+		return nil
+	}
+	// 去掉 "0x" 或 "0X" 前缀
+	hexStr := strings.TrimPrefix(hwnd, "0x")
+	hexStr = strings.TrimPrefix(hexStr, "0X")
+	val, err := strconv.ParseUint(hexStr, 16, 64)
+	if err != nil {
+		logger.Error("MFW", "解析窗口句柄失败: %s, %v", hwnd, err)
+		return nil
+	}
+	hwndPtr := unsafe.Pointer(uintptr(val))
+	logger.Debug("MFW", "解析窗口句柄: %s -> %v", hwnd, hwndPtr)
+	return hwndPtr
+}
+
 // 创建 PlayCover 控制器 (macOS上运行 iOS 应用)
 func (cm *ControllerManager) CreatePlayCoverController(address, deviceUUID string) (string, error) {
 	logger.Debug("MFW", "创建 PlayCover 控制器: %s", address)
@@ -606,11 +613,12 @@ func (cm *ControllerManager) ListControllers() []*ControllerInfo {
 	return result
 }
 
-// 清理非活跃控制器
-func (cm *ControllerManager) CleanupInactive(timeout time.Duration) {
+// 清理非活跃控制器，返回被清理的控制器ID
+func (cm *ControllerManager) CleanupInactive(timeout time.Duration) []string {
 	cm.mu.Lock()
 	defer cm.mu.Unlock()
 
+	var removed []string
 	now := time.Now()
 	for id, info := range cm.controllers {
 		if now.Sub(info.LastActiveAt) > timeout {
@@ -619,9 +627,11 @@ func (cm *ControllerManager) CleanupInactive(timeout time.Duration) {
 				ctrl.Destroy()
 			}
 			delete(cm.controllers, id)
+			removed = append(removed, id)
 			logger.Debug("MFW", "清理非活跃控制器: %s", id)
 		}
 	}
+	return removed
 }
 
 // 断开所有控制器
@@ -0,0 +1,116 @@
+package mfw
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/paths"
+)
+
+// 设备的控制器偏好配置（如测速得到的推荐截图方法）
+type ControllerProfile struct {
+	Key              string   `json:"key"`
+	Type             string   `json:"type"`
+	ScreencapMethods []string `json:"screencap_methods"`
+	MeanLatencyMS    float64  `json:"mean_latency_ms,omitempty"`
+	BenchmarkedAt    string   `json:"benchmarked_at,omitempty"`
+}
+
+// 控制器偏好配置存储，按设备标识保存为单个 JSON 文件
+type ControllerProfileStore struct {
+	path string
+	mu   sync.Mutex
+}
+
+// 创建控制器偏好配置存储，path 为空时使用数据目录下的默认文件
+func NewControllerProfileStore(path string) *ControllerProfileStore {
+	return &ControllerProfileStore{path: path}
+}
+
+// 生成设备标识，ADB 使用地址，Win32 使用窗口句柄或窗口名
+func ControllerProfileKey(controllerType, identifier string) string {
+	identifier = strings.TrimSpace(identifier)
+	if strings.EqualFold(controllerType, "Win32") {
+		identifier = normalizeWin32Hwnd(identifier)
+	}
+	return strings.ToLower(controllerType) + ":" + identifier
+}
+
+// 将十六进制窗口句柄统一为 0x 小写形式，与刷新窗体列表时的格式一致
+func normalizeWin32Hwnd(hwnd string) string {
+	hexStr := strings.TrimPrefix(strings.TrimPrefix(hwnd, "0x"), "0X")
+	val, err := strconv.ParseUint(hexStr, 16, 64)
+	if err != nil {
+		return hwnd
+	}
+	return fmt.Sprintf("0x%x", val)
+}
+
+func (s *ControllerProfileStore) filePath() string {
+	if s.path != "" {
+		return s.path
+	}
+	return paths.GetControllerProfileFile()
+}
+
+// 读取全部偏好配置，文件不存在时返回空集合
+func (s *ControllerProfileStore) Load() (map[string]ControllerProfile, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.loadLocked()
+}
+
+func (s *ControllerProfileStore) loadLocked() (map[string]ControllerProfile, error) {
+	profiles := make(map[string]ControllerProfile)
+	data, err := os.ReadFile(s.filePath())
+	if os.IsNotExist(err) {
+		return profiles, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取控制器偏好配置失败: %w", err)
+	}
+	if err := json.Unmarshal(data, &profiles); err != nil {
+		return nil, fmt.Errorf("解析控制器偏好配置失败: %w", err)
+	}
+	return profiles, nil
+}
+
+// 获取指定设备的偏好配置
+func (s *ControllerProfileStore) Get(key string) (ControllerProfile, bool) {
+	profiles, err := s.Load()
+	if err != nil {
+		return ControllerProfile{}, false
+	}
+	profile, ok := profiles[key]
+	return profile, ok
+}
+
+// 保存指定设备的偏好配置
+func (s *ControllerProfileStore) Save(profile ControllerProfile) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	profiles, err := s.loadLocked()
+	if err != nil {
+		return err
+	}
+	profiles[profile.Key] = profile
+
+	data, err := json.MarshalIndent(profiles, "", "    ")
+	if err != nil {
+		return fmt.Errorf("序列化控制器偏好配置失败: %w", err)
+	}
+	path := s.filePath()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("创建控制器偏好配置目录失败: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("写入控制器偏好配置失败: %w", err)
+	}
+	return nil
+}
@@ -14,6 +14,7 @@ type DeviceManager struct {
 	adbDevices         []AdbDeviceInfo
 	win32Windows       []Win32WindowInfo
 	wlrootsCompositors []WlRootsCompositorInfo
+	profiles           *ControllerProfileStore
 	mu                 sync.RWMutex
 }
 
@@ -22,9 +23,15 @@ func NewDeviceManager() *DeviceManager {
 	return &DeviceManager{
 		adbDevices:   make([]AdbDeviceInfo, 0),
 		win32Windows: make([]Win32WindowInfo, 0),
+		profiles:     NewControllerProfileStore(""),
 	}
 }
 
+// 获取控制器偏好配置存储
+func (dm *DeviceManager) Profiles() *ControllerProfileStore {
+	return dm.profiles
+}
+
 // 刷新ADB设备列表
 func (dm *DeviceManager) RefreshAdbDevices() ([]AdbDeviceInfo, error) {
 	logger.Debug("MFW", "开始刷新 ADB 设备列表")
@@ -50,6 +57,10 @@ func (dm *DeviceManager) RefreshAdbDevices() ([]AdbDeviceInfo, error) {
 			AvailableInputMethods:     allAdbInputMethodNames(),
 			Config:                    dev.Config,
 		}
+		// 优先使用测速保存的截图方法
+		if profile, ok := dm.profiles.Get(ControllerProfileKey("ADB", dev.Address)); ok && len(profile.ScreencapMethods) > 0 {
+			info.ScreencapMethods = append([]string(nil), profile.ScreencapMethods...)
+		}
 		dm.adbDevices = append(dm.adbDevices, info)
 	}
 
@@ -107,6 +118,15 @@ func adbMethodNames[T ~uint64](methods uint64, ordered []struct {
 	return names
 }
 
+// 获取测速保存的 Win32 窗口推荐截图方法，未保存时返回空
+func (dm *DeviceManager) PreferredWin32ScreencapMethod(hwnd string) string {
+	profile, ok := dm.profiles.Get(ControllerProfileKey("Win32", hwnd))
+	if !ok || len(profile.ScreencapMethods) == 0 {
+		return ""
+	}
+	return profile.ScreencapMethods[0]
+}
+
 // 刷新 Win32 窗体列表
 func (dm *DeviceManager) RefreshWin32Windows() ([]Win32WindowInfo, error) {
 	logger.Debug("MFW", "开始刷新 Win32 窗体列表")
@@ -135,6 +155,7 @@ func (dm *DeviceManager) RefreshWin32Windows() ([]Win32WindowInfo, error) {
 			ScreencapMethods: screencapMethods,
 			InputMethods:     inputMethods,
 		}
+		info.PreferredScreencapMethod = dm.PreferredWin32ScreencapMethod(info.Hwnd)
 		dm.win32Windows = append(dm.win32Windows, info)
 	}
 
@@ -1,6 +1,7 @@
 package mfw
 
 import (
+	"path/filepath"
 	"reflect"
 	"testing"
 
@@ -46,3 +47,26 @@ func TestAdbAgentRequirements(t *testing.T) {
 		})
 	}
 }
+
+func TestPreferredWin32ScreencapMethod(t *testing.T) {
+	dm := &DeviceManager{profiles: NewControllerProfileStore(filepath.Join(t.TempDir(), "profiles.json"))}
+	req := ScreencapBenchRequest{Type: "Win32", Hwnd: "0X1A2B"}
+	if err := dm.profiles.Save(ControllerProfile{Key: req.ProfileKey(), Type: "Win32", ScreencapMethods: []string{"DXGI_DesktopDup"}}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	tests := []struct {
+		hwnd string
+		want string
+	}{
+		{hwnd: "0x1a2b", want: "DXGI_DesktopDup"},
+		{hwnd: "1A2B", want: "DXGI_DesktopDup"},
+		{hwnd: "0x1a2c"},
+		{hwnd: ""},
+	}
+	for _, tt := range tests {
+		if got := dm.PreferredWin32ScreencapMethod(tt.hwnd); got != tt.want {
+			t.Fatalf("PreferredWin32ScreencapMethod(%q) = %q, want %q", tt.hwnd, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,387 @@
+package mfw
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"strings"
+	"sync"
+	"time"
+
+	maa "github.com/MaaXYZ/maa-framework-go/v4"
+	"github.com/google/uuid"
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/logger"
+)
+
+const (
+	defaultStreamFPS     = 5
+	maxStreamFPS         = 30
+	defaultStreamQuality = 75
+	minStreamQuality     = 30
+	maxStreamQuality     = 95
+
+	// 发送队列积压达到该值时丢弃本帧
+	streamBacklogDropLimit = 8
+	// 发送队列积压达到该值时降低画质
+	streamBacklogHigh = 3
+	// 连续截图失败达到该次数时停止推流
+	streamMaxCaptureFailures = 5
+)
+
+// 推流停止原因
+const (
+	StreamStopReasonClient         = "client_stop"
+	StreamStopReasonConnection     = "connection_closed"
+	StreamStopReasonExclusive      = "exclusive_access"
+	StreamStopReasonCaptureFailed  = "capture_failed"
+	StreamStopReasonServiceStopped = "service_stopped"
+)
+
+// 实时画面推流参数
+type StreamOptions struct {
+	ControllerID string `json:"controller_id"`
+	FPS          int    `json:"fps"`
+	Format       string `json:"format"` // jpeg / png，未实现 WebP 编码，webp 会被拒绝
+	Quality      int    `json:"quality"`
+	MaxLongSide  int32  `json:"max_long_side,omitempty"`
+}
+
+// 实时画面帧
+type StreamFrame struct {
+	StreamID     string `json:"stream_id"`
+	ControllerID string `json:"controller_id"`
+	Seq          int64  `json:"seq"`
+	Format       string `json:"format"`
+	Quality      int    `json:"quality,omitempty"`
+	ImageData    string `json:"image_data"`
+	Width        int    `json:"width"`
+	Height       int    `json:"height"`
+	Dropped      int64  `json:"dropped"`
+	Timestamp    string `json:"timestamp"`
+}
+
+// 推流输出目标，与具体连接实现解耦
+type StreamTarget struct {
+	Send    func(frame StreamFrame)
+	Backlog func() int
+	Done    <-chan struct{}
+	OnStop  func(streamID, reason string)
+}
+
+type screenStream struct {
+	id      string
+	options StreamOptions
+	target  StreamTarget
+	stopCh  chan struct{}
+	reason  string
+	once    sync.Once
+}
+
+// 实时画面推流管理器
+type StreamManager struct {
+	controllers *ControllerManager
+	streams     map[string]*screenStream
+	reserved    map[string]map[uint64]string // controllerID -> 独占编号 -> 原因
+	reserveSeq  uint64
+	mu          sync.Mutex
+}
+
+// 创建推流管理器
+func NewStreamManager(controllers *ControllerManager) *StreamManager {
+	return &StreamManager{
+		controllers: controllers,
+		streams:     make(map[string]*screenStream),
+		reserved:    make(map[string]map[uint64]string),
+	}
+}
+
+// Normalize 规范化推流参数，参数无效时返回错误
+func (o *StreamOptions) Normalize() error {
+	if strings.TrimSpace(o.ControllerID) == "" {
+		return fmt.Errorf("缺少 controller_id")
+	}
+	if o.FPS <= 0 {
+		o.FPS = defaultStreamFPS
+	}
+	if o.FPS > maxStreamFPS {
+		o.FPS = maxStreamFPS
+	}
+	switch strings.ToLower(strings.TrimSpace(o.Format)) {
+	case "", "jpeg", "jpg":
+		o.Format = "jpeg"
+	case "png":
+		o.Format = "png"
+	case "webp":
+		// 标准库与 x/image 均无 WebP 编码器，引入 cgo 编码库前不提供该格式
+		return fmt.Errorf("未实现 webp 推流编码，请使用 jpeg 或 png")
+	default:
+		return fmt.Errorf("不支持的推流格式: %s", o.Format)
+	}
+	if o.Quality <= 0 {
+		o.Quality = defaultStreamQuality
+	}
+	o.Quality = clampQuality(o.Quality, maxStreamQuality)
+	return nil
+}
+
+// 开始推流，返回推流ID与生效的参数
+func (m *StreamManager) Start(options StreamOptions, target StreamTarget) (string, StreamOptions, error) {
+	if err := options.Normalize(); err != nil {
+		return "", options, err
+	}
+	info, err := m.controllers.GetController(options.ControllerID)
+	if err != nil {
+		return "", options, err
+	}
+	ctrl, ok := info.Controller.(*maa.Controller)
+	if !info.Connected || !ok || ctrl == nil {
+		return "", options, ErrNotConnected
+	}
+
+	m.mu.Lock()
+	if reason, reserved := m.reservedReasonLocked(options.ControllerID); reserved {
+		m.mu.Unlock()
+		return "", options, fmt.Errorf("控制器正被独占使用: %s", reason)
+	}
+	stream := &screenStream{
+		id:      uuid.NewString(),
+		options: options,
+		target:  target,
+		stopCh:  make(chan struct{}),
+	}
+	m.streams[stream.id] = stream
+	m.mu.Unlock()
+
+	screenshotter := NewScreenshotter()
+	screenshotter.SetController(ctrl)
+	go m.run(stream, screenshotter)
+
+	logger.Info("MFW", "开始实时推流: %s, controller=%s, fps=%d, format=%s", stream.id, options.ControllerID, options.FPS, options.Format)
+	return stream.id, options, nil
+}
+
+// 停止指定推流
+func (m *StreamManager) Stop(streamID, reason string) bool {
+	m.mu.Lock()
+	stream, ok := m.streams[streamID]
+	m.mu.Unlock()
+	if !ok {
+		return false
+	}
+	stream.stop(reason)
+	return true
+}
+
+// 停止某个控制器上的全部推流
+func (m *StreamManager) StopController(controllerID, reason string) {
+	for _, stream := range m.snapshot() {
+		if stream.options.ControllerID == controllerID {
+			stream.stop(reason)
+		}
+	}
+}
+
+// 停止全部推流
+func (m *StreamManager) StopAll(reason string) {
+	for _, stream := range m.snapshot() {
+		stream.stop(reason)
+	}
+}
+
+// 独占控制器：停止其上的推流并拒绝新推流，返回的 release 用于解除独占；
+// 同一控制器可被多次独占，全部释放后才恢复推流
+func (m *StreamManager) Reserve(controllerID, reason string) func() {
+	m.mu.Lock()
+	m.reserveSeq++
+	id := m.reserveSeq
+	if m.reserved[controllerID] == nil {
+		m.reserved[controllerID] = make(map[uint64]string)
+	}
+	m.reserved[controllerID][id] = reason
+	m.mu.Unlock()
+	m.StopController(controllerID, StreamStopReasonExclusive)
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			m.mu.Lock()
+			defer m.mu.Unlock()
+			delete(m.reserved[controllerID], id)
+			if len(m.reserved[controllerID]) == 0 {
+				delete(m.reserved, controllerID)
+			}
+		})
+	}
+}
+
+// 返回控制器最早一次仍生效的独占原因，调用方需持有 m.mu
+func (m *StreamManager) reservedReasonLocked(controllerID string) (string, bool) {
+	holders := m.reserved[controllerID]
+	if len(holders) == 0 {
+		return "", false
+	}
+	first := uint64(0)
+	for id := range holders {
+		if first == 0 || id < first {
+			first = id
+		}
+	}
+	return holders[first], true
+}
+
+func (m *StreamManager) snapshot() []*screenStream {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	streams := make([]*screenStream, 0, len(m.streams))
+	for _, stream := range m.streams {
+		streams = append(streams, stream)
+	}
+	return streams
+}
+
+func (s *screenStream) stop(reason string) {
+	s.once.Do(func() {
+		s.reason = reason
+		close(s.stopCh)
+	})
+}
+
+func (m *StreamManager) run(stream *screenStream, screenshotter *Screenshotter) {
+	defer func() {
+		m.mu.Lock()
+		delete(m.streams, stream.id)
+		m.mu.Unlock()
+		logger.Info("MFW", "实时推流已停止: %s, reason=%s", stream.id, stream.reason)
+		if stream.target.OnStop != nil {
+			stream.target.OnStop(stream.id, stream.reason)
+		}
+	}()
+
+	// 帧间隔不低于截图缓存有效期，避免重复推送同一缓存帧
+	interval := time.Second / time.Duration(stream.options.FPS)
+	if ttl := screenshotter.CacheTTL(); interval < ttl {
+		interval = ttl
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	quality := stream.options.Quality
+	var seq, dropped int64
+	failures := 0
+	for {
+		select {
+		case <-stream.stopCh:
+			return
+		case <-stream.target.Done:
+			stream.stop(StreamStopReasonConnection)
+			return
+		case <-ticker.C:
+		}
+
+		backlog := 0
+		if stream.target.Backlog != nil {
+			backlog = stream.target.Backlog()
+		}
+		quality = adaptStreamQuality(quality, backlog, stream.options.Quality)
+		if backlog >= streamBacklogDropLimit {
+			dropped++
+			continue
+		}
+
+		img, err := m.controllers.captureStreamImage(stream.options.ControllerID, screenshotter)
+		if err != nil {
+			failures++
+			if failures >= streamMaxCaptureFailures {
+				logger.Warn("MFW", "实时推流连续截图失败: %s, %v", stream.id, err)
+				stream.stop(StreamStopReasonCaptureFailed)
+				return
+			}
+			continue
+		}
+		failures = 0
+
+		img = resizeImageToLongSide(img, stream.options.MaxLongSide)
+		data, err := encodeStreamImage(img, stream.options.Format, quality)
+		if err != nil {
+			logger.Warn("MFW", "实时推流编码失败: %s, %v", stream.id, err)
+			continue
+		}
+
+		seq++
+		bounds := img.Bounds()
+		frame := StreamFrame{
+			StreamID:     stream.id,
+			ControllerID: stream.options.ControllerID,
+			Seq:          seq,
+			Format:       stream.options.Format,
+			ImageData:    data,
+			Width:        bounds.Dx(),
+			Height:       bounds.Dy(),
+			Dropped:      dropped,
+			Timestamp:    time.Now().Format(time.RFC3339Nano),
+		}
+		if stream.options.Format == "jpeg" {
+			frame.Quality = quality
+		}
+		stream.target.Send(frame)
+	}
+}
+
+// 根据发送队列积压调整画质，积压时快速降低，空闲时缓慢恢复
+func adaptStreamQuality(current, backlog, maxQuality int) int {
+	switch {
+	case backlog >= streamBacklogHigh:
+		current -= 10
+	case backlog == 0:
+		current += 5
+	}
+	return clampQuality(current, maxQuality)
+}
+
+func clampQuality(quality, maxQuality int) int {
+	if maxQuality > maxStreamQuality {
+		maxQuality = maxStreamQuality
+	}
+	if quality > maxQuality {
+		quality = maxQuality
+	}
+	if quality < minStreamQuality {
+		quality = minStreamQuality
+	}
+	return quality
+}
+
+func encodeStreamImage(img image.Image, format string, quality int) (string, error) {
+	var buffer bytes.Buffer
+	if format == "png" {
+		if err := png.Encode(&buffer, img); err != nil {
+			return "", err
+		}
+		return "data:image/png;base64," + base64.StdEncoding.EncodeToString(buffer.Bytes()), nil
+	}
+	if err := jpeg.Encode(&buffer, img, &jpeg.Options{Quality: quality}); err != nil {
+		return "", err
+	}
+	return "data:image/jpeg;base64," + base64.StdEncoding.EncodeToString(buffer.Bytes()), nil
+}
+
+// 在截图锁内为推流截取一帧
+func (cm *ControllerManager) captureStreamImage(controllerID string, screenshotter *Screenshotter) (image.Image, error) {
+	cm.mu.RLock()
+	info, exists := cm.controllers[controllerID]
+	cm.mu.RUnlock()
+
+	if !exists {
+		return nil, ErrControllerNotFound
+	}
+	if !info.Connected {
+		return nil, ErrNotConnected
+	}
+
+	info.screenshotMu.Lock()
+	defer info.screenshotMu.Unlock()
+	return screenshotter.Capture()
+}
@@ -0,0 +1,123 @@
+package mfw
+
+import (
+	"image"
+	"strings"
+	"testing"
+)
+
+func TestStreamOptionsNormalize(t *testing.T) {
+	tests := []struct {
+		name    string
+		options StreamOptions
+		want    StreamOptions
+		wantErr bool
+	}{
+		{
+			name:    "defaults",
+			options: StreamOptions{ControllerID: "ctrl"},
+			want:    StreamOptions{ControllerID: "ctrl", FPS: defaultStreamFPS, Format: "jpeg", Quality: defaultStreamQuality},
+		},
+		{
+			name:    "clamped",
+			options: StreamOptions{ControllerID: "ctrl", FPS: 120, Format: "PNG", Quality: 100},
+			want:    StreamOptions{ControllerID: "ctrl", FPS: maxStreamFPS, Format: "png", Quality: maxStreamQuality},
+		},
+		{
+			name:    "jpg alias",
+			options: StreamOptions{ControllerID: "ctrl", Format: "jpg", Quality: 10},
+			want:    StreamOptions{ControllerID: "ctrl", FPS: defaultStreamFPS, Format: "jpeg", Quality: minStreamQuality},
+		},
+		{name: "webp unsupported", options: StreamOptions{ControllerID: "ctrl", Format: "webp"}, wantErr: true},
+		{name: "missing controller", options: StreamOptions{}, wantErr: true},
+		{name: "unknown format", options: StreamOptions{ControllerID: "ctrl", Format: "gif"}, wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			options := test.options
+			err := options.Normalize()
+			if (err != nil) != test.wantErr {
+				t.Fatalf("Normalize() error = %v, wantErr = %v", err, test.wantErr)
+			}
+			if !test.wantErr && options != test.want {
+				t.Fatalf("Normalize() = %+v, want %+v", options, test.want)
+			}
+		})
+	}
+}
+
+func TestAdaptStreamQuality(t *testing.T) {
+	tests := []struct {
+		name    string
+		current int
+		backlog int
+		max     int
+		want    int
+	}{
+		{name: "idle recovers", current: 60, backlog: 0, max: 75, want: 65},
+		{name: "idle capped by requested", current: 73, backlog: 0, max: 75, want: 75},
+		{name: "busy keeps", current: 60, backlog: 1, max: 75, want: 60},
+		{name: "backlog lowers", current: 60, backlog: streamBacklogHigh, max: 75, want: 50},
+		{name: "floor", current: 35, backlog: streamBacklogDropLimit, max: 75, want: minStreamQuality},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := adaptStreamQuality(test.current, test.backlog, test.max); got != test.want {
+				t.Fatalf("adaptStreamQuality() = %d, want %d", got, test.want)
+			}
+		})
+	}
+}
+
+func TestStreamManagerReserve(t *testing.T) {
+	manager := NewStreamManager(NewControllerManager())
+	stream := &screenStream{id: "s1", options: StreamOptions{ControllerID: "ctrl"}, stopCh: make(chan struct{})}
+	other := &screenStream{id: "s2", options: StreamOptions{ControllerID: "other"}, stopCh: make(chan struct{})}
+	manager.streams[stream.id] = stream
+	manager.streams[other.id] = other
+
+	release := manager.Reserve("ctrl", "debug run")
+	select {
+	case <-stream.stopCh:
+	default:
+		t.Fatal("Reserve() did not stop stream on reserved controller")
+	}
+	if stream.reason != StreamStopReasonExclusive {
+		t.Fatalf("stop reason = %q, want %q", stream.reason, StreamStopReasonExclusive)
+	}
+	select {
+	case <-other.stopCh:
+		t.Fatal("Reserve() stopped stream on another controller")
+	default:
+	}
+	if reason, ok := manager.reservedReasonLocked("ctrl"); !ok || reason != "debug run" {
+		t.Fatalf("reservedReasonLocked() = %q, %v; want debug run", reason, ok)
+	}
+
+	// 另一次独占仍在时，先释放的一方不能解除控制器的独占
+	releaseOther := manager.Reserve("ctrl", "debug run 2")
+	release()
+	release()
+	if reason, ok := manager.reservedReasonLocked("ctrl"); !ok || reason != "debug run 2" {
+		t.Fatalf("reservedReasonLocked() after first release = %q, %v; want debug run 2", reason, ok)
+	}
+	releaseOther()
+	if _, ok := manager.reserved["ctrl"]; ok {
+		t.Fatal("release() did not clear reservation")
+	}
+}
+
+func TestEncodeStreamImage(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	for _, format := range []string{"jpeg", "png"} {
+		data, err := encodeStreamImage(img, format, 50)
+		if err != nil {
+			t.Fatalf("encodeStreamImage(%s) error = %v", format, err)
+		}
+		if !strings.HasPrefix(data, "data:image/"+format+";base64,") {
+			t.Fatalf("encodeStreamImage(%s) prefix = %q", format, data[:min(len(data), 32)])
+		}
+	}
+}
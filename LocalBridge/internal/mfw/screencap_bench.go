@@ -0,0 +1,317 @@
+package mfw
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	maa "github.com/MaaXYZ/maa-framework-go/v4"
+	"github.com/MaaXYZ/maa-framework-go/v4/controller/adb"
+	"github.com/MaaXYZ/maa-framework-go/v4/controller/win32"
+	mpeconfig "github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/config"
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/logger"
+)
+
+const (
+	defaultBenchFrames    = 10
+	maxBenchFrames        = 100
+	benchConnectTimeout   = 10 * time.Second
+	benchScreencapTimeout = 5 * time.Second
+)
+
+// 截图测速请求
+type ScreencapBenchRequest struct {
+	Type    string   `json:"type"` // ADB / Win32
+	AdbPath string   `json:"adb_path,omitempty"`
+	Address string   `json:"address,omitempty"`
+	Config  string   `json:"config,omitempty"`
+	Hwnd    string   `json:"hwnd,omitempty"`
+	Methods []string `json:"methods,omitempty"` // 为空时测试全部截图方法
+	Frames  int      `json:"frames"`
+}
+
+// 单个截图方法的测速结果
+type ScreencapMethodResult struct {
+	Method      string  `json:"method"`
+	Connected   bool    `json:"connected"`
+	ConnectMS   int64   `json:"connect_ms"`
+	Frames      int     `json:"frames"`
+	Failures    int     `json:"failures"`
+	FailureRate float64 `json:"failure_rate"`
+	MeanMS      float64 `json:"mean_ms"`
+	P95MS       float64 `json:"p95_ms"`
+	MinMS       float64 `json:"min_ms"`
+	MaxMS       float64 `json:"max_ms"`
+	RawWidth    int32   `json:"raw_width,omitempty"`
+	RawHeight   int32   `json:"raw_height,omitempty"`
+	ImageWidth  int     `json:"image_width,omitempty"`
+	ImageHeight int     `json:"image_height,omitempty"`
+	Error       string  `json:"error,omitempty"`
+	latencies   []time.Duration
+}
+
+// 截图测速报告
+type ScreencapBenchReport struct {
+	Type        string                  `json:"type"`
+	Device      string                  `json:"device"`
+	ProfileKey  string                  `json:"profile_key"`
+	Frames      int                     `json:"frames"`
+	Results     []ScreencapMethodResult `json:"results"`
+	Recommended string                  `json:"recommended,omitempty"`
+	StartedAt   string                  `json:"started_at"`
+	DurationMS  int64                   `json:"duration_ms"`
+}
+
+// 规范化测速请求并返回待测方法列表
+func (req *ScreencapBenchRequest) normalize() ([]string, error) {
+	req.Type = strings.TrimSpace(req.Type)
+	switch {
+	case strings.EqualFold(req.Type, "ADB"):
+		req.Type = "ADB"
+		if strings.TrimSpace(req.AdbPath) == "" || strings.TrimSpace(req.Address) == "" {
+			return nil, fmt.Errorf("ADB 测速需要 adb_path 与 address")
+		}
+	case strings.EqualFold(req.Type, "Win32"):
+		req.Type = "Win32"
+		if strings.TrimSpace(req.Hwnd) == "" {
+			return nil, fmt.Errorf("Win32 测速需要 hwnd")
+		}
+	default:
+		return nil, fmt.Errorf("不支持的测速控制器类型: %s", req.Type)
+	}
+
+	if req.Frames <= 0 {
+		req.Frames = defaultBenchFrames
+	}
+	if req.Frames > maxBenchFrames {
+		req.Frames = maxBenchFrames
+	}
+
+	available := allAdbScreencapMethodNames()
+	if req.Type == "Win32" {
+		available = allWin32ScreencapMethodNames()
+	}
+	if len(req.Methods) == 0 {
+		return available, nil
+	}
+	methods := make([]string, 0, len(req.Methods))
+	for _, method := range req.Methods {
+		matched := ""
+		for _, name := range available {
+			if strings.EqualFold(strings.TrimSpace(method), name) {
+				matched = name
+				break
+			}
+		}
+		if matched == "" {
+			return nil, fmt.Errorf("未知的截图方法: %s", method)
+		}
+		methods = append(methods, matched)
+	}
+	return methods, nil
+}
+
+// 设备在偏好配置中的标识
+func (req *ScreencapBenchRequest) ProfileKey() string {
+	if strings.EqualFold(req.Type, "Win32") {
+		return ControllerProfileKey("Win32", req.Hwnd)
+	}
+	return ControllerProfileKey("ADB", req.Address)
+}
+
+// 依次使用每种截图方法连接设备并截取若干帧，progress 在每个方法结束后回调
+func BenchmarkScreencap(req ScreencapBenchRequest, progress func(ScreencapMethodResult)) (*ScreencapBenchReport, error) {
+	methods, err := req.normalize()
+	if err != nil {
+		return nil, err
+	}
+
+	started := time.Now()
+	report := &ScreencapBenchReport{
+		Type:       req.Type,
+		Device:     req.Address,
+		ProfileKey: req.ProfileKey(),
+		Frames:     req.Frames,
+		Results:    make([]ScreencapMethodResult, 0, len(methods)),
+		StartedAt:  started.Format(time.RFC3339),
+	}
+	if req.Type == "Win32" {
+		report.Device = req.Hwnd
+	}
+
+	for _, method := range methods {
+		logger.Info("MFW", "截图测速: %s %s 使用 %s", req.Type, report.Device, method)
+		result := benchmarkScreencapMethod(req, method)
+		report.Results = append(report.Results, result)
+		if progress != nil {
+			progress(result)
+		}
+	}
+
+	report.Recommended = recommendScreencapMethod(report.Results)
+	report.DurationMS = time.Since(started).Milliseconds()
+	return report, nil
+}
+
+func benchmarkScreencapMethod(req ScreencapBenchRequest, method string) ScreencapMethodResult {
+	result := ScreencapMethodResult{Method: method, Frames: req.Frames}
+
+	ctrl, err := newBenchController(req, method)
+	if err != nil {
+		result.Failures = req.Frames
+		result.Error = err.Error()
+		return finishMethodResult(result)
+	}
+	// 超时的任务仍在执行时，控制器需等任务结束后再销毁
+	var pending <-chan struct{}
+	defer func() { destroyAfter(ctrl, pending) }()
+
+	connectStart := time.Now()
+	job := ctrl.PostConnect()
+	if job != nil {
+		if done := watchJob(job); !waitDone(done, benchConnectTimeout) {
+			pending = done
+			job = nil
+		}
+	}
+	if job == nil || !ctrl.Connected() {
+		result.Failures = req.Frames
+		result.Error = "controller connection failed"
+		return finishMethodResult(result)
+	}
+	result.Connected = true
+	result.ConnectMS = time.Since(connectStart).Milliseconds()
+	if width, height, err := ctrl.GetResolution(); err == nil {
+		result.RawWidth, result.RawHeight = width, height
+	}
+
+	for frame := 0; frame < req.Frames; frame++ {
+		start := time.Now()
+		job := ctrl.PostScreencap()
+		if job == nil {
+			result.Failures++
+			continue
+		}
+		if done := watchJob(job); !waitDone(done, benchScreencapTimeout) {
+			// 超时后剩余帧全部计为失败，避免阻塞在卡死的截图任务上
+			pending = done
+			result.Failures += req.Frames - frame
+			result.Error = "screencap timeout"
+			break
+		}
+		if !job.Success() {
+			result.Failures++
+			continue
+		}
+		result.latencies = append(result.latencies, time.Since(start))
+		if result.ImageWidth == 0 {
+			if img, err := ctrl.CacheImage(); err == nil && img != nil {
+				result.ImageWidth, result.ImageHeight = img.Bounds().Dx(), img.Bounds().Dy()
+			}
+		}
+	}
+	if result.Failures > 0 && result.Error == "" {
+		result.Error = "screencap job failed"
+	}
+	return finishMethodResult(result)
+}
+
+// 销毁控制器，done 不为 nil 时等任务结束后在后台销毁
+func destroyAfter(ctrl *maa.Controller, done <-chan struct{}) {
+	if done == nil {
+		ctrl.Destroy()
+		return
+	}
+	go func() {
+		<-done
+		ctrl.Destroy()
+	}()
+}
+
+func newBenchController(req ScreencapBenchRequest, method string) (*maa.Controller, error) {
+	if req.Type == "Win32" {
+		scMethod, err := parseWin32ScreencapMethod(method)
+		if err != nil {
+			return nil, err
+		}
+		return maa.NewWin32Controller(parseWin32Hwnd(req.Hwnd), scMethod, win32.InputSendMessage, win32.InputSendMessage)
+	}
+
+	scMethod, err := adb.ParseScreencapMethod(method)
+	if err != nil {
+		return nil, err
+	}
+	agentPath := ""
+	if cfg := mpeconfig.GetGlobal(); cfg != nil {
+		agentPath = cfg.ResolvedMaaFWAgentDir()
+	}
+	return maa.NewAdbController(req.AdbPath, req.Address, scMethod, adb.InputAdbShell, req.Config, agentPath)
+}
+
+func allWin32ScreencapMethodNames() []string {
+	return []string{"GDI", "FramePool", "DXGI_DesktopDup", "DXGI_DesktopDup_Window", "PrintWindow", "ScreenDC"}
+}
+
+// 根据原始耗时计算均值、P95 与失败率
+func finishMethodResult(result ScreencapMethodResult) ScreencapMethodResult {
+	if result.Frames > 0 {
+		result.FailureRate = float64(result.Failures) / float64(result.Frames)
+	}
+	latencies := append([]time.Duration(nil), result.latencies...)
+	if len(latencies) == 0 {
+		return result
+	}
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+
+	var total time.Duration
+	for _, latency := range latencies {
+		total += latency
+	}
+	result.MeanMS = durationMS(total / time.Duration(len(latencies)))
+	result.MinMS = durationMS(latencies[0])
+	result.MaxMS = durationMS(latencies[len(latencies)-1])
+	index := int(math.Ceil(0.95*float64(len(latencies)))) - 1
+	result.P95MS = durationMS(latencies[index])
+	return result
+}
+
+func durationMS(d time.Duration) float64 {
+	return math.Round(float64(d.Microseconds())/10) / 100
+}
+
+// 推荐没有失败且平均耗时最低的方法
+func recommendScreencapMethod(results []ScreencapMethodResult) string {
+	best := ""
+	bestMean := 0.0
+	for _, result := range results {
+		if !result.Connected || result.Failures > 0 || result.MeanMS <= 0 {
+			continue
+		}
+		if best == "" || result.MeanMS < bestMean {
+			best = result.Method
+			bestMean = result.MeanMS
+		}
+	}
+	return best
+}
+
+// 将测速推荐结果写入设备的偏好配置
+func SaveBenchRecommendation(store *ControllerProfileStore, report *ScreencapBenchReport) (ControllerProfile, error) {
+	if report.Recommended == "" {
+		return ControllerProfile{}, fmt.Errorf("没有可推荐的截图方法")
+	}
+	profile := ControllerProfile{
+		Key:              report.ProfileKey,
+		Type:             report.Type,
+		ScreencapMethods: []string{report.Recommended},
+		BenchmarkedAt:    report.StartedAt,
+	}
+	for _, result := range report.Results {
+		if result.Method == report.Recommended {
+			profile.MeanLatencyMS = result.MeanMS
+		}
+	}
+	return profile, store.Save(profile)
+}
@@ -0,0 +1,110 @@
+package mfw
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestScreencapBenchRequestNormalize(t *testing.T) {
+	tests := []struct {
+		name        string
+		req         ScreencapBenchRequest
+		wantMethods []string
+		wantFrames  int
+		wantErr     bool
+	}{
+		{
+			name:        "adb selected methods",
+			req:         ScreencapBenchRequest{Type: "adb", AdbPath: "adb", Address: "127.0.0.1:5555", Methods: []string{"encode", "MinicapStream"}},
+			wantMethods: []string{"Encode", "MinicapStream"},
+			wantFrames:  defaultBenchFrames,
+		},
+		{
+			name:        "win32 all methods",
+			req:         ScreencapBenchRequest{Type: "Win32", Hwnd: "0x1234", Frames: 1000},
+			wantMethods: allWin32ScreencapMethodNames(),
+			wantFrames:  maxBenchFrames,
+		},
+		{name: "missing address", req: ScreencapBenchRequest{Type: "ADB", AdbPath: "adb"}, wantErr: true},
+		{name: "unknown method", req: ScreencapBenchRequest{Type: "ADB", AdbPath: "adb", Address: "a", Methods: []string{"GDI"}}, wantErr: true},
+		{name: "unsupported type", req: ScreencapBenchRequest{Type: "PlayCover"}, wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := test.req
+			methods, err := req.normalize()
+			if (err != nil) != test.wantErr {
+				t.Fatalf("normalize() error = %v, wantErr = %v", err, test.wantErr)
+			}
+			if test.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(methods, test.wantMethods) {
+				t.Fatalf("methods = %v, want %v", methods, test.wantMethods)
+			}
+			if req.Frames != test.wantFrames {
+				t.Fatalf("frames = %d, want %d", req.Frames, test.wantFrames)
+			}
+		})
+	}
+}
+
+func TestFinishMethodResult(t *testing.T) {
+	result := ScreencapMethodResult{Method: "Encode", Connected: true, Frames: 5, Failures: 1}
+	for _, ms := range []int{40, 10, 30, 20} {
+		result.latencies = append(result.latencies, time.Duration(ms)*time.Millisecond)
+	}
+
+	result = finishMethodResult(result)
+	if result.MeanMS != 25 || result.P95MS != 40 || result.MinMS != 10 || result.MaxMS != 40 {
+		t.Fatalf("latency stats = mean %v p95 %v min %v max %v", result.MeanMS, result.P95MS, result.MinMS, result.MaxMS)
+	}
+	if result.FailureRate != 0.2 {
+		t.Fatalf("failure rate = %v, want 0.2", result.FailureRate)
+	}
+}
+
+func TestRecommendScreencapMethod(t *testing.T) {
+	results := []ScreencapMethodResult{
+		{Method: "Encode", Connected: true, MeanMS: 80},
+		{Method: "MinicapStream", Connected: true, MeanMS: 10, Failures: 1},
+		{Method: "RawByNetcat", Connected: true, MeanMS: 30},
+		{Method: "EmulatorExtras", Connected: false},
+	}
+	if got := recommendScreencapMethod(results); got != "RawByNetcat" {
+		t.Fatalf("recommendScreencapMethod() = %q, want RawByNetcat", got)
+	}
+	if got := recommendScreencapMethod(results[3:]); got != "" {
+		t.Fatalf("recommendScreencapMethod() = %q, want empty", got)
+	}
+}
+
+func TestSaveBenchRecommendation(t *testing.T) {
+	store := NewControllerProfileStore(filepath.Join(t.TempDir(), "profiles.json"))
+	report := &ScreencapBenchReport{
+		Type:        "ADB",
+		ProfileKey:  ControllerProfileKey("ADB", "127.0.0.1:5555"),
+		Recommended: "RawByNetcat",
+		StartedAt:   "2026-01-01T00:00:00Z",
+		Results:     []ScreencapMethodResult{{Method: "RawByNetcat", MeanMS: 30}},
+	}
+
+	if _, err := SaveBenchRecommendation(store, report); err != nil {
+		t.Fatalf("SaveBenchRecommendation() error = %v", err)
+	}
+	profile, ok := store.Get("adb:127.0.0.1:5555")
+	if !ok {
+		t.Fatal("saved profile not found")
+	}
+	if !reflect.DeepEqual(profile.ScreencapMethods, []string{"RawByNetcat"}) || profile.MeanLatencyMS != 30 {
+		t.Fatalf("profile = %+v", profile)
+	}
+
+	report.Recommended = ""
+	if _, err := SaveBenchRecommendation(store, report); err == nil {
+		t.Fatal("SaveBenchRecommendation() error = nil without recommendation")
+	}
+}
@@ -134,3 +134,25 @@ func boolOption(values map[string]interface{}, key string) (bool, bool, error) {
 	}
 	return result, true, nil
 }
+
+// ScaledSize 根据设备原始分辨率计算该模式下 MaaFramework 实际使用的截图尺寸
+func (r ScreenshotResolution) ScaledSize(rawWidth, rawHeight int32) (int32, int32) {
+	if rawWidth <= 0 || rawHeight <= 0 || r.UseRawSize {
+		return rawWidth, rawHeight
+	}
+
+	longSide, shortSide := max(rawWidth, rawHeight), min(rawWidth, rawHeight)
+	var scale float64
+	switch {
+	case r.TargetLongSide > 0:
+		scale = float64(r.TargetLongSide) / float64(longSide)
+	case r.TargetShortSide > 0:
+		scale = float64(r.TargetShortSide) / float64(shortSide)
+	default:
+		return rawWidth, rawHeight
+	}
+
+	width := int32(math.Round(float64(rawWidth) * scale))
+	height := int32(math.Round(float64(rawHeight) * scale))
+	return max(1, width), max(1, height)
+}
@@ -18,17 +18,22 @@ type Service struct {
 	controllerManager *ControllerManager
 	resourceManager   *ResourceManager
 	taskManager       *TaskManager
+	healthMonitor     *HealthMonitor
+	streamManager     *StreamManager
 	initialized       bool
 	mu                sync.RWMutex
 }
 
 // 创建MFW服务
 func NewService() *Service {
+	controllerManager := NewControllerManager()
 	return &Service{
 		deviceManager:     NewDeviceManager(),
-		controllerManager: NewControllerManager(),
+		controllerManager: controllerManager,
 		resourceManager:   NewResourceManager(),
 		taskManager:       NewTaskManager(),
+		healthMonitor:     NewHealthMonitor(controllerManager),
+		streamManager:     NewStreamManager(controllerManager),
 		initialized:       false,
 	}
 }
@@ -140,6 +145,9 @@ func (s *Service) Initialize() (err error) {
 
 	s.initialized = true
 
+	// 启动控制器健康监测
+	s.healthMonitor.Start(cfg.MaaFW.ControllerHealth)
+
 	logger.Info("MFW", "MaaFramework 初始化成功")
 	return nil
 }
@@ -155,6 +163,10 @@ func (s *Service) Shutdown() error {
 
 	logger.Debug("MFW", "关闭 MaaFramework")
 
+	// 停止控制器健康监测与实时推流
+	s.healthMonitor.Stop()
+	s.streamManager.StopAll(StreamStopReasonServiceStopped)
+
 	// 停止所有任务
 	s.taskManager.StopAll()
 
@@ -196,6 +208,27 @@ func (s *Service) TaskManager() *TaskManager {
 	return s.taskManager
 }
 
+// 按新配置重启控制器健康监测，服务未初始化时在初始化时生效
+func (s *Service) ApplyHealthConfig(cfg config.ControllerHealthConfig) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.initialized {
+		return
+	}
+	s.healthMonitor.Start(cfg)
+}
+
+// 获取控制器健康监测器
+func (s *Service) HealthMonitor() *HealthMonitor {
+	return s.healthMonitor
+}
+
+// 获取实时画面推流管理器
+func (s *Service) StreamManager() *StreamManager {
+	return s.streamManager
+}
+
 // 检查是否已初始化
 func (s *Service) IsInitialized() bool {
 	s.mu.RLock()
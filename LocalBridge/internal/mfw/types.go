@@ -19,11 +19,12 @@ type AdbDeviceInfo struct {
 
 // Win32窗体信息
 type Win32WindowInfo struct {
-	Hwnd             string   `json:"hwnd"`
-	ClassName        string   `json:"class_name"`
-	WindowName       string   `json:"window_name"`
-	ScreencapMethods []string `json:"screencap_methods"`
-	InputMethods     []string `json:"input_methods"`
+	Hwnd                     string   `json:"hwnd"`
+	ClassName                string   `json:"class_name"`
+	WindowName               string   `json:"window_name"`
+	ScreencapMethods         []string `json:"screencap_methods"`
+	InputMethods             []string `json:"input_methods"`
+	PreferredScreencapMethod string   `json:"preferred_screencap_method,omitempty"` // 测速保存的推荐截图方法
 }
 
 // PlayCover设备信息 (macOS上运行iOS应用)
@@ -58,6 +59,7 @@ type ControllerInfo struct {
 	AgentPath    string    `json:"agent_path,omitempty"`
 	Warning      string    `json:"warning,omitempty"`
 	screenshotMu sync.Mutex
+	healthJob    <-chan struct{} // 健康监测投递的最近一个任务，结束时关闭
 }
 
 // 资源实例信息
@@ -169,12 +169,29 @@ func GetConfigFile() string {
 	return filepath.Join(configDir, "config.json")
 }
 
+// GetTokenFile 获取访问令牌文件路径
+func GetTokenFile() string {
+	return filepath.Join(GetConfigDir(), "tokens.json")
+}
+
 // GetLogDir 获取日志目录
 func GetLogDir() string {
 	Init()
 	return filepath.Join(dataDir, "logs")
 }
 
+// GetAIAuditDir 获取 AI 代理审计日志目录
+func GetAIAuditDir() string {
+	Init()
+	return filepath.Join(dataDir, "ai_audit")
+}
+
+// GetControllerProfileFile 获取控制器偏好配置文件路径
+func GetControllerProfileFile() string {
+	Init()
+	return filepath.Join(dataDir, "controller_profiles.json")
+}
+
 // EnsureAllDirs 确保所有必要目录存在
 func EnsureAllDirs() error {
 	dirs := []string{
@@ -0,0 +1,466 @@
+package ai
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/logger"
+)
+
+const (
+	auditDateLayout     = "2006-01-02"
+	maxAuditErrorLength = 256
+	defaultStatsDays    = 7
+	maxStatsDays        = 90
+	unknownModelName    = "unknown"
+)
+
+// 审计日志中需要脱敏的查询参数
+var sensitiveQueryKeys = map[string]bool{
+	"key":           true,
+	"api_key":       true,
+	"apikey":        true,
+	"api-key":       true,
+	"token":         true,
+	"access_token":  true,
+	"secret":        true,
+	"signature":     true,
+	"sig":           true,
+	"client_secret": true,
+}
+
+// 错误信息中可能出现的密钥片段
+var secretPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)(bearer\s+)[A-Za-z0-9._~+/=\-]+`),
+	regexp.MustCompile(`sk-[A-Za-z0-9_\-]{8,}`),
+	regexp.MustCompile(`AIza[0-9A-Za-z_\-]{20,}`),
+	regexp.MustCompile(`(?i)((?:api[_-]?key|token|secret)["']?\s*[:=]\s*["']?)[^\s"'&,}]+`),
+}
+
+// tokenUsage 从响应中解析出的 token 用量
+type tokenUsage struct {
+	PromptTokens     int64 `json:"prompt_tokens"`
+	CompletionTokens int64 `json:"completion_tokens"`
+	TotalTokens      int64 `json:"total_tokens"`
+}
+
+func (u tokenUsage) isZero() bool {
+	return u.PromptTokens == 0 && u.CompletionTokens == 0 && u.TotalTokens == 0
+}
+
+// merge 合并流式分片中的用量，各字段取最大值（部分厂商按累计值推送）
+func (u tokenUsage) merge(other tokenUsage) tokenUsage {
+	if other.PromptTokens > u.PromptTokens {
+		u.PromptTokens = other.PromptTokens
+	}
+	if other.CompletionTokens > u.CompletionTokens {
+		u.CompletionTokens = other.CompletionTokens
+	}
+	if other.TotalTokens > u.TotalTokens {
+		u.TotalTokens = other.TotalTokens
+	}
+	return u
+}
+
+func (u tokenUsage) normalized() tokenUsage {
+	if u.TotalTokens == 0 {
+		u.TotalTokens = u.PromptTokens + u.CompletionTokens
+	}
+	return u
+}
+
+// auditRecord 单条 AI 代理审计记录，不包含请求头与请求/响应正文
+type auditRecord struct {
+	Time          string `json:"time"`
+	RequestID     string `json:"request_id"`
+	Method        string `json:"method"`
+	Host          string `json:"host"`
+	URL           string `json:"url"`
+	Model         string `json:"model"`
+	Stream        bool   `json:"stream"`
+	Cached        bool   `json:"cached"`
+	Status        int    `json:"status"`
+	Error         string `json:"error,omitempty"`
+	LatencyMS     int64  `json:"latency_ms"`
+	RequestBytes  int64  `json:"request_bytes"`
+	ResponseBytes int64  `json:"response_bytes"`
+	tokenUsage
+}
+
+// auditLog 按天写入 JSON Lines 审计文件，每天首次写入时删除超过保留天数的文件
+type auditLog struct {
+	dir           string
+	enabled       bool
+	retentionDays int
+	prunedDay     string
+	mu            sync.Mutex
+}
+
+func newAuditLog(dir string, enabled bool, retentionDays int) *auditLog {
+	return &auditLog{dir: dir, enabled: enabled, retentionDays: retentionDays}
+}
+
+func (a *auditLog) configure(enabled bool, retentionDays int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.enabled = enabled
+	if a.retentionDays != retentionDays {
+		a.retentionDays = retentionDays
+		a.prunedDay = ""
+	}
+}
+
+func (a *auditLog) isEnabled() bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.enabled
+}
+
+func (a *auditLog) filePath(day string) string {
+	return filepath.Join(a.dir, fmt.Sprintf("audit-%s.jsonl", day))
+}
+
+// write 追加一条审计记录，失败只记录日志不影响代理流程
+func (a *auditLog) write(record auditRecord) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if !a.enabled || a.dir == "" {
+		return
+	}
+
+	recordTime, err := time.Parse(time.RFC3339, record.Time)
+	if err != nil {
+		recordTime = time.Now()
+		record.Time = recordTime.Format(time.RFC3339)
+	}
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		logger.Warn("AI", "序列化审计记录失败: %v", err)
+		return
+	}
+
+	if err := os.MkdirAll(a.dir, 0755); err != nil {
+		logger.Warn("AI", "创建审计目录失败: %v", err)
+		return
+	}
+
+	day := recordTime.Format(auditDateLayout)
+	if day != a.prunedDay {
+		a.pruneLocked(recordTime)
+		a.prunedDay = day
+	}
+
+	file, err := os.OpenFile(a.filePath(day), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		logger.Warn("AI", "打开审计日志失败: %v", err)
+		return
+	}
+	defer file.Close()
+
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		logger.Warn("AI", "写入审计日志失败: %v", err)
+	}
+}
+
+// pruneLocked 删除早于保留天数的审计文件，保留天数包含 now 当天
+func (a *auditLog) pruneLocked(now time.Time) {
+	if a.retentionDays <= 0 {
+		return
+	}
+	entries, err := os.ReadDir(a.dir)
+	if err != nil {
+		return
+	}
+	cutoff := now.AddDate(0, 0, -(a.retentionDays - 1)).Format(auditDateLayout)
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, "audit-") || !strings.HasSuffix(name, ".jsonl") {
+			continue
+		}
+		day := strings.TrimSuffix(strings.TrimPrefix(name, "audit-"), ".jsonl")
+		if _, err := time.Parse(auditDateLayout, day); err != nil || day >= cutoff {
+			continue
+		}
+		if err := os.Remove(filepath.Join(a.dir, name)); err != nil {
+			logger.Warn("AI", "删除过期审计日志失败: %v", err)
+		}
+	}
+}
+
+// readDay 读取某一天的全部审计记录，损坏的行会被跳过
+func (a *auditLog) readDay(day string) []auditRecord {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.dir == "" {
+		return nil
+	}
+
+	file, err := os.Open(a.filePath(day))
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	var records []auditRecord
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var record auditRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			continue
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+// usageStat 聚合后的用量统计
+type usageStat struct {
+	Requests         int64 `json:"requests"`
+	Errors           int64 `json:"errors"`
+	CacheHits        int64 `json:"cache_hits"`
+	PromptTokens     int64 `json:"prompt_tokens"`
+	CompletionTokens int64 `json:"completion_tokens"`
+	TotalTokens      int64 `json:"total_tokens"`
+	TotalLatencyMS   int64 `json:"total_latency_ms"`
+	AvgLatencyMS     int64 `json:"avg_latency_ms"`
+}
+
+func (s *usageStat) add(record auditRecord) {
+	s.Requests++
+	if record.Error != "" || record.Status < 200 || record.Status >= 300 {
+		s.Errors++
+	}
+	if record.Cached {
+		s.CacheHits++
+	}
+	s.PromptTokens += record.PromptTokens
+	s.CompletionTokens += record.CompletionTokens
+	s.TotalTokens += record.TotalTokens
+	s.TotalLatencyMS += record.LatencyMS
+	if s.Requests > 0 {
+		s.AvgLatencyMS = s.TotalLatencyMS / s.Requests
+	}
+}
+
+type modelUsage struct {
+	Model string `json:"model"`
+	usageStat
+}
+
+type dayUsage struct {
+	Date   string       `json:"date"`
+	Models []modelUsage `json:"models"`
+	usageStat
+}
+
+type usageStats struct {
+	AuditEnabled bool         `json:"audit_enabled"`
+	Days         []dayUsage   `json:"days"`
+	Models       []modelUsage `json:"models"`
+	Total        usageStat    `json:"total"`
+}
+
+// buildUsageStats 汇总截至 now 的最近 days 天用量，按天与按模型分组
+func (a *auditLog) buildUsageStats(now time.Time, days int) usageStats {
+	if days <= 0 {
+		days = defaultStatsDays
+	}
+	if days > maxStatsDays {
+		days = maxStatsDays
+	}
+
+	stats := usageStats{
+		AuditEnabled: a.isEnabled(),
+		Days:         make([]dayUsage, 0, days),
+		Models:       []modelUsage{},
+	}
+	totalByModel := make(map[string]*usageStat)
+
+	for offset := days - 1; offset >= 0; offset-- {
+		day := now.AddDate(0, 0, -offset).Format(auditDateLayout)
+		entry := dayUsage{Date: day, Models: []modelUsage{}}
+		byModel := make(map[string]*usageStat)
+
+		for _, record := range a.readDay(day) {
+			model := record.Model
+			if model == "" {
+				model = unknownModelName
+			}
+			entry.usageStat.add(record)
+			stats.Total.add(record)
+			if byModel[model] == nil {
+				byModel[model] = &usageStat{}
+			}
+			byModel[model].add(record)
+			if totalByModel[model] == nil {
+				totalByModel[model] = &usageStat{}
+			}
+			totalByModel[model].add(record)
+		}
+
+		entry.Models = sortedModelUsage(byModel)
+		stats.Days = append(stats.Days, entry)
+	}
+
+	stats.Models = sortedModelUsage(totalByModel)
+	return stats
+}
+
+func sortedModelUsage(byModel map[string]*usageStat) []modelUsage {
+	result := make([]modelUsage, 0, len(byModel))
+	for model, stat := range byModel {
+		result = append(result, modelUsage{Model: model, usageStat: *stat})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Requests != result[j].Requests {
+			return result[i].Requests > result[j].Requests
+		}
+		return result[i].Model < result[j].Model
+	})
+	return result
+}
+
+// redactURL 去除用户信息并脱敏敏感查询参数
+func redactURL(rawURL string) string {
+	parsed, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return ""
+	}
+	parsed.User = nil
+	parsed.Fragment = ""
+
+	query := parsed.Query()
+	for key := range query {
+		if sensitiveQueryKeys[strings.ToLower(key)] {
+			query.Set(key, "REDACTED")
+		}
+	}
+	parsed.RawQuery = query.Encode()
+	return parsed.String()
+}
+
+// redactSecrets 脱敏错误信息中的密钥片段并截断
+func redactSecrets(text string) string {
+	for _, pattern := range secretPatterns {
+		if pattern.NumSubexp() > 0 {
+			text = pattern.ReplaceAllString(text, "${1}REDACTED")
+		} else {
+			text = pattern.ReplaceAllString(text, "REDACTED")
+		}
+	}
+	if len(text) > maxAuditErrorLength {
+		text = text[:maxAuditErrorLength] + "..."
+	}
+	return text
+}
+
+func urlHost(rawURL string) string {
+	parsed, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(parsed.Hostname())
+}
+
+// extractModel 依次从请求体、URL 路径（Gemini 风格）中获取模型名
+func extractModel(rawURL, body string) string {
+	var payload map[string]interface{}
+	if json.Unmarshal([]byte(body), &payload) == nil {
+		if model, ok := payload["model"].(string); ok && model != "" {
+			return model
+		}
+	}
+
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	segments := strings.Split(parsed.Path, "/")
+	for index, segment := range segments {
+		if segment == "models" && index+1 < len(segments) {
+			model := segments[index+1]
+			if colon := strings.Index(model, ":"); colon >= 0 {
+				model = model[:colon]
+			}
+			return model
+		}
+	}
+	return ""
+}
+
+// parseResponseUsage 解析完整响应体中的模型名与 token 用量
+func parseResponseUsage(body []byte) (string, tokenUsage) {
+	var payload map[string]interface{}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return "", tokenUsage{}
+	}
+	return usageFromPayload(payload)
+}
+
+// parseStreamUsage 解析单行 SSE 数据中的用量信息
+func parseStreamUsage(line string) (string, tokenUsage) {
+	line = strings.TrimSpace(line)
+	if !strings.HasPrefix(line, "data:") {
+		return "", tokenUsage{}
+	}
+	data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+	if data == "" || data == "[DONE]" {
+		return "", tokenUsage{}
+	}
+	return parseResponseUsage([]byte(data))
+}
+
+// usageFromPayload 兼容 OpenAI / Anthropic / Gemini 的用量字段
+func usageFromPayload(payload map[string]interface{}) (string, tokenUsage) {
+	model, _ := payload["model"].(string)
+	if model == "" {
+		model, _ = payload["modelVersion"].(string)
+	}
+
+	var usage tokenUsage
+	if raw, ok := payload["usage"].(map[string]interface{}); ok {
+		usage = usage.merge(tokenUsage{
+			PromptTokens:     firstNumber(raw, "prompt_tokens", "input_tokens"),
+			CompletionTokens: firstNumber(raw, "completion_tokens", "output_tokens"),
+			TotalTokens:      firstNumber(raw, "total_tokens"),
+		})
+	}
+	if raw, ok := payload["usageMetadata"].(map[string]interface{}); ok {
+		usage = usage.merge(tokenUsage{
+			PromptTokens:     firstNumber(raw, "promptTokenCount"),
+			CompletionTokens: firstNumber(raw, "candidatesTokenCount"),
+			TotalTokens:      firstNumber(raw, "totalTokenCount"),
+		})
+	}
+	// Anthropic 流式 message_start 事件将用量放在 message 内
+	if message, ok := payload["message"].(map[string]interface{}); ok {
+		nestedModel, nestedUsage := usageFromPayload(message)
+		if model == "" {
+			model = nestedModel
+		}
+		usage = usage.merge(nestedUsage)
+	}
+	return model, usage
+}
+
+func firstNumber(values map[string]interface{}, keys ...string) int64 {
+	for _, key := range keys {
+		if number, ok := values[key].(float64); ok {
+			return int64(number)
+		}
+	}
+	return 0
+}
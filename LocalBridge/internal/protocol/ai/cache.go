@@ -0,0 +1,145 @@
+package ai
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// cachedResponse 缓存的非流式代理响应
+type cachedResponse struct {
+	status  int
+	headers map[string]string
+	body    string
+	model   string
+	usage   tokenUsage
+}
+
+type cacheEntry struct {
+	response cachedResponse
+	storedAt time.Time
+	order    uint64
+}
+
+// responseCache 以请求内容哈希为键的响应缓存
+type responseCache struct {
+	enabled    bool
+	ttl        time.Duration
+	maxEntries int
+	entries    map[string]*cacheEntry
+	nextOrder  uint64
+	now        func() time.Time
+	mu         sync.Mutex
+}
+
+func newResponseCache(enabled bool, ttl time.Duration, maxEntries int) *responseCache {
+	c := &responseCache{
+		entries: make(map[string]*cacheEntry),
+		now:     time.Now,
+	}
+	c.configure(enabled, ttl, maxEntries)
+	return c
+}
+
+// configure 更新缓存配置，关闭时清空已有条目
+func (c *responseCache) configure(enabled bool, ttl time.Duration, maxEntries int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.enabled = enabled && ttl > 0 && maxEntries > 0
+	c.ttl = ttl
+	c.maxEntries = maxEntries
+	if !c.enabled {
+		c.entries = make(map[string]*cacheEntry)
+		return
+	}
+	c.evictLocked()
+}
+
+func (c *responseCache) isEnabled() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.enabled
+}
+
+// cacheKey 计算请求的内容地址，请求头参与哈希以隔离不同凭据
+func cacheKey(request proxyRequest) string {
+	headerKeys := make([]string, 0, len(request.headers))
+	for key := range request.headers {
+		headerKeys = append(headerKeys, strings.ToLower(key))
+	}
+	sort.Strings(headerKeys)
+
+	lowerHeaders := make(map[string]string, len(request.headers))
+	for key, value := range request.headers {
+		lowerHeaders[strings.ToLower(key)] = value
+	}
+
+	hash := sha256.New()
+	hash.Write([]byte(strings.ToUpper(request.method)))
+	hash.Write([]byte{0})
+	hash.Write([]byte(request.url))
+	hash.Write([]byte{0})
+	for _, key := range headerKeys {
+		hash.Write([]byte(key))
+		hash.Write([]byte{':'})
+		hash.Write([]byte(lowerHeaders[key]))
+		hash.Write([]byte{0})
+	}
+	hash.Write([]byte(request.body))
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func (c *responseCache) get(key string) (cachedResponse, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.enabled {
+		return cachedResponse{}, false
+	}
+	entry, ok := c.entries[key]
+	if !ok {
+		return cachedResponse{}, false
+	}
+	if c.now().Sub(entry.storedAt) > c.ttl {
+		delete(c.entries, key)
+		return cachedResponse{}, false
+	}
+	return entry.response, true
+}
+
+func (c *responseCache) put(key string, response cachedResponse) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.enabled {
+		return
+	}
+	c.nextOrder++
+	c.entries[key] = &cacheEntry{response: response, storedAt: c.now(), order: c.nextOrder}
+	c.evictLocked()
+}
+
+// evictLocked 清理过期条目，超出容量时淘汰最早写入的条目
+func (c *responseCache) evictLocked() {
+	now := c.now()
+	for key, entry := range c.entries {
+		if now.Sub(entry.storedAt) > c.ttl {
+			delete(c.entries, key)
+		}
+	}
+	for len(c.entries) > c.maxEntries {
+		var oldestKey string
+		var oldest uint64
+		for key, entry := range c.entries {
+			if oldestKey == "" || entry.order < oldest {
+				oldestKey = key
+				oldest = entry.order
+			}
+		}
+		delete(c.entries, oldestKey)
+	}
+}
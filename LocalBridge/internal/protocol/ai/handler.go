@@ -13,7 +13,9 @@ import (
 	"sync"
 	"time"
 
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/config"
 	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/logger"
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/paths"
 	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/server"
 	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/pkg/models"
 )
@@ -38,17 +40,29 @@ type proxyRequest struct {
 	body    string
 	headers map[string]string
 	timeout time.Duration
+	cache   bool
 }
 
 // AIHandler 只负责 AI HTTP 代理的传输，不包含 Provider 或业务逻辑。
+// 审计、限流与缓存都只基于传输层信息，不解析业务语义。
 type AIHandler struct {
 	httpClient      *http.Client
 	activeRequests  map[string]*activeRequest
 	activeRequestMu sync.Mutex
+	limiter         *hostLimiter
+	cache           *responseCache
+	audit           *auditLog
 }
 
 // NewAIHandler 创建 AI 代理协议处理器。
 func NewAIHandler() *AIHandler {
+	var aiConfig config.AIConfig
+	auditDir := ""
+	if cfg := config.GetGlobal(); cfg != nil {
+		aiConfig = cfg.AI
+		auditDir = paths.GetAIAuditDir()
+	}
+
 	httpClient := &http.Client{}
 	httpClient.CheckRedirect = func(request *http.Request, _ []*http.Request) error {
 		if err := validateProxyURL(request.URL.String()); err != nil {
@@ -60,9 +74,24 @@ func NewAIHandler() *AIHandler {
 	return &AIHandler{
 		httpClient:     httpClient,
 		activeRequests: make(map[string]*activeRequest),
+		limiter:        newHostLimiter(aiConfig.DefaultLimit, aiConfig.HostLimits),
+		cache:          newResponseCache(aiConfig.CacheEnabled, cacheTTL(aiConfig), aiConfig.CacheMaxEntries),
+		audit:          newAuditLog(auditDir, aiConfig.AuditEnabled, aiConfig.AuditRetentionDays),
 	}
 }
 
+// ApplyConfig 应用新的审计、限流与缓存配置。
+func (h *AIHandler) ApplyConfig(aiConfig config.AIConfig) {
+	h.limiter.configure(aiConfig.DefaultLimit, aiConfig.HostLimits)
+	h.cache.configure(aiConfig.CacheEnabled, cacheTTL(aiConfig), aiConfig.CacheMaxEntries)
+	h.audit.configure(aiConfig.AuditEnabled, aiConfig.AuditRetentionDays)
+	logger.Debug("AI", "AI 代理配置已更新 (审计: %v, 缓存: %v)", aiConfig.AuditEnabled, aiConfig.CacheEnabled)
+}
+
+func cacheTTL(aiConfig config.AIConfig) time.Duration {
+	return time.Duration(aiConfig.CacheTTLSeconds) * time.Second
+}
+
 // GetRoutePrefix 返回处理的路由前缀。
 func (h *AIHandler) GetRoutePrefix() []string {
 	return []string{"/etl/ai/"}
@@ -79,6 +108,8 @@ func (h *AIHandler) Handle(msg models.Message, conn *server.Connection) *models.
 		go h.handleStreamProxy(conn, msg)
 	case "/etl/ai/proxy_cancel":
 		h.handleCancel(msg)
+	case "/etl/ai/usage_stats":
+		h.handleUsageStats(conn, msg)
 	default:
 		logger.Warn("AI", "未知的 AI 路由: %s", msg.Path)
 	}
@@ -97,7 +128,8 @@ func parseProxyRequest(msg models.Message) (proxyRequest, error) {
 	method, _ := dataMap["method"].(string)
 	body, _ := dataMap["body"].(string)
 	timeoutMS, _ := dataMap["timeout_ms"].(float64)
-	request := proxyRequest{id: requestID, url: requestURL, method: method, body: body}
+	useCache, _ := dataMap["cache"].(bool)
+	request := proxyRequest{id: requestID, url: requestURL, method: method, body: body, cache: useCache}
 	if requestID == "" {
 		return request, fmt.Errorf("request_id 不能为空")
 	}
@@ -231,30 +263,71 @@ func (h *AIHandler) handleProxy(conn *server.Connection, msg models.Message) {
 		h.finishRequest(request.id, active)
 	}()
 
+	record, startedAt := newAuditRecord(request, false), time.Now()
+	defer func() { h.writeAudit(record, startedAt) }()
+	fail := func(errMsg string) {
+		record.Error = errMsg
+		h.sendError(conn, request.id, errMsg)
+	}
+
+	// 命中缓存时不占用限流配额
+	var key string
+	useCache := request.cache && h.cache.isEnabled()
+	if useCache {
+		key = cacheKey(request)
+		if cached, ok := h.cache.get(key); ok {
+			logger.Debug("AI", "代理请求命中缓存 (ID: %s)", request.id)
+			record.Cached = true
+			record.Status = cached.status
+			record.ResponseBytes = int64(len(cached.body))
+			record.tokenUsage = cached.usage
+			if record.Model == "" {
+				record.Model = cached.model
+			}
+			h.sendProxyResponse(conn, request.id, cached.status, cached.headers, cached.body, true)
+			return
+		}
+	}
+
+	release, err := h.limiter.acquire(ctx, record.Host)
+	if err != nil {
+		if ctx.Err() == context.Canceled {
+			record.Error = "请求已取消"
+			return
+		}
+		fail(err.Error())
+		return
+	}
+	defer release()
+
 	logger.Debug("AI", "代理请求: %s %s (ID: %s)", request.method, request.url, request.id)
 	resp, err := h.doRequest(ctx, request)
 	if err != nil {
 		if ctx.Err() == context.Canceled {
+			record.Error = "请求已取消"
 			return
 		}
 		if ctx.Err() == context.DeadlineExceeded {
-			h.sendError(conn, request.id, "请求超时")
+			fail("请求超时")
 			return
 		}
-		h.sendError(conn, request.id, "请求失败: "+err.Error())
+		fail("请求失败: " + err.Error())
 		return
 	}
 	defer resp.Body.Close()
 
+	record.Status = resp.StatusCode
+	succeeded := resp.StatusCode >= http.StatusOK && resp.StatusCode < http.StatusMultipleChoices
 	responseBodyLimit := maxResponseBodySize
-	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+	if !succeeded {
 		responseBodyLimit = maxErrorBodySize
 	}
 	respBody, err := readLimitedBody(resp.Body, responseBodyLimit)
 	if err != nil {
-		h.sendError(conn, request.id, "读取响应失败: "+err.Error())
+		fail("读取响应失败: " + err.Error())
 		return
 	}
+	record.ResponseBytes = int64(len(respBody))
 
 	respHeaders := make(map[string]string)
 	for key, values := range resp.Header {
@@ -263,17 +336,51 @@ func (h *AIHandler) handleProxy(conn *server.Connection, msg models.Message) {
 		}
 	}
 
+	if succeeded {
+		model, usage := parseResponseUsage(respBody)
+		if record.Model == "" {
+			record.Model = model
+		}
+		record.tokenUsage = usage.normalized()
+		if useCache {
+			h.cache.put(key, cachedResponse{
+				status:  resp.StatusCode,
+				headers: respHeaders,
+				body:    string(respBody),
+				model:   model,
+				usage:   record.tokenUsage,
+			})
+		}
+	} else {
+		record.Error = fmt.Sprintf("HTTP %s", resp.Status)
+	}
+
 	logger.Debug("AI", "代理响应: %d (ID: %s, 大小: %d bytes)", resp.StatusCode, request.id, len(respBody))
+	h.sendProxyResponse(conn, request.id, resp.StatusCode, respHeaders, string(respBody), false)
+}
+
+func (h *AIHandler) sendProxyResponse(
+	conn *server.Connection,
+	requestID string,
+	status int,
+	headers map[string]string,
+	body string,
+	cached bool,
+) {
+	data := map[string]interface{}{
+		"request_id": requestID,
+		"status":     status,
+		"headers":    headers,
+		"body":       body,
+	}
+	if cached {
+		data["cached"] = true
+	}
 	if err := conn.Send(models.Message{
 		Path: "/lte/ai/proxy_response",
-		Data: map[string]interface{}{
-			"request_id": request.id,
-			"status":     resp.StatusCode,
-			"headers":    respHeaders,
-			"body":       string(respBody),
-		},
+		Data: data,
 	}); err != nil {
-		logger.Warn("AI", "发送代理响应失败 (ID: %s): %v", request.id, err)
+		logger.Warn("AI", "发送代理响应失败 (ID: %s): %v", requestID, err)
 	}
 }
 
@@ -294,38 +401,69 @@ func (h *AIHandler) handleStreamProxy(conn *server.Connection, msg models.Messag
 		h.finishRequest(request.id, active)
 	}()
 
+	record, startedAt := newAuditRecord(request, true), time.Now()
+	defer func() { h.writeAudit(record, startedAt) }()
+	fail := func(errMsg string) {
+		record.Error = errMsg
+		h.sendStreamError(conn, request.id, errMsg)
+	}
+
+	release, err := h.limiter.acquire(ctx, record.Host)
+	if err != nil {
+		if ctx.Err() == context.Canceled {
+			record.Error = "请求已取消"
+			return
+		}
+		fail(err.Error())
+		return
+	}
+	defer release()
+
 	logger.Debug("AI", "流式代理请求: %s %s (ID: %s)", request.method, request.url, request.id)
 	resp, err := h.doRequest(ctx, request)
 	if err != nil {
 		if ctx.Err() == context.Canceled {
+			record.Error = "请求已取消"
 			return
 		}
 		if ctx.Err() == context.DeadlineExceeded {
-			h.sendStreamError(conn, request.id, "请求超时")
+			fail("请求超时")
 			return
 		}
-		h.sendStreamError(conn, request.id, "请求失败: "+err.Error())
+		fail("请求失败: " + err.Error())
 		return
 	}
 	defer resp.Body.Close()
 
+	record.Status = resp.StatusCode
 	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
 		errBody, readErr := readLimitedBody(resp.Body, maxErrorBodySize)
 		if readErr != nil {
-			h.sendStreamError(conn, request.id, fmt.Sprintf("HTTP %s: %v", resp.Status, readErr))
+			fail(fmt.Sprintf("HTTP %s: %v", resp.Status, readErr))
 			return
 		}
-		h.sendStreamError(conn, request.id, fmt.Sprintf("HTTP %s: %s", resp.Status, string(errBody)))
+		record.ResponseBytes = int64(len(errBody))
+		fail(fmt.Sprintf("HTTP %s: %s", resp.Status, string(errBody)))
 		return
 	}
 
 	var sendErr error
 	var streamSize int64
+	var usage tokenUsage
+	defer func() {
+		record.ResponseBytes = streamSize
+		record.tokenUsage = usage.normalized()
+	}()
 	err = scanStream(ctx, resp.Body, func(line string) error {
 		streamSize += int64(len(line))
 		if streamSize > maxResponseBodySize {
 			return fmt.Errorf("流式响应超过限制（最大 %d bytes）", maxResponseBodySize)
 		}
+		model, lineUsage := parseStreamUsage(line)
+		if record.Model == "" {
+			record.Model = model
+		}
+		usage = usage.merge(lineUsage)
 		sendErr = conn.Send(models.Message{
 			Path: "/lte/ai/proxy_stream",
 			Data: map[string]interface{}{
@@ -338,18 +476,20 @@ func (h *AIHandler) handleStreamProxy(conn *server.Connection, msg models.Messag
 	})
 	if err != nil {
 		if ctx.Err() == context.Canceled {
+			record.Error = "请求已取消"
 			return
 		}
 		if ctx.Err() == context.DeadlineExceeded {
-			h.sendStreamError(conn, request.id, "流式请求超时")
+			fail("流式请求超时")
 			return
 		}
 		if sendErr != nil {
+			record.Error = "发送流式代理数据失败"
 			logger.Warn("AI", "发送流式代理数据失败 (ID: %s): %v", request.id, sendErr)
 			return
 		}
 		logger.Warn("AI", "流式读取错误 (ID: %s): %v", request.id, err)
-		h.sendStreamError(conn, request.id, "读取流式响应失败: "+err.Error())
+		fail("读取流式响应失败: " + err.Error())
 		return
 	}
 
@@ -430,6 +570,46 @@ func (h *AIHandler) handleCancel(msg models.Message) {
 	}
 }
 
+// newAuditRecord 根据代理请求创建审计记录，仅保留脱敏后的 URL 与模型名
+func newAuditRecord(request proxyRequest, stream bool) auditRecord {
+	return auditRecord{
+		Time:         time.Now().Format(time.RFC3339),
+		RequestID:    request.id,
+		Method:       strings.ToUpper(request.method),
+		Host:         urlHost(request.url),
+		URL:          redactURL(request.url),
+		Model:        extractModel(request.url, request.body),
+		Stream:       stream,
+		RequestBytes: int64(len(request.body)),
+	}
+}
+
+func (h *AIHandler) writeAudit(record auditRecord, startedAt time.Time) {
+	record.LatencyMS = time.Since(startedAt).Milliseconds()
+	if record.Error != "" {
+		record.Error = redactSecrets(record.Error)
+	}
+	h.audit.write(record)
+}
+
+// handleUsageStats 返回最近若干天按日期与模型汇总的用量统计
+func (h *AIHandler) handleUsageStats(conn *server.Connection, msg models.Message) {
+	days := defaultStatsDays
+	if dataMap, ok := msg.Data.(map[string]interface{}); ok {
+		if value, ok := dataMap["days"].(float64); ok && value > 0 {
+			days = int(value)
+		}
+	}
+
+	stats := h.audit.buildUsageStats(time.Now(), days)
+	if err := conn.Send(models.Message{
+		Path: "/lte/ai/usage_stats",
+		Data: stats,
+	}); err != nil {
+		logger.Warn("AI", "发送用量统计失败: %v", err)
+	}
+}
+
 func (h *AIHandler) sendError(conn *server.Connection, requestID, errMsg string) {
 	logger.Error("AI", "代理错误 (ID: %s): %s", requestID, errMsg)
 	if err := conn.Send(models.Message{
@@ -0,0 +1,148 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/config"
+)
+
+const rateLimitWindow = time.Minute
+
+// hostLimitState 单个主机的并发与请求窗口状态
+type hostLimitState struct {
+	active  int
+	starts  []time.Time
+	changed chan struct{}
+}
+
+// hostLimiter 按主机限制每分钟请求数与并发数
+type hostLimiter struct {
+	defaults config.AIHostLimit
+	hosts    map[string]config.AIHostLimit
+	states   map[string]*hostLimitState
+	now      func() time.Time
+	mu       sync.Mutex
+}
+
+func newHostLimiter(defaults config.AIHostLimit, hosts map[string]config.AIHostLimit) *hostLimiter {
+	l := &hostLimiter{
+		states: make(map[string]*hostLimitState),
+		now:    time.Now,
+	}
+	l.configure(defaults, hosts)
+	return l
+}
+
+// configure 更新限流配置，已在进行中的请求不受影响
+func (l *hostLimiter) configure(defaults config.AIHostLimit, hosts map[string]config.AIHostLimit) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.defaults = defaults
+	l.hosts = make(map[string]config.AIHostLimit, len(hosts))
+	for host, limit := range hosts {
+		l.hosts[strings.ToLower(strings.TrimSpace(host))] = limit
+	}
+	for _, state := range l.states {
+		state.notify()
+	}
+}
+
+func (l *hostLimiter) limitFor(host string) config.AIHostLimit {
+	if limit, ok := l.hosts[host]; ok {
+		return limit
+	}
+	return l.defaults
+}
+
+func (l *hostLimiter) stateFor(host string) *hostLimitState {
+	state, ok := l.states[host]
+	if !ok {
+		state = &hostLimitState{changed: make(chan struct{})}
+		l.states[host] = state
+	}
+	return state
+}
+
+// acquire 等待直到主机允许发起新请求，返回的 release 必须调用
+func (l *hostLimiter) acquire(ctx context.Context, host string) (func(), error) {
+	host = strings.ToLower(host)
+	for {
+		l.mu.Lock()
+		limit := l.limitFor(host)
+		state := l.stateFor(host)
+		now := l.now()
+		state.prune(now)
+
+		concurrencyOK := limit.MaxConcurrent <= 0 || state.active < limit.MaxConcurrent
+		rateOK := limit.RequestsPerMinute <= 0 || len(state.starts) < limit.RequestsPerMinute
+		if concurrencyOK && rateOK {
+			state.active++
+			state.starts = append(state.starts, now)
+			l.mu.Unlock()
+			return l.releaseFunc(host), nil
+		}
+
+		var timer *time.Timer
+		var wait <-chan time.Time
+		if !rateOK {
+			timer = time.NewTimer(state.starts[0].Add(rateLimitWindow).Sub(now))
+			wait = timer.C
+		}
+		changed := state.changed
+		l.mu.Unlock()
+
+		var err error
+		select {
+		case <-ctx.Done():
+			if !rateOK {
+				err = fmt.Errorf("等待 %s 速率限制超时", host)
+			} else {
+				err = fmt.Errorf("等待 %s 并发限制超时", host)
+			}
+		case <-changed:
+		case <-wait:
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (l *hostLimiter) releaseFunc(host string) func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			state := l.stateFor(host)
+			if state.active > 0 {
+				state.active--
+			}
+			state.notify()
+		})
+	}
+}
+
+// prune 移除窗口外的请求记录
+func (s *hostLimitState) prune(now time.Time) {
+	cutoff := now.Add(-rateLimitWindow)
+	index := 0
+	for index < len(s.starts) && !s.starts[index].After(cutoff) {
+		index++
+	}
+	s.starts = s.starts[index:]
+}
+
+// notify 唤醒所有等待者重新检查
+func (s *hostLimitState) notify() {
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
@@ -0,0 +1,49 @@
+package ai
+
+import (
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/schema"
+)
+
+// 声明 AI 代理协议的消息结构
+func (h *AIHandler) MessageSchemas() []schema.Message {
+	proxyRequest := schema.Object(
+		schema.RequiredField("request_id", schema.String().Describe("请求标识，用于关联响应与取消")),
+		schema.RequiredField("url", schema.String().Describe("http/https 地址")),
+		schema.RequiredField("method", schema.String()),
+		schema.Field("headers", schema.MapOf(schema.String())),
+		schema.Field("body", schema.String().Describe("请求体，最大 16MB")),
+		schema.RequiredField("timeout_ms", schema.Integer().Describe("超时毫秒数，范围 60000 至 7200000")),
+		schema.Field("cache", schema.Boolean().Describe("是否使用响应缓存，需开启 ai.cache_enabled")),
+	)
+	return []schema.Message{
+		schema.Request("ai", "/etl/ai/proxy", "代理一次 HTTP 请求", proxyRequest),
+		schema.Request("ai", "/etl/ai/proxy_stream", "代理流式 HTTP 请求，响应按行推送", proxyRequest),
+		schema.Request("ai", "/etl/ai/proxy_cancel", "取消进行中的代理请求", schema.Object(
+			schema.RequiredField("request_id", schema.String()),
+		)),
+		schema.Request("ai", "/etl/ai/usage_stats", "查询审计日志汇总的用量", schema.Object(
+			schema.Field("days", schema.Integer().Describe("最近天数")),
+		)),
+
+		schema.Push("ai", "/lte/ai/proxy_response", "代理响应，失败时仅含 request_id 与 error", schema.Object(
+			schema.RequiredField("request_id", schema.String()),
+			schema.Field("status", schema.Integer()),
+			schema.Field("headers", schema.MapOf(schema.String())),
+			schema.Field("body", schema.String()),
+			schema.Field("cached", schema.Boolean()),
+			schema.Field("error", schema.String()),
+		)),
+		schema.Push("ai", "/lte/ai/proxy_stream", "流式响应片段，done 为 true 时结束", schema.Object(
+			schema.RequiredField("request_id", schema.String()),
+			schema.Field("chunk", schema.String()),
+			schema.Field("done", schema.Boolean()),
+			schema.Field("error", schema.String()),
+		)),
+		schema.Push("ai", "/lte/ai/usage_stats", "用量统计", schema.Of(usageStats{})),
+	}
+}
+
+// AI 代理协议提供的功能标识
+func (h *AIHandler) Capabilities() []string {
+	return []string{"ai.proxy", "ai.proxy_stream", "ai.cache", "ai.usage_stats"}
+}
@@ -1,6 +1,8 @@
 package config
 
 import (
+	"strings"
+
 	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/config"
 	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/errors"
 	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/eventbus"
@@ -9,14 +11,30 @@ import (
 	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/pkg/models"
 )
 
+// Reloader 按配置重载各服务，返回的结果随重载响应下发
+type Reloader func(cfg *config.Config) (interface{}, error)
+
 // Config协议处理器
-type ConfigHandler struct{}
+type ConfigHandler struct {
+	reloader      Reloader
+	healthApplier func(config.ControllerHealthConfig)
+}
 
 // 创建Config协议处理器
 func NewConfigHandler() *ConfigHandler {
 	return &ConfigHandler{}
 }
 
+// SetReloader 设置重载请求使用的协调重载，未设置时仅发布配置重载事件
+func (h *ConfigHandler) SetReloader(reloader Reloader) {
+	h.reloader = reloader
+}
+
+// SetHealthApplier 设置控制器健康监测配置保存后的应用函数，使新配置无需重启即可生效
+func (h *ConfigHandler) SetHealthApplier(applier func(config.ControllerHealthConfig)) {
+	h.healthApplier = applier
+}
+
 // 返回处理的路由前缀
 func (h *ConfigHandler) GetRoutePrefix() []string {
 	return []string{"/etl/config/"}
@@ -83,6 +101,7 @@ func (h *ConfigHandler) handleSetConfig(conn *server.Connection, msg models.Mess
 
 	// 更新配置字段
 	updated := false
+	healthUpdated := false
 
 	// 更新服务器配置
 	if serverConfig, ok := dataMap["server"].(map[string]interface{}); ok {
@@ -102,6 +121,10 @@ func (h *ConfigHandler) handleSetConfig(conn *server.Connection, msg models.Mess
 			cfg.File.Root = root
 			updated = true
 		}
+		if roots, ok := fileConfig["roots"].([]interface{}); ok {
+			cfg.File.Roots = toRootConfigs(roots)
+			updated = true
+		}
 		if exclude, ok := fileConfig["exclude"].([]interface{}); ok {
 			cfg.File.Exclude = toStringSlice(exclude)
 			updated = true
@@ -142,6 +165,49 @@ func (h *ConfigHandler) handleSetConfig(conn *server.Connection, msg models.Mess
 			cfg.MaaFW.ResourceDir = resourceDir
 			updated = true
 		}
+		if healthConfig, ok := maafwConfig["controller_health"].(map[string]interface{}); ok {
+			if applyControllerHealthConfig(&cfg.MaaFW.ControllerHealth, healthConfig) {
+				updated = true
+				healthUpdated = true
+			}
+		}
+	}
+
+	// 更新 AI 代理配置
+	if aiConfig, ok := dataMap["ai"].(map[string]interface{}); ok {
+		if auditEnabled, ok := aiConfig["audit_enabled"].(bool); ok {
+			cfg.AI.AuditEnabled = auditEnabled
+			updated = true
+		}
+		if retention, ok := aiConfig["audit_retention_days"].(float64); ok && retention >= 0 {
+			cfg.AI.AuditRetentionDays = int(retention)
+			updated = true
+		}
+		if cacheEnabled, ok := aiConfig["cache_enabled"].(bool); ok {
+			cfg.AI.CacheEnabled = cacheEnabled
+			updated = true
+		}
+		if ttl, ok := aiConfig["cache_ttl_seconds"].(float64); ok && ttl >= 0 {
+			cfg.AI.CacheTTLSeconds = int(ttl)
+			updated = true
+		}
+		if maxEntries, ok := aiConfig["cache_max_entries"].(float64); ok && maxEntries >= 0 {
+			cfg.AI.CacheMaxEntries = int(maxEntries)
+			updated = true
+		}
+		if defaultLimit, ok := aiConfig["default_limit"].(map[string]interface{}); ok {
+			cfg.AI.DefaultLimit = toAIHostLimit(defaultLimit)
+			updated = true
+		}
+		if hostLimits, ok := aiConfig["host_limits"].(map[string]interface{}); ok {
+			cfg.AI.HostLimits = make(map[string]config.AIHostLimit, len(hostLimits))
+			for host, raw := range hostLimits {
+				if limit, ok := raw.(map[string]interface{}); ok {
+					cfg.AI.HostLimits[strings.ToLower(host)] = toAIHostLimit(limit)
+				}
+			}
+			updated = true
+		}
 	}
 
 	if !updated {
@@ -157,6 +223,9 @@ func (h *ConfigHandler) handleSetConfig(conn *server.Connection, msg models.Mess
 	}
 
 	logger.Info("Config", "配置已更新并保存")
+	if healthUpdated && h.healthApplier != nil {
+		h.healthApplier(cfg.MaaFW.ControllerHealth)
+	}
 
 	// 返回更新后的配置
 	conn.Send(models.Message{
@@ -192,14 +261,31 @@ func (h *ConfigHandler) handleReload(conn *server.Connection, msg models.Message
 	eventBus := eventbus.GetGlobalBus()
 	eventBus.Publish(eventbus.EventConfigReload, cfg)
 
+	data := map[string]interface{}{
+		"success": true,
+		"message": "配置重载完成",
+	}
+	if h.reloader != nil {
+		result, err := h.reloader(cfg)
+		if err != nil {
+			logger.Error("Config", "重载失败: %v", err)
+			conn.Send(models.Message{
+				Path: "/lte/config/reload",
+				Data: map[string]interface{}{
+					"success": false,
+					"error":   err.Error(),
+				},
+			})
+			return
+		}
+		data["result"] = result
+	}
+
 	logger.Info("Config", "配置重载完成")
 
 	conn.Send(models.Message{
 		Path: "/lte/config/reload",
-		Data: map[string]interface{}{
-			"success": true,
-			"message": "配置重载完成",
-		},
+		Data: data,
 	})
 }
 
@@ -214,6 +300,23 @@ func toStringSlice(slice []interface{}) []string {
 	return result
 }
 
+// 转换附加根目录列表，忽略缺少名称或路径的条目
+func toRootConfigs(slice []interface{}) []config.RootConfig {
+	result := make([]config.RootConfig, 0, len(slice))
+	for _, v := range slice {
+		entry, ok := v.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name, _ := entry["name"].(string)
+		path, _ := entry["path"].(string)
+		if name != "" && path != "" {
+			result = append(result, config.RootConfig{Name: name, Path: path})
+		}
+	}
+	return result
+}
+
 // 发送错误
 func (h *ConfigHandler) sendError(conn *server.Connection, err *errors.LBError) {
 	errorMsg := models.Message{
@@ -234,3 +337,39 @@ func (h *ConfigHandler) sendConfigError(conn *server.Connection, code, message s
 	}
 	conn.Send(errorMsg)
 }
+
+// 转换 AI 主机限流配置
+func toAIHostLimit(raw map[string]interface{}) config.AIHostLimit {
+	var limit config.AIHostLimit
+	if value, ok := raw["requests_per_minute"].(float64); ok && value > 0 {
+		limit.RequestsPerMinute = int(value)
+	}
+	if value, ok := raw["max_concurrent"].(float64); ok && value > 0 {
+		limit.MaxConcurrent = int(value)
+	}
+	return limit
+}
+
+// 更新控制器健康监测配置，返回是否有字段变化
+func applyControllerHealthConfig(health *config.ControllerHealthConfig, raw map[string]interface{}) bool {
+	updated := false
+	intFields := map[string]*int{
+		"probe_interval_seconds":        &health.ProbeIntervalSeconds,
+		"probe_timeout_seconds":         &health.ProbeTimeoutSeconds,
+		"failure_threshold":             &health.FailureThreshold,
+		"reconnect_max_attempts":        &health.ReconnectMaxAttempts,
+		"reconnect_max_backoff_seconds": &health.ReconnectMaxBackoffSeconds,
+		"inactive_timeout_minutes":      &health.InactiveTimeoutMinutes,
+	}
+	for key, field := range intFields {
+		if value, ok := raw[key].(float64); ok && value >= 0 {
+			*field = int(value)
+			updated = true
+		}
+	}
+	if autoReconnect, ok := raw["auto_reconnect"].(bool); ok {
+		health.AutoReconnect = autoReconnect
+		updated = true
+	}
+	return updated
+}
@@ -0,0 +1,35 @@
+package config
+
+import (
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/config"
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/reload"
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/schema"
+)
+
+// 声明配置协议的消息结构
+func (h *ConfigHandler) MessageSchemas() []schema.Message {
+	return []schema.Message{
+		schema.Request("config", "/etl/config/get", "读取当前配置", schema.Object()),
+		schema.Request("config", "/etl/config/set", "修改配置并写入配置文件，仅提交需要修改的字段；server.allowed_origins 与 server.auth 不可远程修改",
+			schema.Of(config.Config{})),
+		schema.Request("config", "/etl/config/reload", "从配置文件重载并重启相关服务", schema.Object()),
+
+		schema.Push("config", "/lte/config/data", "当前配置", schema.Object(
+			schema.RequiredField("success", schema.Boolean()),
+			schema.RequiredField("config", schema.Of(config.Config{})),
+			schema.RequiredField("config_path", schema.String()),
+			schema.Field("message", schema.String()),
+		)),
+		schema.Push("config", "/lte/config/reload", "重载结果", schema.Object(
+			schema.RequiredField("success", schema.Boolean()),
+			schema.Field("message", schema.String()),
+			schema.Field("error", schema.String()),
+			schema.Field("result", schema.Of(reload.Result{}).Describe("协调重载结果，切换根目录时排空调试运行并推送新的文件列表与资源包")),
+		)),
+	}
+}
+
+// 配置协议提供的功能标识
+func (h *ConfigHandler) Capabilities() []string {
+	return []string{"config", "config.reload", "config.reload.workspace"}
+}
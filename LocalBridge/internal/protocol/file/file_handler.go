@@ -2,30 +2,34 @@ package file
 
 import (
 	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sync"
 
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/config"
 	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/errors"
 	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/eventbus"
 	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/logger"
 	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/server"
 	fileService "github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/service/file"
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/workspace"
 	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/pkg/models"
 )
 
-// 文件协议处理器
+// 文件协议处理器，每个根目录对应一个文件服务
 type Handler struct {
-	fileService *fileService.Service
-	eventBus    *eventbus.EventBus
-	wsServer    *server.WebSocketServer
-	root        string
+	mu       sync.RWMutex
+	services []*fileService.Service
+	eventBus *eventbus.EventBus
+	wsServer *server.WebSocketServer
 }
 
 // 创建文件协议处理器
-func NewHandler(fileService *fileService.Service, eventBus *eventbus.EventBus, wsServer *server.WebSocketServer, root string) *Handler {
+func NewHandler(services []*fileService.Service, eventBus *eventbus.EventBus, wsServer *server.WebSocketServer) *Handler {
 	h := &Handler{
-		fileService: fileService,
-		eventBus:    eventBus,
-		wsServer:    wsServer,
-		root:        root,
+		services: services,
+		eventBus: eventBus,
+		wsServer: wsServer,
 	}
 
 	// 订阅事件
@@ -34,6 +38,66 @@ func NewHandler(fileService *fileService.Service, eventBus *eventbus.EventBus, w
 	return h
 }
 
+// PrepareRoot 以新的文件配置为每个根目录创建并启动文件服务。
+// commit 切换到新服务、停止旧服务并向所有客户端推送新的文件列表；abort 停止新服务
+func (h *Handler) PrepareRoot(cfg config.FileConfig) (commit func(), abort func(), err error) {
+	services, err := fileService.NewWorkspaceServices(cfg, h.eventBus)
+	if err != nil {
+		return nil, nil, err
+	}
+	abort = func() {
+		for _, service := range services {
+			service.Stop()
+		}
+	}
+	for _, service := range services {
+		if err := service.Start(); err != nil {
+			abort()
+			return nil, nil, fmt.Errorf("启动根目录 %s 的文件服务失败: %w", service.Root().Name, err)
+		}
+	}
+
+	commit = func() {
+		h.mu.Lock()
+		previous := h.services
+		h.services = services
+		h.mu.Unlock()
+
+		for _, service := range previous {
+			service.Stop()
+		}
+		h.pushFileList(nil)
+	}
+	return commit, abort, nil
+}
+
+// Stop 停止全部文件服务
+func (h *Handler) Stop() {
+	for _, service := range h.allServices() {
+		service.Stop()
+	}
+}
+
+func (h *Handler) allServices() []*fileService.Service {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.services
+}
+
+// 返回路径所在根目录的文件服务，路径安全性仍由服务自身校验
+func (h *Handler) service(path string) (*fileService.Service, *errors.LBError) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, errors.NewPermissionDeniedError("无效的路径")
+	}
+	for _, service := range h.allServices() {
+		if workspace.Contains(service.Root().Path, absPath) {
+			return service, nil
+		}
+	}
+	return nil, errors.NewPermissionDeniedError("路径不在任何根目录范围内")
+}
+
 // 返回处理的路由前缀
 func (h *Handler) GetRoutePrefix() []string {
 	return []string{
@@ -72,8 +136,14 @@ func (h *Handler) handleOpenFile(msg models.Message, conn *server.Connection) *m
 		return nil
 	}
 
+	service, lbErr := h.service(req.FilePath)
+	if lbErr != nil {
+		h.sendError(conn, lbErr)
+		return nil
+	}
+
 	// 读取 Pipeline 文件
-	content, err := h.fileService.ReadFile(req.FilePath)
+	content, err := service.ReadFile(req.FilePath)
 	if err != nil {
 		if lbErr, ok := err.(*errors.LBError); ok {
 			h.sendError(conn, lbErr)
@@ -113,7 +183,7 @@ func (h *Handler) handleOpenFile(msg models.Message, conn *server.Connection) *m
 		configPath = directory + "." + baseName + ".mpe.json"
 
 		// 尝试读取配置文件
-		configContent, err := h.fileService.ReadFile(configPath)
+		configContent, err := service.ReadFile(configPath)
 		if err == nil {
 			// 配置文件存在
 			mpeConfig = configContent
@@ -155,8 +225,14 @@ func (h *Handler) handleSaveFile(msg models.Message, conn *server.Connection) *m
 		content = req.ContentJSON
 	}
 
+	service, lbErr := h.service(req.FilePath)
+	if lbErr != nil {
+		h.sendError(conn, lbErr)
+		return nil
+	}
+
 	// 保存文件
-	if err := h.fileService.SaveFileWithOrder(req.FilePath, content, req.Indent, keepOrder); err != nil {
+	if err := service.SaveFileWithOrder(req.FilePath, content, req.Indent, keepOrder); err != nil {
 		if lbErr, ok := err.(*errors.LBError); ok {
 			h.sendError(conn, lbErr)
 		} else {
@@ -204,8 +280,19 @@ func (h *Handler) handleSaveSeparated(msg models.Message, conn *server.Connectio
 		configContent = req.ConfigJSON
 	}
 
+	pipelineService, lbErr := h.service(req.PipelinePath)
+	if lbErr != nil {
+		h.sendError(conn, lbErr)
+		return nil
+	}
+	configService, lbErr := h.service(req.ConfigPath)
+	if lbErr != nil {
+		h.sendError(conn, lbErr)
+		return nil
+	}
+
 	// 保存 Pipeline 文件
-	if err := h.fileService.SaveFileWithOrder(req.PipelinePath, pipelineContent, req.Indent, keepPipelineOrder); err != nil {
+	if err := pipelineService.SaveFileWithOrder(req.PipelinePath, pipelineContent, req.Indent, keepPipelineOrder); err != nil {
 		if lbErr, ok := err.(*errors.LBError); ok {
 			h.sendError(conn, lbErr)
 		} else {
@@ -215,7 +302,7 @@ func (h *Handler) handleSaveSeparated(msg models.Message, conn *server.Connectio
 	}
 
 	// 保存配置文件
-	if err := h.fileService.SaveFileWithOrder(req.ConfigPath, configContent, req.Indent, keepConfigOrder); err != nil {
+	if err := configService.SaveFileWithOrder(req.ConfigPath, configContent, req.Indent, keepConfigOrder); err != nil {
 		if lbErr, ok := err.(*errors.LBError); ok {
 			h.sendError(conn, lbErr)
 		} else {
@@ -246,8 +333,14 @@ func (h *Handler) handleCreateFile(msg models.Message, conn *server.Connection)
 		return nil
 	}
 
+	service, lbErr := h.service(req.Directory)
+	if lbErr != nil {
+		h.sendError(conn, lbErr)
+		return nil
+	}
+
 	// 创建文件
-	filePath, err := h.fileService.CreateFile(req.Directory, req.FileName, req.Content)
+	filePath, err := service.CreateFile(req.Directory, req.FileName, req.Content)
 	if err != nil {
 		if lbErr, ok := err.(*errors.LBError); ok {
 			h.sendError(conn, lbErr)
@@ -258,7 +351,7 @@ func (h *Handler) handleCreateFile(msg models.Message, conn *server.Connection)
 	}
 
 	// 重新推送文件列表
-	h.pushFileList()
+	h.pushFileList(nil)
 
 	// 返回确认
 	return &models.Message{
@@ -273,10 +366,12 @@ func (h *Handler) handleCreateFile(msg models.Message, conn *server.Connection)
 // 处理刷新文件列表请求
 func (h *Handler) handleRefreshFileList(msg models.Message, conn *server.Connection) *models.Message {
 	// 重新扫描文件系统，而非仅推送内存索引
-	if err := h.fileService.Rescan(); err != nil {
-		logger.Error("FileProtocol", "重新扫描文件失败: %v", err)
+	for _, service := range h.allServices() {
+		if err := service.Rescan(); err != nil {
+			logger.Error("FileProtocol", "重新扫描根目录 %s 失败: %v", service.Root().Name, err)
+		}
 	}
-	h.pushFileList()
+	h.pushFileList(conn)
 	return nil
 }
 
@@ -285,7 +380,7 @@ func (h *Handler) subscribeEvents() {
 	// 订阅连接建立事件
 	h.eventBus.Subscribe(eventbus.EventConnectionEstablished, func(event eventbus.Event) {
 		// 推送文件列表
-		h.pushFileList()
+		h.pushFileList(nil)
 	})
 
 	// 订阅文件变化事件
@@ -312,25 +407,38 @@ func (h *Handler) subscribeEvents() {
 			// deleted (目录): 多个文件被移除
 			// renamed: 路径变更
 			if changeType == "created" || (changeType == "deleted" && isDirectory) || changeType == "renamed" {
-				h.pushFileList()
+				h.pushFileList(nil)
 			}
 		}
 	})
 }
 
-// 推送文件列表
-func (h *Handler) pushFileList() {
-	fileList := h.fileService.GetFileList()
-	directories := h.fileService.GetDirectories()
+// 推送文件列表，由请求触发时 requester 收到带关联 ID 的副本
+func (h *Handler) pushFileList(requester *server.Connection) {
+	services := h.allServices()
+	roots := make(workspace.Roots, 0, len(services))
+	fileList := make([]models.FileInfo, 0)
+	directories := make([]string, 0)
+	for _, service := range services {
+		roots = append(roots, service.Root())
+		fileList = append(fileList, service.GetFileList()...)
+		directories = append(directories, service.GetDirectories()...)
+	}
 
-	h.wsServer.Broadcast(models.Message{
+	msg := models.Message{
 		Path: "/lte/file_list",
 		Data: models.FileListData{
-			Root:        h.root,
+			Root:        roots.Primary().Path,
+			Roots:       roots.Models(),
 			Files:       fileList,
 			Directories: directories,
 		},
-	})
+	}
+	if requester != nil {
+		h.wsServer.BroadcastReply(msg, requester)
+	} else {
+		h.wsServer.Broadcast(msg)
+	}
 
 	logger.Debug("FileProtocol", "推送文件列表，共 %d 个文件, %d 个目录", len(fileList), len(directories))
 }
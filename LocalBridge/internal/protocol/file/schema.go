@@ -0,0 +1,33 @@
+package file
+
+import (
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/schema"
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/pkg/models"
+)
+
+// 声明文件协议的消息结构
+func (h *Handler) MessageSchemas() []schema.Message {
+	return []schema.Message{
+		schema.Request("file", "/etl/open_file", "读取 pipeline 文件及其同名 .mpe.json 配置",
+			schema.Of(models.OpenFileRequest{}).Require("file_path")),
+		schema.Request("file", "/etl/save_file", "保存 pipeline 文件，content 与 content_json 二选一",
+			schema.Of(models.SaveFileRequest{}).Require("file_path")),
+		schema.Request("file", "/etl/save_separated", "分别保存 pipeline 与配置文件",
+			schema.Of(models.SaveSeparatedRequest{}).Require("pipeline_path", "config_path")),
+		schema.Request("file", "/etl/create_file", "在目录下新建 pipeline 文件",
+			schema.Of(models.CreateFileRequest{}).Require("file_name", "directory")),
+		schema.Request("file", "/etl/refresh_file_list", "重新扫描文件列表，结果以 /lte/file_list 广播", schema.Object()),
+
+		schema.Push("file", "/lte/file_content", "文件内容", schema.Of(models.FileContentData{})),
+		schema.Push("file", "/ack/save_file", "保存成功", schema.Of(models.SaveFileAckData{})),
+		schema.Push("file", "/ack/save_separated", "分离保存成功", schema.Of(models.SaveSeparatedAckData{})),
+		schema.Push("file", "/ack/create_file", "创建成功", schema.Of(models.CreateFileAckData{})),
+		schema.Push("file", "/lte/file_list", "文件列表，连接建立与文件变化时广播", schema.Of(models.FileListData{})),
+		schema.Push("file", "/lte/file_changed", "文件变化通知", schema.Of(models.FileChangedData{})),
+	}
+}
+
+// 文件协议提供的功能标识
+func (h *Handler) Capabilities() []string {
+	return []string{"file", "file.save_separated", "file.watch", "file.multi_root"}
+}
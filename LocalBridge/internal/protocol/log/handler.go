@@ -0,0 +1,150 @@
+package log
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/errors"
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/logger"
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/server"
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/pkg/models"
+)
+
+// 日志协议处理器
+type Handler struct{}
+
+// 创建日志协议处理器
+func NewHandler() *Handler {
+	return &Handler{}
+}
+
+// 返回处理的路由前缀
+func (h *Handler) GetRoutePrefix() []string {
+	return []string{"/etl/log/"}
+}
+
+// 处理消息
+func (h *Handler) Handle(msg models.Message, conn *server.Connection) *models.Message {
+	switch msg.Path {
+	case "/etl/log/set_level":
+		return h.handleSetLevel(msg, conn)
+	case "/etl/log/query":
+		return h.handleQuery(msg, conn)
+	default:
+		h.sendError(conn, errors.NewInvalidRequestError("未知的日志路由: "+msg.Path))
+		return nil
+	}
+}
+
+// 处理设置日志级别请求，仅在本次运行内生效
+func (h *Handler) handleSetLevel(msg models.Message, conn *server.Connection) *models.Message {
+	var req models.SetLogLevelRequest
+	if err := h.parseData(msg.Data, &req); err != nil {
+		h.sendError(conn, err)
+		return nil
+	}
+
+	var err error
+	if strings.TrimSpace(req.Module) == "" {
+		err = logger.SetLevel(req.Level)
+	} else {
+		err = logger.SetModuleLevel(req.Module, req.Level)
+	}
+	if err != nil {
+		h.sendError(conn, errors.NewInvalidRequestError(err.Error()))
+		return nil
+	}
+
+	level, modules := logger.Levels()
+	logger.Info("Log", "日志级别已更新: 全局 %s，模块 %v", level, modules)
+	return &models.Message{
+		Path: "/lte/log/levels",
+		Data: models.LogLevelsData{Level: level, Modules: modules},
+	}
+}
+
+// 处理日志查询请求
+func (h *Handler) handleQuery(msg models.Message, conn *server.Connection) *models.Message {
+	var req models.LogQueryRequest
+	if err := h.parseData(msg.Data, &req); err != nil {
+		h.sendError(conn, err)
+		return nil
+	}
+
+	opts := logger.QueryOptions{
+		Level:   req.Level,
+		Module:  strings.TrimSpace(req.Module),
+		Keyword: req.Keyword,
+		Limit:   req.Limit,
+	}
+	var lbErr *errors.LBError
+	if opts.Since, lbErr = parseTime("since", req.Since); lbErr != nil {
+		h.sendError(conn, lbErr)
+		return nil
+	}
+	if opts.Until, lbErr = parseTime("until", req.Until); lbErr != nil {
+		h.sendError(conn, lbErr)
+		return nil
+	}
+
+	result, err := logger.Query(opts)
+	if err != nil {
+		h.sendError(conn, errors.NewInvalidRequestError(err.Error()))
+		return nil
+	}
+
+	entries := make([]models.LogData, 0, len(result.Entries))
+	for _, entry := range result.Entries {
+		entries = append(entries, models.LogData{
+			Level:     entry.Level,
+			Module:    entry.Module,
+			Message:   entry.Message,
+			Timestamp: entry.Timestamp.Format(time.RFC3339),
+		})
+	}
+	return &models.Message{
+		Path: "/lte/log/query_result",
+		Data: models.LogQueryData{
+			Entries:   entries,
+			Truncated: result.Truncated,
+			Files:     result.Files,
+		},
+	}
+}
+
+// parseTime 解析 RFC 3339 时间，空字符串表示不限制
+func parseTime(field string, value string) (time.Time, *errors.LBError) {
+	if strings.TrimSpace(value) == "" {
+		return time.Time{}, nil
+	}
+	parsed, err := time.Parse(time.RFC3339, strings.TrimSpace(value))
+	if err != nil {
+		return time.Time{}, errors.NewInvalidRequestError(field + " 不是有效的 RFC 3339 时间: " + value)
+	}
+	return parsed, nil
+}
+
+// 解析数据
+func (h *Handler) parseData(data interface{}, target interface{}) *errors.LBError {
+	jsonData, err := json.Marshal(data)
+	if err != nil {
+		return errors.NewInvalidJSONError(err)
+	}
+
+	if err := json.Unmarshal(jsonData, target); err != nil {
+		return errors.NewInvalidJSONError(err)
+	}
+
+	return nil
+}
+
+// 发送错误消息
+func (h *Handler) sendError(conn *server.Connection, err *errors.LBError) {
+	logger.Warn("Log", "%s", err.Error())
+
+	conn.Send(models.Message{
+		Path: "/error",
+		Data: err.ToErrorData(),
+	})
+}
@@ -0,0 +1,24 @@
+package log
+
+import (
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/schema"
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/pkg/models"
+)
+
+// 声明日志协议的消息结构
+func (h *Handler) MessageSchemas() []schema.Message {
+	return []schema.Message{
+		schema.Request("log", "/etl/log/set_level", "修改控制台与推送的日志级别，指定 module 时仅修改该模块，仅在本次运行内生效",
+			schema.Of(models.SetLogLevelRequest{})),
+		schema.Request("log", "/etl/log/query", "按时间范围、最低级别、模块与关键字查询当前及已切分的日志文件",
+			schema.Of(models.LogQueryRequest{})),
+
+		schema.Push("log", "/lte/log/levels", "当前日志级别", schema.Of(models.LogLevelsData{})),
+		schema.Push("log", "/lte/log/query_result", "日志查询结果", schema.Of(models.LogQueryData{})),
+	}
+}
+
+// 日志协议提供的功能标识
+func (h *Handler) Capabilities() []string {
+	return []string{"log.level", "log.query"}
+}
@@ -68,6 +68,10 @@ func (h *MFWHandler) Handle(msg models.Message, conn *server.Connection) *models
 		h.handleDisconnectController(conn, msg)
 	case "/etl/mfw/request_screencap":
 		h.handleScreencap(conn, msg)
+	case "/etl/mfw/start_screen_stream":
+		h.handleStartScreenStream(conn, msg)
+	case "/etl/mfw/stop_screen_stream":
+		h.handleStopScreenStream(conn, msg)
 	case "/etl/mfw/controller_click":
 		h.handleControllerClick(conn, msg)
 	case "/etl/mfw/controller_swipe":
@@ -98,6 +102,11 @@ func (h *MFWHandler) Handle(msg models.Message, conn *server.Connection) *models
 		h.handleControllerShell(conn, msg)
 	case "/etl/mfw/controller_inactive":
 		h.handleControllerInactive(conn, msg)
+	case "/etl/mfw/query_controller_health":
+		h.handleQueryControllerHealth(conn)
+	case "/etl/mfw/benchmark_screencap":
+		// 测速耗时较长，避免阻塞连接的消息读取
+		go h.handleBenchmarkScreencap(conn, msg)
 
 	// 探索模式：执行单节点动作
 	case "/etl/mfw/execute_action":
@@ -250,6 +259,14 @@ func (h *MFWHandler) handleCreateWin32Controller(conn *server.Connection, msg mo
 	screencapMethod, _ := dataMap["screencap_method"].(string)
 	inputMethod, _ := dataMap["input_method"].(string)
 
+	// 未指定截图方法时优先使用测速保存的推荐方法
+	if screencapMethod == "" {
+		if preferred := h.service.DeviceManager().PreferredWin32ScreencapMethod(hwnd); preferred != "" {
+			logger.Info("MFW", "Win32 窗口 %s 使用偏好配置中的截图方法: %s", hwnd, preferred)
+			screencapMethod = preferred
+		}
+	}
+
 	controllerID, err := h.service.ControllerManager().CreateWin32Controller(
 		hwnd, screencapMethod, inputMethod,
 	)
@@ -391,6 +408,16 @@ func (h *MFWHandler) handleCreateWlRootsController(conn *server.Connection, msg
 	conn.Send(response)
 }
 
+// 查询控制器健康状态
+func (h *MFWHandler) handleQueryControllerHealth(conn *server.Connection) {
+	conn.Send(models.Message{
+		Path: "/lte/mfw/controller_health_list",
+		Data: map[string]interface{}{
+			"controllers": h.service.HealthMonitor().Snapshot(),
+		},
+	})
+}
+
 func (h *MFWHandler) handleDisconnectController(conn *server.Connection, msg models.Message) {
 	dataMap, ok := msg.Data.(map[string]interface{})
 	if !ok {
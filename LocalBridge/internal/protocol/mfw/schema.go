@@ -0,0 +1,250 @@
+package mfw
+
+import (
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/mfw"
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/schema"
+)
+
+// 声明 MFW 协议的消息结构
+func (h *MFWHandler) MessageSchemas() []schema.Message {
+	controllerID := schema.RequiredField("controller_id", schema.String())
+	point := func(extra ...schema.Property) schema.Schema {
+		return schema.Object(append([]schema.Property{
+			controllerID,
+			schema.RequiredField("x", schema.Integer()),
+			schema.RequiredField("y", schema.Integer()),
+		}, extra...)...)
+	}
+	keycode := schema.Object(controllerID, schema.RequiredField("keycode", schema.Integer()))
+	appPackage := schema.Object(controllerID, schema.RequiredField("package", schema.String()))
+	controllerCreated := schema.Object(
+		schema.RequiredField("success", schema.Boolean()),
+		schema.RequiredField("controller_id", schema.String()),
+		schema.RequiredField("type", schema.Enum("adb", "win32", "playcover", "gamepad", "wlroots")),
+		schema.Field("input_methods", schema.Array(schema.String())),
+		schema.Field("warning", schema.String()),
+	)
+	pipelineOverride := schema.Object().Describe("覆盖的 pipeline 节点，键为节点名")
+
+	return []schema.Message{
+		// 设备
+		schema.Request("mfw", "/etl/mfw/refresh_adb_devices", "扫描 ADB 设备", schema.Object()),
+		schema.Request("mfw", "/etl/mfw/refresh_win32_windows", "枚举 Win32 窗口", schema.Object()),
+		schema.Request("mfw", "/etl/mfw/refresh_wlroots_sockets", "扫描 wlroots 合成器套接字", schema.Object()),
+		schema.Push("mfw", "/lte/mfw/adb_devices", "ADB 设备列表", schema.Object(
+			schema.RequiredField("devices", schema.Of([]mfw.AdbDeviceInfo{})),
+		)),
+		schema.Push("mfw", "/lte/mfw/win32_windows", "Win32 窗口列表", schema.Object(
+			schema.RequiredField("windows", schema.Of([]mfw.Win32WindowInfo{})),
+		)),
+		schema.Push("mfw", "/lte/mfw/wlroots_sockets", "wlroots 合成器列表", schema.Object(
+			schema.RequiredField("compositors", schema.Of([]mfw.WlRootsCompositorInfo{})),
+		)),
+
+		// 控制器
+		schema.Request("mfw", "/etl/mfw/create_adb_controller", "连接 ADB 控制器", schema.Object(
+			schema.RequiredField("adb_path", schema.String()),
+			schema.RequiredField("address", schema.String()),
+			schema.Field("screencap_methods", schema.Array(schema.String())),
+			schema.Field("input_methods", schema.Array(schema.String())),
+			schema.Field("config", schema.String().Describe("JSON 字符串")),
+			schema.Field("agent_path", schema.String()),
+		)),
+		schema.Request("mfw", "/etl/mfw/create_win32_controller", "连接 Win32 控制器", schema.Object(
+			schema.RequiredField("hwnd", schema.String()),
+			schema.Field("screencap_method", schema.String()),
+			schema.Field("input_method", schema.String()),
+		)),
+		schema.Request("mfw", "/etl/mfw/create_playcover_controller", "连接 PlayCover 控制器", schema.Object(
+			schema.RequiredField("address", schema.String()),
+			schema.RequiredField("uuid", schema.String()),
+		)),
+		schema.Request("mfw", "/etl/mfw/create_gamepad_controller", "连接虚拟手柄控制器", schema.Object(
+			schema.Field("hwnd", schema.String()),
+			schema.Field("gamepad_type", schema.String()),
+			schema.Field("screencap_method", schema.String()),
+		)),
+		schema.Request("mfw", "/etl/mfw/create_wlroots_controller", "连接 wlroots 控制器", schema.Object(
+			schema.RequiredField("socket_path", schema.String()),
+			schema.Field("use_win32_vk_code", schema.Boolean()),
+		)),
+		schema.Request("mfw", "/etl/mfw/disconnect_controller", "断开控制器", schema.Object(controllerID)),
+		schema.Request("mfw", "/etl/mfw/controller_inactive", "标记控制器空闲", schema.Object(controllerID)),
+		schema.Request("mfw", "/etl/mfw/query_controller_health", "查询控制器健康状态", schema.Object()),
+		schema.Push("mfw", "/lte/mfw/controller_created", "控制器已连接", controllerCreated),
+		schema.Push("mfw", "/lte/mfw/controller_status", "控制器连接状态", schema.Object(
+			schema.RequiredField("controller_id", schema.String()),
+			schema.RequiredField("connected", schema.Boolean()),
+		)),
+		schema.Push("mfw", "/lte/mfw/controller_health_list", "控制器健康状态", schema.Object(
+			schema.RequiredField("controllers", schema.Of([]mfw.ControllerHealth{})),
+		)),
+
+		// 控制器操作，结果统一为 controller_operation_result
+		schema.Request("mfw", "/etl/mfw/controller_click", "点击", point()),
+		schema.Request("mfw", "/etl/mfw/controller_swipe", "滑动", schema.Object(
+			controllerID,
+			schema.RequiredField("x1", schema.Integer()),
+			schema.RequiredField("y1", schema.Integer()),
+			schema.RequiredField("x2", schema.Integer()),
+			schema.RequiredField("y2", schema.Integer()),
+			schema.Field("duration", schema.Integer().Describe("毫秒")),
+		)),
+		schema.Request("mfw", "/etl/mfw/controller_input_text", "输入文本", schema.Object(
+			controllerID,
+			schema.RequiredField("text", schema.String()),
+		)),
+		schema.Request("mfw", "/etl/mfw/controller_start_app", "启动应用", appPackage),
+		schema.Request("mfw", "/etl/mfw/controller_stop_app", "停止应用", appPackage),
+		schema.Request("mfw", "/etl/mfw/controller_click_key", "按键", keycode),
+		schema.Request("mfw", "/etl/mfw/controller_key_down", "按下按键", keycode),
+		schema.Request("mfw", "/etl/mfw/controller_key_up", "抬起按键", keycode),
+		schema.Request("mfw", "/etl/mfw/controller_touch_gamepad", "手柄触控", point(
+			schema.Field("contact", schema.Integer()),
+			schema.Field("pressure", schema.Integer()),
+			schema.RequiredField("action", schema.String()),
+		)),
+		schema.Request("mfw", "/etl/mfw/controller_scroll", "滚动", schema.Object(
+			controllerID,
+			schema.RequiredField("dx", schema.Integer()),
+			schema.RequiredField("dy", schema.Integer()),
+		)),
+		schema.Request("mfw", "/etl/mfw/controller_click_v2", "按触点与压力点击", point(
+			schema.Field("contact", schema.Integer()),
+			schema.Field("pressure", schema.Integer()),
+		)),
+		schema.Request("mfw", "/etl/mfw/controller_swipe_v2", "按触点与压力滑动", schema.Object(
+			controllerID,
+			schema.RequiredField("x1", schema.Integer()),
+			schema.RequiredField("y1", schema.Integer()),
+			schema.RequiredField("x2", schema.Integer()),
+			schema.RequiredField("y2", schema.Integer()),
+			schema.Field("duration", schema.Integer().Describe("毫秒")),
+			schema.Field("contact", schema.Integer()),
+			schema.Field("pressure", schema.Integer()),
+		)),
+		schema.Request("mfw", "/etl/mfw/controller_shell", "执行 ADB shell 命令", schema.Object(
+			controllerID,
+			schema.RequiredField("command", schema.String()),
+			schema.Field("timeout", schema.Integer().Describe("毫秒")),
+		)),
+		schema.Push("mfw", "/lte/mfw/controller_operation_result", "控制器操作结果", schema.Object(
+			schema.RequiredField("controller_id", schema.String()),
+			schema.RequiredField("operation", schema.String()),
+			schema.RequiredField("success", schema.Boolean()),
+			schema.RequiredField("status", schema.String()),
+		)),
+
+		// 截图与推流
+		schema.Request("mfw", "/etl/mfw/request_screencap", "截图", schema.Object(
+			controllerID,
+			schema.Field("request_id", schema.String()),
+			schema.Field("use_cache", schema.Boolean()),
+			schema.Field("output_long_side", schema.Integer()),
+		)),
+		schema.Push("mfw", "/lte/mfw/screencap_result", "截图结果", schema.Object(
+			schema.RequiredField("controller_id", schema.String()),
+			schema.Field("request_id", schema.String()),
+			schema.RequiredField("success", schema.Boolean()),
+			schema.Field("image", schema.String().Describe("data URL")),
+			schema.Field("width", schema.Integer()),
+			schema.Field("height", schema.Integer()),
+			schema.Field("error", schema.String()),
+		)),
+		schema.Request("mfw", "/etl/mfw/start_screen_stream", "开始实时画面推流", schema.Of(mfw.StreamOptions{}).Require("controller_id")),
+		schema.Request("mfw", "/etl/mfw/stop_screen_stream", "停止实时画面推流", schema.Object(
+			schema.RequiredField("stream_id", schema.String()),
+		)),
+		schema.Push("mfw", "/lte/mfw/screen_stream_started", "推流已开始，返回生效参数", schema.Object(
+			schema.RequiredField("stream_id", schema.String()),
+			schema.RequiredField("controller_id", schema.String()),
+			schema.RequiredField("fps", schema.Integer()),
+			schema.RequiredField("format", schema.String()),
+			schema.RequiredField("quality", schema.Integer()),
+			schema.RequiredField("max_long_side", schema.Integer()),
+		)),
+		schema.Push("mfw", "/lte/mfw/screen_stream_frame", "推流画面帧", schema.Of(mfw.StreamFrame{})),
+		schema.Push("mfw", "/lte/mfw/screen_stream_stopped", "推流已停止", schema.Object(
+			schema.RequiredField("stream_id", schema.String()),
+			schema.RequiredField("controller_id", schema.String()),
+			schema.RequiredField("reason", schema.String()),
+		)),
+		schema.Request("mfw", "/etl/mfw/benchmark_screencap", "截图方法测速", schema.Object(
+			schema.RequiredField("type", schema.String().Describe("ADB 或 Win32，不区分大小写")),
+			schema.Field("adb_path", schema.String()),
+			schema.Field("address", schema.String()),
+			schema.Field("config", schema.String()),
+			schema.Field("hwnd", schema.String()),
+			schema.Field("methods", schema.Array(schema.String())),
+			schema.Field("frames", schema.Integer()),
+			schema.Field("save", schema.Boolean().Describe("保存推荐方法到设备配置")),
+		)),
+		schema.Push("mfw", "/lte/mfw/screencap_benchmark_progress", "单个截图方法的测速结果", schema.Of(mfw.ScreencapMethodResult{})),
+		schema.Push("mfw", "/lte/mfw/screencap_benchmark", "测速报告", schema.Object(
+			schema.RequiredField("success", schema.Boolean()),
+			schema.RequiredField("report", schema.Of(mfw.ScreencapBenchReport{})),
+			schema.RequiredField("saved", schema.Boolean()),
+			schema.Field("profile", schema.Of(mfw.ControllerProfile{})),
+			schema.Field("save_error", schema.String()),
+		)),
+
+		// 任务与资源
+		schema.Request("mfw", "/etl/mfw/execute_action", "执行单个节点的动作", schema.Object(
+			controllerID,
+			schema.RequiredField("resource_path", schema.String()),
+			schema.RequiredField("entry", schema.String()),
+			schema.Field("pipeline_override", pipelineOverride),
+		)),
+		schema.Push("mfw", "/lte/mfw/execute_action_result", "节点执行完成", schema.Object(
+			schema.RequiredField("success", schema.Boolean()),
+			schema.Field("message", schema.String()),
+		)),
+		schema.Request("mfw", "/etl/mfw/submit_task", "提交任务", schema.Object(
+			controllerID,
+			schema.RequiredField("resource_id", schema.String()),
+			schema.RequiredField("entry", schema.String()),
+			schema.Field("pipeline_override", pipelineOverride),
+		)),
+		schema.Request("mfw", "/etl/mfw/query_task_status", "查询任务状态", schema.Object(
+			schema.RequiredField("task_id", schema.Integer()),
+		)),
+		schema.Request("mfw", "/etl/mfw/stop_task", "停止任务", schema.Object(
+			schema.RequiredField("task_id", schema.Integer()),
+		)),
+		schema.Push("mfw", "/lte/mfw/task_submitted", "任务已提交", schema.Object(
+			schema.RequiredField("task_id", schema.Integer()),
+			schema.RequiredField("controller_id", schema.String()),
+			schema.RequiredField("resource_id", schema.String()),
+			schema.RequiredField("entry", schema.String()),
+		)),
+		schema.Push("mfw", "/lte/mfw/task_status", "任务状态", schema.Object(
+			schema.RequiredField("task_id", schema.Integer()),
+			schema.RequiredField("status", schema.String()),
+		)),
+		schema.Request("mfw", "/etl/mfw/load_resource", "加载资源，路径可指向 pipeline 所在目录，自动解析资源包", schema.Object(
+			schema.RequiredField("resource_path", schema.String()),
+		)),
+		schema.Push("mfw", "/lte/mfw/resource_loaded", "资源已加载", schema.Object(
+			schema.RequiredField("resource_id", schema.String()),
+			schema.RequiredField("resource_hash", schema.String()),
+			schema.RequiredField("resource_path", schema.String()),
+			schema.RequiredField("input_resource_path", schema.String()),
+			schema.Field("resource_resolution", schema.Object().Describe("资源包解析诊断")),
+			schema.RequiredField("resolved_resource_path", schema.String()),
+		)),
+	}
+}
+
+// MFW 协议提供的功能标识，MaaFramework 未初始化时为空
+func (h *MFWHandler) Capabilities() []string {
+	if h.service == nil || !h.service.IsInitialized() {
+		return nil
+	}
+	return []string{
+		"mfw",
+		"mfw.controller_health",
+		"mfw.screen_stream",
+		"mfw.screencap_benchmark",
+		"mfw.execute_action",
+	}
+}
@@ -0,0 +1,92 @@
+package mfw
+
+import (
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/errors"
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/logger"
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/mfw"
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/server"
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/pkg/models"
+)
+
+// 开始实时画面推流，连接断开或调试运行独占控制器时自动停止
+func (h *MFWHandler) handleStartScreenStream(conn *server.Connection, msg models.Message) {
+	dataMap, ok := msg.Data.(map[string]interface{})
+	if !ok {
+		h.sendError(conn, errors.NewInvalidRequestError("请求数据格式错误"))
+		return
+	}
+
+	options := mfw.StreamOptions{}
+	options.ControllerID, _ = dataMap["controller_id"].(string)
+	options.Format, _ = dataMap["format"].(string)
+	if fps, ok := dataMap["fps"].(float64); ok {
+		options.FPS = int(fps)
+	}
+	if quality, ok := dataMap["quality"].(float64); ok {
+		options.Quality = int(quality)
+	}
+	if maxLongSide, ok := dataMap["max_long_side"].(float64); ok && maxLongSide > 0 {
+		options.MaxLongSide = int32(maxLongSide)
+	}
+	if err := options.Normalize(); err != nil {
+		h.sendMFWError(conn, mfw.ErrCodeInvalidParameter, "推流参数无效", err.Error())
+		return
+	}
+
+	// 画面帧与停止通知属于推送，经原连接发送，不带开始请求的关联 ID
+	stream := conn.Root()
+	streamID, effective, err := h.service.StreamManager().Start(options, mfw.StreamTarget{
+		Send: func(frame mfw.StreamFrame) {
+			stream.Send(models.Message{
+				Path: "/lte/mfw/screen_stream_frame",
+				Data: frame,
+			})
+		},
+		Backlog: stream.Backlog,
+		Done:    stream.Done(),
+		OnStop: func(streamID, reason string) {
+			if reason == mfw.StreamStopReasonConnection {
+				return
+			}
+			stream.Send(models.Message{
+				Path: "/lte/mfw/screen_stream_stopped",
+				Data: map[string]interface{}{
+					"stream_id":     streamID,
+					"controller_id": options.ControllerID,
+					"reason":        reason,
+				},
+			})
+		},
+	})
+	if err != nil {
+		logger.Warn("MFW", "开始实时推流失败: %v", err)
+		h.sendMFWError(conn, mfw.ErrCodeScreencapFailed, "开始实时推流失败", err.Error())
+		return
+	}
+
+	conn.Send(models.Message{
+		Path: "/lte/mfw/screen_stream_started",
+		Data: map[string]interface{}{
+			"stream_id":     streamID,
+			"controller_id": effective.ControllerID,
+			"fps":           effective.FPS,
+			"format":        effective.Format,
+			"quality":       effective.Quality,
+			"max_long_side": effective.MaxLongSide,
+		},
+	})
+}
+
+// 停止实时画面推流
+func (h *MFWHandler) handleStopScreenStream(conn *server.Connection, msg models.Message) {
+	dataMap, ok := msg.Data.(map[string]interface{})
+	if !ok {
+		h.sendError(conn, errors.NewInvalidRequestError("请求数据格式错误"))
+		return
+	}
+
+	streamID, _ := dataMap["stream_id"].(string)
+	if !h.service.StreamManager().Stop(streamID, mfw.StreamStopReasonClient) {
+		h.sendMFWError(conn, mfw.ErrCodeInvalidParameter, "推流不存在或已停止", streamID)
+	}
+}
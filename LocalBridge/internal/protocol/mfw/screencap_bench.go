@@ -0,0 +1,65 @@
+package mfw
+
+import (
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/errors"
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/logger"
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/mfw"
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/server"
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/pkg/models"
+)
+
+// 截图方法测速，每个方法完成后推送进度，最后返回完整报告
+func (h *MFWHandler) handleBenchmarkScreencap(conn *server.Connection, msg models.Message) {
+	dataMap, ok := msg.Data.(map[string]interface{})
+	if !ok {
+		h.sendError(conn, errors.NewInvalidRequestError("请求数据格式错误"))
+		return
+	}
+
+	req := mfw.ScreencapBenchRequest{}
+	req.Type, _ = dataMap["type"].(string)
+	req.AdbPath, _ = dataMap["adb_path"].(string)
+	req.Address, _ = dataMap["address"].(string)
+	req.Config, _ = dataMap["config"].(string)
+	req.Hwnd, _ = dataMap["hwnd"].(string)
+	methods, _ := dataMap["methods"].([]interface{})
+	req.Methods = h.convertInterfaceSliceToStringSlice(methods)
+	if frames, ok := dataMap["frames"].(float64); ok {
+		req.Frames = int(frames)
+	}
+	save, _ := dataMap["save"].(bool)
+
+	report, err := mfw.BenchmarkScreencap(req, func(result mfw.ScreencapMethodResult) {
+		conn.Send(models.Message{
+			Path: "/lte/mfw/screencap_benchmark_progress",
+			Data: result,
+		})
+	})
+	if err != nil {
+		logger.Error("MFW", "截图测速失败: %v", err)
+		h.sendMFWError(conn, mfw.ErrCodeInvalidParameter, "截图测速失败", err.Error())
+		return
+	}
+
+	data := map[string]interface{}{
+		"success": true,
+		"report":  report,
+		"saved":   false,
+	}
+	if save {
+		profile, err := mfw.SaveBenchRecommendation(h.service.DeviceManager().Profiles(), report)
+		if err != nil {
+			logger.Warn("MFW", "保存截图测速结果失败: %v", err)
+			data["save_error"] = err.Error()
+		} else {
+			logger.Info("MFW", "已保存推荐截图方法: %s -> %v", profile.Key, profile.ScreencapMethods)
+			data["saved"] = true
+			data["profile"] = profile
+		}
+	}
+
+	conn.Send(models.Message{
+		Path: "/lte/mfw/screencap_benchmark",
+		Data: data,
+	})
+}
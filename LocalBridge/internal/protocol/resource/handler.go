@@ -109,7 +109,7 @@ func (h *Handler) handleRefreshResources(msg models.Message, conn *server.Connec
 	if err := h.resourceService.Scan(); err != nil {
 		logger.Error("ResourceProtocol", "刷新资源失败: %v", err)
 	}
-	h.pushResourceBundles()
+	h.pushResourceBundles(conn)
 	return nil
 }
 
@@ -221,24 +221,29 @@ func (h *Handler) subscribeEvents() {
 	// 订阅连接建立事件
 	h.eventBus.Subscribe(eventbus.EventConnectionEstablished, func(event eventbus.Event) {
 		// 推送资源包列表
-		h.pushResourceBundles()
+		h.pushResourceBundles(nil)
 	})
 
 	// 订阅资源扫描完成事件
 	h.eventBus.Subscribe(eventbus.EventResourceScanCompleted, func(event eventbus.Event) {
 		// 推送资源包列表
-		h.pushResourceBundles()
+		h.pushResourceBundles(nil)
 	})
 }
 
-// 推送资源包列表
-func (h *Handler) pushResourceBundles() {
+// 推送资源包列表，由请求触发时 requester 收到带关联 ID 的副本
+func (h *Handler) pushResourceBundles(requester *server.Connection) {
 	bundleList := h.resourceService.GetBundleList()
 
-	h.wsServer.Broadcast(models.Message{
+	msg := models.Message{
 		Path: "/lte/resource_bundles",
 		Data: bundleList,
-	})
+	}
+	if requester != nil {
+		h.wsServer.BroadcastReply(msg, requester)
+	} else {
+		h.wsServer.Broadcast(msg)
+	}
 
 	logger.Debug("ResourceProtocol", "推送资源包列表，共 %d 个资源包，%d 个 image 目录",
 		len(bundleList.Bundles), len(bundleList.ImageDirs))
@@ -0,0 +1,29 @@
+package resource
+
+import (
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/schema"
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/pkg/models"
+)
+
+// 声明资源协议的消息结构
+func (h *Handler) MessageSchemas() []schema.Message {
+	return []schema.Message{
+		schema.Request("resource", "/etl/get_image", "按相对 image 目录的路径读取图片",
+			schema.Of(models.GetImageRequest{}).Require("relative_path")),
+		schema.Request("resource", "/etl/get_images", "批量读取图片",
+			schema.Of(models.GetImagesRequest{}).Require("relative_paths")),
+		schema.Request("resource", "/etl/get_image_list", "列出图片，指定 pipeline_path 时仅返回所属资源包",
+			schema.Of(models.GetImageListRequest{})),
+		schema.Request("resource", "/etl/refresh_resources", "重新扫描资源包，结果以 /lte/resource_bundles 广播", schema.Object()),
+
+		schema.Push("resource", "/lte/image", "图片数据", schema.Of(models.GetImageResponse{})),
+		schema.Push("resource", "/lte/images", "批量图片数据", schema.Of(models.GetImagesResponse{})),
+		schema.Push("resource", "/lte/image_list", "图片列表", schema.Of(models.GetImageListResponse{})),
+		schema.Push("resource", "/lte/resource_bundles", "资源包列表", schema.Of(models.ResourceBundleListData{})),
+	}
+}
+
+// 资源协议提供的功能标识
+func (h *Handler) Capabilities() []string {
+	return []string{"resource", "resource.image_list"}
+}
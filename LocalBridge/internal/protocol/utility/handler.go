@@ -13,6 +13,7 @@ import (
 	"path/filepath"
 	"runtime"
 	"strings"
+	"sync"
 
 	maa "github.com/MaaXYZ/maa-framework-go/v4"
 	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/config"
@@ -20,23 +21,40 @@ import (
 	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/logger"
 	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/mfw"
 	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/server"
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/support"
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/workspace"
 	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/pkg/models"
 )
 
 // Utility协议处理器
 type UtilityHandler struct {
 	mfwService *mfw.Service
-	root       string // 根目录路径
+	mu         sync.RWMutex
+	roots      workspace.Roots // 工作区根目录
+	support    support.Sources // 诊断包的数据来源
 }
 
 // 创建Utility协议处理器
-func NewUtilityHandler(mfwService *mfw.Service, root string) *UtilityHandler {
+func NewUtilityHandler(mfwService *mfw.Service, roots workspace.Roots) *UtilityHandler {
 	return &UtilityHandler{
 		mfwService: mfwService,
-		root:       root,
+		roots:      roots,
 	}
 }
 
+// SetRoots 切换查找图片、日志与换算分辨率使用的根目录
+func (h *UtilityHandler) SetRoots(roots workspace.Roots) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.roots = roots
+}
+
+func (h *UtilityHandler) workspaceRoots() workspace.Roots {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.roots
+}
+
 // 返回处理的路由前缀
 func (h *UtilityHandler) GetRoutePrefix() []string {
 	return []string{"/etl/utility/"}
@@ -67,6 +85,12 @@ func (h *UtilityHandler) Handle(msg models.Message, conn *server.Connection) *mo
 	case "/etl/utility/open_maafw_log_dir":
 		h.handleOpenMaafwLogDir(conn, msg)
 
+	case "/etl/utility/convert_resolution":
+		h.handleConvertResolution(conn, msg)
+
+	case "/etl/utility/support_bundle":
+		h.handleSupportBundle(conn, msg)
+
 	default:
 		logger.Warn("Utility", "未知的Utility路由: %s", path)
 		h.sendError(conn, errors.NewInvalidRequestError("未知的Utility路由: "+path))
@@ -546,8 +570,8 @@ func (h *UtilityHandler) handleResolveImagePath(conn *server.Connection, msg mod
 
 	logger.Debug("Utility", "解析图片路径 - 文件名: %s", fileName)
 
-	// 在根目录下搜索所有 image 目录中的文件
-	result, imageDir, err := h.searchFileInAllImageDirs(h.root, fileName)
+	// 在各根目录下搜索所有 image 目录中的文件
+	result, imageDir, err := h.searchFileInAllRoots(fileName)
 	if err != nil {
 		logger.Error("Utility", "搜索文件失败: %v", err)
 		conn.Send(models.Message{
@@ -600,6 +624,25 @@ type fileSearchResult struct {
 	LastModified int64
 }
 
+// 在所有根目录的 image 目录中搜索文件，返回修改时间最新的结果
+func (h *UtilityHandler) searchFileInAllRoots(fileName string) (*fileSearchResult, string, error) {
+	var latestFile *fileSearchResult
+	var latestImageDir string
+
+	for _, root := range h.workspaceRoots() {
+		result, imageDir, err := h.searchFileInAllImageDirs(root.Path, fileName)
+		if err != nil {
+			return nil, "", err
+		}
+		if result != nil && (latestFile == nil || result.LastModified > latestFile.LastModified) {
+			latestFile = result
+			latestImageDir = imageDir
+		}
+	}
+
+	return latestFile, latestImageDir, nil
+}
+
 // 在所有 image 目录中搜索文件
 func (h *UtilityHandler) searchFileInAllImageDirs(root string, fileName string) (*fileSearchResult, string, error) {
 	var latestFile *fileSearchResult
@@ -683,7 +726,7 @@ func (h *UtilityHandler) handleOpenLog(conn *server.Connection, msg models.Messa
 		logDir = cfg.Log.Dir
 	} else {
 		// 使用默认日志目录
-		logDir = filepath.Join(h.root, "debug")
+		logDir = filepath.Join(h.workspaceRoots().Primary().Path, "debug")
 	}
 
 	// 构建 maa.log 路径
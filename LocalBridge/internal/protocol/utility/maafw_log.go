@@ -8,26 +8,18 @@ import (
 
 	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/config"
 	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/logger"
-	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/paths"
 	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/server"
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/support"
 	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/pkg/models"
 )
 
-// maafw.log 文件名
-const maafwLogFileName = "maafw.log"
-
 // 读取日志尾部时的最大字节数（约 256KB）
 const maafwLogTailLimit int64 = 256 * 1024
 
 // 解析 maafw.log 所在目录与完整路径
 func resolveMaafwLogPath() (logDir string, logPath string) {
-	cfg := config.GetGlobal()
-	if cfg != nil && cfg.Log.Dir != "" {
-		logDir = cfg.Log.Dir
-	} else {
-		logDir = paths.GetLogDir()
-	}
-	logPath = filepath.Join(logDir, maafwLogFileName)
+	logDir = support.LogDir(config.GetGlobal())
+	logPath = filepath.Join(logDir, support.MaafwLogFileName)
 	return logDir, logPath
 }
 
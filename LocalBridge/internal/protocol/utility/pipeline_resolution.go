@@ -0,0 +1,564 @@
+package utility
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/logger"
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/mfw"
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/server"
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/workspace"
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/pkg/models"
+	"github.com/tailscale/hujson"
+	xdraw "golang.org/x/image/draw"
+)
+
+// Pipeline 分辨率转换：将按某一分辨率编写的 pipeline 坐标（roi、target、target_offset、
+// 滑动的 begin/end 等）以及可选的模板图按比例换算到另一分辨率。
+//
+// 直接在 hujson 语法树上修改数值，保留原文件的字段顺序、注释与格式。
+
+const resolutionConvertedRoute = "/lte/utility/resolution_converted"
+
+// 需要按分辨率换算的坐标字段
+var resolutionCoordinateKeys = map[string]bool{
+	"roi":           true,
+	"roi_offset":    true,
+	"target":        true,
+	"target_offset": true,
+	"begin":         true,
+	"begin_offset":  true,
+	"end":           true,
+	"end_offset":    true,
+}
+
+// 用户自定义参数不参与换算
+var resolutionSkipKeys = map[string]bool{
+	"custom_recognition_param": true,
+	"custom_action_param":      true,
+	"attach":                   true,
+}
+
+// 模板图支持的扩展名
+var resolutionTemplateExts = map[string]bool{
+	".png":  true,
+	".jpg":  true,
+	".jpeg": true,
+}
+
+// 分辨率尺寸
+type resolutionSize struct {
+	Width  int32 `json:"width"`
+	Height int32 `json:"height"`
+}
+
+// 单个坐标字段的换算结果
+type coordinateChange struct {
+	Node   string  `json:"node"`
+	Field  string  `json:"field"`
+	Before []int64 `json:"before"`
+	After  []int64 `json:"after"`
+}
+
+// 单张模板图的换算结果
+type templateChange struct {
+	Template   string         `json:"template"`
+	Nodes      []string       `json:"nodes"`
+	SourcePath string         `json:"source_path,omitempty"`
+	OutputPath string         `json:"output_path,omitempty"`
+	Before     resolutionSize `json:"before"`
+	After      resolutionSize `json:"after"`
+	Error      string         `json:"error,omitempty"`
+}
+
+// 转换报告
+type resolutionConvertReport struct {
+	Success        bool               `json:"success"`
+	DryRun         bool               `json:"dry_run"`
+	FilePath       string             `json:"file_path"`
+	OutputPath     string             `json:"output_path,omitempty"`
+	From           resolutionSize     `json:"from"`
+	To             resolutionSize     `json:"to"`
+	ScaleX         float64            `json:"scale_x"`
+	ScaleY         float64            `json:"scale_y"`
+	AspectMismatch bool               `json:"aspect_mismatch"`
+	NodeCount      int                `json:"node_count"`
+	ChangedNodes   int                `json:"changed_nodes"`
+	Changes        []coordinateChange `json:"changes"`
+	Templates      []templateChange   `json:"templates"`
+	Warnings       []string           `json:"warnings"`
+	Message        string             `json:"message,omitempty"`
+}
+
+// pipeline 坐标换算器
+type pipelineScaler struct {
+	scaleX        float64
+	scaleY        float64
+	changes       []coordinateChange
+	templateNodes map[string][]string
+	warnings      []string
+}
+
+func newPipelineScaler(from, to resolutionSize) *pipelineScaler {
+	return &pipelineScaler{
+		scaleX:        float64(to.Width) / float64(from.Width),
+		scaleY:        float64(to.Height) / float64(from.Height),
+		templateNodes: make(map[string][]string),
+	}
+}
+
+// convert 换算整个 pipeline 文件，返回新内容与节点数
+func (s *pipelineScaler) convert(content []byte) ([]byte, int, error) {
+	root, err := hujson.Parse(content)
+	if err != nil {
+		return nil, 0, fmt.Errorf("解析 pipeline 失败: %w", err)
+	}
+	object, ok := root.Value.(*hujson.Object)
+	if !ok {
+		return nil, 0, fmt.Errorf("pipeline 顶层必须是对象")
+	}
+
+	nodeCount := 0
+	for i := range object.Members {
+		member := &object.Members[i]
+		name := resolutionMemberName(member)
+		if name == "" || strings.HasPrefix(name, "$") {
+			continue
+		}
+		node, ok := member.Value.Value.(*hujson.Object)
+		if !ok {
+			continue
+		}
+		nodeCount++
+		s.walkObject(name, "", node)
+	}
+
+	root.UpdateOffsets()
+	return root.Pack(), nodeCount, nil
+}
+
+func (s *pipelineScaler) walkObject(node, prefix string, object *hujson.Object) {
+	for i := range object.Members {
+		member := &object.Members[i]
+		key := resolutionMemberName(member)
+		if key == "" || strings.HasPrefix(key, "$") || resolutionSkipKeys[key] {
+			continue
+		}
+		field := key
+		if prefix != "" {
+			field = prefix + "." + key
+		}
+
+		switch {
+		case resolutionCoordinateKeys[key]:
+			s.convertCoordinate(node, field, &member.Value)
+		case key == "template":
+			s.collectTemplates(node, member.Value)
+		default:
+			s.walkValue(node, field, &member.Value)
+		}
+	}
+}
+
+func (s *pipelineScaler) walkValue(node, field string, value *hujson.Value) {
+	switch typed := value.Value.(type) {
+	case *hujson.Object:
+		s.walkObject(node, field, typed)
+	case *hujson.Array:
+		for i := range typed.Elements {
+			s.walkValue(node, fmt.Sprintf("%s[%d]", field, i), &typed.Elements[i])
+		}
+	}
+}
+
+// convertCoordinate 换算 [x, y] / [x, y, w, h] 数组，嵌套数组（如多段滑动 end）逐项换算。
+// 节点名引用、true 等非数组值保持不变。
+func (s *pipelineScaler) convertCoordinate(node, field string, value *hujson.Value) {
+	array, ok := value.Value.(*hujson.Array)
+	if !ok || len(array.Elements) == 0 {
+		return
+	}
+
+	if _, nested := array.Elements[0].Value.(*hujson.Array); nested {
+		for i := range array.Elements {
+			s.convertCoordinate(node, fmt.Sprintf("%s[%d]", field, i), &array.Elements[i])
+		}
+		return
+	}
+
+	if len(array.Elements) != 2 && len(array.Elements) != 4 {
+		s.warnings = append(s.warnings, fmt.Sprintf("%s.%s 不是有效的坐标数组，已跳过", node, field))
+		return
+	}
+
+	before := make([]int64, len(array.Elements))
+	for i := range array.Elements {
+		literal, ok := array.Elements[i].Value.(hujson.Literal)
+		if !ok || literal.Kind() != '0' {
+			s.warnings = append(s.warnings, fmt.Sprintf("%s.%s 包含非数字元素，已跳过", node, field))
+			return
+		}
+		number, err := strconv.ParseFloat(string(literal), 64)
+		if err != nil {
+			s.warnings = append(s.warnings, fmt.Sprintf("%s.%s 数值无效，已跳过", node, field))
+			return
+		}
+		before[i] = int64(math.Round(number))
+	}
+
+	after := scaleCoordinate(before, s.scaleX, s.scaleY)
+	changed := false
+	for i := range after {
+		if after[i] != before[i] {
+			changed = true
+		}
+		array.Elements[i].Value = hujson.Int(after[i])
+	}
+	if changed {
+		s.changes = append(s.changes, coordinateChange{Node: node, Field: field, Before: before, After: after})
+	}
+}
+
+func (s *pipelineScaler) collectTemplates(node string, value hujson.Value) {
+	addTemplate := func(literal hujson.Literal) {
+		if literal.Kind() != '"' {
+			return
+		}
+		template := literal.String()
+		if template == "" {
+			return
+		}
+		nodes := s.templateNodes[template]
+		if len(nodes) == 0 || nodes[len(nodes)-1] != node {
+			s.templateNodes[template] = append(nodes, node)
+		}
+	}
+
+	switch typed := value.Value.(type) {
+	case hujson.Literal:
+		addTemplate(typed)
+	case *hujson.Array:
+		for _, element := range typed.Elements {
+			if literal, ok := element.Value.(hujson.Literal); ok {
+				addTemplate(literal)
+			}
+		}
+	}
+}
+
+// scaleCoordinate 按轴向比例换算坐标，x/宽度使用 scaleX，y/高度使用 scaleY
+func scaleCoordinate(values []int64, scaleX, scaleY float64) []int64 {
+	result := make([]int64, len(values))
+	for i, value := range values {
+		scale := scaleX
+		if i%2 == 1 {
+			scale = scaleY
+		}
+		result[i] = int64(math.Round(float64(value) * scale))
+	}
+	return result
+}
+
+func resolutionMemberName(member *hujson.ObjectMember) string {
+	literal, ok := member.Name.Value.(hujson.Literal)
+	if !ok || literal.Kind() != '"' {
+		return ""
+	}
+	return literal.String()
+}
+
+// parseResolutionSize 解析分辨率参数。
+// 支持直接给出 width/height，或给出设备原始分辨率 device_width/device_height
+// 加截图模式（target_long_side / target_short_side / use_raw_size，缺省为短边 720）。
+func parseResolutionSize(value interface{}, label string) (resolutionSize, error) {
+	values, ok := value.(map[string]interface{})
+	if !ok {
+		return resolutionSize{}, fmt.Errorf("%s 必须是对象", label)
+	}
+
+	width, _ := values["width"].(float64)
+	height, _ := values["height"].(float64)
+	if width > 0 && height > 0 {
+		return resolutionSize{Width: int32(width), Height: int32(height)}, nil
+	}
+
+	deviceWidth, _ := values["device_width"].(float64)
+	deviceHeight, _ := values["device_height"].(float64)
+	if deviceWidth <= 0 || deviceHeight <= 0 {
+		return resolutionSize{}, fmt.Errorf("%s 需要 width/height 或 device_width/device_height", label)
+	}
+
+	resolution, err := mfw.ParseOptionalScreenshotResolution(values)
+	if err != nil {
+		return resolutionSize{}, fmt.Errorf("%s: %w", label, err)
+	}
+	if resolution == nil {
+		defaultResolution := mfw.DefaultScreenshotResolution()
+		resolution = &defaultResolution
+	}
+	scaledWidth, scaledHeight := resolution.ScaledSize(int32(deviceWidth), int32(deviceHeight))
+	return resolutionSize{Width: scaledWidth, Height: scaledHeight}, nil
+}
+
+// 处理 pipeline 分辨率转换请求
+func (h *UtilityHandler) handleConvertResolution(conn *server.Connection, msg models.Message) {
+	dataMap, ok := msg.Data.(map[string]interface{})
+	if !ok {
+		h.sendUtilityError(conn, "INVALID_REQUEST", "请求数据格式错误", nil)
+		return
+	}
+
+	filePath, _ := dataMap["file_path"].(string)
+	outputPath, _ := dataMap["output_path"].(string)
+	dryRun, _ := dataMap["dry_run"].(bool)
+	includeTemplates, _ := dataMap["include_templates"].(bool)
+	templateOutputDir, _ := dataMap["template_output_dir"].(string)
+
+	if filePath == "" {
+		h.sendUtilityError(conn, "INVALID_REQUEST", "file_path 不能为空", nil)
+		return
+	}
+	from, err := parseResolutionSize(dataMap["from"], "from")
+	if err != nil {
+		h.sendUtilityError(conn, "INVALID_REQUEST", err.Error(), nil)
+		return
+	}
+	to, err := parseResolutionSize(dataMap["to"], "to")
+	if err != nil {
+		h.sendUtilityError(conn, "INVALID_REQUEST", err.Error(), nil)
+		return
+	}
+	if outputPath == "" {
+		outputPath = filePath
+	}
+	for _, path := range []string{filePath, outputPath, templateOutputDir} {
+		if path != "" && !h.isInsideRoot(path) {
+			h.sendUtilityError(conn, "PERMISSION_DENIED", "路径不在根目录范围内", path)
+			return
+		}
+	}
+
+	logger.Info("Utility", "转换 pipeline 分辨率: %s (%dx%d -> %dx%d, dry_run=%v)",
+		filePath, from.Width, from.Height, to.Width, to.Height, dryRun)
+
+	report := h.convertPipelineFile(filePath, outputPath, from, to, dryRun, includeTemplates, templateOutputDir)
+	if report.Success && !dryRun {
+		logger.Info("Utility", "pipeline 分辨率转换完成: %s, 修改 %d 处坐标", outputPath, len(report.Changes))
+	} else if !report.Success {
+		logger.Warn("Utility", "pipeline 分辨率转换失败: %s", report.Message)
+	}
+	conn.Send(models.Message{
+		Path: resolutionConvertedRoute,
+		Data: report,
+	})
+}
+
+// convertPipelineFile 执行转换并生成报告，dryRun 时不写入任何文件
+func (h *UtilityHandler) convertPipelineFile(
+	filePath, outputPath string,
+	from, to resolutionSize,
+	dryRun, includeTemplates bool,
+	templateOutputDir string,
+) resolutionConvertReport {
+	scaler := newPipelineScaler(from, to)
+	report := resolutionConvertReport{
+		DryRun:     dryRun,
+		FilePath:   filePath,
+		OutputPath: outputPath,
+		From:       from,
+		To:         to,
+		ScaleX:     scaler.scaleX,
+		ScaleY:     scaler.scaleY,
+		Changes:    []coordinateChange{},
+		Templates:  []templateChange{},
+		Warnings:   []string{},
+	}
+
+	// 宽高比不同时坐标按轴独立缩放，提示可能需要人工复核
+	if math.Abs(scaler.scaleX-scaler.scaleY) > 0.01 {
+		report.AspectMismatch = true
+		report.Warnings = append(report.Warnings, "源分辨率与目标分辨率宽高比不同，坐标将按轴独立缩放，请人工复核")
+	}
+
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		report.Message = "读取 pipeline 失败: " + err.Error()
+		return report
+	}
+
+	converted, nodeCount, err := scaler.convert(content)
+	if err != nil {
+		report.Message = err.Error()
+		return report
+	}
+
+	report.NodeCount = nodeCount
+	report.Changes = append(report.Changes, scaler.changes...)
+	report.Warnings = append(report.Warnings, scaler.warnings...)
+	changedNodes := make(map[string]bool)
+	for _, change := range scaler.changes {
+		changedNodes[change.Node] = true
+	}
+	report.ChangedNodes = len(changedNodes)
+
+	if includeTemplates {
+		report.Templates = h.convertTemplates(filePath, scaler, to, dryRun, templateOutputDir, &report.Warnings)
+	}
+
+	if !dryRun {
+		if err := os.WriteFile(outputPath, converted, 0644); err != nil {
+			report.Message = "写入 pipeline 失败: " + err.Error()
+			return report
+		}
+	}
+
+	report.Success = true
+	return report
+}
+
+// convertTemplates 按比例缩放 pipeline 引用的模板图。
+// 未指定输出目录时写入资源包下的 image_<宽>x<高>，不覆盖原模板图
+func (h *UtilityHandler) convertTemplates(
+	filePath string,
+	scaler *pipelineScaler,
+	to resolutionSize,
+	dryRun bool,
+	outputDir string,
+	warnings *[]string,
+) []templateChange {
+	changes := []templateChange{}
+	if len(scaler.templateNodes) == 0 {
+		return changes
+	}
+
+	resolution, err := mfw.ResolveResourceBundlePath(filePath)
+	if err != nil {
+		*warnings = append(*warnings, "无法定位 pipeline 所属资源包，已跳过模板图: "+err.Error())
+		return changes
+	}
+	imageDir := filepath.Join(resolution.ResolvedPath, "image")
+	if outputDir == "" {
+		outputDir = filepath.Join(resolution.ResolvedPath, fmt.Sprintf("image_%dx%d", to.Width, to.Height))
+		*warnings = append(*warnings, "未指定 template_output_dir，模板图将写入 "+outputDir)
+	}
+	if !h.isInsideRoot(imageDir) || !h.isInsideRoot(outputDir) {
+		*warnings = append(*warnings, "模板图目录不在根目录范围内，已跳过模板图")
+		return changes
+	}
+
+	templates := make([]string, 0, len(scaler.templateNodes))
+	for template := range scaler.templateNodes {
+		templates = append(templates, template)
+	}
+	sort.Strings(templates)
+
+	for _, template := range templates {
+		nodes := scaler.templateNodes[template]
+		for _, relPath := range expandTemplatePath(imageDir, template) {
+			change := templateChange{
+				Template:   relPath,
+				Nodes:      nodes,
+				SourcePath: filepath.Join(imageDir, filepath.FromSlash(relPath)),
+				OutputPath: filepath.Join(outputDir, filepath.FromSlash(relPath)),
+			}
+			// 模板路径来自 pipeline 内容，须确认读写位置没有越出模板图目录
+			if !workspace.Contains(imageDir, change.SourcePath) || !workspace.Contains(outputDir, change.OutputPath) {
+				change.SourcePath = ""
+				change.OutputPath = ""
+				change.Error = "模板路径超出模板图目录"
+			} else if err := scaleTemplateImage(&change, scaler.scaleX, scaler.scaleY, dryRun); err != nil {
+				change.Error = err.Error()
+			}
+			changes = append(changes, change)
+		}
+	}
+	return changes
+}
+
+// expandTemplatePath 展开模板路径，目录模板会展开为目录下所有图片
+func expandTemplatePath(imageDir, template string) []string {
+	absPath := filepath.Join(imageDir, filepath.FromSlash(template))
+	if !workspace.Contains(imageDir, absPath) {
+		return []string{filepath.ToSlash(template)}
+	}
+	info, err := os.Stat(absPath)
+	if err != nil || !info.IsDir() {
+		return []string{filepath.ToSlash(template)}
+	}
+
+	var result []string
+	filepath.Walk(absPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() || !resolutionTemplateExts[strings.ToLower(filepath.Ext(path))] {
+			return nil
+		}
+		if relPath, err := filepath.Rel(imageDir, path); err == nil {
+			result = append(result, filepath.ToSlash(relPath))
+		}
+		return nil
+	})
+	return result
+}
+
+func scaleTemplateImage(change *templateChange, scaleX, scaleY float64, dryRun bool) error {
+	ext := strings.ToLower(filepath.Ext(change.SourcePath))
+	if !resolutionTemplateExts[ext] {
+		return fmt.Errorf("不支持的模板图格式: %s", ext)
+	}
+
+	file, err := os.Open(change.SourcePath)
+	if err != nil {
+		return fmt.Errorf("读取模板图失败: %w", err)
+	}
+	source, _, err := image.Decode(file)
+	file.Close()
+	if err != nil {
+		return fmt.Errorf("解码模板图失败: %w", err)
+	}
+
+	bounds := source.Bounds()
+	change.Before = resolutionSize{Width: int32(bounds.Dx()), Height: int32(bounds.Dy())}
+	change.After = resolutionSize{
+		Width:  int32(max(1, int(math.Round(float64(bounds.Dx())*scaleX)))),
+		Height: int32(max(1, int(math.Round(float64(bounds.Dy())*scaleY)))),
+	}
+	if dryRun {
+		return nil
+	}
+
+	destination := image.NewRGBA(image.Rect(0, 0, int(change.After.Width), int(change.After.Height)))
+	xdraw.CatmullRom.Scale(destination, destination.Bounds(), source, bounds, draw.Src, nil)
+
+	if err := os.MkdirAll(filepath.Dir(change.OutputPath), 0755); err != nil {
+		return fmt.Errorf("创建输出目录失败: %w", err)
+	}
+	output, err := os.Create(change.OutputPath)
+	if err != nil {
+		return fmt.Errorf("写入模板图失败: %w", err)
+	}
+	defer output.Close()
+
+	if ext == ".png" {
+		err = png.Encode(output, destination)
+	} else {
+		err = jpeg.Encode(output, destination, &jpeg.Options{Quality: 95})
+	}
+	if err != nil {
+		return fmt.Errorf("编码模板图失败: %w", err)
+	}
+	return nil
+}
+
+// isInsideRoot 检查路径是否位于任一根目录内
+func (h *UtilityHandler) isInsideRoot(path string) bool {
+	_, ok := h.workspaceRoots().Locate(path)
+	return ok
+}
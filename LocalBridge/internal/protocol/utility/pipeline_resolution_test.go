@@ -0,0 +1,236 @@
+package utility
+
+import (
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/workspace"
+)
+
+const resolutionTestPipeline = `{
+    // 入口节点
+    "Start": {
+        "recognition": "TemplateMatch",
+        "template": ["start.png", "icons/start_alt.png"],
+        "roi": [100, 200, 300, 150],
+        "action": "Click",
+        "target": true,
+        "target_offset": [10, -10, 0, 0],
+        "next": ["Swipe"]
+    },
+    "Swipe": {
+        "recognition": {
+            "type": "DirectHit",
+            "param": { "roi": "Start" }
+        },
+        "action": {
+            "type": "Swipe",
+            "param": {
+                "begin": [640, 360],
+                "end": [[640, 100], [320, 100, 10, 10]]
+            }
+        },
+        "custom_action_param": { "roi": [1, 2, 3, 4] },
+    },
+    "$mpe": { "roi": [1, 2, 3, 4] }
+}`
+
+func TestPipelineScalerConvert(t *testing.T) {
+	scaler := newPipelineScaler(resolutionSize{Width: 1280, Height: 720}, resolutionSize{Width: 1920, Height: 1080})
+	converted, nodeCount, err := scaler.convert([]byte(resolutionTestPipeline))
+	if err != nil {
+		t.Fatalf("convert() error = %v", err)
+	}
+	if nodeCount != 2 {
+		t.Fatalf("nodeCount = %d, want 2", nodeCount)
+	}
+
+	output := string(converted)
+	for _, want := range []string{
+		"// 入口节点",
+		`"roi": [150, 300, 450, 225]`,
+		`"target_offset": [15, -15, 0, 0]`,
+		`"roi": "Start"`,
+		`"begin": [960, 540]`,
+		`"end": [[960, 150], [480, 150, 15, 15]]`,
+		`"custom_action_param": { "roi": [1, 2, 3, 4] }`,
+		`"$mpe": { "roi": [1, 2, 3, 4] }`,
+	} {
+		if !strings.Contains(output, want) {
+			t.Fatalf("converted output missing %q:\n%s", want, output)
+		}
+	}
+	if strings.Index(output, `"Start"`) > strings.Index(output, `"Swipe"`) {
+		t.Fatal("converted output must preserve node order")
+	}
+
+	fields := make([]string, 0, len(scaler.changes))
+	for _, change := range scaler.changes {
+		fields = append(fields, change.Node+":"+change.Field)
+	}
+	wantFields := []string{
+		"Start:roi",
+		"Start:target_offset",
+		"Swipe:action.param.begin",
+		"Swipe:action.param.end[0]",
+		"Swipe:action.param.end[1]",
+	}
+	if !reflect.DeepEqual(fields, wantFields) {
+		t.Fatalf("changed fields = %v, want %v", fields, wantFields)
+	}
+
+	if got := scaler.templateNodes["icons/start_alt.png"]; !reflect.DeepEqual(got, []string{"Start"}) {
+		t.Fatalf("template nodes = %v, want [Start]", got)
+	}
+}
+
+func TestParseResolutionSize(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   interface{}
+		want    resolutionSize
+		wantErr bool
+	}{
+		{name: "explicit", value: map[string]interface{}{"width": 1280.0, "height": 720.0}, want: resolutionSize{Width: 1280, Height: 720}},
+		{name: "raw mode", value: map[string]interface{}{"device_width": 1920.0, "device_height": 1080.0, "use_raw_size": true}, want: resolutionSize{Width: 1920, Height: 1080}},
+		{name: "default short side", value: map[string]interface{}{"device_width": 2560.0, "device_height": 1440.0}, want: resolutionSize{Width: 1280, Height: 720}},
+		{name: "long side", value: map[string]interface{}{"device_width": 1080.0, "device_height": 2400.0, "target_long_side": 1200.0}, want: resolutionSize{Width: 540, Height: 1200}},
+		{name: "missing", value: map[string]interface{}{}, wantErr: true},
+		{name: "conflicting modes", value: map[string]interface{}{"device_width": 1920.0, "device_height": 1080.0, "use_raw_size": true, "target_short_side": 720.0}, wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := parseResolutionSize(test.value, "to")
+			if (err != nil) != test.wantErr {
+				t.Fatalf("parseResolutionSize() error = %v, wantErr = %v", err, test.wantErr)
+			}
+			if !test.wantErr && got != test.want {
+				t.Fatalf("parseResolutionSize() = %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestConvertPipelineFileDryRunAndTemplates(t *testing.T) {
+	root := t.TempDir()
+	bundle := filepath.Join(root, "resource")
+	pipelinePath := filepath.Join(bundle, "pipeline", "main.json")
+	mustWriteTestFile(t, pipelinePath, resolutionTestPipeline)
+	mustWriteTestPNG(t, filepath.Join(bundle, "image", "start.png"), 40, 20)
+	mustWriteTestPNG(t, filepath.Join(bundle, "image", "icons", "start_alt.png"), 10, 10)
+
+	handler := NewUtilityHandler(nil, workspace.Single(root))
+	from := resolutionSize{Width: 1280, Height: 720}
+	to := resolutionSize{Width: 1920, Height: 1080}
+
+	report := handler.convertPipelineFile(pipelinePath, pipelinePath, from, to, true, true, "")
+	if !report.Success || report.ChangedNodes != 2 || len(report.Templates) != 2 {
+		t.Fatalf("dry run report = %+v", report)
+	}
+	if report.Templates[1].Template != "start.png" || report.Templates[1].After != (resolutionSize{Width: 60, Height: 30}) {
+		t.Fatalf("template report = %+v", report.Templates[1])
+	}
+	if content, _ := os.ReadFile(pipelinePath); string(content) != resolutionTestPipeline {
+		t.Fatal("dry run must not modify pipeline")
+	}
+
+	outputDir := filepath.Join(root, "converted_image")
+	report = handler.convertPipelineFile(pipelinePath, pipelinePath, from, to, false, true, outputDir)
+	if !report.Success {
+		t.Fatalf("convert report = %+v", report)
+	}
+	if content, _ := os.ReadFile(pipelinePath); !strings.Contains(string(content), `"roi": [150, 300, 450, 225]`) {
+		t.Fatalf("pipeline not converted:\n%s", content)
+	}
+	file, err := os.Open(filepath.Join(outputDir, "start.png"))
+	if err != nil {
+		t.Fatalf("open converted template error = %v", err)
+	}
+	defer file.Close()
+	config, err := png.DecodeConfig(file)
+	if err != nil {
+		t.Fatalf("decode converted template error = %v", err)
+	}
+	if config.Width != 60 || config.Height != 30 {
+		t.Fatalf("converted template size = %dx%d, want 60x30", config.Width, config.Height)
+	}
+}
+
+func TestConvertTemplatesKeepsSourcesAndStaysInImageDir(t *testing.T) {
+	root := t.TempDir()
+	bundle := filepath.Join(root, "resource")
+	pipelinePath := filepath.Join(bundle, "pipeline", "main.json")
+	mustWriteTestFile(t, pipelinePath, `{
+    "Start": { "recognition": "TemplateMatch", "template": ["start.png", "../../outside.png"] }
+}`)
+	mustWriteTestPNG(t, filepath.Join(bundle, "image", "start.png"), 40, 20)
+	mustWriteTestPNG(t, filepath.Join(root, "outside.png"), 40, 20)
+
+	handler := NewUtilityHandler(nil, workspace.Single(root))
+	report := handler.convertPipelineFile(pipelinePath, pipelinePath,
+		resolutionSize{Width: 1280, Height: 720}, resolutionSize{Width: 1920, Height: 1080}, false, true, "")
+	if !report.Success || len(report.Templates) != 2 {
+		t.Fatalf("convert report = %+v", report)
+	}
+	if report.Templates[0].Template != "../../outside.png" || report.Templates[0].Error == "" || report.Templates[0].OutputPath != "" {
+		t.Fatalf("template outside image dir = %+v, want rejected", report.Templates[0])
+	}
+
+	for path, width := range map[string]int{
+		filepath.Join(root, "outside.png"):                    40,
+		filepath.Join(bundle, "image", "start.png"):           40,
+		filepath.Join(bundle, "image_1920x1080", "start.png"): 60,
+	} {
+		file, err := os.Open(path)
+		if err != nil {
+			t.Fatalf("open %s error = %v", path, err)
+		}
+		config, err := png.DecodeConfig(file)
+		file.Close()
+		if err != nil || config.Width != width {
+			t.Fatalf("%s width = %d, %v; want %d", path, config.Width, err, width)
+		}
+	}
+}
+
+func TestIsInsideRoot(t *testing.T) {
+	root := t.TempDir()
+	handler := NewUtilityHandler(nil, workspace.Single(root))
+	if !handler.isInsideRoot(filepath.Join(root, "a", "b.json")) {
+		t.Fatal("isInsideRoot() = false for child path")
+	}
+	if handler.isInsideRoot(filepath.Join(root, "..", "outside.json")) {
+		t.Fatal("isInsideRoot() = true for parent path")
+	}
+}
+
+func mustWriteTestFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("MkdirAll(%q) error = %v", path, err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("WriteFile(%q) error = %v", path, err)
+	}
+}
+
+func mustWriteTestPNG(t *testing.T, path string, width, height int) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("MkdirAll(%q) error = %v", path, err)
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("Create(%q) error = %v", path, err)
+	}
+	defer file.Close()
+	if err := png.Encode(file, image.NewRGBA(image.Rect(0, 0, width, height))); err != nil {
+		t.Fatalf("png.Encode(%q) error = %v", path, err)
+	}
+}
@@ -0,0 +1,113 @@
+package utility
+
+import (
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/schema"
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/pkg/models"
+)
+
+// 声明 Utility 协议的消息结构
+func (h *UtilityHandler) MessageSchemas() []schema.Message {
+	roi := schema.Array(schema.Integer()).Describe("[x, y, width, height]")
+	roi["minItems"], roi["maxItems"] = 4, 4
+	box := schema.Object(
+		schema.RequiredField("x", schema.Integer()),
+		schema.RequiredField("y", schema.Integer()),
+		schema.RequiredField("width", schema.Integer()),
+		schema.RequiredField("height", schema.Integer()),
+		schema.Field("text", schema.String()),
+		schema.RequiredField("score", schema.Number()),
+	)
+	resolution := schema.Object(
+		schema.Field("width", schema.Integer()),
+		schema.Field("height", schema.Integer()),
+		schema.Field("device_width", schema.Integer()),
+		schema.Field("device_height", schema.Integer()),
+	).Describe("直接给出 width/height，或给出设备分辨率由截图缩放规则推算")
+	opened := schema.Object(
+		schema.RequiredField("success", schema.Boolean()),
+		schema.RequiredField("message", schema.String()),
+		schema.Field("path", schema.String()),
+	)
+
+	return []schema.Message{
+		schema.Request("utility", "/etl/utility/ocr_recognize", "对截图区域进行 OCR", schema.Object(
+			schema.RequiredField("base_image", schema.String().Describe("data URL")),
+			schema.Field("resource_id", schema.String()),
+			schema.Field("roi", roi),
+		)),
+		schema.Push("utility", "/lte/utility/ocr_result", "OCR 结果，失败时仅含 success 与 error", schema.Object(
+			schema.RequiredField("success", schema.Boolean()),
+			schema.Field("text", schema.String()),
+			schema.Field("boxes", schema.Array(box)),
+			schema.Field("image", schema.String()),
+			schema.Field("roi", roi),
+			schema.Field("no_content", schema.Boolean()),
+			schema.Field("error", schema.String()),
+		)),
+		schema.Request("utility", "/etl/utility/template_match", "在截图区域内匹配模板图", schema.Object(
+			schema.RequiredField("base_image", schema.String().Describe("data URL")),
+			schema.RequiredField("template_image", schema.String().Describe("data URL")),
+			schema.Field("roi", roi),
+			schema.Field("threshold", schema.Number()),
+			schema.Field("method", schema.Integer()),
+			schema.Field("green_mask", schema.Boolean()),
+		)),
+		schema.Push("utility", templateMatchRoute, "模板匹配结果，失败时含 code、error 与 detail", schema.Object(
+			schema.RequiredField("success", schema.Boolean()),
+			schema.Field("hit", schema.Boolean()),
+			schema.Field("best", schema.OneOf(box, schema.Null())),
+			schema.Field("all", schema.Array(box)),
+			schema.Field("image", schema.String()),
+			schema.Field("roi", roi),
+			schema.Field("detail_json", schema.String()),
+			schema.Field("code", schema.String()),
+			schema.Field("error", schema.String()),
+			schema.Field("detail", schema.Any()),
+		)),
+		schema.Request("utility", "/etl/utility/resolve_image_path", "按文件名查找资源图片", schema.Object(
+			schema.RequiredField("file_name", schema.String()),
+		)),
+		schema.Push("utility", "/lte/utility/image_path_resolved", "图片路径", schema.Of(models.ResolveImagePathResponse{})),
+		schema.Request("utility", "/etl/utility/open_log", "在文件管理器中打开日志目录", schema.Object()),
+		schema.Push("utility", "/lte/utility/log_opened", "打开结果", opened),
+		schema.Request("utility", "/etl/utility/read_maafw_log", "读取 maafw.log 尾部内容", schema.Object()),
+		schema.Push("utility", "/lte/utility/maafw_log_content", "maafw.log 内容", schema.Object(
+			schema.RequiredField("success", schema.Boolean()),
+			schema.RequiredField("exists", schema.Boolean()),
+			schema.RequiredField("dir", schema.String()),
+			schema.RequiredField("path", schema.String()),
+			schema.Field("content", schema.String()),
+			schema.Field("size", schema.Integer()),
+			schema.Field("truncated", schema.Boolean()),
+			schema.Field("modTime", schema.String()),
+			schema.Field("message", schema.String()),
+		)),
+		schema.Request("utility", "/etl/utility/open_maafw_log_dir", "在文件管理器中打开 MaaFramework 日志目录", schema.Object()),
+		schema.Push("utility", "/lte/utility/maafw_log_opened", "打开结果", schema.Object(
+			schema.RequiredField("success", schema.Boolean()),
+			schema.RequiredField("target", schema.String()),
+			schema.RequiredField("path", schema.String()),
+			schema.RequiredField("message", schema.String()),
+		)),
+		schema.Request("utility", "/etl/utility/convert_resolution", "按分辨率换算 pipeline 坐标与模板图", schema.Object(
+			schema.RequiredField("file_path", schema.String()),
+			schema.Field("output_path", schema.String()),
+			schema.Field("dry_run", schema.Boolean()),
+			schema.Field("include_templates", schema.Boolean()),
+			schema.Field("template_output_dir", schema.String()),
+			schema.RequiredField("from", resolution),
+			schema.RequiredField("to", resolution),
+		)),
+		schema.Push("utility", resolutionConvertedRoute, "换算报告", schema.Of(resolutionConvertReport{})),
+		schema.Request("utility", "/etl/utility/support_bundle", "生成诊断包并执行环境自检", schema.Object(
+			schema.Field("trace_limit", schema.Integer().Describe("打包的最近调试 trace 数量，默认 5")),
+			schema.Field("inline", schema.Boolean().Describe("同时返回 base64 编码的 zip 内容")),
+		)),
+		schema.Push("utility", supportBundleRoute, "诊断包路径与自检报告", schema.Of(supportBundleResult{})),
+	}
+}
+
+// Utility 协议提供的功能标识
+func (h *UtilityHandler) Capabilities() []string {
+	return []string{"utility.ocr", "utility.template_match", "utility.convert_resolution", "utility.maafw_log", "utility.support_bundle"}
+}
@@ -0,0 +1,78 @@
+package utility
+
+import (
+	"encoding/base64"
+	"os"
+	"time"
+
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/config"
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/logger"
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/server"
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/support"
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/pkg/models"
+)
+
+const supportBundleRoute = "/lte/utility/support_bundle"
+
+// 诊断包生成结果
+type supportBundleResult struct {
+	Success bool            `json:"success"`
+	Path    string          `json:"path,omitempty"`
+	Size    int64           `json:"size,omitempty"`
+	Content string          `json:"content,omitempty"` // inline 时为 base64 编码的 zip
+	Report  *support.Report `json:"report,omitempty"`
+	Message string          `json:"message,omitempty"`
+}
+
+// SetSupportSources 设置诊断包使用的版本号、资源包列表与调试 trace 来源
+func (h *UtilityHandler) SetSupportSources(version string, resources support.BundleSource, traces support.TraceSource) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.support = support.Sources{Version: version, Resources: resources, Traces: traces}
+}
+
+// 生成诊断包并保存到数据目录
+func (h *UtilityHandler) handleSupportBundle(conn *server.Connection, msg models.Message) {
+	dataMap, _ := msg.Data.(map[string]interface{})
+	traceLimit := support.DefaultTraceLimit
+	if value, ok := dataMap["trace_limit"].(float64); ok && value >= 0 {
+		traceLimit = int(value)
+	}
+	inline, _ := dataMap["inline"].(bool)
+
+	h.mu.RLock()
+	sources := h.support
+	h.mu.RUnlock()
+	sources.Config = config.GetGlobal()
+	sources.MaaFW = h.mfwService
+	sources.Serving = true
+
+	bundle := support.Collect(sources, traceLimit)
+	path := support.DefaultOutputPath(time.Now())
+	if err := bundle.WriteFile(path); err != nil {
+		h.sendSupportBundleResult(conn, supportBundleResult{Report: &bundle.Report, Message: err.Error()})
+		return
+	}
+	logger.Info("Utility", "诊断包已生成: %s", path)
+
+	result := supportBundleResult{Success: true, Path: path, Report: &bundle.Report}
+	if info, err := os.Stat(path); err == nil {
+		result.Size = info.Size()
+	}
+	if inline {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			h.sendSupportBundleResult(conn, supportBundleResult{Path: path, Report: &bundle.Report, Message: "读取诊断包失败: " + err.Error()})
+			return
+		}
+		result.Content = base64.StdEncoding.EncodeToString(data)
+	}
+	h.sendSupportBundleResult(conn, result)
+}
+
+func (h *UtilityHandler) sendSupportBundleResult(conn *server.Connection, result supportBundleResult) {
+	conn.Send(models.Message{
+		Path: supportBundleRoute,
+		Data: result,
+	})
+}
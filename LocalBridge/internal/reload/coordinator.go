@@ -0,0 +1,206 @@
+package reload
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/config"
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/workspace"
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/pkg/models"
+)
+
+// 排空调试运行时的停止原因
+const drainReason = "工作区正在重载"
+
+// 默认等待调试运行结束的时间
+const DefaultDrainTimeout = 15 * time.Second
+
+// Files 持有当前文件服务，PrepareRoot 以新配置创建并启动文件服务，commit 时才切换
+type Files interface {
+	PrepareRoot(cfg config.FileConfig) (commit func(), abort func(), err error)
+}
+
+// Runs 在切换前停止并等待调试运行结束，Resume 恢复接受新的运行
+type Runs interface {
+	Drain(reason string, timeout time.Duration) (int, error)
+	Resume()
+}
+
+// Resources 以新根目录列表重新扫描资源包，完成后自行推送资源包列表
+type Resources interface {
+	Reload(roots workspace.Roots) error
+}
+
+// Library 重新加载 MaaFramework 库
+type Library interface {
+	Reload() error
+}
+
+// RootSetter 仅持有根目录列表的组件
+type RootSetter interface {
+	SetRoots(roots workspace.Roots)
+}
+
+// Result 重载结果，组件级失败不回滚已切换的根目录，记录在 Warnings 中
+type Result struct {
+	Root            string                 `json:"root"`
+	Roots           []models.WorkspaceRoot `json:"roots"`
+	PreviousRoot    string                 `json:"previous_root"`
+	RootChanged     bool                   `json:"root_changed"`
+	DrainedRuns     int                    `json:"drained_runs"`
+	LibraryReloaded bool                   `json:"library_reloaded"`
+	Duration        string                 `json:"duration"`
+	Warnings        []string               `json:"warnings,omitempty"`
+}
+
+// Coordinator 协调工作区根目录与 MaaFW 库的进程内重载。
+// 先以新根目录启动文件服务并排空调试运行，任一步失败时保持原状态；
+// 之后再一次性切换文件服务、资源包索引及各处理器的根目录
+type Coordinator struct {
+	mu           sync.Mutex
+	root         string
+	files        Files
+	runs         Runs
+	resources    Resources
+	library      Library
+	roots        []RootSetter
+	drainTimeout time.Duration
+}
+
+func New(root string, files Files, runs Runs, resources Resources, library Library, roots ...RootSetter) *Coordinator {
+	return &Coordinator{
+		root:         root,
+		files:        files,
+		runs:         runs,
+		resources:    resources,
+		library:      library,
+		roots:        roots,
+		drainTimeout: DefaultDrainTimeout,
+	}
+}
+
+// SetDrainTimeout 设置等待调试运行结束的时间
+func (c *Coordinator) SetDrainTimeout(timeout time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.drainTimeout = timeout
+}
+
+// Root 返回当前生效的根目录
+func (c *Coordinator) Root() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.root
+}
+
+// Reload 按配置重载，同一时间只进行一次
+func (c *Coordinator) Reload(cfg *config.Config) (Result, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	start := time.Now()
+	files, err := c.validate(cfg)
+	if err != nil {
+		return Result{}, err
+	}
+	roots := files.WorkspaceRoots()
+	result := Result{
+		Root:         files.Root,
+		Roots:        roots.Models(),
+		PreviousRoot: c.root,
+		RootChanged:  filepath.Clean(files.Root) != filepath.Clean(c.root),
+	}
+
+	commit, abort, err := c.files.PrepareRoot(files)
+	if err != nil {
+		return Result{}, fmt.Errorf("启动新根目录的文件服务失败: %w", err)
+	}
+
+	if c.runs != nil {
+		drained, err := c.runs.Drain(drainReason, c.drainTimeout)
+		if err != nil {
+			c.runs.Resume()
+			abort()
+			return Result{}, fmt.Errorf("排空调试运行失败: %w", err)
+		}
+		defer c.runs.Resume()
+		result.DrainedRuns = drained
+	}
+
+	commit()
+	for _, target := range c.roots {
+		target.SetRoots(roots)
+	}
+	c.root = files.Root
+	if c.resources != nil {
+		if err := c.resources.Reload(roots); err != nil {
+			result.Warnings = append(result.Warnings, "资源扫描失败: "+err.Error())
+		}
+	}
+
+	if c.library != nil && cfg.MaaFW.Enabled {
+		if err := c.library.Reload(); err != nil {
+			result.Warnings = append(result.Warnings, "MaaFramework 重载失败: "+err.Error())
+		} else {
+			result.LibraryReloaded = true
+		}
+	}
+
+	result.Duration = time.Since(start).Round(time.Millisecond).String()
+	return result, nil
+}
+
+// validate 返回各根目录转为绝对路径后的文件配置，拒绝不存在的目录与高风险目录
+func (c *Coordinator) validate(cfg *config.Config) (config.FileConfig, error) {
+	if cfg == nil {
+		return config.FileConfig{}, fmt.Errorf("配置未加载")
+	}
+	files := cfg.File
+	if files.Root == "" {
+		files.Root = c.root
+	}
+	root, err := checkRoot(files.Root)
+	if err != nil {
+		return config.FileConfig{}, err
+	}
+	files.Root = root
+
+	named := make([]config.RootConfig, 0, len(files.Roots))
+	for _, entry := range files.Roots {
+		path, err := checkRoot(entry.Path)
+		if err != nil {
+			return config.FileConfig{}, fmt.Errorf("根目录 %s: %w", entry.Name, err)
+		}
+		named = append(named, config.RootConfig{Name: entry.Name, Path: path})
+	}
+	files.Roots = named
+
+	if err := files.WorkspaceRoots().Validate(); err != nil {
+		return config.FileConfig{}, err
+	}
+	return files, nil
+}
+
+// checkRoot 返回绝对路径，拒绝不存在的目录与高风险目录
+func checkRoot(path string) (string, error) {
+	root, err := filepath.Abs(path)
+	if err != nil {
+		return "", fmt.Errorf("解析根目录路径失败: %w", err)
+	}
+	info, err := os.Stat(root)
+	if err != nil {
+		return "", fmt.Errorf("根目录不存在: %s", root)
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("根目录不是文件夹: %s", root)
+	}
+
+	check := config.Config{File: config.FileConfig{Root: root}}
+	if safety := check.CheckRootSafety(); safety.RiskLevel == "high" {
+		return "", fmt.Errorf("拒绝切换到高风险目录 %s: %v", root, safety.RiskReasons)
+	}
+	return root, nil
+}
@@ -0,0 +1,163 @@
+package reload
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/config"
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/workspace"
+)
+
+type recorder struct {
+	steps      []string
+	prepareErr error
+	drainErr   error
+	libraryErr error
+}
+
+func (r *recorder) PrepareRoot(cfg config.FileConfig) (func(), func(), error) {
+	r.steps = append(r.steps, "prepare "+filepath.Base(cfg.Root))
+	if r.prepareErr != nil {
+		return nil, nil, r.prepareErr
+	}
+	return func() { r.steps = append(r.steps, "commit") }, func() { r.steps = append(r.steps, "abort") }, nil
+}
+
+func (r *recorder) Drain(reason string, timeout time.Duration) (int, error) {
+	r.steps = append(r.steps, "drain")
+	return 2, r.drainErr
+}
+
+func (r *recorder) Resume() { r.steps = append(r.steps, "resume") }
+
+func (r *recorder) Reload(roots workspace.Roots) error {
+	r.steps = append(r.steps, "resources "+filepath.Base(roots.Primary().Path))
+	return nil
+}
+
+func (r *recorder) SetRoots(roots workspace.Roots) {
+	r.steps = append(r.steps, "root "+filepath.Base(roots.Primary().Path))
+}
+
+type library struct{ r *recorder }
+
+func (l library) Reload() error {
+	l.r.steps = append(l.r.steps, "library")
+	return l.r.libraryErr
+}
+
+func TestCoordinatorReload(t *testing.T) {
+	previous, next := t.TempDir(), t.TempDir()
+
+	tests := []struct {
+		name     string
+		root     string
+		maafw    bool
+		rec      recorder
+		wantErr  bool
+		wantRoot string
+		steps    []string
+	}{
+		{
+			name:     "switch root",
+			root:     next,
+			maafw:    true,
+			wantRoot: next,
+			steps: []string{
+				"prepare " + filepath.Base(next), "drain", "commit", "root " + filepath.Base(next),
+				"resources " + filepath.Base(next), "library", "resume",
+			},
+		},
+		{
+			name:     "maafw disabled",
+			root:     next,
+			wantRoot: next,
+			steps: []string{
+				"prepare " + filepath.Base(next), "drain", "commit", "root " + filepath.Base(next),
+				"resources " + filepath.Base(next), "resume",
+			},
+		},
+		{
+			name:     "drain timeout keeps previous root",
+			root:     next,
+			rec:      recorder{drainErr: fmt.Errorf("timeout")},
+			wantErr:  true,
+			wantRoot: previous,
+			steps:    []string{"prepare " + filepath.Base(next), "drain", "resume", "abort"},
+		},
+		{
+			name:     "prepare failure",
+			root:     next,
+			rec:      recorder{prepareErr: fmt.Errorf("watch failed")},
+			wantErr:  true,
+			wantRoot: previous,
+			steps:    []string{"prepare " + filepath.Base(next)},
+		},
+		{
+			name:     "missing root",
+			root:     filepath.Join(next, "missing"),
+			wantErr:  true,
+			wantRoot: previous,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rec := test.rec
+			coordinator := New(previous, &rec, &rec, &rec, library{&rec}, &rec)
+			cfg := &config.Config{File: config.FileConfig{Root: test.root}, MaaFW: config.MaaFWConfig{Enabled: test.maafw}}
+
+			result, err := coordinator.Reload(cfg)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("Reload() error = %v, wantErr %v", err, test.wantErr)
+			}
+			if coordinator.Root() != test.wantRoot {
+				t.Fatalf("Root() = %s, want %s", coordinator.Root(), test.wantRoot)
+			}
+			if !reflect.DeepEqual(rec.steps, test.steps) {
+				t.Fatalf("steps = %v, want %v", rec.steps, test.steps)
+			}
+			if err == nil && (!result.RootChanged || result.DrainedRuns != 2 || result.LibraryReloaded != test.maafw) {
+				t.Fatalf("result = %+v", result)
+			}
+		})
+	}
+}
+
+func TestCoordinatorLibraryFailureIsWarning(t *testing.T) {
+	root := t.TempDir()
+	rec := recorder{libraryErr: fmt.Errorf("lib missing")}
+	coordinator := New(root, &rec, nil, nil, library{&rec})
+
+	result, err := coordinator.Reload(&config.Config{File: config.FileConfig{Root: root}, MaaFW: config.MaaFWConfig{Enabled: true}})
+	if err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	if result.RootChanged || result.LibraryReloaded || len(result.Warnings) != 1 {
+		t.Fatalf("result = %+v, want one warning without root change", result)
+	}
+}
+
+func TestCoordinatorRejectsNestedRoots(t *testing.T) {
+	root := t.TempDir()
+	nested := filepath.Join(root, "common")
+	if err := os.Mkdir(nested, 0755); err != nil {
+		t.Fatal(err)
+	}
+	rec := recorder{}
+	coordinator := New(root, &rec, nil, nil, nil)
+
+	cfg := &config.Config{File: config.FileConfig{
+		Root:  root,
+		Roots: []config.RootConfig{{Name: "nested", Path: nested}},
+	}}
+	if _, err := coordinator.Reload(cfg); err == nil {
+		t.Fatal("Reload() with nested roots should fail")
+	}
+	if len(rec.steps) != 0 {
+		t.Fatalf("steps = %v, want none", rec.steps)
+	}
+}
@@ -0,0 +1,103 @@
+package router
+
+import (
+	"strings"
+
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/server"
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/utils"
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/pkg/models"
+)
+
+// 协商结果
+type negotiation struct {
+	response models.HandshakeResponse
+	// 版本不兼容，区别于缺少功能等可由前端自行处理的失败
+	incompatible bool
+}
+
+// negotiate 校验前端版本是否在支持范围内、后端版本是否满足前端的可接受范围，以及前端依赖的功能是否齐备
+func negotiate(req models.HandshakeRequest, capabilities []string) negotiation {
+	result := negotiation{response: models.HandshakeResponse{
+		ServerVersion:   server.ProtocolVersion,
+		RequiredVersion: server.SupportedProtocolRange,
+		SupportedRange:  server.SupportedProtocolRange,
+		Capabilities:    capabilities,
+		SchemaPath:      PathSchema,
+	}}
+	fail := func(incompatible bool, message string) negotiation {
+		result.incompatible = incompatible
+		result.response.Message = message
+		return result
+	}
+
+	serverVersion, err := utils.ParseVersion(server.ProtocolVersion)
+	if err != nil {
+		return fail(true, "本地服务协议版本无效: "+server.ProtocolVersion)
+	}
+	supported, err := utils.ParseVersionRange(server.SupportedProtocolRange)
+	if err != nil {
+		return fail(true, "本地服务协议范围无效: "+server.SupportedProtocolRange)
+	}
+
+	clientVersion, err := utils.ParseVersion(req.ProtocolVersion)
+	if err != nil || !supported.Contains(clientVersion) {
+		return fail(true, "协议版本不兼容，前端协议: "+req.ProtocolVersion+"，本地服务支持: "+server.SupportedProtocolRange+"，请按后端提示更新")
+	}
+
+	if strings.TrimSpace(req.AcceptedRange) != "" {
+		accepted, err := utils.ParseVersionRange(req.AcceptedRange)
+		if err != nil {
+			return fail(false, err.Error())
+		}
+		if !accepted.Contains(serverVersion) {
+			return fail(true, "本地服务协议 "+server.ProtocolVersion+" 不满足前端要求的 "+req.AcceptedRange+"，请按后端提示更新")
+		}
+	}
+
+	if missing := missingCapabilities(req.RequiredCapabilities, capabilities); len(missing) > 0 {
+		result.response.MissingCapabilities = missing
+		return fail(false, "本地服务缺少前端依赖的功能: "+strings.Join(missing, ", "))
+	}
+
+	negotiated := serverVersion
+	if clientVersion.Compare(serverVersion) < 0 {
+		negotiated = clientVersion
+	}
+	result.response.Success = true
+	result.response.NegotiatedVersion = negotiated.String()
+	result.response.Message = "连接成功"
+	return result
+}
+
+func missingCapabilities(required []string, available []string) []string {
+	set := make(map[string]struct{}, len(available))
+	for _, capability := range available {
+		set[capability] = struct{}{}
+	}
+	var missing []string
+	for _, capability := range required {
+		if _, ok := set[capability]; !ok {
+			missing = append(missing, capability)
+		}
+	}
+	return missing
+}
+
+// parseHandshakeRequest 从消息数据中读取握手请求，未知字段忽略
+func parseHandshakeRequest(data interface{}) (models.HandshakeRequest, bool) {
+	dataMap, ok := data.(map[string]interface{})
+	if !ok {
+		return models.HandshakeRequest{}, false
+	}
+	req := models.HandshakeRequest{}
+	req.ProtocolVersion, _ = dataMap["protocol_version"].(string)
+	req.AcceptedRange, _ = dataMap["accepted_range"].(string)
+	if values, ok := dataMap["required_capabilities"].([]interface{}); ok {
+		for _, value := range values {
+			if capability, ok := value.(string); ok && capability != "" {
+				req.RequiredCapabilities = append(req.RequiredCapabilities, capability)
+			}
+		}
+	}
+	return req, true
+}
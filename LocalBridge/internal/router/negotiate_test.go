@@ -0,0 +1,88 @@
+package router
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/server"
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/pkg/models"
+)
+
+func TestNegotiate(t *testing.T) {
+	capabilities := []string{"file", "mfw"}
+	tests := []struct {
+		name         string
+		req          models.HandshakeRequest
+		success      bool
+		incompatible bool
+		negotiated   string
+		missing      []string
+	}{
+		{
+			name:       "旧版前端仍可连接",
+			req:        models.HandshakeRequest{ProtocolVersion: "1.3.1"},
+			success:    true,
+			negotiated: "1.3.1",
+		},
+		{
+			name:       "较新的同主版本前端按后端版本协商",
+			req:        models.HandshakeRequest{ProtocolVersion: "1.9.0", AcceptedRange: "^1.3.0"},
+			success:    true,
+			negotiated: server.ProtocolVersion,
+		},
+		{
+			name:         "前端版本超出支持范围",
+			req:          models.HandshakeRequest{ProtocolVersion: "2.0.0"},
+			incompatible: true,
+		},
+		{
+			name:         "缺少协议版本",
+			req:          models.HandshakeRequest{},
+			incompatible: true,
+		},
+		{
+			name:         "后端版本低于前端要求",
+			req:          models.HandshakeRequest{ProtocolVersion: "1.3.1", AcceptedRange: ">=9.0.0"},
+			incompatible: true,
+		},
+		{
+			name: "前端可接受范围无效",
+			req:  models.HandshakeRequest{ProtocolVersion: "1.3.1", AcceptedRange: ">=x"},
+		},
+		{
+			name:    "缺少前端依赖的功能",
+			req:     models.HandshakeRequest{ProtocolVersion: "1.3.1", RequiredCapabilities: []string{"file", "debug", "ai.proxy"}},
+			missing: []string{"debug", "ai.proxy"},
+		},
+	}
+	for _, test := range tests {
+		result := negotiate(test.req, capabilities)
+		response := result.response
+		if response.Success != test.success || result.incompatible != test.incompatible {
+			t.Fatalf("%s: success = %v, incompatible = %v, message = %s", test.name, response.Success, result.incompatible, response.Message)
+		}
+		if response.NegotiatedVersion != test.negotiated {
+			t.Fatalf("%s: negotiated = %q, want %q", test.name, response.NegotiatedVersion, test.negotiated)
+		}
+		if !reflect.DeepEqual(response.MissingCapabilities, test.missing) {
+			t.Fatalf("%s: missing = %v, want %v", test.name, response.MissingCapabilities, test.missing)
+		}
+		if response.SupportedRange != server.SupportedProtocolRange || response.SchemaPath != PathSchema || !reflect.DeepEqual(response.Capabilities, capabilities) {
+			t.Fatalf("%s: response = %+v", test.name, response)
+		}
+	}
+}
+
+func TestParseHandshakeRequest(t *testing.T) {
+	req, ok := parseHandshakeRequest(map[string]interface{}{
+		"protocol_version":      "1.4.0",
+		"accepted_range":        "^1.4.0",
+		"required_capabilities": []interface{}{"debug", 1, ""},
+	})
+	if !ok || req.ProtocolVersion != "1.4.0" || req.AcceptedRange != "^1.4.0" || !reflect.DeepEqual(req.RequiredCapabilities, []string{"debug"}) {
+		t.Fatalf("parseHandshakeRequest() = %+v, %v", req, ok)
+	}
+	if _, ok := parseHandshakeRequest("1.4.0"); ok {
+		t.Fatal("parseHandshakeRequest(string) ok = true")
+	}
+}
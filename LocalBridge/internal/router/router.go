@@ -1,11 +1,15 @@
 package router
 
 import (
+	"sort"
 	"strings"
 	"sync"
+	"time"
 
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/auth"
 	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/errors"
 	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/logger"
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/schema"
 	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/server"
 	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/pkg/models"
 )
@@ -14,6 +18,9 @@ import (
 const (
 	PathHandshake         = "/system/handshake"
 	PathHandshakeResponse = "/system/handshake/response"
+	PathSchema            = "/system/schema"
+	PathSchemaResponse    = "/system/schema/response"
+	PathUpdateReport      = "/lte/system/update_report"
 )
 
 // 协议处理器接口
@@ -25,9 +32,21 @@ type Handler interface {
 	Handle(msg models.Message, conn *server.Connection) *models.Message
 }
 
+// 可选接口：声明处理器收发消息的 JSON Schema
+type SchemaProvider interface {
+	MessageSchemas() []schema.Message
+}
+
+// 可选接口：声明处理器提供的功能标识，随握手响应下发
+type CapabilityProvider interface {
+	Capabilities() []string
+}
+
 // 路由分发器
 type Router struct {
 	handlers                    map[string]Handler // key: 路由前缀
+	ordered                     []Handler          // 按注册顺序，用于汇总 Schema 与功能标识
+	capabilities                []string           // 非处理器提供的功能标识
 	protocolMismatchHandler     func(clientVersion string)
 	protocolMismatchHandlerOnce sync.Once
 }
@@ -42,12 +61,50 @@ func New() *Router {
 // 注册处理器
 func (r *Router) RegisterHandler(handler Handler) {
 	prefixes := handler.GetRoutePrefix()
+	r.ordered = append(r.ordered, handler)
 	for _, prefix := range prefixes {
 		r.handlers[prefix] = handler
 		logger.Debug("Router", "注册路由处理器: %s", prefix)
 	}
 }
 
+// 追加由服务自身提供的功能标识，如 HTTP 网关、鉴权
+func (r *Router) AddCapabilities(capabilities ...string) {
+	r.capabilities = append(r.capabilities, capabilities...)
+}
+
+// 返回去重排序后的全部功能标识
+func (r *Router) Capabilities() []string {
+	set := make(map[string]struct{})
+	for _, capability := range r.capabilities {
+		set[capability] = struct{}{}
+	}
+	for _, handler := range r.ordered {
+		if provider, ok := handler.(CapabilityProvider); ok {
+			for _, capability := range provider.Capabilities() {
+				set[capability] = struct{}{}
+			}
+		}
+	}
+	result := make([]string, 0, len(set))
+	for capability := range set {
+		result = append(result, capability)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// 生成全部消息的 JSON Schema 文档
+func (r *Router) Schema() schema.Document {
+	messages := systemSchemas()
+	for _, handler := range r.ordered {
+		if provider, ok := handler.(SchemaProvider); ok {
+			messages = append(messages, provider.MessageSchemas()...)
+		}
+	}
+	return schema.NewDocument(server.ProtocolVersion, server.SupportedProtocolRange, r.Capabilities(), messages)
+}
+
 // 设置协议版本不匹配时的回调
 func (r *Router) SetProtocolMismatchHandler(handler func(clientVersion string)) {
 	r.protocolMismatchHandler = handler
@@ -57,11 +114,37 @@ func (r *Router) SetProtocolMismatchHandler(handler func(clientVersion string))
 func (r *Router) Route(msg models.Message, conn *server.Connection) {
 	path := msg.Path
 
+	// 处理器通过请求视图发送的响应与错误都会带回请求的关联 ID
+	start := time.Now()
+	label := requestLabel(path, msg.CorrelationID)
+	conn = conn.ForRequest(msg.CorrelationID, func(replyPath string) {
+		logger.Debug("Router", "请求 %s 首个响应 %s，耗时 %s", label, replyPath, time.Since(start))
+	})
+	defer func() {
+		logger.Debug("Router", "请求 %s 处理返回，耗时 %s", label, time.Since(start))
+	}()
+
 	// 处理版本握手
 	if path == PathHandshake {
 		r.handleHandshake(msg, conn)
 		return
 	}
+	if path == PathSchema {
+		if err := conn.Send(models.Message{Path: PathSchemaResponse, Data: r.Schema()}); err != nil {
+			logger.Error("Router", "发送协议 Schema 失败: %v", err)
+		}
+		return
+	}
+
+	// 分发前校验连接令牌的权限
+	if scope := auth.RequiredScope(path); !conn.Allows(scope) {
+		logger.Warn("Router", "连接 %s 缺少权限 %s，拒绝请求: %s", conn.ID, scope, path)
+		r.sendError(conn, errors.New(errors.ErrPermissionDenied, "当前令牌缺少权限: "+scope).WithDetail(map[string]string{
+			"path":  path,
+			"scope": scope,
+		}))
+		return
+	}
 
 	// 查找匹配的处理器
 	handler := r.findHandler(path)
@@ -82,6 +165,14 @@ func (r *Router) Route(msg models.Message, conn *server.Connection) {
 	}
 }
 
+// 日志中的请求标识，带关联 ID 时附在路由后
+func requestLabel(path string, correlationID string) string {
+	if correlationID == "" {
+		return path
+	}
+	return path + " [" + correlationID + "]"
+}
+
 // 查找匹配的处理器
 func (r *Router) findHandler(path string) Handler {
 	// 精确匹配
@@ -113,48 +204,32 @@ func (r *Router) sendError(conn *server.Connection, err *errors.LBError) {
 
 // 处理版本握手请求
 func (r *Router) handleHandshake(msg models.Message, conn *server.Connection) {
-	// 解析客户端版本
-	dataMap, ok := msg.Data.(map[string]interface{})
+	req, ok := parseHandshakeRequest(msg.Data)
 	if !ok {
 		logger.Error("Router", "握手消息格式错误")
-		r.sendHandshakeResponse(conn, false, "握手消息格式错误")
-		return
+		req = models.HandshakeRequest{}
 	}
+	logger.Debug("Router", "收到客户端握手请求，协议版本: %s，可接受范围: %s", req.ProtocolVersion, req.AcceptedRange)
 
-	clientVersion, _ := dataMap["protocol_version"].(string)
-	logger.Debug("Router", "收到客户端握手请求，协议版本: %s", clientVersion)
-
-	// 版本验证
-	if clientVersion != server.ProtocolVersion {
-		message := "协议版本不匹配，前端需求: " + clientVersion + "，当前本地服务协议: " + server.ProtocolVersion + "，请按后端提示更新"
-		logger.Warn("Router", "%s", message)
-		r.sendHandshakeResponse(conn, false, message)
-		if r.protocolMismatchHandler != nil {
-			r.protocolMismatchHandlerOnce.Do(func() {
-				r.protocolMismatchHandler(clientVersion)
-			})
-		}
-		return
+	result := negotiate(req, r.Capabilities())
+	if !ok {
+		result.response.Message = "握手消息格式错误"
 	}
+	result.response.Scopes = conn.Scopes()
 
-	// 版本匹配
-	logger.Debug("Router", "协议版本验证成功: %s", clientVersion)
-	r.sendHandshakeResponse(conn, true, "连接成功")
-}
-
-// 发送握手响应
-func (r *Router) sendHandshakeResponse(conn *server.Connection, success bool, message string) {
-	response := models.Message{
-		Path: PathHandshakeResponse,
-		Data: models.HandshakeResponse{
-			Success:         success,
-			ServerVersion:   server.ProtocolVersion,
-			RequiredVersion: server.ProtocolVersion,
-			Message:         message,
-		},
+	if result.response.Success {
+		logger.Debug("Router", "协议协商成功: %s", result.response.NegotiatedVersion)
+	} else {
+		logger.Warn("Router", "%s", result.response.Message)
 	}
-
-	if err := conn.Send(response); err != nil {
+	if err := conn.Send(models.Message{Path: PathHandshakeResponse, Data: result.response}); err != nil {
 		logger.Error("Router", "发送握手响应失败: %v", err)
 	}
+
+	// 仅在版本确实不兼容时交给上层处理，缺少功能由前端自行降级或提示
+	if ok && result.incompatible && r.protocolMismatchHandler != nil {
+		r.protocolMismatchHandlerOnce.Do(func() {
+			r.protocolMismatchHandler(req.ProtocolVersion)
+		})
+	}
 }
@@ -0,0 +1,28 @@
+package router
+
+import (
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/schema"
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/pkg/models"
+)
+
+// 路由器自身处理或所有模块共用的消息
+func systemSchemas() []schema.Message {
+	return []schema.Message{
+		schema.Request("system", PathHandshake, "版本协商，连接建立后首先发送", schema.Object(
+			schema.RequiredField("protocol_version", schema.String().Describe("前端协议版本")),
+			schema.Field("accepted_range", schema.String().Describe("前端可接受的后端协议版本范围，如 \">=1.4.0 <2.0.0\"")),
+			schema.Field("required_capabilities", schema.Array(schema.String()).Describe("前端依赖的功能标识")),
+		)),
+		schema.Push("system", PathHandshakeResponse, "版本协商结果", schema.Of(models.HandshakeResponse{})),
+		schema.Request("system", PathSchema, "获取本文档", schema.Object()),
+		schema.Push("system", PathSchemaResponse, "本文档", schema.Object(
+			schema.RequiredField("protocolVersion", schema.String()),
+			schema.RequiredField("supportedRange", schema.String()),
+			schema.RequiredField("capabilities", schema.Array(schema.String())),
+			schema.RequiredField("messages", schema.MapOf(schema.Object())),
+		)),
+		schema.Push("system", "/error", "请求处理失败", schema.Of(models.ErrorData{})),
+		schema.Push("system", "/lte/logger", "日志推送", schema.Of(models.LogData{})),
+		schema.Push("system", PathUpdateReport, "离线更新后的版本与协议变化", schema.Of(models.UpdateReport{})),
+	}
+}
@@ -0,0 +1,258 @@
+package schema
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// JSON Schema 规范版本
+const Dialect = "https://json-schema.org/draft/2020-12/schema"
+
+// 消息方向
+const (
+	DirectionRequest = "request" // 客户端 → LocalBridge
+	DirectionPush    = "push"    // LocalBridge → 客户端
+)
+
+// Schema 是一个 JSON Schema 对象
+type Schema map[string]interface{}
+
+// Message 描述一条消息路由及其 data 的结构
+type Message struct {
+	Path        string `json:"-"`
+	Direction   string `json:"direction"`
+	Domain      string `json:"domain"`
+	Description string `json:"description,omitempty"`
+	Payload     Schema `json:"payload"`
+}
+
+// Request 声明客户端发送的请求消息
+func Request(domain string, path string, description string, payload Schema) Message {
+	return Message{Path: path, Direction: DirectionRequest, Domain: domain, Description: description, Payload: payload}
+}
+
+// Push 声明 LocalBridge 发送的响应或推送消息
+func Push(domain string, path string, description string, payload Schema) Message {
+	return Message{Path: path, Direction: DirectionPush, Domain: domain, Description: description, Payload: payload}
+}
+
+// Property 是对象的一个字段
+type Property struct {
+	Name     string
+	Schema   Schema
+	Required bool
+}
+
+// Field 声明可选字段
+func Field(name string, schema Schema) Property {
+	return Property{Name: name, Schema: schema}
+}
+
+// RequiredField 声明必填字段
+func RequiredField(name string, schema Schema) Property {
+	return Property{Name: name, Schema: schema, Required: true}
+}
+
+// Object 构造对象 Schema；处理器会忽略未知字段，因此允许额外字段
+func Object(properties ...Property) Schema {
+	props := make(map[string]interface{}, len(properties))
+	required := make([]string, 0)
+	for _, property := range properties {
+		props[property.Name] = property.Schema
+		if property.Required {
+			required = append(required, property.Name)
+		}
+	}
+	result := Schema{"type": "object", "properties": props}
+	if len(required) > 0 {
+		result["required"] = required
+	}
+	return result
+}
+
+func String() Schema  { return Schema{"type": "string"} }
+func Integer() Schema { return Schema{"type": "integer"} }
+func Number() Schema  { return Schema{"type": "number"} }
+func Boolean() Schema { return Schema{"type": "boolean"} }
+
+// Any 接受任意 JSON 值
+func Any() Schema { return Schema{} }
+
+// Null 仅接受 null
+func Null() Schema { return Schema{"type": "null"} }
+
+func Array(items Schema) Schema {
+	return Schema{"type": "array", "items": items}
+}
+
+// MapOf 键任意、值为指定结构的对象
+func MapOf(values Schema) Schema {
+	return Schema{"type": "object", "additionalProperties": values}
+}
+
+func Enum(values ...string) Schema {
+	return Schema{"type": "string", "enum": values}
+}
+
+// OneOf 值满足其中一个结构
+func OneOf(schemas ...Schema) Schema {
+	return Schema{"oneOf": schemas}
+}
+
+// Describe 返回附加说明的副本
+func (s Schema) Describe(description string) Schema {
+	result := make(Schema, len(s)+1)
+	for key, value := range s {
+		result[key] = value
+	}
+	result["description"] = description
+	return result
+}
+
+// Require 返回将指定字段标记为必填的副本
+func (s Schema) Require(names ...string) Schema {
+	result := make(Schema, len(s)+1)
+	for key, value := range s {
+		result[key] = value
+	}
+	required, _ := s["required"].([]string)
+	result["required"] = append(append([]string(nil), required...), names...)
+	return result
+}
+
+// Of 按 Go 值的类型与 json 标签生成 Schema，命名结构体内联展开，递归引用退化为任意值
+func Of(value interface{}) Schema {
+	return fromType(reflect.TypeOf(value), make(map[reflect.Type]bool))
+}
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	durationType   = reflect.TypeOf(time.Duration(0))
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+	marshalerType  = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+)
+
+func fromType(t reflect.Type, visiting map[reflect.Type]bool) Schema {
+	if t == nil {
+		return Any()
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t {
+	case timeType:
+		return Schema{"type": "string", "format": "date-time"}
+	case durationType:
+		return Integer().Describe("纳秒")
+	case rawMessageType:
+		return Any()
+	}
+	// 自定义序列化的类型无法从结构推断
+	if t.Kind() != reflect.Interface && (t.Implements(marshalerType) || reflect.PointerTo(t).Implements(marshalerType)) {
+		return Any()
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return Boolean()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return Integer()
+	case reflect.Float32, reflect.Float64:
+		return Number()
+	case reflect.String:
+		return String()
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return Schema{"type": "string", "contentEncoding": "base64"}
+		}
+		return Array(fromType(t.Elem(), visiting))
+	case reflect.Array:
+		result := Array(fromType(t.Elem(), visiting))
+		result["minItems"] = t.Len()
+		result["maxItems"] = t.Len()
+		return result
+	case reflect.Map:
+		return MapOf(fromType(t.Elem(), visiting))
+	case reflect.Struct:
+		if visiting[t] {
+			return Any()
+		}
+		visiting[t] = true
+		defer delete(visiting, t)
+		properties := make([]Property, 0, t.NumField())
+		collectFields(t, visiting, &properties)
+		return Object(properties...)
+	default:
+		return Any()
+	}
+}
+
+// collectFields 收集导出字段，匿名嵌入且无 json 名称的结构体字段展开到外层
+func collectFields(t reflect.Type, visiting map[reflect.Type]bool, properties *[]Property) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			for embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				collectFields(embedded, visiting, properties)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fieldSchema := fromType(field.Type, visiting)
+		if strings.Contains(options, "string") {
+			fieldSchema = String()
+		}
+		*properties = append(*properties, Field(name, fieldSchema))
+	}
+}
+
+// Document 是 /system/schema 返回的协议描述
+type Document struct {
+	Schema          string             `json:"$schema"`
+	Title           string             `json:"title"`
+	ProtocolVersion string             `json:"protocolVersion"`
+	SupportedRange  string             `json:"supportedRange"`
+	Capabilities    []string           `json:"capabilities"`
+	Envelope        Schema             `json:"envelope"`
+	Messages        map[string]Message `json:"messages"`
+}
+
+// NewDocument 汇总各处理器声明的消息，重复的路由以先声明者为准
+func NewDocument(protocolVersion string, supportedRange string, capabilities []string, messages []Message) Document {
+	index := make(map[string]Message, len(messages))
+	for _, message := range messages {
+		if _, ok := index[message.Path]; !ok {
+			index[message.Path] = message
+		}
+	}
+	return Document{
+		Schema:          Dialect,
+		Title:           "MPE Local Bridge 消息协议",
+		ProtocolVersion: protocolVersion,
+		SupportedRange:  supportedRange,
+		Capabilities:    capabilities,
+		Envelope: Object(
+			RequiredField("path", String().Describe("消息路由")),
+			Field("data", Any().Describe("结构见 messages 中对应路由的 payload")),
+			Field("correlation_id", String().Describe("请求可选携带，对应的响应与错误消息原样带回")),
+		),
+		Messages: index,
+	}
+}
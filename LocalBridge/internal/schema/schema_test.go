@@ -0,0 +1,89 @@
+package schema
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type schemaBase struct {
+	ID string `json:"id"`
+}
+
+type schemaNode struct {
+	schemaBase
+	Name     string            `json:"name"`
+	Skipped  string            `json:"-"`
+	Count    int64             `json:"count,omitempty,string"`
+	Tags     []string          `json:"tags"`
+	Labels   map[string]int    `json:"labels"`
+	Box      [4]int32          `json:"box"`
+	Created  time.Time         `json:"created"`
+	Raw      json.RawMessage   `json:"raw"`
+	Children []*schemaNode     `json:"children"`
+	Extra    map[string]string `json:"extra,omitempty"`
+	hidden   bool
+}
+
+func TestOf(t *testing.T) {
+	s := Of(schemaNode{})
+	props, _ := s["properties"].(map[string]interface{})
+	want := []string{"id", "name", "count", "tags", "labels", "box", "created", "raw", "children", "extra"}
+	if len(props) != len(want) {
+		t.Fatalf("properties = %v", props)
+	}
+	for _, name := range want {
+		if _, ok := props[name]; !ok {
+			t.Fatalf("missing property %q in %v", name, props)
+		}
+	}
+
+	checks := map[string]Schema{
+		"count":   String(),
+		"tags":    Array(String()),
+		"labels":  MapOf(Integer()),
+		"created": {"type": "string", "format": "date-time"},
+		"raw":     Any(),
+	}
+	for name, expected := range checks {
+		if !reflect.DeepEqual(props[name], expected) {
+			t.Fatalf("%s = %v, want %v", name, props[name], expected)
+		}
+	}
+	if box := props["box"].(Schema); box["minItems"] != 4 || box["maxItems"] != 4 {
+		t.Fatalf("box = %v", box)
+	}
+	// 递归引用不再展开
+	children := props["children"].(Schema)
+	if !reflect.DeepEqual(children["items"], Any()) {
+		t.Fatalf("children = %v", children)
+	}
+}
+
+func TestNewDocumentKeepsFirstDeclaration(t *testing.T) {
+	doc := NewDocument("1.4.0", ">=1.3.0 <2.0.0", []string{"file"}, []Message{
+		Request("file", "/etl/open_file", "first", Object(RequiredField("file_path", String()))),
+		Request("file", "/etl/open_file", "second", Object()),
+	})
+	if len(doc.Messages) != 1 || doc.Messages["/etl/open_file"].Description != "first" {
+		t.Fatalf("messages = %+v", doc.Messages)
+	}
+
+	data, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil || decoded["$schema"] != Dialect {
+		t.Fatalf("document = %s, %v", data, err)
+	}
+}
+
+func TestRequireDoesNotMutate(t *testing.T) {
+	base := Object(RequiredField("a", String()), Field("b", String()))
+	extended := base.Require("b")
+	if !reflect.DeepEqual(base["required"], []string{"a"}) || !reflect.DeepEqual(extended["required"], []string{"a", "b"}) {
+		t.Fatalf("base = %v, extended = %v", base["required"], extended["required"])
+	}
+}
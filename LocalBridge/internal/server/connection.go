@@ -5,6 +5,7 @@ import (
 	"sync"
 
 	"github.com/gorilla/websocket"
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/auth"
 	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/logger"
 	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/pkg/models"
 )
@@ -16,8 +17,15 @@ type Connection struct {
 	send   chan []byte
 	done   chan struct{}
 	server *WebSocketServer
+	token  *auth.Token
 	mu     sync.Mutex
 	doneMu sync.Once
+
+	// 请求视图：由 ForRequest 创建，与 root 共享连接，发送时附加关联 ID
+	root          *Connection
+	correlationID string
+	onFirstReply  func(path string)
+	replyOnce     sync.Once
 }
 
 // 创建新连接
@@ -31,6 +39,35 @@ func newConnection(id string, conn *websocket.Conn, server *WebSocketServer) *Co
 	}
 }
 
+// ForRequest 返回单个请求的连接视图，经其发送且未指定关联 ID 的消息带上 correlationID，
+// 首条消息发出时调用 onFirstReply；视图可以和原连接一样长期持有
+func (c *Connection) ForRequest(correlationID string, onFirstReply func(path string)) *Connection {
+	root := c.Root()
+	return &Connection{
+		ID:            root.ID,
+		send:          root.send,
+		done:          root.done,
+		server:        root.server,
+		token:         root.token,
+		root:          root,
+		correlationID: correlationID,
+		onFirstReply:  onFirstReply,
+	}
+}
+
+// Root 返回请求视图对应的原连接，用于按连接登记的长期订阅
+func (c *Connection) Root() *Connection {
+	if c.root != nil {
+		return c.root
+	}
+	return c
+}
+
+// CorrelationID 返回请求视图的关联 ID，原连接返回空字符串
+func (c *Connection) CorrelationID() string {
+	return c.correlationID
+}
+
 // Done 返回连接关闭信号。
 func (c *Connection) Done() <-chan struct{} {
 	return c.done
@@ -42,6 +79,32 @@ func (c *Connection) closeDone() {
 	})
 }
 
+// Allows 判断连接的令牌是否拥有指定权限，未启用鉴权时总是允许
+func (c *Connection) Allows(scope string) bool {
+	if c.token == nil {
+		return true
+	}
+	return c.token.Allows(scope)
+}
+
+// Scopes 返回连接令牌的权限，未启用鉴权时返回 nil
+func (c *Connection) Scopes() []string {
+	if c.token == nil {
+		return nil
+	}
+	return append([]string(nil), c.token.Scopes...)
+}
+
+// Receive 返回发送队列，仅供合成连接读取已发送的消息
+func (c *Connection) Receive() <-chan []byte {
+	return c.send
+}
+
+// Backlog 返回发送队列中尚未写出的消息数。
+func (c *Connection) Backlog() int {
+	return len(c.send)
+}
+
 // 读取客户端消息
 func (c *Connection) readPump() {
 	defer func() {
@@ -92,6 +155,16 @@ func (c *Connection) writePump() {
 
 // 发送消息到客户端
 func (c *Connection) Send(msg models.Message) error {
+	if c.root != nil {
+		if msg.CorrelationID == "" {
+			msg.CorrelationID = c.correlationID
+		}
+		if c.onFirstReply != nil {
+			c.replyOnce.Do(func() { c.onFirstReply(msg.Path) })
+		}
+		return c.root.Send(msg)
+	}
+
 	data, err := json.Marshal(msg)
 	if err != nil {
 		return err
@@ -100,6 +173,13 @@ func (c *Connection) Send(msg models.Message) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
+	// 连接关闭后不再入队
+	select {
+	case <-c.done:
+		return nil
+	default:
+	}
+
 	select {
 	case c.send <- data:
 		return nil
@@ -1,6 +1,7 @@
 package server
 
 import (
+	"context"
 	"fmt"
 	"net"
 	"net/http"
@@ -10,13 +11,17 @@ import (
 	"time"
 
 	"github.com/gorilla/websocket"
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/auth"
 	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/eventbus"
 	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/logger"
 	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/pkg/models"
 )
 
 // 通信协议版本
-const ProtocolVersion = "1.3.1"
+const ProtocolVersion = "1.4.0"
+
+// 可接受的前端协议版本范围，同一主版本内的消息保持向后兼容
+const SupportedProtocolRange = ">=1.3.0 <2.0.0"
 
 // 版本握手路由
 const (
@@ -39,8 +44,19 @@ type WebSocketServer struct {
 	mu             sync.RWMutex
 	server         *http.Server
 	allowedOrigins []string
+	tokens         *auth.Store
+	synthetic      map[*Connection]struct{}
+	httpRoutes     []httpRoute
+}
+
+// 与 WebSocket 共用端口的 HTTP 路由
+type httpRoute struct {
+	pattern string
+	handler http.Handler
 }
 
+type tokenContextKey struct{}
+
 // 创建 WebSocket 服务器
 func NewWebSocketServer(
 	host string,
@@ -52,6 +68,7 @@ func NewWebSocketServer(
 		host:           host,
 		port:           port,
 		connections:    make(map[*Connection]bool),
+		synthetic:      make(map[*Connection]struct{}),
 		register:       make(chan *Connection),
 		unregister:     make(chan *Connection),
 		eventBus:       eventBus,
@@ -65,6 +82,42 @@ func (s *WebSocketServer) SetMessageHandler(handler MessageHandler) {
 	s.messageHandler = handler
 }
 
+// 设置令牌存储，设置后连接握手必须携带有效令牌
+func (s *WebSocketServer) SetTokenStore(tokens *auth.Store) {
+	s.tokens = tokens
+}
+
+// Handle 注册与 WebSocket 共用端口的 HTTP 路由，需在 Start 前调用；
+// 请求需通过与 WebSocket 握手相同的 Origin 与令牌校验
+func (s *WebSocketServer) Handle(pattern string, handler http.Handler) {
+	s.httpRoutes = append(s.httpRoutes, httpRoute{pattern: pattern, handler: handler})
+}
+
+// TokenFromContext 返回 HTTP 请求通过校验的令牌，未启用鉴权时返回 nil
+func TokenFromContext(ctx context.Context) *auth.Token {
+	token, _ := ctx.Value(tokenContextKey{}).(*auth.Token)
+	return token
+}
+
+// NewSyntheticConnection 创建不对应 WebSocket 的连接，发送给它的消息通过 Receive 读取；
+// 关闭前同样会收到广播消息
+func (s *WebSocketServer) NewSyntheticConnection(id string, token *auth.Token) *Connection {
+	connection := newConnection(id, nil, s)
+	connection.token = token
+	s.mu.Lock()
+	s.synthetic[connection] = struct{}{}
+	s.mu.Unlock()
+	return connection
+}
+
+// CloseSyntheticConnection 关闭合成连接，之后发送给它的消息会被丢弃
+func (s *WebSocketServer) CloseSyntheticConnection(connection *Connection) {
+	s.mu.Lock()
+	delete(s.synthetic, connection)
+	s.mu.Unlock()
+	connection.closeDone()
+}
+
 // 启动服务器
 func (s *WebSocketServer) Start() error {
 	// 启动连接管理协程
@@ -73,6 +126,9 @@ func (s *WebSocketServer) Start() error {
 	// 设置 HTTP 路由
 	mux := http.NewServeMux()
 	mux.HandleFunc("/", s.handleWebSocket)
+	for _, route := range s.httpRoutes {
+		mux.Handle(route.pattern, s.guardHTTP(route.handler))
+	}
 
 	// 创建 HTTP 服务器
 	s.server = &http.Server{
@@ -83,6 +139,9 @@ func (s *WebSocketServer) Start() error {
 	}
 
 	logger.Info("WebSocket", "服务器已启动，监听地址: %s:%d", s.host, s.port)
+	if s.tokens != nil {
+		logger.Info("WebSocket", "已启用令牌鉴权，连接时需携带 token 参数")
+	}
 	// 根据端口动态生成在线服务地址
 	onlineURL := fmt.Sprintf("https://mpe.codax.site/stable/?link_lb=true&port=%d", s.port)
 	logger.Info("Main", "在线服务地址: %s", onlineURL)
@@ -128,6 +187,45 @@ func (s *WebSocketServer) originAllowed(origin string) bool {
 	return false
 }
 
+// HTTP 路由与 WebSocket 握手使用相同的 Origin 与令牌校验，防止网页跨站调用本地接口
+func (s *WebSocketServer) guardHTTP(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !s.originAllowed(r.Header.Get("Origin")) {
+			http.Error(w, "forbidden origin", http.StatusForbidden)
+			return
+		}
+		token, ok := s.authenticate(r)
+		if !ok {
+			logger.Warn("WebSocket", "拒绝未授权的 HTTP 请求: %s %s", r.RemoteAddr, r.URL.Path)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		handler.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), tokenContextKey{}, token)))
+	})
+}
+
+// 校验握手请求中的令牌，未启用鉴权时返回 nil
+func (s *WebSocketServer) authenticate(r *http.Request) (*auth.Token, bool) {
+	if s.tokens == nil {
+		return nil, true
+	}
+	token, ok := s.tokens.Lookup(requestToken(r))
+	if !ok {
+		return nil, false
+	}
+	return &token, true
+}
+
+// 浏览器无法为 WebSocket 设置请求头，因此同时支持查询参数
+func requestToken(r *http.Request) string {
+	if header := strings.TrimSpace(r.Header.Get("Authorization")); header != "" {
+		if len(header) > 7 && strings.EqualFold(header[:7], "Bearer ") {
+			return strings.TrimSpace(header[7:])
+		}
+	}
+	return strings.TrimSpace(r.URL.Query().Get("token"))
+}
+
 func isLoopbackHost(hostname string) bool {
 	ip := net.ParseIP(hostname)
 	return strings.EqualFold(hostname, "localhost") || (ip != nil && ip.IsLoopback())
@@ -184,6 +282,13 @@ func (s *WebSocketServer) run() {
 
 // 处理WebSocket连接请求
 func (s *WebSocketServer) handleWebSocket(w http.ResponseWriter, r *http.Request) {
+	token, ok := s.authenticate(r)
+	if !ok {
+		logger.Warn("WebSocket", "拒绝未授权的连接: %s", r.RemoteAddr)
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
 	upgrader := websocket.Upgrader{
 		ReadBufferSize:  1024,
 		WriteBufferSize: 1024,
@@ -199,6 +304,7 @@ func (s *WebSocketServer) handleWebSocket(w http.ResponseWriter, r *http.Request
 
 	// 创建连接对象
 	connection := newConnection(r.RemoteAddr, conn, s)
+	connection.token = token
 
 	// 注册连接
 	s.register <- connection
@@ -216,6 +322,31 @@ func (s *WebSocketServer) Broadcast(msg models.Message) {
 	for conn := range s.connections {
 		conn.Send(msg)
 	}
+	for conn := range s.synthetic {
+		conn.Send(msg)
+	}
+}
+
+// BroadcastReply 广播由请求触发的消息，发起请求的连接经其请求视图收到带关联 ID 的副本
+func (s *WebSocketServer) BroadcastReply(msg models.Message, requester *Connection) {
+	root := requester.Root()
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for conn := range s.connections {
+		if conn == root {
+			requester.Send(msg)
+			continue
+		}
+		conn.Send(msg)
+	}
+	for conn := range s.synthetic {
+		if conn == root {
+			requester.Send(msg)
+			continue
+		}
+		conn.Send(msg)
+	}
 }
 
 // 获取活跃连接数
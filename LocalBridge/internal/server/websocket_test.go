@@ -1,10 +1,14 @@
 package server
 
 import (
+	"encoding/json"
 	"net/http/httptest"
+	"path/filepath"
 	"testing"
 
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/auth"
 	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/eventbus"
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/pkg/models"
 )
 
 func TestOriginAllowed(t *testing.T) {
@@ -54,3 +58,75 @@ func TestConnectionDoneClosesOnce(t *testing.T) {
 		t.Fatal("connection Done channel was not closed")
 	}
 }
+
+func TestRequestViewStampsCorrelationID(t *testing.T) {
+	connection := newConnection("test", nil, nil)
+	var replies []string
+	view := connection.ForRequest("req-1", func(path string) { replies = append(replies, path) })
+	if view.Root() != connection || view.CorrelationID() != "req-1" {
+		t.Fatalf("view root/id = %p/%q, want %p/req-1", view.Root(), view.CorrelationID(), connection)
+	}
+
+	view.Send(models.Message{Path: "/lte/a"})
+	view.Send(models.Message{Path: "/lte/b", CorrelationID: "explicit"})
+	connection.Send(models.Message{Path: "/lte/c"})
+
+	want := []string{"req-1", "explicit", ""}
+	for i, id := range want {
+		var msg models.Message
+		if err := json.Unmarshal(<-connection.Receive(), &msg); err != nil {
+			t.Fatalf("unmarshal message %d: %v", i, err)
+		}
+		if msg.CorrelationID != id {
+			t.Fatalf("message %s correlation_id = %q, want %q", msg.Path, msg.CorrelationID, id)
+		}
+	}
+	if len(replies) != 1 || replies[0] != "/lte/a" {
+		t.Fatalf("onFirstReply calls = %v, want [/lte/a]", replies)
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	webSocketServer := NewWebSocketServer("localhost", 9066, eventbus.New(), nil)
+	if token, ok := webSocketServer.authenticate(httptest.NewRequest("GET", "http://localhost/", nil)); !ok || token != nil {
+		t.Fatalf("authenticate() without store = %v, %v", token, ok)
+	}
+
+	store := auth.NewStore(filepath.Join(t.TempDir(), "tokens.json"))
+	issued, err := store.Issue("viewer", []string{auth.ScopeFileRead})
+	if err != nil {
+		t.Fatalf("Issue() error = %v", err)
+	}
+	webSocketServer.SetTokenStore(store)
+
+	tests := []struct {
+		name    string
+		url     string
+		header  string
+		allowed bool
+	}{
+		{name: "query token", url: "http://localhost/?token=" + issued.Value, allowed: true},
+		{name: "bearer header", url: "http://localhost/", header: "Bearer " + issued.Value, allowed: true},
+		{name: "missing token", url: "http://localhost/", allowed: false},
+		{name: "wrong token", url: "http://localhost/?token=mpelb_wrong", allowed: false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			request := httptest.NewRequest("GET", test.url, nil)
+			if test.header != "" {
+				request.Header.Set("Authorization", test.header)
+			}
+			token, ok := webSocketServer.authenticate(request)
+			if ok != test.allowed {
+				t.Fatalf("authenticate() = %v, want %v", ok, test.allowed)
+			}
+			if ok {
+				connection := newConnection("test", nil, webSocketServer)
+				connection.token = token
+				if !connection.Allows(auth.ScopeFileRead) || connection.Allows(auth.ScopeShell) {
+					t.Fatalf("connection scopes = %v", connection.Scopes())
+				}
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package file
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
 	"sort"
@@ -9,15 +10,18 @@ import (
 	"sync"
 	"time"
 
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/config"
 	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/errors"
 	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/eventbus"
 	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/logger"
 	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/utils"
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/workspace"
 	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/pkg/models"
 )
 
 // 文件服务
 type Service struct {
+	name      string
 	root      string
 	scanner   *Scanner
 	watcher   *Watcher
@@ -37,6 +41,7 @@ type Service struct {
 // 创建文件服务实例
 func NewService(root string, exclude []string, extensions []string, maxDepth, maxFiles int, eb *eventbus.EventBus) (*Service, error) {
 	s := &Service{
+		name:                  workspace.DefaultName,
 		root:                  root,
 		scanner:               NewScanner(root, exclude, extensions),
 		fileIndex:             make(map[string]*models.File),
@@ -61,6 +66,29 @@ func NewService(root string, exclude []string, extensions []string, maxDepth, ma
 	return s, nil
 }
 
+// NewWorkspaceServices 为每个命名根目录创建独立的文件服务（各自扫描与监听）
+func NewWorkspaceServices(cfg config.FileConfig, eb *eventbus.EventBus) ([]*Service, error) {
+	roots := cfg.WorkspaceRoots()
+	services := make([]*Service, 0, len(roots))
+	for _, root := range roots {
+		service, err := NewService(root.Path, cfg.Exclude, cfg.Extensions, cfg.MaxDepth, cfg.MaxFiles, eb)
+		if err != nil {
+			for _, created := range services {
+				created.Stop()
+			}
+			return nil, fmt.Errorf("创建根目录 %s 的文件服务失败: %w", root.Name, err)
+		}
+		service.name = root.Name
+		services = append(services, service)
+	}
+	return services, nil
+}
+
+// Root 返回服务对应的命名根目录
+func (s *Service) Root() workspace.Root {
+	return workspace.Root{Name: s.name, Path: s.root}
+}
+
 // 启动文件服务
 func (s *Service) Start() error {
 	// 初始扫描
@@ -108,7 +136,9 @@ func (s *Service) GetFileList() []models.FileInfo {
 
 	fileList := make([]models.FileInfo, 0, len(s.fileIndex))
 	for _, file := range s.fileIndex {
-		fileList = append(fileList, file.ToFileInfo())
+		info := file.ToFileInfo()
+		info.RootName = s.name
+		fileList = append(fileList, info)
 	}
 
 	// 按相对路径排序，确保列表顺序稳定
@@ -433,10 +463,7 @@ func (s *Service) validatePath(path string) error {
 		return errors.NewPermissionDeniedError("无效的路径")
 	}
 
-	normAbs := filepath.Clean(strings.ToLower(absPath))
-	normRoot := filepath.Clean(strings.ToLower(s.root))
-
-	if !strings.HasPrefix(normAbs, normRoot) {
+	if !workspace.Contains(s.root, absPath) {
 		return errors.NewPermissionDeniedError("路径不在根目录范围内")
 	}
 
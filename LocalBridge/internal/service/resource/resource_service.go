@@ -8,22 +8,31 @@ import (
 
 	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/eventbus"
 	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/logger"
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/workspace"
 	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/pkg/models"
 )
 
-// 资源扫描服务
+// 资源扫描服务，每个根目录维护独立的资源包索引
 type Service struct {
-	root      string
-	bundles   []models.ResourceBundle
-	imageDirs []string // 所有 image 目录的绝对路径
+	roots     workspace.Roots
+	indexes   []rootIndex
+	bundles   []models.ResourceBundle // 按根目录顺序合并的资源包
+	imageDirs []string                // 所有 image 目录的绝对路径
 	mu        sync.RWMutex
 	eventBus  *eventbus.EventBus
 }
 
+// 单个根目录的资源包索引
+type rootIndex struct {
+	root      workspace.Root
+	bundles   []models.ResourceBundle
+	imageDirs []string
+}
+
 // 创建资源服务
-func NewService(root string, eb *eventbus.EventBus) *Service {
+func NewService(roots workspace.Roots, eb *eventbus.EventBus) *Service {
 	return &Service{
-		root:      root,
+		roots:     roots,
 		bundles:   make([]models.ResourceBundle, 0),
 		imageDirs: make([]string, 0),
 		eventBus:  eb,
@@ -50,25 +59,49 @@ func (s *Service) Scan() error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	s.indexes = make([]rootIndex, 0, len(s.roots))
 	s.bundles = make([]models.ResourceBundle, 0)
 	s.imageDirs = make([]string, 0)
 
-	// 检查根目录本身是否是资源包
-	if bundle := s.checkResourceBundle(s.root, ""); bundle != nil {
-		s.bundles = append(s.bundles, *bundle)
-		if bundle.HasImage && bundle.ImageDir != "" {
-			s.imageDirs = append(s.imageDirs, bundle.ImageDir)
+	for _, root := range s.roots {
+		index := &rootIndex{
+			root:      root,
+			bundles:   make([]models.ResourceBundle, 0),
+			imageDirs: make([]string, 0),
+		}
+
+		// 检查根目录本身是否是资源包
+		if bundle := s.checkResourceBundle(root.Path, ""); bundle != nil {
+			index.add(*bundle)
 		}
-	}
 
-	// 递归扫描子目录（最多2层）
-	s.scanDirectory(s.root, "", 0, 2)
+		// 递归扫描子目录（最多2层）
+		s.scanDirectory(index, root.Path, "", 0, 2)
+
+		s.indexes = append(s.indexes, *index)
+		s.bundles = append(s.bundles, index.bundles...)
+		s.imageDirs = append(s.imageDirs, index.imageDirs...)
+	}
 
 	return nil
 }
 
+// 加入资源包并记录所属根目录
+func (index *rootIndex) add(bundle models.ResourceBundle) {
+	for _, existing := range index.bundles {
+		if existing.AbsPath == bundle.AbsPath {
+			return
+		}
+	}
+	bundle.RootName = index.root.Name
+	index.bundles = append(index.bundles, bundle)
+	if bundle.HasImage && bundle.ImageDir != "" {
+		index.imageDirs = append(index.imageDirs, bundle.ImageDir)
+	}
+}
+
 // 递归扫描目录
-func (s *Service) scanDirectory(absPath, relPath string, depth, maxDepth int) {
+func (s *Service) scanDirectory(index *rootIndex, absPath, relPath string, depth, maxDepth int) {
 	if depth >= maxDepth {
 		return
 	}
@@ -97,24 +130,11 @@ func (s *Service) scanDirectory(absPath, relPath string, depth, maxDepth int) {
 
 		// 检查是否是资源包
 		if bundle := s.checkResourceBundle(subAbsPath, subRelPath); bundle != nil {
-			// 检查是否已存在
-			exists := false
-			for _, b := range s.bundles {
-				if b.AbsPath == bundle.AbsPath {
-					exists = true
-					break
-				}
-			}
-			if !exists {
-				s.bundles = append(s.bundles, *bundle)
-				if bundle.HasImage && bundle.ImageDir != "" {
-					s.imageDirs = append(s.imageDirs, bundle.ImageDir)
-				}
-			}
+			index.add(*bundle)
 		}
 
 		// 继续递归
-		s.scanDirectory(subAbsPath, subRelPath, depth+1, maxDepth)
+		s.scanDirectory(index, subAbsPath, subRelPath, depth+1, maxDepth)
 	}
 }
 
@@ -158,7 +178,8 @@ func (s *Service) GetBundleList() models.ResourceBundleListData {
 	defer s.mu.RUnlock()
 
 	return models.ResourceBundleListData{
-		Root:      s.root,
+		Root:      s.roots.Primary().Path,
+		Roots:     s.roots.Models(),
 		Bundles:   s.bundles,
 		ImageDirs: s.imageDirs,
 	}
@@ -333,15 +354,19 @@ func (s *Service) scanImageDir(imageDir, bundleName string) []models.ImageFileIn
 	return images
 }
 
-// Reload 重新扫描资源目录
-func (s *Service) Reload(newRoot string) error {
+// Reload 以新的根目录列表重新扫描资源目录
+func (s *Service) Reload(roots workspace.Roots) error {
 	logger.Info("ResourceService", "开始重载资源扫描服务...")
 
 	// 如果根目录变化，更新根目录
-	if newRoot != "" && newRoot != s.root {
-		logger.Info("ResourceService", "根目录变化: %s -> %s", s.root, newRoot)
-		s.root = newRoot
+	s.mu.Lock()
+	if len(roots) > 0 {
+		if previous := s.roots.Primary().Path; previous != roots.Primary().Path || len(s.roots) != len(roots) {
+			logger.Info("ResourceService", "根目录变化: %v -> %v", s.roots.Paths(), roots.Paths())
+		}
+		s.roots = roots
 	}
+	s.mu.Unlock()
 
 	// 重新扫描
 	if err := s.Scan(); err != nil {
@@ -0,0 +1,324 @@
+package support
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"time"
+
+	maa "github.com/MaaXYZ/maa-framework-go/v4"
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/config"
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/debug/protocol"
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/mfw"
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/paths"
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/pkg/models"
+)
+
+// 诊断包中日志的大小上限，超出时只保留尾部
+const (
+	logFileLimit  int64 = 4 * 1024 * 1024
+	logTotalLimit int64 = 16 * 1024 * 1024
+	maafwLogLimit int64 = 2 * 1024 * 1024
+)
+
+// DefaultTraceLimit 默认打包的调试 trace 数量
+const DefaultTraceLimit = 5
+
+// MaafwLogFileName maafw.log 文件名
+const MaafwLogFileName = "maafw.log"
+
+// 配置中需要脱敏的字段名
+var sensitiveKey = regexp.MustCompile(`(?i)token|secret|password|passwd|api_?key|authorization|credential`)
+
+// 脱敏后的占位值
+const redacted = "***"
+
+// BundleSource 提供资源包列表
+type BundleSource interface {
+	GetBundleList() models.ResourceBundleListData
+}
+
+// TraceSource 提供最近的调试 trace
+type TraceSource interface {
+	RecentTraces(limit int) []protocol.TraceSnapshot
+}
+
+// Sources 诊断信息的数据来源，为空的来源不写入诊断包
+type Sources struct {
+	Version    string
+	Config     *config.Config
+	MaaFW      *mfw.Service
+	MaaFWError error // MaaFramework 初始化失败的原因
+	Resources  BundleSource
+	Traces     TraceSource
+	Serving    bool // 由正在监听服务端口的 Local Bridge 生成
+}
+
+// PathsInfo 运行模式与目录信息
+type PathsInfo struct {
+	Mode       string `json:"mode"`
+	DataDir    string `json:"data_dir"`
+	ConfigFile string `json:"config_file"`
+	LogDir     string `json:"log_dir"`
+	ExeDir     string `json:"exe_dir"`
+}
+
+// Report 环境自检报告
+type Report struct {
+	GeneratedAt  string    `json:"generated_at"`
+	Version      string    `json:"version"`
+	OS           string    `json:"os"`
+	Arch         string    `json:"arch"`
+	Paths        PathsInfo `json:"paths"`
+	MaaFWVersion string    `json:"maafw_version,omitempty"`
+	Checks       []Check   `json:"checks"`
+}
+
+// Healthy 没有失败的自检项时返回 true
+func (r Report) Healthy() bool {
+	for _, check := range r.Checks {
+		if check.Status == StatusFail {
+			return false
+		}
+	}
+	return true
+}
+
+// Bundle 收集到的诊断信息
+type Bundle struct {
+	Report    Report
+	Devices   []mfw.AdbDeviceInfo
+	Resources *models.ResourceBundleListData
+	Traces    []protocol.TraceSnapshot
+
+	config *config.Config
+}
+
+// LogDir 返回 Local Bridge 与 MaaFramework 日志所在目录
+func LogDir(cfg *config.Config) string {
+	if cfg != nil && cfg.Log.Dir != "" {
+		return cfg.Log.Dir
+	}
+	return paths.GetLogDir()
+}
+
+// DefaultOutputPath 返回诊断包的默认保存路径
+func DefaultOutputPath(now time.Time) string {
+	return filepath.Join(paths.GetDataDir(), "support", "mpelb-support-"+now.Format("20060102-150405")+".zip")
+}
+
+// Collect 执行环境自检并收集诊断信息，traceLimit 为打包的调试 trace 数量
+func Collect(src Sources, traceLimit int) *Bundle {
+	cfg := src.Config
+	if cfg == nil {
+		cfg = &config.Config{}
+	}
+
+	bundle := &Bundle{
+		Report: Report{
+			GeneratedAt: time.Now().Format(time.RFC3339),
+			Version:     src.Version,
+			OS:          runtime.GOOS,
+			Arch:        runtime.GOARCH,
+			Paths: PathsInfo{
+				Mode:       paths.GetModeName(),
+				DataDir:    paths.GetDataDir(),
+				ConfigFile: config.GetConfigFilePath(),
+				LogDir:     LogDir(cfg),
+				ExeDir:     paths.GetExeDir(),
+			},
+		},
+		config: cfg,
+	}
+
+	if src.MaaFW != nil && src.MaaFW.IsInitialized() {
+		bundle.Report.MaaFWVersion = maa.Version()
+	}
+	adbCheck, devices := checkAdb(src.MaaFW)
+	bundle.Devices = append([]mfw.AdbDeviceInfo{}, devices...)
+	bundle.Report.Checks = []Check{
+		checkLibrary(cfg, src.MaaFW, src.MaaFWError),
+		checkOCRModel(cfg.ResolvedMaaFWResourceDir()),
+		adbCheck,
+		checkPort(cfg.Server.Host, cfg.Server.Port, src.Serving),
+	}
+
+	if src.Resources != nil {
+		resources := src.Resources.GetBundleList()
+		bundle.Resources = &resources
+	}
+	if src.Traces != nil && traceLimit > 0 {
+		bundle.Traces = src.Traces.RecentTraces(traceLimit)
+	}
+	return bundle
+}
+
+// WriteFile 将诊断包写入 zip 文件，目录不存在时自动创建
+func (b *Bundle) WriteFile(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("创建诊断包目录失败: %w", err)
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("创建诊断包失败: %w", err)
+	}
+	if err := b.WriteZip(file); err != nil {
+		file.Close()
+		os.Remove(path)
+		return err
+	}
+	return file.Close()
+}
+
+// WriteZip 写出诊断包：自检报告、脱敏配置、设备与资源列表、调试 trace 以及日志尾部
+func (b *Bundle) WriteZip(w io.Writer) error {
+	zw := zip.NewWriter(w)
+
+	configData, err := redactConfig(b.config)
+	if err != nil {
+		return err
+	}
+	entries := []struct {
+		name  string
+		value interface{}
+	}{
+		{"report.json", b.Report},
+		{"config.json", configData},
+		{"devices.json", b.Devices},
+		{"resources.json", b.Resources},
+	}
+	for _, entry := range entries {
+		if err := writeJSON(zw, entry.name, entry.value); err != nil {
+			return err
+		}
+	}
+	for index, snapshot := range b.Traces {
+		name := fmt.Sprintf("traces/%02d-%s-%s.json", index+1, snapshot.SessionID, snapshot.RunID)
+		if err := writeJSON(zw, name, snapshot); err != nil {
+			return err
+		}
+	}
+	if err := writeLogs(zw, LogDir(b.config)); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("写入诊断包失败: %w", err)
+	}
+	return nil
+}
+
+func writeJSON(zw *zip.Writer, name string, value interface{}) error {
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化 %s 失败: %w", name, err)
+	}
+	entry, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: time.Now()})
+	if err != nil {
+		return fmt.Errorf("写入 %s 失败: %w", name, err)
+	}
+	_, err = entry.Write(data)
+	return err
+}
+
+// writeLogs 从新到旧打包 Local Bridge 日志直至达到总量上限，并附上 maafw.log 尾部；日志目录不存在时跳过
+func writeLogs(zw *zip.Writer, dir string) error {
+	matches, _ := filepath.Glob(filepath.Join(dir, "lb-*.log"))
+	type logFile struct {
+		path string
+		info os.FileInfo
+	}
+	files := make([]logFile, 0, len(matches))
+	for _, path := range matches {
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			files = append(files, logFile{path: path, info: info})
+		}
+	}
+	sort.SliceStable(files, func(i, j int) bool {
+		return files[i].info.ModTime().After(files[j].info.ModTime())
+	})
+
+	budget := logTotalLimit
+	for _, file := range files {
+		if budget <= 0 {
+			break
+		}
+		limit := logFileLimit
+		if limit > budget {
+			limit = budget
+		}
+		written, err := writeTail(zw, "logs/"+filepath.Base(file.path), file.path, file.info, limit)
+		if err != nil {
+			return err
+		}
+		budget -= written
+	}
+
+	maafwLog := filepath.Join(dir, MaafwLogFileName)
+	if info, err := os.Stat(maafwLog); err == nil && !info.IsDir() {
+		if _, err := writeTail(zw, "logs/"+MaafwLogFileName, maafwLog, info, maafwLogLimit); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeTail 写入文件末尾至多 limit 字节
+func writeTail(zw *zip.Writer, name string, path string, info os.FileInfo, limit int64) (int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, fmt.Errorf("打开日志文件失败: %w", err)
+	}
+	defer file.Close()
+	if info.Size() > limit {
+		if _, err := file.Seek(info.Size()-limit, io.SeekStart); err != nil {
+			return 0, fmt.Errorf("读取日志文件失败: %w", err)
+		}
+	}
+
+	entry, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: info.ModTime()})
+	if err != nil {
+		return 0, fmt.Errorf("写入 %s 失败: %w", name, err)
+	}
+	written, err := io.Copy(entry, io.LimitReader(file, limit))
+	if err != nil {
+		return written, fmt.Errorf("写入 %s 失败: %w", name, err)
+	}
+	return written, nil
+}
+
+// redactConfig 将生效的配置转为通用结构，并隐去令牌、密钥等字段的值
+func redactConfig(cfg *config.Config) (interface{}, error) {
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("序列化配置失败: %w", err)
+	}
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil, fmt.Errorf("解析配置失败: %w", err)
+	}
+	return redact(value), nil
+}
+
+func redact(value interface{}) interface{} {
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		for key, item := range typed {
+			if item != nil && sensitiveKey.MatchString(key) {
+				typed[key] = redacted
+				continue
+			}
+			typed[key] = redact(item)
+		}
+	case []interface{}:
+		for index, item := range typed {
+			typed[index] = redact(item)
+		}
+	}
+	return value
+}
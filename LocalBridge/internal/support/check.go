@@ -0,0 +1,120 @@
+package support
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/config"
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/mfw"
+)
+
+// 自检结果状态
+const (
+	StatusOK   = "ok"
+	StatusWarn = "warn"
+	StatusFail = "fail"
+	StatusSkip = "skip"
+)
+
+// OCR 模型目录下必需的文件
+var ocrModelFiles = []string{"det.onnx", "rec.onnx", "keys.txt"}
+
+// Check 单项自检结果
+type Check struct {
+	Name    string `json:"name"`
+	Status  string `json:"status"`
+	Message string `json:"message"`
+	Detail  string `json:"detail,omitempty"`
+}
+
+// checkLibrary 检查 MaaFramework 库是否已加载
+func checkLibrary(cfg *config.Config, service *mfw.Service, initErr error) Check {
+	check := Check{Name: "maafw_lib", Detail: cfg.ResolvedMaaFWLibDir()}
+	switch {
+	case service != nil && service.IsInitialized():
+		check.Status, check.Message = StatusOK, "MaaFramework 库已加载"
+	case initErr != nil:
+		check.Status, check.Message = StatusFail, initErr.Error()
+	case !cfg.MaaFW.Enabled:
+		check.Status, check.Message = StatusSkip, "MaaFramework 未启用"
+	case check.Detail == "":
+		check.Status, check.Message = StatusFail, "MaaFramework 库路径未配置"
+	default:
+		check.Status, check.Message = StatusFail, "MaaFramework 未初始化"
+	}
+	return check
+}
+
+// checkOCRModel 检查 <resource_dir>/model/ocr/ 下的 OCR 模型文件是否齐全
+func checkOCRModel(resourceDir string) Check {
+	check := Check{Name: "ocr_model"}
+	if resourceDir == "" {
+		check.Status, check.Message = StatusWarn, "MaaFramework 资源路径未配置，OCR 不可用"
+		return check
+	}
+	dir := filepath.Join(resourceDir, "model", "ocr")
+	check.Detail = dir
+
+	missing := make([]string, 0)
+	for _, name := range ocrModelFiles {
+		if info, err := os.Stat(filepath.Join(dir, name)); err != nil || info.IsDir() {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		check.Status, check.Message = StatusFail, "缺少 OCR 模型文件: "+strings.Join(missing, ", ")
+		return check
+	}
+	check.Status, check.Message = StatusOK, "OCR 模型文件齐全"
+	return check
+}
+
+// checkAdb 通过 MaaFramework 扫描 ADB 设备，返回扫描到的设备
+func checkAdb(service *mfw.Service) (Check, []mfw.AdbDeviceInfo) {
+	check := Check{Name: "adb"}
+	if adbPath, err := exec.LookPath("adb"); err == nil {
+		check.Detail = adbPath
+	} else {
+		check.Detail = "PATH 中未找到 adb"
+	}
+	if service == nil || !service.IsInitialized() {
+		check.Status, check.Message = StatusSkip, "MaaFramework 未加载，无法扫描设备"
+		return check, nil
+	}
+
+	devices, err := service.DeviceManager().RefreshAdbDevices()
+	if err != nil {
+		check.Status, check.Message = StatusFail, err.Error()
+		return check, nil
+	}
+	if len(devices) == 0 {
+		check.Status, check.Message = StatusWarn, "未发现 ADB 设备"
+		return check, devices
+	}
+	check.Status, check.Message = StatusOK, fmt.Sprintf("发现 %d 个 ADB 设备", len(devices))
+	return check, devices
+}
+
+// checkPort 检查服务端口是否可用；serving 为 true 表示由正在监听该端口的服务自身生成
+func checkPort(host string, port int, serving bool) Check {
+	address := net.JoinHostPort(host, strconv.Itoa(port))
+	check := Check{Name: "port", Detail: address}
+	if serving {
+		check.Status, check.Message = StatusOK, "端口由当前 Local Bridge 使用"
+		return check
+	}
+
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		check.Status, check.Message = StatusWarn, fmt.Sprintf("端口 %d 已被占用（可能已有 Local Bridge 在运行）: %v", port, err)
+		return check
+	}
+	listener.Close()
+	check.Status, check.Message = StatusOK, fmt.Sprintf("端口 %d 可用", port)
+	return check
+}
@@ -0,0 +1,171 @@
+package support
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/config"
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/debug/protocol"
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/pkg/models"
+)
+
+type stubResources struct{}
+
+func (stubResources) GetBundleList() models.ResourceBundleListData {
+	return models.ResourceBundleListData{Root: "/workspace", Bundles: []models.ResourceBundle{}}
+}
+
+type stubTraces struct{}
+
+func (stubTraces) RecentTraces(limit int) []protocol.TraceSnapshot {
+	return []protocol.TraceSnapshot{{SessionID: "session-1", RunID: "run-1"}}[:limit]
+}
+
+func TestRedactConfig(t *testing.T) {
+	value := redact(map[string]interface{}{
+		"server": map[string]interface{}{"port": 9066.0, "auth": map[string]interface{}{"enabled": true}},
+		"ai": map[string]interface{}{
+			"api_key":   "sk-123",
+			"providers": []interface{}{map[string]interface{}{"AccessToken": "abc", "name": "openai"}},
+			"password":  nil,
+		},
+	})
+	want := map[string]interface{}{
+		"server": map[string]interface{}{"port": 9066.0, "auth": map[string]interface{}{"enabled": true}},
+		"ai": map[string]interface{}{
+			"api_key":   redacted,
+			"providers": []interface{}{map[string]interface{}{"AccessToken": redacted, "name": "openai"}},
+			"password":  nil,
+		},
+	}
+	if !reflect.DeepEqual(value, want) {
+		t.Fatalf("redact() = %v, want %v", value, want)
+	}
+}
+
+func TestCheckOCRModel(t *testing.T) {
+	resourceDir := t.TempDir()
+	ocrDir := filepath.Join(resourceDir, "model", "ocr")
+	if err := os.MkdirAll(ocrDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(ocrDir, "det.onnx"), []byte("onnx"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		dir    string
+		status string
+	}{
+		{name: "not configured", dir: "", status: StatusWarn},
+		{name: "missing files", dir: resourceDir, status: StatusFail},
+	}
+	for _, test := range tests {
+		if check := checkOCRModel(test.dir); check.Status != test.status {
+			t.Fatalf("%s: checkOCRModel() = %+v, want %s", test.name, check, test.status)
+		}
+	}
+
+	for _, name := range []string{"rec.onnx", "keys.txt"} {
+		if err := os.WriteFile(filepath.Join(ocrDir, name), []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if check := checkOCRModel(resourceDir); check.Status != StatusOK {
+		t.Fatalf("checkOCRModel() complete = %+v, want ok", check)
+	}
+}
+
+func TestCheckPort(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	port := listener.Addr().(*net.TCPAddr).Port
+
+	if check := checkPort("127.0.0.1", port, false); check.Status != StatusWarn {
+		t.Fatalf("checkPort() in use = %+v, want warn", check)
+	}
+	if check := checkPort("127.0.0.1", port, true); check.Status != StatusOK {
+		t.Fatalf("checkPort() serving = %+v, want ok", check)
+	}
+}
+
+func TestBundleWriteZip(t *testing.T) {
+	logDir := t.TempDir()
+	now := time.Now()
+	for i, name := range []string{"lb-2026-10-18.log", "lb-2026-10-19.log", "maafw.log", "other.txt"} {
+		path := filepath.Join(logDir, name)
+		if err := os.WriteFile(path, []byte(name+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		modTime := now.Add(time.Duration(i) * time.Minute)
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cfg := &config.Config{}
+	cfg.Server.Host = "127.0.0.1"
+	cfg.Log.Dir = logDir
+	cfg.MaaFW.Enabled = true
+	bundle := Collect(Sources{Version: "1.0.0", Config: cfg, Resources: stubResources{}, Traces: stubTraces{}, Serving: true}, 1)
+	if bundle.Report.Healthy() {
+		t.Fatalf("Report.Healthy() = true, want false without MaaFramework: %+v", bundle.Report.Checks)
+	}
+
+	var buffer bytes.Buffer
+	if err := bundle.WriteZip(&buffer); err != nil {
+		t.Fatalf("WriteZip() error = %v", err)
+	}
+	reader, err := zip.NewReader(bytes.NewReader(buffer.Bytes()), int64(buffer.Len()))
+	if err != nil {
+		t.Fatalf("zip.NewReader() error = %v", err)
+	}
+	names := make([]string, 0, len(reader.File))
+	contents := make(map[string]string, len(reader.File))
+	for _, file := range reader.File {
+		names = append(names, file.Name)
+		rc, err := file.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := io.ReadAll(rc)
+		rc.Close()
+		contents[file.Name] = string(data)
+	}
+	sort.Strings(names)
+
+	want := []string{
+		"config.json",
+		"devices.json",
+		"logs/lb-2026-10-18.log",
+		"logs/lb-2026-10-19.log",
+		"logs/maafw.log",
+		"report.json",
+		"resources.json",
+		"traces/01-session-1-run-1.json",
+	}
+	if !reflect.DeepEqual(names, want) {
+		t.Fatalf("zip entries = %v, want %v", names, want)
+	}
+	var report Report
+	if err := json.Unmarshal([]byte(contents["report.json"]), &report); err != nil || report.Version != "1.0.0" || len(report.Checks) != 4 {
+		t.Fatalf("report.json = %s, err = %v", contents["report.json"], err)
+	}
+	if !strings.Contains(contents["resources.json"], `"/workspace"`) {
+		t.Fatalf("resources.json = %s", contents["resources.json"])
+	}
+}
@@ -0,0 +1,84 @@
+package update
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/utils"
+)
+
+// 安装脚本在运行时目录下记录 MaaFramework 版本的文件
+const runtimeVersionFile = ".version"
+
+// InstalledMaaFWVersion 读取已安装运行时的 MaaFramework 版本，未记录时返回空字符串
+func InstalledMaaFWVersion(runtimeDir string) string {
+	data, err := os.ReadFile(filepath.Join(runtimeDir, runtimeVersionFile))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// CheckMaaFW 校验更新后使用的 MaaFramework 版本在可执行文件绑定支持的范围内。
+// 更新包附带运行时时检查包内版本，否则检查已安装的版本；
+// 更新可执行文件时使用清单声明的范围，否则使用当前可执行文件的范围 currentRange。
+// 无法确定版本时返回提示而不是错误
+func CheckMaaFW(m Manifest, installedVersion string, currentRange string) (string, error) {
+	version := installedVersion
+	if m.HasRuntime() {
+		version = m.MaaFWVersion
+	}
+	rangeText := currentRange
+	if m.Binary != "" {
+		rangeText = m.MaaFWRange
+	}
+	if version == "" {
+		return "无法确定 MaaFramework 运行时版本，已跳过绑定兼容性检查", nil
+	}
+
+	parsedVersion, err := utils.ParseVersion(version)
+	if err != nil {
+		return "", fmt.Errorf("MaaFramework 版本无效: %w", err)
+	}
+	parsedRange, err := utils.ParseVersionRange(rangeText)
+	if err != nil {
+		return "", err
+	}
+	if !parsedRange.Contains(parsedVersion) {
+		return "", fmt.Errorf("MaaFramework %s 不在 maa-framework-go 绑定支持的范围 %s 内", version, rangeText)
+	}
+	return "", nil
+}
+
+// ProtocolChange 描述更新前后的协议版本变化
+type ProtocolChange struct {
+	From     string
+	To       string
+	Changed  bool
+	Breaking bool // 更新后不再支持更新前的协议版本，前端需要同步更新
+}
+
+// CompareProtocol 比较当前协议版本与更新包的协议版本
+func CompareProtocol(current string, m Manifest) ProtocolChange {
+	change := ProtocolChange{From: current, To: m.ProtocolVersion}
+	from, fromErr := utils.ParseVersion(current)
+	to, toErr := utils.ParseVersion(m.ProtocolVersion)
+	if fromErr != nil || toErr != nil {
+		change.Changed = current != m.ProtocolVersion
+		return change
+	}
+	change.Changed = from.Compare(to) != 0
+	if !change.Changed {
+		return change
+	}
+	if m.SupportedProtocolRange == "" {
+		change.Breaking = from.Major != to.Major
+		return change
+	}
+	if supported, err := utils.ParseVersionRange(m.SupportedProtocolRange); err == nil {
+		change.Breaking = !supported.Contains(from)
+	}
+	return change
+}
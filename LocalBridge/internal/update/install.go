@@ -0,0 +1,198 @@
+package update
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// 安装目录下的更新工作目录，解压与备份都放在其中，保证替换时的重命名发生在同一文件系统上
+const workDirName = ".mpelb-update"
+
+// 备份记录文件名
+const backupRecordName = "backup.json"
+
+// Target 更新替换的目标位置
+type Target struct {
+	Binary  string // 当前可执行文件
+	Runtime string // 附带的 MaaFramework 运行时目录
+}
+
+// TargetFor 返回安装目录下的默认更新目标
+func TargetFor(binary string) Target {
+	return Target{
+		Binary:  binary,
+		Runtime: filepath.Join(filepath.Dir(binary), filepath.FromSlash(RuntimeDir)),
+	}
+}
+
+// StagingDir 返回解压更新包使用的临时目录
+func StagingDir(installDir string) string {
+	return filepath.Join(installDir, workDirName, "staging")
+}
+
+// BackupDir 返回保存上一版本文件的备份目录
+func BackupDir(installDir string) string {
+	return filepath.Join(installDir, workDirName, "backup")
+}
+
+// 备份记录，Rollback 按此恢复
+type backupRecord struct {
+	Version string       `json:"version"` // 备份的 Local Bridge 版本
+	Items   []backupItem `json:"items"`
+}
+
+type backupItem struct {
+	Target string `json:"target"`           // 被替换的路径
+	Backup string `json:"backup,omitempty"` // 旧文件的备份路径，为空表示更新前不存在
+}
+
+type installStep struct {
+	from string
+	to   string
+	name string
+}
+
+// Install 依次用 stagedDir 中解压好的文件替换目标位置，任一步失败或 verify 返回错误时回滚全部替换。
+// 被替换的旧文件移动到 backupDir，previousVersion 为其版本号，供 Rollback 恢复
+func Install(p *Package, stagedDir string, target Target, backupDir string, previousVersion string, verify func() error) error {
+	steps := make([]installStep, 0, 2)
+	if p.Manifest.Binary != "" {
+		steps = append(steps, installStep{
+			from: filepath.Join(stagedDir, filepath.FromSlash(p.Manifest.Binary)),
+			to:   target.Binary,
+			name: "binary",
+		})
+	}
+	if p.Manifest.HasRuntime() {
+		steps = append(steps, installStep{
+			from: filepath.Join(stagedDir, filepath.FromSlash(RuntimeDir)),
+			to:   target.Runtime,
+			name: "runtime",
+		})
+	}
+	if len(steps) == 0 {
+		return fmt.Errorf("更新包中没有可替换的内容")
+	}
+
+	if err := os.RemoveAll(backupDir); err != nil {
+		return fmt.Errorf("清理旧备份失败: %w", err)
+	}
+	if err := os.MkdirAll(backupDir, 0755); err != nil {
+		return fmt.Errorf("创建备份目录失败: %w", err)
+	}
+
+	done := make([]backupItem, 0, len(steps))
+	for _, step := range steps {
+		item, err := replace(step, backupDir)
+		if err != nil {
+			return withRollback(err, done)
+		}
+		done = append(done, item)
+	}
+	if verify != nil {
+		if err := verify(); err != nil {
+			return withRollback(fmt.Errorf("新版本校验失败: %w", err), done)
+		}
+	}
+
+	data, err := json.MarshalIndent(backupRecord{Version: previousVersion, Items: done}, "", "  ")
+	if err == nil {
+		err = os.WriteFile(filepath.Join(backupDir, backupRecordName), data, 0644)
+	}
+	if err != nil {
+		return withRollback(fmt.Errorf("写入备份记录失败: %w", err), done)
+	}
+	return nil
+}
+
+// replace 将目标移入备份目录后放入新文件，放入失败时恢复目标
+func replace(step installStep, backupDir string) (backupItem, error) {
+	item := backupItem{Target: step.to}
+	if _, err := os.Lstat(step.to); err == nil {
+		item.Backup = filepath.Join(backupDir, step.name)
+		if err := os.Rename(step.to, item.Backup); err != nil {
+			return item, fmt.Errorf("备份 %s 失败: %w", step.to, err)
+		}
+	}
+	err := os.MkdirAll(filepath.Dir(step.to), 0755)
+	if err == nil {
+		err = os.Rename(step.from, step.to)
+	}
+	if err == nil {
+		return item, nil
+	}
+	err = fmt.Errorf("替换 %s 失败: %w", step.to, err)
+	if item.Backup != "" {
+		if restoreErr := os.Rename(item.Backup, step.to); restoreErr != nil {
+			err = fmt.Errorf("%w，且恢复原文件失败: %v", err, restoreErr)
+		}
+	}
+	return item, err
+}
+
+// withRollback 撤销已完成的替换，并在错误中附上回滚结果
+func withRollback(err error, done []backupItem) error {
+	if rollbackErr := restore(done, ""); rollbackErr != nil {
+		return fmt.Errorf("%w；回滚失败: %v", err, rollbackErr)
+	}
+	return fmt.Errorf("%w，已回滚", err)
+}
+
+// restore 按相反顺序恢复备份；trashDir 不为空时把当前文件移入其中
+// （正在运行的可执行文件在 Windows 上无法删除，只能改名），否则直接删除
+func restore(items []backupItem, trashDir string) error {
+	var errs []error
+	for i := len(items) - 1; i >= 0; i-- {
+		item := items[i]
+		if trashDir != "" {
+			if _, err := os.Lstat(item.Target); err == nil {
+				if err := os.Rename(item.Target, filepath.Join(trashDir, fmt.Sprintf("replaced-%d", i))); err != nil {
+					errs = append(errs, fmt.Errorf("移除 %s 失败: %w", item.Target, err))
+					continue
+				}
+			}
+		} else if err := os.RemoveAll(item.Target); err != nil {
+			errs = append(errs, fmt.Errorf("移除 %s 失败: %w", item.Target, err))
+			continue
+		}
+		if item.Backup == "" {
+			continue
+		}
+		if err := os.Rename(item.Backup, item.Target); err != nil {
+			errs = append(errs, fmt.Errorf("恢复 %s 失败: %w", item.Target, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Rollback 恢复上一次更新前的版本，返回恢复的版本号；恢复后备份记录失效
+func Rollback(backupDir string) (string, error) {
+	data, err := os.ReadFile(filepath.Join(backupDir, backupRecordName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", fmt.Errorf("没有可回滚的更新")
+		}
+		return "", fmt.Errorf("读取备份记录失败: %w", err)
+	}
+	var record backupRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return "", fmt.Errorf("解析备份记录失败: %w", err)
+	}
+	for _, item := range record.Items {
+		if item.Backup != "" && !strings.HasPrefix(item.Backup, filepath.Clean(backupDir)+string(filepath.Separator)) {
+			return "", fmt.Errorf("备份记录中的路径无效: %s", item.Backup)
+		}
+	}
+
+	if err := os.Remove(filepath.Join(backupDir, backupRecordName)); err != nil {
+		return "", fmt.Errorf("更新备份记录失败: %w", err)
+	}
+	if err := restore(record.Items, backupDir); err != nil {
+		return "", err
+	}
+	return record.Version, nil
+}
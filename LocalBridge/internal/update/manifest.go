@@ -0,0 +1,288 @@
+package update
+
+import (
+	"archive/zip"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/internal/utils"
+)
+
+// 更新包内的清单与签名文件名
+const (
+	ManifestName  = "manifest.json"
+	SignatureName = "manifest.sig"
+)
+
+// RuntimeDir 更新包与安装目录中附带的 MaaFramework 运行时路径
+const RuntimeDir = "runtime/maafw"
+
+// ErrUnsigned 更新包未签名或缺少校验签名的公钥
+var ErrUnsigned = errors.New("更新包签名无法校验")
+
+// 单个文件的解压大小上限，防止异常的更新包占满磁盘
+const maxFileSize int64 = 1 << 30
+
+// Manifest 离线更新包清单
+type Manifest struct {
+	Version                string            `json:"version"`                            // Local Bridge 版本
+	ProtocolVersion        string            `json:"protocol_version"`                   // 后端协议版本
+	SupportedProtocolRange string            `json:"supported_protocol_range,omitempty"` // 支持的前端协议版本范围
+	OS                     string            `json:"os"`                                 // 目标系统，与 GOOS 一致
+	Arch                   string            `json:"arch"`                               // 目标架构，与 GOARCH 一致
+	Binary                 string            `json:"binary,omitempty"`                   // 包内可执行文件路径，为空表示不更新可执行文件
+	MaaFWBinding           string            `json:"maafw_binding,omitempty"`            // 新可执行文件使用的 maa-framework-go 版本
+	MaaFWRange             string            `json:"maafw_range,omitempty"`              // 新可执行文件可加载的 MaaFramework 版本范围
+	MaaFWVersion           string            `json:"maafw_version,omitempty"`            // 包内 runtime/maafw 的 MaaFramework 版本
+	Files                  map[string]string `json:"files"`                              // 包内文件路径到 SHA-256 的映射
+}
+
+// HasRuntime 更新包是否附带 MaaFramework 运行时
+func (m Manifest) HasRuntime() bool {
+	for name := range m.Files {
+		if strings.HasPrefix(name, RuntimeDir+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// Package 已通过清单与签名校验的更新包
+type Package struct {
+	Manifest Manifest
+	Signed   bool     // 是否通过签名校验
+	Warnings []string // 校验过程中的提示
+	path     string
+}
+
+// Open 打开更新包并校验清单：默认要求签名可用 publicKey 验证，allowUnsigned 为 true 时
+// 才接受未签名或无法验证的更新包，签名存在但与公钥不符时始终拒绝；
+// 包内文件必须与清单一一对应，校验和在解压时核对
+func Open(archive string, publicKey ed25519.PublicKey, allowUnsigned bool) (*Package, error) {
+	reader, err := zip.OpenReader(archive)
+	if err != nil {
+		return nil, fmt.Errorf("打开更新包失败: %w", err)
+	}
+	defer reader.Close()
+
+	var manifestData, signature []byte
+	entries := make(map[string]struct{}, len(reader.File))
+	for _, file := range reader.File {
+		if file.FileInfo().IsDir() {
+			continue
+		}
+		switch file.Name {
+		case ManifestName:
+			manifestData, err = readEntry(file)
+		case SignatureName:
+			signature, err = readEntry(file)
+		default:
+			entries[file.Name] = struct{}{}
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if manifestData == nil {
+		return nil, fmt.Errorf("更新包缺少 %s", ManifestName)
+	}
+
+	pkg := &Package{path: archive}
+	if err := pkg.verifySignature(manifestData, signature, publicKey, allowUnsigned); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(manifestData, &pkg.Manifest); err != nil {
+		return nil, fmt.Errorf("解析更新包清单失败: %w", err)
+	}
+	if err := pkg.Manifest.validate(entries); err != nil {
+		return nil, err
+	}
+	return pkg, nil
+}
+
+func (p *Package) verifySignature(manifest []byte, signature []byte, publicKey ed25519.PublicKey, allowUnsigned bool) error {
+	if len(publicKey) == 0 {
+		if !allowUnsigned {
+			return fmt.Errorf("%w: 未配置更新公钥", ErrUnsigned)
+		}
+		p.Warnings = append(p.Warnings, "未配置更新公钥，已跳过签名校验，仅核对 SHA-256")
+		return nil
+	}
+	if signature == nil {
+		if !allowUnsigned {
+			return fmt.Errorf("%w: 缺少签名文件 %s", ErrUnsigned, SignatureName)
+		}
+		p.Warnings = append(p.Warnings, "更新包未签名，仅核对 SHA-256")
+		return nil
+	}
+	decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(signature)))
+	if err != nil {
+		return fmt.Errorf("签名格式无效: %w", err)
+	}
+	if !ed25519.Verify(publicKey, manifest, decoded) {
+		return fmt.Errorf("更新包签名校验失败")
+	}
+	p.Signed = true
+	return nil
+}
+
+// validate 校验清单字段、目标平台以及包内文件与清单一一对应
+func (m Manifest) validate(entries map[string]struct{}) error {
+	if _, err := utils.ParseVersion(m.Version); err != nil {
+		return fmt.Errorf("清单 version 无效: %w", err)
+	}
+	if _, err := utils.ParseVersion(m.ProtocolVersion); err != nil {
+		return fmt.Errorf("清单 protocol_version 无效: %w", err)
+	}
+	if m.SupportedProtocolRange != "" {
+		if _, err := utils.ParseVersionRange(m.SupportedProtocolRange); err != nil {
+			return fmt.Errorf("清单 supported_protocol_range 无效: %w", err)
+		}
+	}
+	if m.OS != runtime.GOOS || m.Arch != runtime.GOARCH {
+		return fmt.Errorf("更新包适用于 %s/%s，当前系统为 %s/%s", m.OS, m.Arch, runtime.GOOS, runtime.GOARCH)
+	}
+	if len(m.Files) == 0 {
+		return fmt.Errorf("清单未列出任何文件")
+	}
+	for name, sum := range m.Files {
+		if !validEntryName(name) {
+			return fmt.Errorf("清单中的路径无效: %s", name)
+		}
+		if decoded, err := hex.DecodeString(sum); err != nil || len(decoded) != sha256.Size {
+			return fmt.Errorf("清单中 %s 的 SHA-256 无效", name)
+		}
+		if _, ok := entries[name]; !ok {
+			return fmt.Errorf("更新包缺少文件: %s", name)
+		}
+	}
+	for name := range entries {
+		if _, ok := m.Files[name]; !ok {
+			return fmt.Errorf("更新包包含清单外的文件: %s", name)
+		}
+	}
+	if m.Binary != "" {
+		if _, ok := m.Files[m.Binary]; !ok {
+			return fmt.Errorf("清单中的可执行文件不存在: %s", m.Binary)
+		}
+		if m.MaaFWRange == "" {
+			return fmt.Errorf("清单缺少 maafw_range")
+		}
+	}
+	if m.MaaFWRange != "" {
+		if _, err := utils.ParseVersionRange(m.MaaFWRange); err != nil {
+			return fmt.Errorf("清单 maafw_range 无效: %w", err)
+		}
+	}
+	if m.HasRuntime() && m.MaaFWVersion == "" {
+		return fmt.Errorf("清单缺少 maafw_version")
+	}
+	return nil
+}
+
+// validEntryName 包内路径必须是不含 .. 的相对路径
+func validEntryName(name string) bool {
+	if name == "" || strings.Contains(name, `\`) || path.IsAbs(name) || filepath.IsAbs(name) {
+		return false
+	}
+	if name == ManifestName || name == SignatureName {
+		return false
+	}
+	return path.Clean(name) == name && name != ".." && !strings.HasPrefix(name, "../")
+}
+
+// Extract 将更新包解压到 dir，写入时核对每个文件的 SHA-256
+func (p *Package) Extract(dir string) error {
+	reader, err := zip.OpenReader(p.path)
+	if err != nil {
+		return fmt.Errorf("打开更新包失败: %w", err)
+	}
+	defer reader.Close()
+
+	files := make([]*zip.File, 0, len(reader.File))
+	for _, file := range reader.File {
+		if _, ok := p.Manifest.Files[file.Name]; ok && !file.FileInfo().IsDir() {
+			files = append(files, file)
+		}
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Name < files[j].Name })
+
+	for _, file := range files {
+		if err := p.extractFile(file, dir); err != nil {
+			return err
+		}
+	}
+
+	// 与安装脚本一致，在运行时目录记录 MaaFramework 版本，供下次更新时检查
+	if p.Manifest.HasRuntime() {
+		versionFile := filepath.Join(dir, filepath.FromSlash(RuntimeDir), runtimeVersionFile)
+		if _, err := os.Stat(versionFile); os.IsNotExist(err) {
+			if err := os.WriteFile(versionFile, []byte(p.Manifest.MaaFWVersion+"\n"), 0644); err != nil {
+				return fmt.Errorf("写入 MaaFramework 版本失败: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+func (p *Package) extractFile(file *zip.File, dir string) error {
+	target := filepath.Join(dir, filepath.FromSlash(file.Name))
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return fmt.Errorf("创建目录失败: %w", err)
+	}
+	mode := os.FileMode(0644)
+	if file.Name == p.Manifest.Binary || file.Mode()&0111 != 0 {
+		mode = 0755
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return fmt.Errorf("读取 %s 失败: %w", file.Name, err)
+	}
+	defer src.Close()
+	dst, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		return fmt.Errorf("写入 %s 失败: %w", file.Name, err)
+	}
+
+	hash := sha256.New()
+	written, err := io.Copy(io.MultiWriter(dst, hash), io.LimitReader(src, maxFileSize+1))
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("写入 %s 失败: %w", file.Name, err)
+	}
+	if written > maxFileSize {
+		return fmt.Errorf("%s 超出大小上限", file.Name)
+	}
+	if sum := hex.EncodeToString(hash.Sum(nil)); !strings.EqualFold(sum, p.Manifest.Files[file.Name]) {
+		return fmt.Errorf("%s 校验和不匹配", file.Name)
+	}
+	return nil
+}
+
+func readEntry(file *zip.File) ([]byte, error) {
+	src, err := file.Open()
+	if err != nil {
+		return nil, fmt.Errorf("读取 %s 失败: %w", file.Name, err)
+	}
+	defer src.Close()
+	data, err := io.ReadAll(io.LimitReader(src, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("读取 %s 失败: %w", file.Name, err)
+	}
+	return data, nil
+}
@@ -0,0 +1,44 @@
+package update
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/pkg/models"
+)
+
+// 数据目录下待推送的更新报告
+const reportFileName = "update_report.json"
+
+// SaveReport 保存更新报告，由更新后首次启动的服务推送给客户端
+func SaveReport(dataDir string, report models.UpdateReport) error {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化更新报告失败: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dataDir, reportFileName), data, 0644); err != nil {
+		return fmt.Errorf("保存更新报告失败: %w", err)
+	}
+	return nil
+}
+
+// TakeReport 读取并删除待推送的更新报告，不存在时返回 nil
+func TakeReport(dataDir string) (*models.UpdateReport, error) {
+	path := filepath.Join(dataDir, reportFileName)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("读取更新报告失败: %w", err)
+	}
+	os.Remove(path)
+
+	var report models.UpdateReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("解析更新报告失败: %w", err)
+	}
+	return &report, nil
+}
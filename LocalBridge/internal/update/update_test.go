@@ -0,0 +1,296 @@
+package update
+
+import (
+	"archive/zip"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/pkg/models"
+)
+
+func sha(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+func testManifest(files map[string]string) Manifest {
+	manifest := Manifest{
+		Version:                "1.5.0",
+		ProtocolVersion:        "1.5.0",
+		SupportedProtocolRange: ">=1.3.0 <2.0.0",
+		OS:                     runtime.GOOS,
+		Arch:                   runtime.GOARCH,
+		Binary:                 "mpelb",
+		MaaFWRange:             ">=5.10.0 <6.0.0",
+		MaaFWVersion:           "v5.10.3",
+		Files:                  make(map[string]string, len(files)),
+	}
+	for name, content := range files {
+		manifest.Files[name] = sha(content)
+	}
+	return manifest
+}
+
+// writeArchive 写出更新包，privateKey 不为空时附带签名
+func writeArchive(t *testing.T, manifest Manifest, files map[string]string, privateKey ed25519.PrivateKey) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "update.zip")
+	out, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer out.Close()
+	zw := zip.NewWriter(out)
+
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries := map[string]string{ManifestName: string(data)}
+	if privateKey != nil {
+		entries[SignatureName] = base64.StdEncoding.EncodeToString(ed25519.Sign(privateKey, data))
+	}
+	for name, content := range files {
+		entries[name] = content
+	}
+	for name, content := range entries {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func testFiles() map[string]string {
+	return map[string]string{
+		"mpelb":                             "new binary",
+		"runtime/maafw/bin/libMaaFramework": "new lib",
+	}
+}
+
+func TestOpenVerifiesManifestAndSignature(t *testing.T) {
+	publicKey, privateKey, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, otherKey, _ := ed25519.GenerateKey(nil)
+
+	tests := []struct {
+		name          string
+		edit          func(m *Manifest, files map[string]string)
+		signer        ed25519.PrivateKey
+		key           ed25519.PublicKey
+		allowUnsigned bool
+		wantErr       string
+		signed        bool
+	}{
+		{name: "signed", signer: privateKey, key: publicKey, signed: true},
+		{name: "no key", signer: privateKey, wantErr: "未配置更新公钥"},
+		{name: "no key allowed unsigned", signer: privateKey, allowUnsigned: true},
+		{name: "wrong signature", signer: otherKey, key: publicKey, wantErr: "签名校验失败"},
+		{name: "wrong signature allowed unsigned", signer: otherKey, key: publicKey, allowUnsigned: true, wantErr: "签名校验失败"},
+		{name: "missing signature", key: publicKey, wantErr: "缺少签名"},
+		{name: "missing signature allowed unsigned", key: publicKey, allowUnsigned: true},
+		{name: "extra file", signer: privateKey, key: publicKey, edit: func(m *Manifest, files map[string]string) { files["extra.txt"] = "x" }, wantErr: "清单外的文件"},
+		{name: "missing file", signer: privateKey, key: publicKey, edit: func(m *Manifest, files map[string]string) { delete(files, "mpelb") }, wantErr: "缺少文件"},
+		{name: "other platform", signer: privateKey, key: publicKey, edit: func(m *Manifest, files map[string]string) { m.OS = "plan9" }, wantErr: "适用于"},
+		{name: "path traversal", signer: privateKey, key: publicKey, edit: func(m *Manifest, files map[string]string) {
+			files["../evil"] = "x"
+			m.Files["../evil"] = sha("x")
+		}, wantErr: "路径无效"},
+		{name: "missing maafw range", signer: privateKey, key: publicKey, edit: func(m *Manifest, files map[string]string) { m.MaaFWRange = "" }, wantErr: "maafw_range"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			files := testFiles()
+			manifest := testManifest(files)
+			if test.edit != nil {
+				test.edit(&manifest, files)
+			}
+			pkg, err := Open(writeArchive(t, manifest, files, test.signer), test.key, test.allowUnsigned)
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("Open() error = %v, want %q", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Open() error = %v", err)
+			}
+			if pkg.Signed != test.signed {
+				t.Fatalf("Signed = %v, want %v", pkg.Signed, test.signed)
+			}
+		})
+	}
+}
+
+func TestExtractRejectsChecksumMismatch(t *testing.T) {
+	files := testFiles()
+	manifest := testManifest(files)
+	manifest.Files["mpelb"] = sha("tampered")
+	pkg, err := Open(writeArchive(t, manifest, files, nil), nil, true)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if err := pkg.Extract(t.TempDir()); err == nil || !strings.Contains(err.Error(), "校验和不匹配") {
+		t.Fatalf("Extract() error = %v, want checksum mismatch", err)
+	}
+}
+
+func TestCheckMaaFW(t *testing.T) {
+	withRuntime := testManifest(testFiles())
+	binaryOnly := testManifest(map[string]string{"mpelb": "x"})
+	runtimeOnly := testManifest(map[string]string{"runtime/maafw/bin/lib": "x"})
+	runtimeOnly.Binary = ""
+
+	tests := []struct {
+		name      string
+		manifest  Manifest
+		installed string
+		current   string
+		wantErr   bool
+		warning   bool
+	}{
+		{name: "bundled runtime in range", manifest: withRuntime},
+		{name: "installed runtime out of new range", manifest: binaryOnly, installed: "v4.5.0", wantErr: true},
+		{name: "installed runtime unknown", manifest: binaryOnly, warning: true},
+		{name: "runtime only checked against current binding", manifest: runtimeOnly, current: ">=6.0.0", wantErr: true},
+	}
+	for _, test := range tests {
+		warning, err := CheckMaaFW(test.manifest, test.installed, test.current)
+		if (err != nil) != test.wantErr || (warning != "") != test.warning {
+			t.Fatalf("%s: CheckMaaFW() = %q, %v", test.name, warning, err)
+		}
+	}
+}
+
+func TestCompareProtocol(t *testing.T) {
+	tests := []struct {
+		name     string
+		current  string
+		to       string
+		supports string
+		want     ProtocolChange
+	}{
+		{name: "unchanged", current: "1.4.0", to: "1.4.0", supports: ">=1.3.0 <2.0.0", want: ProtocolChange{From: "1.4.0", To: "1.4.0"}},
+		{name: "compatible", current: "1.4.0", to: "1.5.0", supports: ">=1.3.0 <2.0.0", want: ProtocolChange{From: "1.4.0", To: "1.5.0", Changed: true}},
+		{name: "breaking range", current: "1.4.0", to: "2.0.0", supports: ">=2.0.0 <3.0.0", want: ProtocolChange{From: "1.4.0", To: "2.0.0", Changed: true, Breaking: true}},
+		{name: "breaking major", current: "1.4.0", to: "2.0.0", want: ProtocolChange{From: "1.4.0", To: "2.0.0", Changed: true, Breaking: true}},
+	}
+	for _, test := range tests {
+		got := CompareProtocol(test.current, Manifest{ProtocolVersion: test.to, SupportedProtocolRange: test.supports})
+		if got != test.want {
+			t.Fatalf("%s: CompareProtocol() = %+v, want %+v", test.name, got, test.want)
+		}
+	}
+}
+
+// installFixture 准备安装目录与解压好的更新包
+func installFixture(t *testing.T) (*Package, string, Target, string) {
+	t.Helper()
+	installDir := t.TempDir()
+	target := TargetFor(filepath.Join(installDir, "mpelb"))
+	if err := os.WriteFile(target.Binary, []byte("old binary"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(target.Runtime, "bin"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(target.Runtime, "bin", "libMaaFramework"), []byte("old lib"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	files := testFiles()
+	pkg, err := Open(writeArchive(t, testManifest(files), files, nil), nil, true)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	staged := StagingDir(installDir)
+	if err := pkg.Extract(staged); err != nil {
+		t.Fatalf("Extract() error = %v", err)
+	}
+	return pkg, staged, target, BackupDir(installDir)
+}
+
+func readString(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestInstallRollsBackWhenVerifyFails(t *testing.T) {
+	pkg, staged, target, backupDir := installFixture(t)
+	err := Install(pkg, staged, target, backupDir, "1.4.0", func() error { return errors.New("exit status 1") })
+	if err == nil || !strings.Contains(err.Error(), "已回滚") {
+		t.Fatalf("Install() error = %v, want rollback", err)
+	}
+	if got := readString(t, target.Binary); got != "old binary" {
+		t.Fatalf("binary = %q, want old binary", got)
+	}
+	if got := readString(t, filepath.Join(target.Runtime, "bin", "libMaaFramework")); got != "old lib" {
+		t.Fatalf("lib = %q, want old lib", got)
+	}
+}
+
+func TestInstallAndRollback(t *testing.T) {
+	pkg, staged, target, backupDir := installFixture(t)
+	if err := Install(pkg, staged, target, backupDir, "1.4.0", nil); err != nil {
+		t.Fatalf("Install() error = %v", err)
+	}
+	if got := readString(t, target.Binary); got != "new binary" {
+		t.Fatalf("binary = %q, want new binary", got)
+	}
+	if got := readString(t, filepath.Join(target.Runtime, "bin", "libMaaFramework")); got != "new lib" {
+		t.Fatalf("lib = %q, want new lib", got)
+	}
+	if got := InstalledMaaFWVersion(target.Runtime); got != "v5.10.3" {
+		t.Fatalf("InstalledMaaFWVersion() = %q, want v5.10.3", got)
+	}
+
+	version, err := Rollback(backupDir)
+	if err != nil || version != "1.4.0" {
+		t.Fatalf("Rollback() = %q, %v", version, err)
+	}
+	if got := readString(t, target.Binary); got != "old binary" {
+		t.Fatalf("binary after rollback = %q, want old binary", got)
+	}
+	if _, err := Rollback(backupDir); err == nil {
+		t.Fatal("second Rollback() should fail")
+	}
+}
+
+func TestTakeReport(t *testing.T) {
+	dir := t.TempDir()
+	if report, err := TakeReport(dir); report != nil || err != nil {
+		t.Fatalf("TakeReport() empty = %v, %v", report, err)
+	}
+	if err := SaveReport(dir, models.UpdateReport{FromVersion: "1.4.0", ToVersion: "1.5.0"}); err != nil {
+		t.Fatalf("SaveReport() error = %v", err)
+	}
+	report, err := TakeReport(dir)
+	if err != nil || report == nil || report.ToVersion != "1.5.0" {
+		t.Fatalf("TakeReport() = %+v, %v", report, err)
+	}
+	if report, _ := TakeReport(dir); report != nil {
+		t.Fatalf("TakeReport() should remove the report, got %+v", report)
+	}
+}
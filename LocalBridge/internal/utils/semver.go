@@ -0,0 +1,188 @@
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Version 语义化版本号，预发布与构建信息不参与比较
+type Version struct {
+	Major int
+	Minor int
+	Patch int
+}
+
+func (v Version) String() string {
+	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
+
+// Compare 返回 -1、0、1
+func (v Version) Compare(other Version) int {
+	for _, diff := range []int{v.Major - other.Major, v.Minor - other.Minor, v.Patch - other.Patch} {
+		if diff < 0 {
+			return -1
+		}
+		if diff > 0 {
+			return 1
+		}
+	}
+	return 0
+}
+
+// ParseVersion 解析 "1.2.3"、"v1.2" 形式的版本号，缺省部分为 0
+func ParseVersion(value string) (Version, error) {
+	v, _, err := parsePartial(value)
+	return v, err
+}
+
+// parsePartial 额外返回写出的段数，用于 ~ 与 ^ 的范围计算
+func parsePartial(value string) (Version, int, error) {
+	text := strings.TrimPrefix(strings.TrimSpace(value), "v")
+	if i := strings.IndexAny(text, "-+"); i >= 0 {
+		text = text[:i]
+	}
+	parts := strings.Split(text, ".")
+	if text == "" || len(parts) > 3 {
+		return Version{}, 0, fmt.Errorf("无效的版本号: %q", value)
+	}
+	numbers := [3]int{}
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return Version{}, 0, fmt.Errorf("无效的版本号: %q", value)
+		}
+		numbers[i] = n
+	}
+	return Version{Major: numbers[0], Minor: numbers[1], Patch: numbers[2]}, len(parts), nil
+}
+
+// VersionRange 版本范围，"||" 分隔的任一组满足即可，组内空格分隔的条件需全部满足
+type VersionRange struct {
+	raw    string
+	groups [][]versionBound
+}
+
+type versionBound struct {
+	op      string
+	version Version
+}
+
+func (r VersionRange) String() string {
+	return r.raw
+}
+
+// ParseVersionRange 支持 * 、=、>、>=、<、<=、~、^ 与裸版本号，例如 ">=1.3.0 <2.0.0 || ^3.1"
+func ParseVersionRange(value string) (VersionRange, error) {
+	result := VersionRange{raw: strings.TrimSpace(value)}
+	for _, group := range strings.Split(value, "||") {
+		fields := strings.Fields(group)
+		if len(fields) == 0 {
+			return VersionRange{}, fmt.Errorf("无效的版本范围: %q", value)
+		}
+		bounds := make([]versionBound, 0, len(fields))
+		for _, field := range fields {
+			parsed, err := parseBounds(field)
+			if err != nil {
+				return VersionRange{}, fmt.Errorf("无效的版本范围 %q: %w", value, err)
+			}
+			bounds = append(bounds, parsed...)
+		}
+		result.groups = append(result.groups, bounds)
+	}
+	return result, nil
+}
+
+func parseBounds(field string) ([]versionBound, error) {
+	if field == "*" || field == "x" {
+		return nil, nil
+	}
+	for _, op := range []string{">=", "<=", ">", "<", "="} {
+		if rest, ok := strings.CutPrefix(field, op); ok {
+			v, _, err := parsePartial(rest)
+			if err != nil {
+				return nil, err
+			}
+			return []versionBound{{op: op, version: v}}, nil
+		}
+	}
+
+	op := field[:1]
+	if op != "~" && op != "^" {
+		// 裸版本号：写出的段精确匹配，如 "1.3" 等价于 ">=1.3.0 <1.4.0"
+		v, parts, err := parsePartial(field)
+		if err != nil {
+			return nil, err
+		}
+		if parts == 3 {
+			return []versionBound{{op: "=", version: v}}, nil
+		}
+		return []versionBound{{op: ">=", version: v}, {op: "<", version: bump(v, parts-1)}}, nil
+	}
+
+	v, parts, err := parsePartial(field[1:])
+	if err != nil {
+		return nil, err
+	}
+	var upper Version
+	if op == "~" {
+		// ~1.3.1 → <1.4.0；~1 → <2.0.0
+		upper = bump(v, min(parts-1, 1))
+	} else {
+		// ^1.3.1 → <2.0.0；^0.3.1 → <0.4.0；^0.0.3 → <0.0.4
+		switch {
+		case v.Major > 0 || parts == 1:
+			upper = bump(v, 0)
+		case v.Minor > 0 || parts == 2:
+			upper = bump(v, 1)
+		default:
+			upper = bump(v, 2)
+		}
+	}
+	return []versionBound{{op: ">=", version: v}, {op: "<", version: upper}}, nil
+}
+
+// bump 将第 index 段加一并清零其后各段
+func bump(v Version, index int) Version {
+	switch index {
+	case 0:
+		return Version{Major: v.Major + 1}
+	case 1:
+		return Version{Major: v.Major, Minor: v.Minor + 1}
+	default:
+		return Version{Major: v.Major, Minor: v.Minor, Patch: v.Patch + 1}
+	}
+}
+
+// Contains 判断版本是否落在范围内
+func (r VersionRange) Contains(v Version) bool {
+	for _, group := range r.groups {
+		if groupContains(group, v) {
+			return true
+		}
+	}
+	return false
+}
+
+func groupContains(bounds []versionBound, v Version) bool {
+	for _, bound := range bounds {
+		cmp := v.Compare(bound.version)
+		var ok bool
+		switch bound.op {
+		case ">=":
+			ok = cmp >= 0
+		case ">":
+			ok = cmp > 0
+		case "<=":
+			ok = cmp <= 0
+		case "<":
+			ok = cmp < 0
+		default:
+			ok = cmp == 0
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
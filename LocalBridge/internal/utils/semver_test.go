@@ -0,0 +1,47 @@
+package utils
+
+import "testing"
+
+func TestVersionRangeContains(t *testing.T) {
+	tests := []struct {
+		rangeText string
+		version   string
+		want      bool
+	}{
+		{rangeText: ">=1.3.0 <2.0.0", version: "1.3.1", want: true},
+		{rangeText: ">=1.3.0 <2.0.0", version: "1.2.9", want: false},
+		{rangeText: ">=1.3.0 <2.0.0", version: "2.0.0", want: false},
+		{rangeText: "^1.3.1", version: "1.9.0", want: true},
+		{rangeText: "^1.3.1", version: "1.3.0", want: false},
+		{rangeText: "^0.3.1", version: "0.4.0", want: false},
+		{rangeText: "~1.3.1", version: "1.3.9", want: true},
+		{rangeText: "~1.3.1", version: "1.4.0", want: false},
+		{rangeText: "1.3", version: "1.3.7", want: true},
+		{rangeText: "1.3.1", version: "1.3.2", want: false},
+		{rangeText: "<1.0.0 || >=2.0", version: "2.1.0", want: true},
+		{rangeText: "<1.0.0 || >=2.0", version: "1.5.0", want: false},
+		{rangeText: "*", version: "9.9.9", want: true},
+		{rangeText: ">1.3.0", version: "v1.3.1-beta+build", want: true},
+	}
+	for _, test := range tests {
+		r, err := ParseVersionRange(test.rangeText)
+		if err != nil {
+			t.Fatalf("ParseVersionRange(%q) error = %v", test.rangeText, err)
+		}
+		v, err := ParseVersion(test.version)
+		if err != nil {
+			t.Fatalf("ParseVersion(%q) error = %v", test.version, err)
+		}
+		if got := r.Contains(v); got != test.want {
+			t.Fatalf("%q contains %q = %v, want %v", test.rangeText, test.version, got, test.want)
+		}
+	}
+}
+
+func TestParseVersionRangeInvalid(t *testing.T) {
+	for _, value := range []string{"", "||", ">=abc", "1.2.3.4", "^", ">=1.0 ||"} {
+		if _, err := ParseVersionRange(value); err == nil {
+			t.Fatalf("ParseVersionRange(%q) error = nil", value)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package workspace
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/kqcoxn/MaaPipelineEditor/LocalBridge/pkg/models"
+)
+
+// file.root 对应的根目录名称
+const DefaultName = "default"
+
+// 根目录名称至少两个字符，避免与 Windows 盘符混淆
+var namePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]+$`)
+
+// Root 命名的工作区根目录
+type Root struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+}
+
+// Roots 工作区根目录列表，第一个为主根目录。
+// 路径可写作 "名称:相对路径" 指定根目录，不带名称的相对路径按主根目录解析
+type Roots []Root
+
+// Single 返回只含一个默认根目录的列表，path 为空时返回空列表
+func Single(path string) Roots {
+	if path == "" {
+		return nil
+	}
+	return Roots{{Name: DefaultName, Path: path}}
+}
+
+// Primary 返回主根目录，列表为空时返回零值
+func (r Roots) Primary() Root {
+	if len(r) == 0 {
+		return Root{}
+	}
+	return r[0]
+}
+
+// Paths 返回全部根目录路径
+func (r Roots) Paths() []string {
+	paths := make([]string, 0, len(r))
+	for _, root := range r {
+		paths = append(paths, root.Path)
+	}
+	return paths
+}
+
+// Models 转为推送给前端的根目录列表
+func (r Roots) Models() []models.WorkspaceRoot {
+	result := make([]models.WorkspaceRoot, 0, len(r))
+	for _, root := range r {
+		result = append(result, models.WorkspaceRoot{Name: root.Name, Path: root.Path})
+	}
+	return result
+}
+
+// Find 按名称查找根目录
+func (r Roots) Find(name string) (Root, bool) {
+	for _, root := range r {
+		if root.Name == name {
+			return root, true
+		}
+	}
+	return Root{}, false
+}
+
+// Locate 返回包含 path 的根目录
+func (r Roots) Locate(path string) (Root, bool) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return Root{}, false
+	}
+	for _, root := range r {
+		if Contains(root.Path, absPath) {
+			return root, true
+		}
+	}
+	return Root{}, false
+}
+
+// Resolve 将请求中的路径解析为绝对路径，并校验其位于对应的根目录内。
+// 列表为空时不做限制，与未配置根目录时的行为一致
+func (r Roots) Resolve(candidate string) (string, error) {
+	trimmed := strings.TrimSpace(candidate)
+	if trimmed == "" {
+		return "", fmt.Errorf("路径为空")
+	}
+	if root, rel, ok := r.split(trimmed); ok {
+		return join(root, rel, candidate)
+	}
+
+	resolved := filepath.Clean(trimmed)
+	if len(r) == 0 {
+		return resolved, nil
+	}
+	if !filepath.IsAbs(resolved) {
+		return join(r[0], resolved, candidate)
+	}
+	if _, ok := r.Locate(resolved); !ok {
+		return "", fmt.Errorf("路径不在工作区内: %s", candidate)
+	}
+	return resolved, nil
+}
+
+// ResolveResource 解析资源路径：带根目录名称的路径展开并校验，其余路径原样保留，
+// 以兼容工作区外的公共资源目录
+func (r Roots) ResolveResource(candidate string) (string, error) {
+	trimmed := strings.TrimSpace(candidate)
+	if root, rel, ok := r.split(trimmed); ok {
+		return join(root, rel, candidate)
+	}
+	return trimmed, nil
+}
+
+// Validate 校验名称合法且不重复、路径为绝对路径且互不嵌套
+func (r Roots) Validate() error {
+	names := make(map[string]struct{}, len(r))
+	for i, root := range r {
+		if !namePattern.MatchString(root.Name) {
+			return fmt.Errorf("根目录名称无效: %q（至少两个字符，仅限字母、数字、_ . -）", root.Name)
+		}
+		if _, ok := names[root.Name]; ok {
+			return fmt.Errorf("根目录名称重复: %s", root.Name)
+		}
+		names[root.Name] = struct{}{}
+		if !filepath.IsAbs(root.Path) {
+			return fmt.Errorf("根目录 %s 不是绝对路径: %s", root.Name, root.Path)
+		}
+		for _, other := range r[:i] {
+			if Contains(other.Path, root.Path) || Contains(root.Path, other.Path) {
+				return fmt.Errorf("根目录 %s 与 %s 相互嵌套", root.Name, other.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// Contains 判断 path 是否位于 root 内（含 root 本身）
+func Contains(root string, path string) bool {
+	rel, err := filepath.Rel(filepath.Clean(root), filepath.Clean(path))
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) && !filepath.IsAbs(rel)
+}
+
+// split 拆分 "名称:相对路径"，名称必须是已配置的根目录
+func (r Roots) split(candidate string) (Root, string, bool) {
+	name, rel, ok := strings.Cut(candidate, ":")
+	if !ok || !namePattern.MatchString(name) {
+		return Root{}, "", false
+	}
+	root, ok := r.Find(name)
+	if !ok {
+		return Root{}, "", false
+	}
+	return root, rel, true
+}
+
+func join(root Root, rel string, candidate string) (string, error) {
+	rel = strings.TrimLeft(filepath.FromSlash(rel), `/\`)
+	resolved := filepath.Join(root.Path, rel)
+	if !Contains(root.Path, resolved) {
+		return "", fmt.Errorf("路径超出根目录 %s: %s", root.Name, candidate)
+	}
+	return resolved, nil
+}
@@ -0,0 +1,81 @@
+package workspace
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestRootsResolve(t *testing.T) {
+	base := t.TempDir()
+	game := filepath.Join(base, "game")
+	common := filepath.Join(base, "common")
+	roots := Roots{{Name: "game", Path: game}, {Name: "common", Path: common}}
+
+	tests := []struct {
+		name      string
+		roots     Roots
+		candidate string
+		want      string
+		wantErr   bool
+	}{
+		{name: "relative to primary", roots: roots, candidate: "pipeline/a.json", want: filepath.Join(game, "pipeline", "a.json")},
+		{name: "qualified", roots: roots, candidate: "common:resource/base", want: filepath.Join(common, "resource", "base")},
+		{name: "absolute in second root", roots: roots, candidate: filepath.Join(common, "a.json"), want: filepath.Join(common, "a.json")},
+		{name: "absolute outside", roots: roots, candidate: filepath.Join(base, "other", "a.json"), wantErr: true},
+		{name: "sibling with shared prefix", roots: roots, candidate: game + "-backup", wantErr: true},
+		{name: "relative escape", roots: roots, candidate: "../common/a.json", wantErr: true},
+		{name: "qualified escape", roots: roots, candidate: "common:../game/a.json", wantErr: true},
+		{name: "unknown name is relative", roots: roots, candidate: "shared:a.json", want: filepath.Join(game, "shared:a.json")},
+		{name: "no roots", roots: nil, candidate: "a/../b.json", want: "b.json"},
+		{name: "empty", roots: roots, candidate: " ", wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := test.roots.Resolve(test.candidate)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("Resolve(%q) error = %v, wantErr %v", test.candidate, err, test.wantErr)
+			}
+			if got != test.want {
+				t.Fatalf("Resolve(%q) = %q, want %q", test.candidate, got, test.want)
+			}
+		})
+	}
+}
+
+func TestRootsResolveResourceKeepsExternalPaths(t *testing.T) {
+	base := t.TempDir()
+	roots := Roots{{Name: "game", Path: filepath.Join(base, "game")}}
+	external := filepath.Join(base, "MaaResource")
+
+	if got, err := roots.ResolveResource(external); err != nil || got != external {
+		t.Fatalf("ResolveResource(%q) = %q, %v", external, got, err)
+	}
+	if got, err := roots.ResolveResource("game:resource"); err != nil || got != filepath.Join(base, "game", "resource") {
+		t.Fatalf("ResolveResource(game:resource) = %q, %v", got, err)
+	}
+	if _, err := roots.ResolveResource("game:../MaaResource"); err == nil {
+		t.Fatal("ResolveResource escaping the root should fail")
+	}
+}
+
+func TestRootsValidate(t *testing.T) {
+	base := t.TempDir()
+	tests := []struct {
+		name    string
+		roots   Roots
+		wantErr bool
+	}{
+		{name: "valid", roots: Roots{{Name: "game", Path: filepath.Join(base, "game")}, {Name: "common", Path: filepath.Join(base, "common")}}},
+		{name: "drive letter name", roots: Roots{{Name: "C", Path: base}}, wantErr: true},
+		{name: "duplicate name", roots: Roots{{Name: "game", Path: filepath.Join(base, "a")}, {Name: "game", Path: filepath.Join(base, "b")}}, wantErr: true},
+		{name: "nested", roots: Roots{{Name: "game", Path: base}, {Name: "common", Path: filepath.Join(base, "common")}}, wantErr: true},
+		{name: "relative path", roots: Roots{{Name: "game", Path: "game"}}, wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := test.roots.Validate(); (err != nil) != test.wantErr {
+				t.Fatalf("Validate() error = %v, wantErr %v", err, test.wantErr)
+			}
+		})
+	}
+}
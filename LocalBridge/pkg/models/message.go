@@ -2,8 +2,9 @@ package models
 
 // WebSocket 消息通用结构。
 type Message struct {
-	Path string      `json:"path"` // 路由路径
-	Data interface{} `json:"data"` // 消息数据
+	Path          string      `json:"path"`                     // 路由路径
+	Data          interface{} `json:"data"`                     // 消息数据
+	CorrelationID string      `json:"correlation_id,omitempty"` // 请求携带的关联 ID，响应与错误消息原样带回
 }
 
 // 错误消息
@@ -20,13 +21,21 @@ type FileInfo struct {
 	RelativePath string     `json:"relative_path"` // 相对路径
 	Nodes        []FileNode `json:"nodes"`         // 节点列表
 	Prefix       string     `json:"prefix"`        // 文件前缀
+	RootName     string     `json:"root_name"`     // 所属根目录名称
+}
+
+// 工作区根目录
+type WorkspaceRoot struct {
+	Name string `json:"name"` // 根目录名称
+	Path string `json:"path"` // 根目录绝对路径
 }
 
 // 文件列表数据
 type FileListData struct {
-	Root        string     `json:"root"`        // 根目录绝对路径
-	Files       []FileInfo `json:"files"`       // 文件列表
-	Directories []string   `json:"directories"` // 子目录绝对路径列表（包括空目录）
+	Root        string          `json:"root"`        // 主根目录绝对路径
+	Roots       []WorkspaceRoot `json:"roots"`       // 全部根目录，第一个为主根目录
+	Files       []FileInfo      `json:"files"`       // 文件列表
+	Directories []string        `json:"directories"` // 子目录绝对路径列表（包括空目录）
 }
 
 // 文件内容数据
@@ -102,17 +111,65 @@ type LogData struct {
 	Timestamp string `json:"timestamp"` // ISO 8601 时间戳
 }
 
+// 设置日志级别请求，module 为空时设置全局级别
+type SetLogLevelRequest struct {
+	Module string `json:"module,omitempty"` // 模块名称，不区分大小写
+	Level  string `json:"level"`            // trace/debug/info/warn/error，指定模块时为空表示移除覆盖
+}
+
+// 当前日志级别
+type LogLevelsData struct {
+	Level   string            `json:"level"`
+	Modules map[string]string `json:"modules"`
+}
+
+// 日志查询请求，时间为 RFC 3339 格式
+type LogQueryRequest struct {
+	Since   string `json:"since,omitempty"`
+	Until   string `json:"until,omitempty"`
+	Level   string `json:"level,omitempty"` // 最低级别
+	Module  string `json:"module,omitempty"`
+	Keyword string `json:"keyword,omitempty"`
+	Limit   int    `json:"limit,omitempty"` // 默认 200，最大 2000
+}
+
+// 日志查询结果，按时间先后排列
+type LogQueryData struct {
+	Entries   []LogData `json:"entries"`
+	Truncated bool      `json:"truncated"` // 超出条数时仅保留最新的部分
+	Files     []string  `json:"files"`     // 搜索过的日志文件
+}
+
 // 版本握手请求
 type HandshakeRequest struct {
-	ProtocolVersion string `json:"protocol_version"` // 前端协议版本
+	ProtocolVersion      string   `json:"protocol_version"`                // 前端协议版本
+	AcceptedRange        string   `json:"accepted_range,omitempty"`        // 前端可接受的后端协议版本范围，如 ">=1.4.0 <2.0.0"
+	RequiredCapabilities []string `json:"required_capabilities,omitempty"` // 前端依赖的功能标识，缺少任一项时握手失败
 }
 
 // 版本握手响应
 type HandshakeResponse struct {
-	Success         bool   `json:"success"`          // 是否成功
-	ServerVersion   string `json:"server_version"`   // 后端协议版本
-	RequiredVersion string `json:"required_version"` // 需要的协议版本
-	Message         string `json:"message"`          // 消息说明
+	Success             bool     `json:"success"`                        // 是否成功
+	ServerVersion       string   `json:"server_version"`                 // 后端协议版本
+	RequiredVersion     string   `json:"required_version"`               // 后端支持的前端协议版本范围
+	SupportedRange      string   `json:"supported_range"`                // 同 required_version
+	NegotiatedVersion   string   `json:"negotiated_version,omitempty"`   // 协商结果，取双方版本中较低者
+	Capabilities        []string `json:"capabilities"`                   // 后端提供的功能标识
+	MissingCapabilities []string `json:"missing_capabilities,omitempty"` // 前端依赖但后端缺少的功能
+	SchemaPath          string   `json:"schema_path"`                    // 消息 JSON Schema 的获取路由
+	Message             string   `json:"message"`                        // 消息说明
+	Scopes              []string `json:"scopes,omitempty"`               // 连接令牌的权限，未启用鉴权时省略
+}
+
+// 离线更新报告，更新后首次启动时推送给连接的客户端
+type UpdateReport struct {
+	FromVersion            string `json:"from_version"`             // 更新前的 Local Bridge 版本
+	ToVersion              string `json:"to_version"`               // 更新后的 Local Bridge 版本
+	FromProtocolVersion    string `json:"from_protocol_version"`    // 更新前的后端协议版本
+	ToProtocolVersion      string `json:"to_protocol_version"`      // 更新后的后端协议版本
+	SupportedProtocolRange string `json:"supported_protocol_range"` // 更新后支持的前端协议版本范围
+	MaaFWVersion           string `json:"maafw_version,omitempty"`  // 更新包附带的 MaaFramework 版本
+	UpdatedAt              string `json:"updated_at"`               // 更新时间 (RFC 3339)
 }
 
 // 解析图片路径请求
@@ -10,11 +10,13 @@ type ResourceBundle struct {
 	HasModel           bool   `json:"has_model"`            // 是否有 model 目录
 	HasDefaultPipeline bool   `json:"has_default_pipeline"` // 是否有 default_pipeline.json
 	ImageDir           string `json:"image_dir"`            // image 目录绝对路径
+	RootName           string `json:"root_name"`            // 所属根目录名称
 }
 
 // 资源包列表数据（推送给前端）
 type ResourceBundleListData struct {
-	Root      string           `json:"root"`       // 根目录绝对路径
+	Root      string           `json:"root"`       // 主根目录绝对路径
+	Roots     []WorkspaceRoot  `json:"roots"`      // 全部根目录，第一个为主根目录
 	Bundles   []ResourceBundle `json:"bundles"`    // 资源包列表
 	ImageDirs []string         `json:"image_dirs"` // 所有 image 目录的绝对路径列表（按优先级排序）
 }